@@ -94,6 +94,13 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 		return err
 	}
 
+	if config.PCTableSize() != 0 {
+		err := checkPCTableSupport(config)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Create a temporary directory for intermediary files.
 	dir, err := ioutil.TempDir("", "tinygo")
 	if err != nil {
@@ -402,6 +409,7 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 	// Add job that links and optimizes all packages together.
 	var mod llvm.Module
 	var stackSizeLoads []string
+	var hasPCTable bool
 	programJob := &compileJob{
 		description:  "link+optimize packages (LTO)",
 		dependencies: packageJobs,
@@ -471,6 +479,12 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 				fmt.Println(mod.String())
 			}
 
+			if config.PCTableSize() == 0 {
+				// Stack traces are disabled. Create an empty table now, so
+				// that the optimizer can remove all code that uses it.
+				transform.CreatePCTable(mod, 0)
+			}
+
 			// Run all optimization passes, which are much more effective now
 			// that the optimizer can see the whole program at once.
 			err := optimizeProgram(mod, config)
@@ -478,6 +492,12 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 				return err
 			}
 
+			// Reserve space for the table used for stack traces. It is filled
+			// in after linking.
+			if config.PCTableSize() != 0 {
+				hasPCTable = transform.CreatePCTable(mod, config.PCTableSize())
+			}
+
 			// Make sure stack sizes are loaded from a separate section so they can be
 			// modified after linking.
 			if config.AutomaticStackSize() {
@@ -656,7 +676,7 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 
 			var calculatedStacks []string
 			var stackSizes map[string]functionStackSize
-			if config.Options.PrintStacks || config.AutomaticStackSize() {
+			if config.Options.PrintStacks || config.Options.StackGraph != "" || config.AutomaticStackSize() {
 				// Try to determine stack sizes at compile time.
 				// Don't do this by default as it usually doesn't work on
 				// unsupported architectures.
@@ -667,6 +687,14 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 			}
 
 			// Apply ELF patches
+			if hasPCTable {
+				// Fill in the table used for stack traces, now that all
+				// function addresses are known.
+				err = writePCTable(executable, mod)
+				if err != nil {
+					return fmt.Errorf("could not write stack trace table: %w", err)
+				}
+			}
 			if config.AutomaticStackSize() {
 				// Modify the .tinygo_stacksizes section that contains a stack size
 				// for each goroutine.
@@ -715,7 +743,8 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 			}
 
 			// Print code size if requested.
-			if config.Options.PrintSizes == "short" || config.Options.PrintSizes == "full" {
+			switch config.Options.PrintSizes {
+			case "short", "full", "json", "csv", "symbols", "html":
 				packagePathMap := make(map[string]string, len(lprogram.Packages))
 				for _, pkg := range lprogram.Sorted() {
 					packagePathMap[pkg.OriginalDir()] = pkg.Pkg.Path()
@@ -724,10 +753,11 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 				if err != nil {
 					return err
 				}
-				if config.Options.PrintSizes == "short" {
+				switch config.Options.PrintSizes {
+				case "short":
 					fmt.Printf("   code    data     bss |   flash     ram\n")
 					fmt.Printf("%7d %7d %7d | %7d %7d\n", sizes.Code+sizes.ROData, sizes.Data, sizes.BSS, sizes.Flash(), sizes.RAM())
-				} else {
+				case "full":
 					if !config.Debug() {
 						fmt.Println("warning: data incomplete, remove the -no-debug flag for more detail")
 					}
@@ -739,6 +769,26 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 					}
 					fmt.Printf("------------------------------- | --------------- | -------\n")
 					fmt.Printf("%7d %7d %7d %7d | %7d %7d | total\n", sizes.Code, sizes.ROData, sizes.Data, sizes.BSS, sizes.Code+sizes.ROData+sizes.Data, sizes.Data+sizes.BSS)
+				case "json":
+					err := sizes.printJSON(os.Stdout)
+					if err != nil {
+						return err
+					}
+				case "csv":
+					err := sizes.printCSV(os.Stdout)
+					if err != nil {
+						return err
+					}
+				case "symbols":
+					if !config.Debug() {
+						fmt.Println("warning: data incomplete, remove the -no-debug flag for more detail")
+					}
+					sizes.printSymbols(os.Stdout)
+				case "html":
+					err := sizes.printHTML(os.Stdout)
+					if err != nil {
+						return err
+					}
 				}
 			}
 
@@ -747,6 +797,14 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 				printStacks(calculatedStacks, stackSizes)
 			}
 
+			// Print the call graph that was used to determine the stack sizes.
+			if config.Options.StackGraph != "" {
+				err := printStackGraph(config.Options.StackGraph, calculatedStacks, stackSizes)
+				if err != nil {
+					return err
+				}
+			}
+
 			return nil
 		},
 	}
@@ -958,6 +1016,7 @@ type functionStackSize struct {
 	stackSize        uint64
 	stackSizeType    stacksize.SizeType
 	missingStackSize *stacksize.CallNode
+	node             *stacksize.CallNode
 }
 
 // determineStackSizes tries to determine the stack sizes of all started
@@ -1033,6 +1092,7 @@ func determineStackSizes(mod llvm.Module, executable string) ([]string, map[stri
 			stackSizeType:    stackSizeType,
 			missingStackSize: missingStackSize,
 			humanName:        resetFunction,
+			node:             funcs[0],
 		}
 	}
 
@@ -1064,6 +1124,7 @@ func determineStackSizes(mod llvm.Module, executable string) ([]string, map[stri
 			stackSizeType:    stackSizeType,
 			missingStackSize: missingStackSize,
 			humanName:        humanName,
+			node:             funcs[0],
 		}
 	}
 
@@ -1167,6 +1228,29 @@ func printStacks(calculatedStacks []string, stackSizes map[string]functionStackS
 	}
 }
 
+// printStackGraph prints the call graph of all functions in calculatedStacks
+// (goroutines and the reset handler) in the given format, which is either "dot"
+// or "json". The worst case path through the call graph is highlighted, which
+// makes it possible to see why a stack is as large as it is or why its size
+// couldn't be determined.
+func printStackGraph(format string, calculatedStacks []string, stackSizes map[string]functionStackSize) error {
+	entries := make([]stacksize.Entry, len(calculatedStacks))
+	for i, name := range calculatedStacks {
+		entries[i] = stacksize.Entry{
+			Name: stackSizes[name].humanName,
+			Node: stackSizes[name].node,
+		}
+	}
+	switch format {
+	case "dot":
+		return stacksize.WriteDOT(os.Stdout, entries)
+	case "json":
+		return stacksize.WriteJSON(os.Stdout, entries)
+	default:
+		return fmt.Errorf("unknown stack graph format: %s", format)
+	}
+}
+
 // RP2040 second stage bootloader CRC32 calculation
 //
 // Spec: https://datasheets.raspberrypi.org/rp2040/rp2040-datasheet.pdf
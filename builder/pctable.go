@@ -0,0 +1,303 @@
+package builder
+
+// This file fills in the runtime.pcTable global after linking. This table is
+// used by the runtime to map program counters to function names and source
+// locations, for runtime.Callers and stack traces printed on panic.
+//
+// The table has the following layout (in the byte order of the target, all
+// fields are 32 bits wide):
+//
+//     header:
+//         number of functions
+//         number of line entries
+//     functions (sorted by start address):
+//         start address (relative to the table)
+//         size in bytes
+//         offset of the NUL-terminated function name (relative to the table)
+//         index of the first line entry of this function
+//     line entries (sorted by address, grouped by function):
+//         start address (relative to the table)
+//         offset of the NUL-terminated file name (relative to the table)
+//         line number
+//     strings
+//
+// Addresses are stored relative to the table itself, so that the table also
+// works for position independent executables.
+// Keep this in sync with src/runtime/symtab.go.
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+	"github.com/tinygo-org/tinygo/transform"
+	"tinygo.org/x/go-llvm"
+)
+
+// pcTableFunc is a single function in the PC table.
+type pcTableFunc struct {
+	name  string
+	start uint64
+	size  uint64
+	lines []pcTableLine
+}
+
+// pcTableLine is the start of a range of instructions with the same source
+// location.
+type pcTableLine struct {
+	address uint64
+	file    string
+	line    int
+}
+
+// checkPCTableSupport returns an error if the given configuration does not
+// support stack traces. Walking the stack needs frame pointers, and the table
+// can only be written into ELF files.
+func checkPCTableSupport(config *compileopts.Config) error {
+	arch := strings.Split(config.Triple(), "-")[0]
+	switch {
+	case strings.HasPrefix(arch, "wasm"), arch == "avr", arch == "xtensa":
+		return fmt.Errorf("stack traces (-pctable-size) are not supported on %s", arch)
+	case config.GOOS() == "darwin" || config.GOOS() == "windows":
+		return fmt.Errorf("stack traces (-pctable-size) are not supported on %s", config.GOOS())
+	}
+	return nil
+}
+
+// writePCTable fills in the runtime.pcTable symbol in the given executable. Only
+// functions defined in the given LLVM module (in other words, Go functions) are
+// included, as they are the only functions that are known to maintain a frame
+// pointer.
+func writePCTable(executable string, mod llvm.Module) error {
+	f, err := elf.Open(executable)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Find the table itself.
+	symbols, err := f.Symbols()
+	if err != nil {
+		return err
+	}
+	var tableSymbol *elf.Symbol
+	for i := range symbols {
+		if symbols[i].Name == "runtime.pcTable" {
+			tableSymbol = &symbols[i]
+			break
+		}
+	}
+	if tableSymbol == nil {
+		return errors.New("could not find runtime.pcTable symbol")
+	}
+	if int(tableSymbol.Section) >= len(f.Sections) {
+		return errors.New("runtime.pcTable is not defined in a section")
+	}
+	section := f.Sections[tableSymbol.Section]
+	if section.Type != elf.SHT_PROGBITS {
+		return fmt.Errorf("runtime.pcTable is in unexpected section %s", section.Name)
+	}
+
+	// Collect all Go functions, with their start address and size.
+	goFunctions := make(map[string]struct{})
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		if !fn.IsDeclaration() {
+			goFunctions[fn.Name()] = struct{}{}
+		}
+	}
+	var functions []*pcTableFunc
+	for _, symbol := range symbols {
+		if elf.ST_TYPE(symbol.Info) != elf.STT_FUNC || symbol.Size == 0 {
+			continue
+		}
+		if _, ok := goFunctions[symbol.Name]; !ok {
+			continue
+		}
+		start := symbol.Value
+		if f.Machine == elf.EM_ARM {
+			start &^= 1 // clear the Thumb bit
+		}
+		functions = append(functions, &pcTableFunc{
+			name:  symbol.Name,
+			start: start,
+			size:  symbol.Size,
+		})
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		return functions[i].start < functions[j].start
+	})
+
+	// Add line information from DWARF, if available (it isn't when the binary
+	// was built with -no-debug).
+	if data, err := f.DWARF(); err == nil {
+		err := readPCTableLines(data, functions)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Create the table, dropping line information if there isn't enough space.
+	tableSize := int(tableSymbol.Size)
+	table := encodePCTable(f.ByteOrder, tableSymbol.Value, functions, true)
+	if len(table) > tableSize {
+		fullSize := len(table)
+		table = encodePCTable(f.ByteOrder, tableSymbol.Value, functions, false)
+		if len(table) > tableSize {
+			return fmt.Errorf("stack trace table is too small: need at least %d bytes, got %d (set with -pctable-size)", len(table), tableSize)
+		}
+		fmt.Fprintf(os.Stderr, "warning: stack trace table is too small for line information: need %d bytes, got %d (set with -pctable-size)\n", fullSize, tableSize)
+	}
+	table = append(table, make([]byte, tableSize-len(table))...)
+
+	// Write the table to the executable.
+	offset := section.Offset + (tableSymbol.Value - section.Addr)
+	fp, err := os.OpenFile(executable, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	_, err = fp.WriteAt(table, int64(offset))
+	return err
+}
+
+// readPCTableLines reads all line information from DWARF and adds it to the
+// functions that contain these addresses.
+func readPCTableLines(data *dwarf.Data, functions []*pcTableFunc) error {
+	r := data.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag != dwarf.TagCompileUnit {
+			r.SkipChildren()
+			continue
+		}
+		r.SkipChildren()
+		lr, err := data.LineReader(e)
+		if err != nil {
+			return err
+		}
+		if lr == nil {
+			continue
+		}
+		var entry dwarf.LineEntry
+		for {
+			err := lr.Next(&entry)
+			if err != nil {
+				break // io.EOF or a broken line table
+			}
+			if entry.EndSequence || entry.File == nil || entry.Line == 0 {
+				continue
+			}
+			fn := findPCTableFunc(functions, entry.Address)
+			if fn == nil {
+				continue
+			}
+			fn.lines = append(fn.lines, pcTableLine{
+				address: entry.Address,
+				file:    entry.File.Name,
+				line:    entry.Line,
+			})
+		}
+	}
+
+	// Sort the line entries and merge entries with the same source location.
+	for _, fn := range functions {
+		sort.SliceStable(fn.lines, func(i, j int) bool {
+			return fn.lines[i].address < fn.lines[j].address
+		})
+		var lines []pcTableLine
+		for _, line := range fn.lines {
+			if len(lines) != 0 {
+				last := &lines[len(lines)-1]
+				if last.address == line.address {
+					// The later entry wins, like in the DWARF line program.
+					*last = line
+					continue
+				}
+				if last.file == line.file && last.line == line.line {
+					continue
+				}
+			}
+			lines = append(lines, line)
+		}
+		fn.lines = lines
+	}
+	return nil
+}
+
+// findPCTableFunc returns the function that contains the given address, or nil
+// if there is no such function.
+func findPCTableFunc(functions []*pcTableFunc, address uint64) *pcTableFunc {
+	i := sort.Search(len(functions), func(i int) bool {
+		return functions[i].start > address
+	})
+	if i == 0 {
+		return nil
+	}
+	fn := functions[i-1]
+	if address >= fn.start+fn.size {
+		return nil
+	}
+	return fn
+}
+
+// encodePCTable returns the contents of the runtime.pcTable global for the given
+// functions. See the top of this file for a description of the format.
+func encodePCTable(order binary.ByteOrder, tableAddress uint64, functions []*pcTableFunc, withLines bool) []byte {
+	numLines := 0
+	if withLines {
+		for _, fn := range functions {
+			numLines += len(fn.lines)
+		}
+	}
+	stringsOffset := transform.PCTableHeaderSize + len(functions)*16 + numLines*12
+
+	// Deduplicate all strings (function and file names).
+	var stringData bytes.Buffer
+	stringOffsets := make(map[string]uint32)
+	addString := func(s string) uint32 {
+		if offset, ok := stringOffsets[s]; ok {
+			return offset
+		}
+		offset := uint32(stringsOffset + stringData.Len())
+		stringData.WriteString(s)
+		stringData.WriteByte(0)
+		stringOffsets[s] = offset
+		return offset
+	}
+
+	buf := make([]byte, stringsOffset)
+	order.PutUint32(buf[0:], uint32(len(functions)))
+	order.PutUint32(buf[4:], uint32(numLines))
+	funcData := buf[transform.PCTableHeaderSize:]
+	lineData := funcData[len(functions)*16:]
+	lineIndex := 0
+	for i, fn := range functions {
+		order.PutUint32(funcData[i*16+0:], uint32(fn.start-tableAddress))
+		order.PutUint32(funcData[i*16+4:], uint32(fn.size))
+		order.PutUint32(funcData[i*16+8:], addString(fn.name))
+		order.PutUint32(funcData[i*16+12:], uint32(lineIndex))
+		if !withLines {
+			continue
+		}
+		for _, line := range fn.lines {
+			order.PutUint32(lineData[lineIndex*12+0:], uint32(line.address-tableAddress))
+			order.PutUint32(lineData[lineIndex*12+4:], addString(line.file))
+			order.PutUint32(lineData[lineIndex*12+8:], uint32(line.line))
+			lineIndex++
+		}
+	}
+	return append(buf, stringData.Bytes()...)
+}
@@ -0,0 +1,192 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+)
+
+// SizeDiff compares the size of two builds of a program, and writes the growth
+// (or shrinkage) of every package and every symbol to w. Both files can be a
+// binary (ELF, PE/COFF or WebAssembly) or the output of -size=json. The JSON
+// output is preferred, as it contains the import path of each package while
+// packages can only be identified by their directory when reading a binary.
+func SizeDiff(oldPath, newPath string, w io.Writer) error {
+	oldSizes, err := readProgramSize(oldPath)
+	if err != nil {
+		return err
+	}
+	newSizes, err := readProgramSize(newPath)
+	if err != nil {
+		return err
+	}
+	printSizeDiff(oldSizes, newSizes, w)
+	return nil
+}
+
+// readProgramSize reads the program size from a binary or from a file written
+// with -size=json.
+func readProgramSize(path string) (*programSize, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		sizes := &programSize{}
+		err := json.Unmarshal(data, sizes)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", path, err)
+		}
+		return sizes, nil
+	}
+	return loadProgramSize(path, nil)
+}
+
+// sizeDelta is the difference in size of a package or symbol between two
+// builds.
+type sizeDelta struct {
+	name   string
+	code   int64
+	rodata int64
+	data   int64
+	bss    int64
+}
+
+func (d *sizeDelta) flash() int64 {
+	return d.code + d.rodata + d.data
+}
+
+func (d *sizeDelta) ram() int64 {
+	return d.data + d.bss
+}
+
+func (d *sizeDelta) isZero() bool {
+	return d.code == 0 && d.rodata == 0 && d.data == 0 && d.bss == 0
+}
+
+// add adds the given size of a section to the delta.
+func (d *sizeDelta) add(section memoryType, size int64) {
+	switch section {
+	case memoryCode:
+		d.code += size
+	case memoryROData:
+		d.rodata += size
+	case memoryData:
+		d.data += size
+	case memoryBSS:
+		d.bss += size
+	}
+}
+
+// sortDeltas sorts the deltas by flash growth (largest first), then by RAM
+// growth, then by name.
+func sortDeltas(deltas []*sizeDelta) {
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].flash() != deltas[j].flash() {
+			return deltas[i].flash() > deltas[j].flash()
+		}
+		if deltas[i].ram() != deltas[j].ram() {
+			return deltas[i].ram() > deltas[j].ram()
+		}
+		return deltas[i].name < deltas[j].name
+	})
+}
+
+// printSizeDiff prints the changes in size between two builds, per package and
+// per symbol. Packages and symbols that didn't change in size are omitted.
+func printSizeDiff(oldSizes, newSizes *programSize, w io.Writer) {
+	// Calculate the change in size of each package.
+	packageDeltas := make(map[string]*sizeDelta)
+	getPackage := func(name string) *sizeDelta {
+		if packageDeltas[name] == nil {
+			packageDeltas[name] = &sizeDelta{name: name}
+		}
+		return packageDeltas[name]
+	}
+	for name, pkg := range oldSizes.Packages {
+		d := getPackage(name)
+		d.code -= int64(pkg.Code)
+		d.rodata -= int64(pkg.ROData)
+		d.data -= int64(pkg.Data)
+		d.bss -= int64(pkg.BSS)
+	}
+	for name, pkg := range newSizes.Packages {
+		d := getPackage(name)
+		d.code += int64(pkg.Code)
+		d.rodata += int64(pkg.ROData)
+		d.data += int64(pkg.Data)
+		d.bss += int64(pkg.BSS)
+	}
+	var packages []*sizeDelta
+	for _, d := range packageDeltas {
+		if !d.isZero() {
+			packages = append(packages, d)
+		}
+	}
+	sortDeltas(packages)
+
+	// Calculate the change in size of each symbol. Symbols with the same name
+	// (such as static functions in C) are added together.
+	symbolDeltas := make(map[string]*sizeDelta)
+	getSymbol := func(name string) *sizeDelta {
+		if symbolDeltas[name] == nil {
+			symbolDeltas[name] = &sizeDelta{name: name}
+		}
+		return symbolDeltas[name]
+	}
+	for _, symbol := range oldSizes.Symbols {
+		getSymbol(symbol.Name).add(symbol.Section, -int64(symbol.Size))
+	}
+	for _, symbol := range newSizes.Symbols {
+		getSymbol(symbol.Name).add(symbol.Section, int64(symbol.Size))
+	}
+	var symbols []*sizeDelta
+	for _, d := range symbolDeltas {
+		if !d.isZero() {
+			symbols = append(symbols, d)
+		}
+	}
+	sortDeltas(symbols)
+
+	if len(packages) == 0 && len(symbols) == 0 {
+		fmt.Fprintln(w, "no change in size")
+		return
+	}
+
+	total := &sizeDelta{
+		code:   int64(newSizes.Code) - int64(oldSizes.Code),
+		rodata: int64(newSizes.ROData) - int64(oldSizes.ROData),
+		data:   int64(newSizes.Data) - int64(oldSizes.Data),
+		bss:    int64(newSizes.BSS) - int64(oldSizes.BSS),
+	}
+	fmt.Fprintf(w, "   code  rodata    data     bss |   flash     ram | package\n")
+	fmt.Fprintf(w, "------------------------------- | --------------- | -------\n")
+	for _, d := range packages {
+		fmt.Fprintf(w, "%7s %7s %7s %7s | %7s %7s | %s\n", formatDelta(d.code), formatDelta(d.rodata), formatDelta(d.data), formatDelta(d.bss), formatDelta(d.flash()), formatDelta(d.ram()), d.name)
+	}
+	fmt.Fprintf(w, "------------------------------- | --------------- | -------\n")
+	fmt.Fprintf(w, "%7s %7s %7s %7s | %7s %7s | total\n", formatDelta(total.code), formatDelta(total.rodata), formatDelta(total.data), formatDelta(total.bss), formatDelta(total.flash()), formatDelta(total.ram()))
+
+	if len(symbols) != 0 {
+		fmt.Fprintf(w, "\n  flash     ram | symbol\n")
+		fmt.Fprintf(w, "--------------- | ------\n")
+		for _, d := range symbols {
+			fmt.Fprintf(w, "%7s %7s | %s\n", formatDelta(d.flash()), formatDelta(d.ram()), d.name)
+		}
+	}
+}
+
+// formatDelta formats a change in size, with an explicit sign.
+func formatDelta(n int64) string {
+	if n == 0 {
+		return "0"
+	}
+	if n > 0 {
+		return "+" + strconv.FormatInt(n, 10)
+	}
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,68 @@
+package builder
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSizeDiff(t *testing.T) {
+	oldSizes := &programSize{
+		Packages: map[string]packageSize{
+			"main":    {Code: 100, ROData: 20},
+			"runtime": {Code: 1000, Data: 8, BSS: 100},
+			"strconv": {Code: 300},
+		},
+		Symbols: []symbolSize{
+			{Name: "main.main", Section: memoryCode, Size: 100},
+			{Name: "runtime.heap", Section: memoryBSS, Size: 100},
+			{Name: "strconv.Itoa", Section: memoryCode, Size: 300},
+		},
+		Code:   1400,
+		ROData: 20,
+		Data:   8,
+		BSS:    100,
+	}
+	newSizes := &programSize{
+		Packages: map[string]packageSize{
+			"main":    {Code: 140, ROData: 20},
+			"runtime": {Code: 1000, Data: 8, BSS: 100},
+			"fmt":     {Code: 500, BSS: 16},
+		},
+		Symbols: []symbolSize{
+			{Name: "main.main", Section: memoryCode, Size: 140},
+			{Name: "runtime.heap", Section: memoryBSS, Size: 100},
+			{Name: "fmt.Println", Section: memoryCode, Size: 500},
+			{Name: "fmt.buf", Section: memoryBSS, Size: 16},
+		},
+		Code:   1640,
+		ROData: 20,
+		Data:   8,
+		BSS:    116,
+	}
+	const expected = `   code  rodata    data     bss |   flash     ram | package
+------------------------------- | --------------- | -------
+   +500       0       0     +16 |    +500     +16 | fmt
+    +40       0       0       0 |     +40       0 | main
+   -300       0       0       0 |    -300       0 | strconv
+------------------------------- | --------------- | -------
+   +240       0       0     +16 |    +240     +16 | total
+
+  flash     ram | symbol
+--------------- | ------
+   +500       0 | fmt.Println
+    +40       0 | main.main
+      0     +16 | fmt.buf
+   -300       0 | strconv.Itoa
+`
+	buf := &bytes.Buffer{}
+	printSizeDiff(oldSizes, newSizes, buf)
+	if buf.String() != expected {
+		t.Errorf("unexpected size diff, got:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	printSizeDiff(oldSizes, oldSizes, buf)
+	if buf.String() != "no change in size\n" {
+		t.Errorf("unexpected size diff for identical builds:\n%s", buf.String())
+	}
+}
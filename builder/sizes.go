@@ -6,12 +6,15 @@ import (
 	"debug/elf"
 	"debug/pe"
 	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/aykevl/go-wasm"
@@ -23,11 +26,13 @@ const sizesDebug = false
 
 // programSize contains size statistics per package of a compiled program.
 type programSize struct {
-	Packages map[string]packageSize
-	Code     uint64
-	ROData   uint64
-	Data     uint64
-	BSS      uint64
+	Packages map[string]packageSize `json:"packages"`
+	Symbols  []symbolSize           `json:"symbols"` // only available for ELF files
+	Lines    []lineSize             `json:"lines"`   // sorted by flash usage
+	Code     uint64                 `json:"code"`
+	ROData   uint64                 `json:"rodata"`
+	Data     uint64                 `json:"data"`
+	BSS      uint64                 `json:"bss"`
 }
 
 // sortedPackageNames returns the list of package names (ProgramSize.Packages)
@@ -54,10 +59,24 @@ func (ps *programSize) RAM() uint64 {
 // packageSize contains the size of a package, calculated from the linked object
 // file.
 type packageSize struct {
-	Code   uint64
-	ROData uint64
-	Data   uint64
-	BSS    uint64
+	Code   uint64 `json:"code"`
+	ROData uint64 `json:"rodata"`
+	Data   uint64 `json:"data"`
+	BSS    uint64 `json:"bss"`
+}
+
+// add adds the given number of bytes in the given type of memory.
+func (ps *packageSize) add(typ memoryType, size uint64) {
+	switch typ {
+	case memoryCode:
+		ps.Code += size
+	case memoryROData:
+		ps.ROData += size
+	case memoryData:
+		ps.Data += size
+	case memoryBSS:
+		ps.BSS += size
+	}
 }
 
 // Flash usage in regular microcontrollers.
@@ -70,11 +89,150 @@ func (ps *packageSize) RAM() uint64 {
 	return ps.Data + ps.BSS
 }
 
+// symbolSize is the size of a single symbol (function or global) in the linked
+// binary.
+type symbolSize struct {
+	Name    string     `json:"name"`
+	Package string     `json:"package"`
+	Section memoryType `json:"section"`
+	Address uint64     `json:"address"`
+	Size    uint64     `json:"size"`
+	File    string     `json:"file,omitempty"` // source location, if known
+	Line    int        `json:"line,omitempty"`
+}
+
+// Flash usage in regular microcontrollers.
+func (s *symbolSize) Flash() uint64 {
+	switch s.Section {
+	case memoryCode, memoryROData, memoryData:
+		return s.Size
+	default:
+		return 0
+	}
+}
+
+// Static RAM usage in regular microcontrollers.
+func (s *symbolSize) RAM() uint64 {
+	switch s.Section {
+	case memoryData, memoryBSS:
+		return s.Size
+	default:
+		return 0
+	}
+}
+
+// lineSize is the size of all code and data that the DWARF debug information
+// attributes to a single line of source code. Inlined code is attributed to the
+// line it was inlined from.
+type lineSize struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Package string `json:"package"`
+	packageSize
+}
+
+// printJSON writes the size of every package and every symbol as JSON, so that
+// it can be processed by other tools.
+func (ps *programSize) printJSON(w io.Writer) error {
+	type jsonPackageSize struct {
+		packageSize
+		Flash uint64 `json:"flash"`
+		RAM   uint64 `json:"ram"`
+	}
+	packages := make(map[string]jsonPackageSize, len(ps.Packages))
+	for name, pkg := range ps.Packages {
+		packages[name] = jsonPackageSize{pkg, pkg.Flash(), pkg.RAM()}
+	}
+	symbols := ps.Symbols
+	if symbols == nil {
+		symbols = []symbolSize{} // print [] instead of null
+	}
+	lines := ps.Lines
+	if lines == nil {
+		lines = []lineSize{}
+	}
+	data, err := json.MarshalIndent(struct {
+		Packages map[string]jsonPackageSize `json:"packages"`
+		Symbols  []symbolSize               `json:"symbols"`
+		Lines    []lineSize                 `json:"lines"`
+		Code     uint64                     `json:"code"`
+		ROData   uint64                     `json:"rodata"`
+		Data     uint64                     `json:"data"`
+		BSS      uint64                     `json:"bss"`
+		Flash    uint64                     `json:"flash"`
+		RAM      uint64                     `json:"ram"`
+	}{
+		Packages: packages,
+		Symbols:  symbols,
+		Lines:    lines,
+		Code:     ps.Code,
+		ROData:   ps.ROData,
+		Data:     ps.Data,
+		BSS:      ps.BSS,
+		Flash:    ps.Flash(),
+		RAM:      ps.RAM(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// printCSV writes the size of every package as CSV, with one package per row.
+func (ps *programSize) printCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"package", "code", "rodata", "data", "bss", "flash", "ram"})
+	for _, name := range ps.sortedPackageNames() {
+		pkg := ps.Packages[name]
+		cw.Write([]string{name, strconv.FormatUint(pkg.Code, 10), strconv.FormatUint(pkg.ROData, 10), strconv.FormatUint(pkg.Data, 10), strconv.FormatUint(pkg.BSS, 10), strconv.FormatUint(pkg.Flash(), 10), strconv.FormatUint(pkg.RAM(), 10)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// sortedSymbols returns a copy of the list of symbols, sorted by flash usage
+// (largest first), then by RAM usage, then by name.
+func (ps *programSize) sortedSymbols() []symbolSize {
+	symbols := append([]symbolSize(nil), ps.Symbols...)
+	sort.SliceStable(symbols, func(i, j int) bool {
+		if symbols[i].Flash() != symbols[j].Flash() {
+			return symbols[i].Flash() > symbols[j].Flash()
+		}
+		if symbols[i].RAM() != symbols[j].RAM() {
+			return symbols[i].RAM() > symbols[j].RAM()
+		}
+		return symbols[i].Name < symbols[j].Name
+	})
+	return symbols
+}
+
+// printSymbols prints the size of every function and global, followed by the
+// size of every line of source code, both with the largest first.
+func (ps *programSize) printSymbols(w io.Writer) {
+	fmt.Fprintf(w, "  flash     ram | symbol\n")
+	fmt.Fprintf(w, "--------------- | ------\n")
+	for _, symbol := range ps.sortedSymbols() {
+		location := symbol.Package
+		if symbol.File != "" {
+			location += ", " + symbol.File + ":" + strconv.Itoa(symbol.Line)
+		}
+		fmt.Fprintf(w, "%7d %7d | %s (%s)\n", symbol.Flash(), symbol.RAM(), symbol.Name, location)
+	}
+
+	fmt.Fprintf(w, "\n  flash     ram | source line\n")
+	fmt.Fprintf(w, "--------------- | -----------\n")
+	for _, line := range ps.Lines {
+		fmt.Fprintf(w, "%7d %7d | %s:%d (%s)\n", line.Flash(), line.RAM(), line.File, line.Line, line.Package)
+	}
+}
+
 // A mapping of a single chunk of code or data to a file path.
 type addressLine struct {
 	Address    uint64
 	Length     uint64 // length of this chunk
 	File       string // file path as stored in DWARF
+	Line       int    // line number, or 0 if unknown
 	IsVariable bool   // true if this is a variable (or constant), false if it is code
 }
 
@@ -97,15 +255,63 @@ const (
 	memoryStack
 )
 
+var memoryTypeNames = [...]string{
+	0:            "-",
+	memoryCode:   "code",
+	memoryData:   "data",
+	memoryROData: "rodata",
+	memoryBSS:    "bss",
+	memoryStack:  "stack",
+}
+
 func (t memoryType) String() string {
-	return [...]string{
-		0:            "-",
-		memoryCode:   "code",
-		memoryData:   "data",
-		memoryROData: "rodata",
-		memoryBSS:    "bss",
-		memoryStack:  "stack",
-	}[t]
+	return memoryTypeNames[t]
+}
+
+// MarshalText implements encoding.TextMarshaler, for the JSON output.
+func (t memoryType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, for reading back the JSON
+// output in size-diff.
+func (t *memoryType) UnmarshalText(text []byte) error {
+	for i, name := range memoryTypeNames {
+		if string(text) == name {
+			*t = memoryType(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown memory type: %s", text)
+}
+
+// elfSectionType returns the kind of memory an ELF section is stored in, or 0
+// if it isn't stored in memory at all (for example, debug information).
+func elfSectionType(section *elf.Section) memoryType {
+	if section.Flags&elf.SHF_ALLOC == 0 {
+		return 0
+	}
+	if section.Type == elf.SHT_NOBITS {
+		if section.Name == ".stack" {
+			// TinyGo emits stack sections on microcontroller using the
+			// ".stack" name.
+			// This is a bit ugly, but I don't think there is a way to
+			// mark the stack section in a linker script.
+			return memoryStack
+		}
+		// Regular .bss section.
+		return memoryBSS
+	} else if section.Type == elf.SHT_PROGBITS && section.Flags&elf.SHF_EXECINSTR != 0 {
+		// .text
+		return memoryCode
+	} else if section.Type == elf.SHT_PROGBITS && section.Flags&elf.SHF_WRITE != 0 {
+		// .data
+		return memoryData
+	} else if section.Type == elf.SHT_PROGBITS {
+		// .rodata
+		return memoryROData
+	}
+	return 0
 }
 
 // Regular expressions to match particular symbol names. These are not stored as
@@ -195,6 +401,7 @@ func readProgramSizeFromDWARF(data *dwarf.Data, codeOffset uint64) ([]addressLin
 						Address: prevLineEntry.Address + codeOffset,
 						Length:  lineEntry.Address - prevLineEntry.Address,
 						File:    prevLineEntry.File.Name,
+						Line:    prevLineEntry.Line,
 					}
 					if line.Length != 0 {
 						addresses = append(addresses, line)
@@ -242,10 +449,16 @@ func readProgramSizeFromDWARF(data *dwarf.Data, codeOffset uint64) ([]addressLin
 				return nil, err
 			}
 
+			var line int
+			if declLine, ok := e.Val(dwarf.AttrDeclLine).(int64); ok {
+				line = int(declLine)
+			}
+
 			addresses = append(addresses, addressLine{
 				Address:    addr,
 				Length:     uint64(typ.Size()),
 				File:       lines[file.Val.(int64)].Name,
+				Line:       line,
 				IsVariable: true,
 			})
 		default:
@@ -255,6 +468,65 @@ func readProgramSizeFromDWARF(data *dwarf.Data, codeOffset uint64) ([]addressLin
 	return addresses, nil
 }
 
+// readFunctionsFromDWARF reads the source location where each function is
+// declared from the DWARF debug information, indexed by the start address of
+// the function.
+func readFunctionsFromDWARF(data *dwarf.Data) (map[uint64]addressLine, error) {
+	functions := make(map[uint64]addressLine)
+	r := data.Reader()
+	var files []*dwarf.LineFile
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			break
+		}
+		switch e.Tag {
+		case dwarf.TagCompileUnit:
+			lr, err := data.LineReader(e)
+			if err != nil {
+				return nil, err
+			}
+			files = nil
+			if lr != nil {
+				files = lr.Files()
+			}
+		case dwarf.TagSubprogram:
+			r.SkipChildren()
+			lowpc, ok := e.Val(dwarf.AttrLowpc).(uint64)
+			if !ok {
+				// Abstract (inlined) or external function.
+				continue
+			}
+			if origin, ok := e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset); ok {
+				// A function that is also inlined elsewhere. The declaration
+				// is stored in the abstract entry.
+				or := data.Reader()
+				or.Seek(origin)
+				e, err = or.Next()
+				if err != nil || e == nil {
+					continue
+				}
+			}
+			file, ok1 := e.Val(dwarf.AttrDeclFile).(int64)
+			line, ok2 := e.Val(dwarf.AttrDeclLine).(int64)
+			if !ok1 || !ok2 || file <= 0 || int(file) >= len(files) || files[file] == nil {
+				continue
+			}
+			functions[lowpc] = addressLine{
+				Address: lowpc,
+				File:    files[file].Name,
+				Line:    int(line),
+			}
+		default:
+			r.SkipChildren()
+		}
+	}
+	return functions, nil
+}
+
 // loadProgramSize calculate a program/data size breakdown of each package for a
 // given ELF file.
 // If the file doesn't contain DWARF debug information, the returned program
@@ -271,6 +543,12 @@ func loadProgramSize(path string, packagePathMap map[string]string) (*programSiz
 	// This stores all chunks of addresses found in the binary.
 	var addresses []addressLine
 
+	// All functions and globals, as far as they're known.
+	var symbols []symbolSize
+
+	// The location where each function is declared, if known.
+	var functions map[uint64]addressLine
+
 	// Load the binary file, which could be in a number of file formats.
 	var sections []memorySection
 	if file, err := elf.NewFile(f); err == nil {
@@ -283,6 +561,10 @@ func loadProgramSize(path string, packagePathMap map[string]string) (*programSiz
 				// wrong while trying to parse DWARF data.
 				return nil, err
 			}
+			functions, err = readFunctionsFromDWARF(data)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		// Read the ELF symbols for some more chunks of location information.
@@ -311,6 +593,14 @@ func loadProgramSize(path string, packagePathMap map[string]string) (*programSiz
 			if section.Flags&elf.SHF_ALLOC == 0 {
 				continue
 			}
+			if typ := elfSectionType(section); symType != elf.STT_NOTYPE && typ != 0 {
+				symbols = append(symbols, symbolSize{
+					Name:    symbol.Name,
+					Section: typ,
+					Address: symbol.Value,
+					Size:    symbol.Size,
+				})
+			}
 			if packageSymbolRegexp.MatchString(symbol.Name) || reflectDataRegexp.MatchString(symbol.Name) {
 				addresses = append(addresses, addressLine{
 					Address:    symbol.Value,
@@ -323,50 +613,15 @@ func loadProgramSize(path string, packagePathMap map[string]string) (*programSiz
 
 		// Load allocated sections.
 		for _, section := range file.Sections {
-			if section.Flags&elf.SHF_ALLOC == 0 {
+			typ := elfSectionType(section)
+			if typ == 0 {
 				continue
 			}
-			if section.Type == elf.SHT_NOBITS {
-				if section.Name == ".stack" {
-					// TinyGo emits stack sections on microcontroller using the
-					// ".stack" name.
-					// This is a bit ugly, but I don't think there is a way to
-					// mark the stack section in a linker script.
-					sections = append(sections, memorySection{
-						Address: section.Addr,
-						Size:    section.Size,
-						Type:    memoryStack,
-					})
-				} else {
-					// Regular .bss section.
-					sections = append(sections, memorySection{
-						Address: section.Addr,
-						Size:    section.Size,
-						Type:    memoryBSS,
-					})
-				}
-			} else if section.Type == elf.SHT_PROGBITS && section.Flags&elf.SHF_EXECINSTR != 0 {
-				// .text
-				sections = append(sections, memorySection{
-					Address: section.Addr,
-					Size:    section.Size,
-					Type:    memoryCode,
-				})
-			} else if section.Type == elf.SHT_PROGBITS && section.Flags&elf.SHF_WRITE != 0 {
-				// .data
-				sections = append(sections, memorySection{
-					Address: section.Addr,
-					Size:    section.Size,
-					Type:    memoryData,
-				})
-			} else if section.Type == elf.SHT_PROGBITS {
-				// .rodata
-				sections = append(sections, memorySection{
-					Address: section.Addr,
-					Size:    section.Size,
-					Type:    memoryROData,
-				})
-			}
+			sections = append(sections, memorySection{
+				Address: section.Addr,
+				Size:    section.Size,
+				Type:    typ,
+			})
 		}
 	} else if file, err := pe.NewFile(f); err == nil {
 		// Read DWARF information. The error is intentionally ignored.
@@ -530,50 +785,107 @@ func loadProgramSize(path string, packagePathMap map[string]string) (*programSiz
 		return addresses[i].Address < addresses[j].Address
 	})
 
-	// Now finally determine the binary/RAM size usage per package by going
-	// through each allocated section.
+	// Now finally determine the binary/RAM size usage per package and per
+	// source line by going through each allocated section.
+	type sourceLine struct {
+		file string
+		line int
+	}
 	sizes := make(map[string]packageSize)
+	lineSizes := make(map[sourceLine]*lineSize)
 	for _, section := range sections {
-		switch section.Type {
-		case memoryCode:
-			readSection(section, addresses, func(path string, size uint64, isVariable bool) {
-				field := sizes[path]
-				if isVariable {
-					field.ROData += size
-				} else {
-					field.Code += size
-				}
-				sizes[path] = field
-			}, packagePathMap)
-		case memoryROData:
-			readSection(section, addresses, func(path string, size uint64, isVariable bool) {
-				field := sizes[path]
-				field.ROData += size
-				sizes[path] = field
-			}, packagePathMap)
-		case memoryData:
-			readSection(section, addresses, func(path string, size uint64, isVariable bool) {
-				field := sizes[path]
-				field.Data += size
-				sizes[path] = field
-			}, packagePathMap)
-		case memoryBSS:
-			readSection(section, addresses, func(path string, size uint64, isVariable bool) {
-				field := sizes[path]
-				field.BSS += size
-				sizes[path] = field
-			}, packagePathMap)
-		case memoryStack:
+		if section.Type == memoryStack {
 			// We store the C stack as a pseudo-package.
 			sizes["C stack"] = packageSize{
 				BSS: section.Size,
 			}
+			continue
 		}
+		readSection(section, addresses, func(line *addressLine, size uint64) {
+			path := "(unknown)"
+			typ := section.Type
+			if line != nil {
+				path = findPackagePath(line.File, packagePathMap)
+				if typ == memoryCode && line.IsVariable {
+					// Constants can be stored in the code section.
+					typ = memoryROData
+				}
+			}
+			field := sizes[path]
+			field.add(typ, size)
+			sizes[path] = field
+
+			if line == nil || line.Line == 0 {
+				// Not a line of source code, for example a string buffer.
+				return
+			}
+			key := sourceLine{line.File, line.Line}
+			if lineSizes[key] == nil {
+				lineSizes[key] = &lineSize{
+					File:    line.File,
+					Line:    line.Line,
+					Package: path,
+				}
+			}
+			lineSizes[key].add(typ, size)
+		})
 	}
+	lines := make([]lineSize, 0, len(lineSizes))
+	for _, line := range lineSizes {
+		lines = append(lines, *line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Flash() != lines[j].Flash() {
+			return lines[i].Flash() > lines[j].Flash()
+		}
+		if lines[i].RAM() != lines[j].RAM() {
+			return lines[i].RAM() > lines[j].RAM()
+		}
+		if lines[i].File != lines[j].File {
+			return lines[i].File < lines[j].File
+		}
+		return lines[i].Line < lines[j].Line
+	})
+
+	// Determine the package and source location of each symbol, using the
+	// same information as used for the package sizes above.
+	for i := range symbols {
+		symbol := &symbols[i]
+		if index := findAddressLine(addresses, symbol.Address); index >= 0 {
+			symbol.Package = findPackagePath(addresses[index].File, packagePathMap)
+			if fn, ok := functions[symbol.Address]; ok && symbol.Section == memoryCode {
+				symbol.File = fn.File
+				symbol.Line = fn.Line
+				continue
+			}
+			// The first instructions of a function (the prologue) usually
+			// don't have a line number, so use the first chunk that does.
+			for _, line := range addresses[index:] {
+				if line.Address >= symbol.Address+symbol.Size {
+					break
+				}
+				if line.Line != 0 {
+					symbol.File = line.File
+					symbol.Line = line.Line
+					break
+				}
+			}
+		} else {
+			symbol.Package = findSymbolPackage(symbol.Name, packagePathMap)
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].Address == symbols[j].Address {
+			return symbols[i].Name < symbols[j].Name
+		}
+		return symbols[i].Address < symbols[j].Address
+	})
 
 	// ...and summarize the results.
 	program := &programSize{
 		Packages: sizes,
+		Symbols:  symbols,
+		Lines:    lines,
 	}
 	for _, pkg := range sizes {
 		program.Code += pkg.Code
@@ -585,8 +897,9 @@ func loadProgramSize(path string, packagePathMap map[string]string) (*programSiz
 }
 
 // readSection determines for each byte in this section to which package it
-// belongs. It reports this usage through the addSize callback.
-func readSection(section memorySection, addresses []addressLine, addSize func(string, uint64, bool), packagePathMap map[string]string) {
+// belongs. It reports this usage through the addSize callback, with a nil line
+// for bytes that aren't described by any address chunk.
+func readSection(section memorySection, addresses []addressLine, addSize func(line *addressLine, size uint64)) {
 	// The addr variable tracks at which address we are while going through this
 	// section. We start at the beginning.
 	addr := section.Address
@@ -594,7 +907,8 @@ func readSection(section memorySection, addresses []addressLine, addSize func(st
 	if sizesDebug {
 		fmt.Printf("%08x..%08x %5d: %s\n", addr, sectionEnd, section.Size, section.Type)
 	}
-	for _, line := range addresses {
+	for i := range addresses {
+		line := &addresses[i]
 		if line.Address < section.Address || line.Address+line.Length > sectionEnd {
 			// Check that this line is entirely within the section.
 			// Don't bother dealing with line entries that cross sections (that
@@ -604,7 +918,7 @@ func readSection(section memorySection, addresses []addressLine, addSize func(st
 		if addr < line.Address {
 			// There is a gap: there is a space between the current and the
 			// previous line entry.
-			addSize("(unknown)", line.Address-addr, false)
+			addSize(nil, line.Address-addr)
 			if sizesDebug {
 				fmt.Printf("%08x..%08x %5d:  unknown (gap)\n", addr, line.Address, line.Address-addr)
 			}
@@ -623,13 +937,13 @@ func readSection(section memorySection, addresses []addressLine, addSize func(st
 			// remaining bit of the line entry.
 			length = line.Length - (addr - line.Address)
 		}
-		// Finally, mark this chunk of memory as used by the given package.
-		addSize(findPackagePath(line.File, packagePathMap), length, line.IsVariable)
+		// Finally, mark this chunk of memory as used by the given line.
+		addSize(line, length)
 		addr = line.Address + line.Length
 	}
 	if addr < sectionEnd {
 		// There is a gap at the end of the section.
-		addSize("(unknown)", sectionEnd-addr, false)
+		addSize(nil, sectionEnd-addr)
 		if sizesDebug {
 			fmt.Printf("%08x..%08x %5d:  unknown (end)\n", addr, sectionEnd, sectionEnd-addr)
 		}
@@ -674,3 +988,36 @@ func findPackagePath(path string, packagePathMap map[string]string) string {
 	}
 	return packagePath
 }
+
+// findAddressLine returns the index of the chunk of code or data (as read from
+// the DWARF debug information) that contains the given address, or -1 if there
+// is none. The addresses must be sorted by address.
+func findAddressLine(addresses []addressLine, address uint64) int {
+	i := sort.Search(len(addresses), func(i int) bool {
+		return addresses[i].Address > address
+	})
+	if i > 0 {
+		line := addresses[i-1]
+		if address < line.Address+line.Length {
+			return i - 1
+		}
+	}
+	return -1
+}
+
+// findSymbolPackage returns the package a symbol belongs to, for symbols that
+// aren't described in the DWARF debug information. It does this by parsing the
+// symbol name.
+func findSymbolPackage(name string, packagePathMap map[string]string) string {
+	if packageSymbolRegexp.MatchString(name) || reflectDataRegexp.MatchString(name) {
+		return findPackagePath(name, packagePathMap)
+	}
+
+	// Parse Go symbol names, such as fmt.Println or (*bytes.Buffer).Write.
+	name = strings.TrimLeft(name, "(*")
+	slash := strings.LastIndexByte(name, '/')
+	if dot := strings.IndexByte(name[slash+1:], '.'); dot > 0 {
+		return name[:slash+1+dot]
+	}
+	return "(unknown)"
+}
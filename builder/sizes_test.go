@@ -0,0 +1,96 @@
+package builder
+
+import (
+	"debug/elf"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+)
+
+// Test that a map with basic key types doesn't link in the code for other key
+// types: hashing interface keys needs much of the reflect package, which is
+// too big for small chips.
+func TestMapSize(t *testing.T) {
+	const withoutMap = `package main
+
+func main() {
+	var a [10]int
+	for i := range a {
+		a[i] = i * 2
+	}
+	println(a[3], len(a))
+}
+`
+	const withMap = `package main
+
+func main() {
+	m := map[int]int{}
+	for i := 0; i < 10; i++ {
+		m[i] = i * 2
+	}
+	println(m[3], len(m))
+	for k, v := range m {
+		println(k, v)
+	}
+}
+`
+	baseSize, _ := buildCodeSize(t, "nomap.go", withoutMap)
+	mapSize, symbols := buildCodeSize(t, "map.go", withMap)
+	for _, name := range symbols {
+		if name == "runtime.hashmapInterfaceHash" || strings.HasPrefix(name, "reflect.") || strings.HasPrefix(name, "(reflect.") {
+			t.Errorf("map with int keys links in %s", name)
+		}
+	}
+	// The hashmap implementation is about 1kB on Cortex-M.
+	if mapSize-baseSize > 2048 {
+		t.Errorf("map with int keys adds %d bytes of code (from %d to %d bytes)", mapSize-baseSize, baseSize, mapSize)
+	}
+}
+
+// buildCodeSize compiles the given program to an object file for Cortex-M and
+// returns the size of its code and the names of its symbols.
+func buildCodeSize(t *testing.T, filename, source string) (uint64, []string) {
+	dir := t.TempDir()
+	srcpath := filepath.Join(dir, filename)
+	err := ioutil.WriteFile(srcpath, []byte(source), 0o666)
+	if err != nil {
+		t.Fatal("could not write source file:", err)
+	}
+	config, err := NewConfig(&compileopts.Options{
+		Target:    "cortex-m-qemu",
+		Opt:       "z",
+		Semaphore: make(chan struct{}, 1),
+	})
+	if err != nil {
+		t.Fatal("could not load config:", err)
+	}
+	outpath := filepath.Join(dir, "out.o")
+	err = Build(srcpath, outpath, config, func(BuildResult) error { return nil })
+	if err != nil {
+		t.Fatal("could not build program:", err)
+	}
+
+	file, err := elf.Open(outpath)
+	if err != nil {
+		t.Fatal("could not open object file:", err)
+	}
+	defer file.Close()
+	var size uint64
+	for _, section := range file.Sections {
+		if section.Flags&elf.SHF_EXECINSTR != 0 {
+			size += section.Size
+		}
+	}
+	elfSymbols, err := file.Symbols()
+	if err != nil {
+		t.Fatal("could not read symbols:", err)
+	}
+	var symbols []string
+	for _, symbol := range elfSymbols {
+		symbols = append(symbols, symbol.Name)
+	}
+	return size, symbols
+}
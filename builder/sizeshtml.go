@@ -0,0 +1,157 @@
+package builder
+
+// This file writes the -size=html output: a single self-contained HTML page
+// with a treemap of flash and RAM usage, and tables of the largest symbols and
+// source lines.
+
+import (
+	"html/template"
+	"io"
+	"sort"
+)
+
+// treemapNode is a single rectangle in the treemap, which is either a package
+// or a symbol within a package.
+type treemapNode struct {
+	Name     string
+	Size     uint64
+	Hue      int // only used for packages
+	Children []*treemapNode
+}
+
+// makeTreemap creates a treemap of all packages and the symbols within them,
+// using the given function to determine the size of a package or symbol (for
+// example, flash or RAM usage).
+func (ps *programSize) makeTreemap(name string, packageSize func(*packageSize) uint64, symbolSize func(*symbolSize) uint64) *treemapNode {
+	root := &treemapNode{Name: name}
+	packages := make(map[string]*treemapNode)
+	for i, name := range ps.sortedPackageNames() {
+		pkg := ps.Packages[name]
+		size := packageSize(&pkg)
+		if size == 0 {
+			continue
+		}
+		node := &treemapNode{
+			Name: name,
+			Size: size,
+			Hue:  i * 47 % 360, // spread the colors of neighboring packages
+		}
+		packages[name] = node
+		root.Children = append(root.Children, node)
+		root.Size += size
+	}
+	for i := range ps.Symbols {
+		symbol := &ps.Symbols[i]
+		size := symbolSize(symbol)
+		pkg := packages[symbol.Package]
+		if size == 0 || pkg == nil {
+			continue
+		}
+		pkg.Children = append(pkg.Children, &treemapNode{
+			Name: symbol.Name,
+			Size: size,
+		})
+	}
+	for _, pkg := range root.Children {
+		// Symbols don't necessarily cover all bytes of a package. Add the
+		// remainder as a separate node.
+		var total uint64
+		for _, symbol := range pkg.Children {
+			total += symbol.Size
+		}
+		if len(pkg.Children) != 0 && total < pkg.Size {
+			pkg.Children = append(pkg.Children, &treemapNode{
+				Name: "(other)",
+				Size: pkg.Size - total,
+			})
+		}
+		sort.SliceStable(pkg.Children, func(i, j int) bool {
+			return pkg.Children[i].Size > pkg.Children[j].Size
+		})
+	}
+	sort.SliceStable(root.Children, func(i, j int) bool {
+		return root.Children[i].Size > root.Children[j].Size
+	})
+	return root
+}
+
+// printHTML writes a self-contained HTML page with the size of every package,
+// symbol and source line.
+func (ps *programSize) printHTML(w io.Writer) error {
+	type namedPackageSize struct {
+		Name string
+		packageSize
+	}
+	var packages []namedPackageSize
+	for _, name := range ps.sortedPackageNames() {
+		packages = append(packages, namedPackageSize{name, ps.Packages[name]})
+	}
+	return sizesHTMLTemplate.Execute(w, map[string]interface{}{
+		"Sizes":    ps,
+		"Packages": packages,
+		"Flash":    ps.makeTreemap("flash", (*packageSize).Flash, (*symbolSize).Flash),
+		"RAM":      ps.makeTreemap("RAM", (*packageSize).RAM, (*symbolSize).RAM),
+		"Symbols":  ps.sortedSymbols(),
+	})
+}
+
+var sizesHTMLTemplate = template.Must(template.New("sizes").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Program size</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; margin: 1em; }
+.treemap { display: flex; height: 60vh; border: 1px solid #444; margin-bottom: 2em; }
+.node { display: flex; flex-direction: column; flex-basis: 0; min-width: 0; min-height: 0; overflow: hidden; box-sizing: border-box; border: 1px solid rgba(0, 0, 0, 0.3); }
+.label { font-size: 12px; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; padding: 1px 2px; }
+.children { display: flex; flex: 1; min-width: 0; min-height: 0; }
+.treemap > .node > .children { flex-direction: column; } /* slice-and-dice layout */
+.children > .node { background: rgba(255, 255, 255, 0.4); }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { text-align: left; padding: 2px 8px; }
+td.size { text-align: right; font-family: monospace; }
+tr:nth-child(even) { background: #eee; }
+</style>
+</head>
+<body>
+{{define "treemap"}}<h2>{{.Name}}: {{.Size}} bytes</h2>
+<div class="treemap">
+{{- range .Children}}
+<div class="node" style="flex-grow: {{.Size}}; background: hsl({{.Hue}}, 60%, 75%)" title="{{.Name}}: {{.Size}} bytes">
+<div class="label">{{.Name}}</div>
+<div class="children">
+{{- range .Children}}
+<div class="node" style="flex-grow: {{.Size}}" title="{{.Name}}: {{.Size}} bytes"><div class="label">{{.Name}}</div></div>
+{{- end}}
+</div>
+</div>
+{{- end}}
+</div>
+{{end}}
+{{template "treemap" .Flash}}
+{{template "treemap" .RAM}}
+<h2>Packages</h2>
+<table>
+<tr><th>code</th><th>rodata</th><th>data</th><th>bss</th><th>flash</th><th>ram</th><th>package</th></tr>
+{{- range .Packages}}
+<tr><td class="size">{{.Code}}</td><td class="size">{{.ROData}}</td><td class="size">{{.Data}}</td><td class="size">{{.BSS}}</td><td class="size">{{.Flash}}</td><td class="size">{{.RAM}}</td><td>{{.Name}}</td></tr>
+{{- end}}
+</table>
+<h2>Symbols</h2>
+<table>
+<tr><th>flash</th><th>ram</th><th>symbol</th><th>package</th><th>source</th></tr>
+{{- range .Symbols}}
+<tr><td class="size">{{.Flash}}</td><td class="size">{{.RAM}}</td><td>{{.Name}}</td><td>{{.Package}}</td><td>{{if .File}}{{.File}}:{{.Line}}{{end}}</td></tr>
+{{- end}}
+</table>
+<h2>Source lines</h2>
+<table>
+<tr><th>flash</th><th>ram</th><th>source</th><th>package</th></tr>
+{{- range .Sizes.Lines}}
+<tr><td class="size">{{.Flash}}</td><td class="size">{{.RAM}}</td><td>{{.File}}:{{.Line}}</td><td>{{.Package}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,61 @@
+package builder
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSizesHTML(t *testing.T) {
+	sizes := &programSize{
+		Packages: map[string]packageSize{
+			"main":    {Code: 100, ROData: 20},
+			"runtime": {Code: 1000, BSS: 100},
+		},
+		Symbols: []symbolSize{
+			{Name: "main.main", Package: "main", Section: memoryCode, Size: 100},
+			{Name: "runtime.alloc", Package: "runtime", Section: memoryCode, Size: 700, File: "gc.go", Line: 10},
+			{Name: "runtime.heap", Package: "runtime", Section: memoryBSS, Size: 100},
+		},
+		Lines: []lineSize{
+			{File: "gc.go", Line: 12, Package: "runtime", packageSize: packageSize{Code: 40}},
+		},
+		Code:   1100,
+		ROData: 20,
+		BSS:    100,
+	}
+
+	// Check that the bytes not covered by a symbol are still part of the
+	// treemap.
+	flash := sizes.makeTreemap("flash", (*packageSize).Flash, (*symbolSize).Flash)
+	if flash.Size != 1120 || len(flash.Children) != 2 {
+		t.Fatalf("unexpected flash treemap: size %d with %d packages", flash.Size, len(flash.Children))
+	}
+	runtime := flash.Children[0]
+	if runtime.Name != "runtime" || len(runtime.Children) != 2 || runtime.Children[1].Name != "(other)" || runtime.Children[1].Size != 300 {
+		t.Errorf("unexpected runtime node: %#v", runtime)
+	}
+	main := flash.Children[1]
+	if main.Name != "main" || len(main.Children) != 2 || main.Children[1].Size != 20 {
+		t.Errorf("unexpected main node: %#v", main)
+	}
+	ram := sizes.makeTreemap("RAM", (*packageSize).RAM, (*symbolSize).RAM)
+	if ram.Size != 100 || len(ram.Children) != 1 || len(ram.Children[0].Children) != 1 {
+		t.Errorf("unexpected RAM treemap: %#v", ram)
+	}
+
+	buf := &bytes.Buffer{}
+	err := sizes.printHTML(buf)
+	if err != nil {
+		t.Fatal("could not write HTML:", err)
+	}
+	for _, s := range []string{
+		`title="runtime.alloc: 700 bytes"`,
+		`<td>gc.go:10</td>`,
+		`<td>gc.go:12</td>`,
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("HTML output doesn't contain %q", s)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/tinygo-org/tinygo/goenv"
 )
@@ -85,7 +86,7 @@ func (c *Config) CgoEnabled() bool {
 }
 
 // GC returns the garbage collection strategy in use on this platform. Valid
-// values are "none", "leaking", and "conservative".
+// values are "none", "leaking", "conservative" and "precise".
 func (c *Config) GC() string {
 	if c.Options.GC != "" {
 		return c.Options.GC
@@ -100,7 +101,7 @@ func (c *Config) GC() string {
 // that can be traced by the garbage collector.
 func (c *Config) NeedsStackObjects() bool {
 	switch c.GC() {
-	case "conservative":
+	case "conservative", "precise":
 		for _, tag := range c.BuildTags() {
 			if tag == "tinygo.wasm" {
 				return true
@@ -176,6 +177,22 @@ func (c *Config) AutomaticStackSize() bool {
 	return false
 }
 
+// PCTableSize returns the number of bytes reserved in the binary for the table
+// that maps program counters to functions and source locations, which is used
+// for stack traces. If it is zero, stack traces are disabled.
+func (c *Config) PCTableSize() int {
+	return c.Options.PCTableSize
+}
+
+// ReflectNames returns whether the names and package paths of named types
+// should be stored in the binary for use by the reflect package (for example
+// in reflect.Type.String). Leaving them out only saves the space taken by the
+// names themselves: the code of reflect.Type.String and the method sidetables
+// are still included when the program uses them.
+func (c *Config) ReflectNames() bool {
+	return !c.Options.NoReflectNames
+}
+
 // RP2040BootPatch returns whether the RP2040 boot patch should be applied that
 // calculates and patches in the checksum for the 2nd stage bootloader.
 func (c *Config) RP2040BootPatch() bool {
@@ -449,5 +466,23 @@ func (c *Config) Emulator() []string {
 
 type TestConfig struct {
 	CompileTestBinary bool
-	// TODO: Filter the test functions to run, include verbose flag, etc
+	CompileOnly       bool   // only compile the test binary, don't run it
+	Verbose           bool   // print additional output (-test.v)
+	Short             bool   // run a smaller test suite (-test.short)
+	JSON              bool   // convert the test output to JSON (like test2json)
+	RunRegexp         string // regexp of tests to run (-test.run)
+	BenchRegexp       string // regexp of benchmarks to run (-test.bench)
+	BenchTime         string // duration to run each benchmark (-test.benchtime)
+	CoverMode         string // coverage mode (set, count, atomic), empty if disabled
+	CoverProfile      string // file to write the coverage profile to
+	FuzzRegexp        string // regexp of the fuzz test to fuzz (-test.fuzz)
+	FuzzTime          string // time to spend fuzzing (-test.fuzztime)
+	Count             int    // run each test this many times (-test.count)
+	FailFast          bool   // don't start new tests after a failure (-test.failfast)
+	ListRegexp        string // only list the tests matching this regexp (-test.list)
+
+	// Timeout is the time after which the test binary is killed, or 0 for no
+	// timeout. This is handled by tinygo test (not by the test binary), so
+	// that it also works for emulated systems.
+	Timeout time.Duration
 }
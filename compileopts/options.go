@@ -7,12 +7,14 @@ import (
 )
 
 var (
-	validGCOptions            = []string{"none", "leaking", "conservative"}
+	validGCOptions            = []string{"none", "leaking", "conservative", "precise"}
 	validSchedulerOptions     = []string{"none", "tasks", "asyncify"}
 	validSerialOptions        = []string{"none", "uart", "usb"}
-	validPrintSizeOptions     = []string{"none", "short", "full"}
+	validPrintSizeOptions     = []string{"none", "short", "full", "json", "csv", "symbols", "html"}
 	validPanicStrategyOptions = []string{"print", "trap"}
 	validOptOptions           = []string{"none", "0", "1", "2", "s", "z"}
+	validCoverModeOptions     = []string{"set", "count", "atomic"}
+	validStackGraphOptions    = []string{"dot", "json"}
 )
 
 // Options contains extra options to give to the compiler. These options are
@@ -37,6 +39,9 @@ type Options struct {
 	PrintSizes      string
 	PrintAllocs     *regexp.Regexp // regexp string
 	PrintStacks     bool
+	StackGraph      string // print the call graph used for -print-stacks (dot, json)
+	PCTableSize     int
+	NoReflectNames  bool
 	Tags            string
 	WasmAbi         string
 	GlobalValues    map[string]map[string]string // map[pkgpath]map[varname]value
@@ -99,6 +104,22 @@ func (o *Options) Verify() error {
 		}
 	}
 
+	if o.StackGraph != "" {
+		if !isInArray(validStackGraphOptions, o.StackGraph) {
+			return fmt.Errorf("invalid -stack-graph=%s: valid values are %s", o.StackGraph, strings.Join(validStackGraphOptions, ", "))
+		}
+	}
+
+	if o.PCTableSize < 0 {
+		return fmt.Errorf("invalid -pctable-size=%d: must not be negative", o.PCTableSize)
+	}
+
+	if o.TestConfig.CoverMode != "" {
+		if !isInArray(validCoverModeOptions, o.TestConfig.CoverMode) {
+			return fmt.Errorf("invalid -covermode=%s: valid values are %s", o.TestConfig.CoverMode, strings.Join(validCoverModeOptions, ", "))
+		}
+	}
+
 	return nil
 }
 
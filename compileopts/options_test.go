@@ -9,10 +9,12 @@ import (
 
 func TestVerifyOptions(t *testing.T) {
 
-	expectedGCError := errors.New(`invalid gc option 'incorrect': valid values are none, leaking, conservative`)
+	expectedGCError := errors.New(`invalid gc option 'incorrect': valid values are none, leaking, conservative, precise`)
 	expectedSchedulerError := errors.New(`invalid scheduler option 'incorrect': valid values are none, tasks, asyncify`)
-	expectedPrintSizeError := errors.New(`invalid size option 'incorrect': valid values are none, short, full`)
+	expectedPrintSizeError := errors.New(`invalid size option 'incorrect': valid values are none, short, full, json, csv, symbols, html`)
 	expectedPanicStrategyError := errors.New(`invalid panic option 'incorrect': valid values are print, trap`)
+	expectedCoverModeError := errors.New(`invalid -covermode=incorrect: valid values are set, count, atomic`)
+	expectedStackGraphError := errors.New(`invalid -stack-graph=incorrect: valid values are dot, json`)
 
 	testCases := []struct {
 		name          string
@@ -92,6 +94,30 @@ func TestVerifyOptions(t *testing.T) {
 				PrintSizes: "full",
 			},
 		},
+		{
+			name: "PrintSizeOptionJSON",
+			opts: compileopts.Options{
+				PrintSizes: "json",
+			},
+		},
+		{
+			name: "PrintSizeOptionCSV",
+			opts: compileopts.Options{
+				PrintSizes: "csv",
+			},
+		},
+		{
+			name: "PrintSizeOptionSymbols",
+			opts: compileopts.Options{
+				PrintSizes: "symbols",
+			},
+		},
+		{
+			name: "PrintSizeOptionHTML",
+			opts: compileopts.Options{
+				PrintSizes: "html",
+			},
+		},
 		{
 			name: "InvalidPanicOption",
 			opts: compileopts.Options{
@@ -111,6 +137,42 @@ func TestVerifyOptions(t *testing.T) {
 				PanicStrategy: "trap",
 			},
 		},
+		{
+			name: "InvalidCoverModeOption",
+			opts: compileopts.Options{
+				TestConfig: compileopts.TestConfig{
+					CoverMode: "incorrect",
+				},
+			},
+			expectedError: expectedCoverModeError,
+		},
+		{
+			name: "CoverModeOptionCount",
+			opts: compileopts.Options{
+				TestConfig: compileopts.TestConfig{
+					CoverMode: "count",
+				},
+			},
+		},
+		{
+			name: "InvalidStackGraphOption",
+			opts: compileopts.Options{
+				StackGraph: "incorrect",
+			},
+			expectedError: expectedStackGraphError,
+		},
+		{
+			name: "StackGraphOptionDOT",
+			opts: compileopts.Options{
+				StackGraph: "dot",
+			},
+		},
+		{
+			name: "StackGraphOptionJSON",
+			opts: compileopts.Options{
+				StackGraph: "json",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -139,17 +139,27 @@ func (spec *TargetSpec) loadFromGivenStr(str string) error {
 	return spec.load(fp)
 }
 
-// resolveInherits loads inherited targets, recursively.
-func (spec *TargetSpec) resolveInherits() error {
+// resolveInherits loads inherited targets, recursively. The special "host"
+// target is the target for the GOOS/GOARCH in the options (the host system by
+// default), so that a target can run on the host system, such as a simulator.
+func (spec *TargetSpec) resolveInherits(options *Options) error {
 	// First create a new spec with all the inherited properties.
 	newSpec := &TargetSpec{}
 	for _, name := range spec.Inherits {
+		if name == "host" {
+			subtarget, err := hostTarget(options)
+			if err != nil {
+				return err
+			}
+			newSpec.overrideProperties(subtarget)
+			continue
+		}
 		subtarget := &TargetSpec{}
 		err := subtarget.loadFromGivenStr(name)
 		if err != nil {
 			return err
 		}
-		err = subtarget.resolveInherits()
+		err = subtarget.resolveInherits(options)
 		if err != nil {
 			return err
 		}
@@ -166,52 +176,7 @@ func (spec *TargetSpec) resolveInherits() error {
 // Load a target specification.
 func LoadTarget(options *Options) (*TargetSpec, error) {
 	if options.Target == "" {
-		// Configure based on GOOS/GOARCH environment variables (falling back to
-		// runtime.GOOS/runtime.GOARCH), and generate a LLVM target based on it.
-		var llvmarch string
-		switch options.GOARCH {
-		case "386":
-			llvmarch = "i386"
-		case "amd64":
-			llvmarch = "x86_64"
-		case "arm64":
-			llvmarch = "aarch64"
-		case "arm":
-			switch options.GOARM {
-			case "5":
-				llvmarch = "armv5"
-			case "6":
-				llvmarch = "armv6"
-			case "7":
-				llvmarch = "armv7"
-			default:
-				return nil, fmt.Errorf("invalid GOARM=%s, must be 5, 6, or 7", options.GOARM)
-			}
-		default:
-			llvmarch = options.GOARCH
-		}
-		llvmos := options.GOOS
-		if llvmos == "darwin" {
-			// Use macosx* instead of darwin, otherwise darwin/arm64 will refer
-			// to iOS!
-			llvmos = "macosx10.12.0"
-			if llvmarch == "aarch64" {
-				// Looks like Apple prefers to call this architecture ARM64
-				// instead of AArch64.
-				llvmarch = "arm64"
-			}
-		}
-		// Target triples (which actually have four components, but are called
-		// triples for historical reasons) have the form:
-		//   arch-vendor-os-environment
-		target := llvmarch + "-unknown-" + llvmos
-		if options.GOARCH == "arm" {
-			target += "-gnueabihf"
-		}
-		if options.GOOS == "windows" {
-			target += "-gnu"
-		}
-		return defaultTarget(options.GOOS, options.GOARCH, target)
+		return targetFromOSArch(options)
 	}
 
 	// See whether there is a target specification for this target (e.g.
@@ -223,7 +188,7 @@ func LoadTarget(options *Options) (*TargetSpec, error) {
 	}
 	// Successfully loaded this target from a built-in .json file. Make sure
 	// it includes all parents as specified in the "inherits" key.
-	err = spec.resolveInherits()
+	err = spec.resolveInherits(options)
 	if err != nil {
 		return nil, err
 	}
@@ -235,6 +200,78 @@ func LoadTarget(options *Options) (*TargetSpec, error) {
 	return spec, nil
 }
 
+// hostTarget returns the target for the GOOS/GOARCH in the options, falling
+// back to the GOOS/GOARCH environment variables (or the host system) if they
+// aren't set.
+func hostTarget(options *Options) (*TargetSpec, error) {
+	hostOptions := &Options{
+		GOOS:   options.GOOS,
+		GOARCH: options.GOARCH,
+		GOARM:  options.GOARM,
+	}
+	if hostOptions.GOOS == "" {
+		hostOptions.GOOS = goenv.Get("GOOS")
+	}
+	if hostOptions.GOARCH == "" {
+		hostOptions.GOARCH = goenv.Get("GOARCH")
+	}
+	if hostOptions.GOARM == "" {
+		hostOptions.GOARM = goenv.Get("GOARM")
+	}
+	return targetFromOSArch(hostOptions)
+}
+
+// targetFromOSArch returns the target for the GOOS/GOARCH (and GOARM) in the
+// options, for when no target is given.
+func targetFromOSArch(options *Options) (*TargetSpec, error) {
+	// Configure based on GOOS/GOARCH environment variables (falling back to
+	// runtime.GOOS/runtime.GOARCH), and generate a LLVM target based on it.
+	var llvmarch string
+	switch options.GOARCH {
+	case "386":
+		llvmarch = "i386"
+	case "amd64":
+		llvmarch = "x86_64"
+	case "arm64":
+		llvmarch = "aarch64"
+	case "arm":
+		switch options.GOARM {
+		case "5":
+			llvmarch = "armv5"
+		case "6":
+			llvmarch = "armv6"
+		case "7":
+			llvmarch = "armv7"
+		default:
+			return nil, fmt.Errorf("invalid GOARM=%s, must be 5, 6, or 7", options.GOARM)
+		}
+	default:
+		llvmarch = options.GOARCH
+	}
+	llvmos := options.GOOS
+	if llvmos == "darwin" {
+		// Use macosx* instead of darwin, otherwise darwin/arm64 will refer
+		// to iOS!
+		llvmos = "macosx10.12.0"
+		if llvmarch == "aarch64" {
+			// Looks like Apple prefers to call this architecture ARM64
+			// instead of AArch64.
+			llvmarch = "arm64"
+		}
+	}
+	// Target triples (which actually have four components, but are called
+	// triples for historical reasons) have the form:
+	//   arch-vendor-os-environment
+	target := llvmarch + "-unknown-" + llvmos
+	if options.GOARCH == "arm" {
+		target += "-gnueabihf"
+	}
+	if options.GOOS == "windows" {
+		target += "-gnu"
+	}
+	return defaultTarget(options.GOOS, options.GOARCH, target)
+}
+
 func defaultTarget(goos, goarch, triple string) (*TargetSpec, error) {
 	// No target spec available. Use the default one, useful on most systems
 	// with a regular OS.
@@ -312,6 +349,7 @@ func defaultTarget(goos, goarch, triple string) (*TargetSpec, error) {
 			suffix = "_windows"
 		}
 		spec.ExtraFiles = append(spec.ExtraFiles, "src/runtime/gc_"+goarch+suffix+".S")
+		spec.ExtraFiles = append(spec.ExtraFiles, "src/runtime/asm_"+goarch+suffix+".S")
 		spec.ExtraFiles = append(spec.ExtraFiles, "src/internal/task/task_stack_"+goarch+suffix+".S")
 	}
 	if goarch != runtime.GOARCH {
@@ -20,6 +20,18 @@ func TestLoadTarget(t *testing.T) {
 	if !os.IsNotExist(err) {
 		t.Error("LoadTarget failed for wrong reason:", err)
 	}
+
+	// Targets can inherit from the host system.
+	spec, err := LoadTarget(&Options{Target: "sim-arduino", GOOS: "linux", GOARCH: "arm64"})
+	if err != nil {
+		t.Fatal("LoadTarget failed for simulator:", err)
+	}
+	if spec.GOOS != "linux" || spec.GOARCH != "arm64" || spec.Triple != "aarch64-unknown-linux" {
+		t.Errorf("unexpected simulator target: %s/%s (%s)", spec.GOOS, spec.GOARCH, spec.Triple)
+	}
+	if !reflect.DeepEqual(spec.BuildTags, []string{"linux", "arm64", "sim", "arduino"}) {
+		t.Errorf("unexpected simulator build tags: %v", spec.BuildTags)
+	}
 }
 
 func TestOverrideProperties(t *testing.T) {
@@ -17,6 +17,7 @@ import (
 	"github.com/tinygo-org/tinygo/compiler/llvmutil"
 	"github.com/tinygo-org/tinygo/loader"
 	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types/typeutil"
 	"tinygo.org/x/go-llvm"
 )
 
@@ -65,7 +66,7 @@ type compilerContext struct {
 	cu               llvm.Metadata
 	difiles          map[string]llvm.Metadata
 	ditypes          map[types.Type]llvm.Metadata
-	llvmTypes        map[types.Type]llvm.Type
+	llvmTypes        typeutil.Map
 	machine          llvm.TargetMachine
 	targetData       llvm.TargetData
 	intType          llvm.Type
@@ -89,7 +90,6 @@ func newCompilerContext(moduleName string, machine llvm.TargetMachine, config *C
 		DumpSSA:     dumpSSA,
 		difiles:     make(map[string]llvm.Metadata),
 		ditypes:     make(map[types.Type]llvm.Metadata),
-		llvmTypes:   make(map[types.Type]llvm.Type),
 		machine:     machine,
 		targetData:  machine.CreateTargetData(),
 		astComments: map[string]*ast.CommentGroup{},
@@ -137,6 +137,8 @@ type builder struct {
 	currentBlock      *ssa.BasicBlock
 	phis              []phiNode
 	deferPtr          llvm.Value
+	deferFrame        llvm.Value
+	landingpad        llvm.BasicBlock
 	difunc            llvm.Metadata
 	dilocals          map[*types.Var]llvm.Metadata
 	initInlinedAt     llvm.Metadata            // fake inlinedAt position
@@ -314,12 +316,12 @@ func (c *compilerContext) getLLVMRuntimeType(name string) llvm.Type {
 // important for named struct types (which should only be created once).
 func (c *compilerContext) getLLVMType(goType types.Type) llvm.Type {
 	// Try to load the LLVM type from the cache.
-	if t, ok := c.llvmTypes[goType]; ok {
-		return t
+	if t := c.llvmTypes.At(goType); t != nil {
+		return t.(llvm.Type)
 	}
 	// Not already created, so adding this type to the cache.
 	llvmType := c.makeLLVMType(goType)
-	c.llvmTypes[goType] = llvmType
+	c.llvmTypes.Set(goType, llvmType)
 	return llvmType
 }
 
@@ -373,9 +375,11 @@ func (c *compilerContext) makeLLVMType(goType types.Type) llvm.Type {
 			// in LLVM IR, named structs are implemented as named structs in
 			// LLVM. This is because it is otherwise impossible to create
 			// self-referencing types such as linked lists.
-			llvmName := typ.Obj().Pkg().Path() + "." + typ.Obj().Name()
+			// The name includes type arguments for instantiated generic
+			// types, such as "main.Point[int]".
+			llvmName := typ.String()
 			llvmType := c.ctx.StructCreateNamed(llvmName)
-			c.llvmTypes[goType] = llvmType // avoid infinite recursion
+			c.llvmTypes.Set(goType, llvmType) // avoid infinite recursion
 			underlying := c.getLLVMType(st)
 			llvmType.StructSetBody(underlying.StructElementTypes(), false)
 			return llvmType
@@ -651,7 +655,9 @@ func (b *builder) setDebugLocation(pos token.Pos) {
 // getLocalVariable returns a debug info entry for a local variable, which may
 // either be a parameter or a regular variable. It will create a new metadata
 // entry if there isn't one for the variable yet.
-func (b *builder) getLocalVariable(variable *types.Var) llvm.Metadata {
+// The type is passed separately because in an instantiated generic function,
+// the variable still has the type from the generic function declaration.
+func (b *builder) getLocalVariable(variable *types.Var, typ types.Type) llvm.Metadata {
 	if dilocal, ok := b.dilocals[variable]; ok {
 		// DILocalVariable was already created, return it directly.
 		return dilocal
@@ -667,7 +673,7 @@ func (b *builder) getLocalVariable(variable *types.Var) llvm.Metadata {
 				Name:           param.Name(),
 				File:           b.getDIFile(pos.Filename),
 				Line:           pos.Line,
-				Type:           b.getDIType(variable.Type()),
+				Type:           b.getDIType(typ),
 				AlwaysPreserve: true,
 				ArgNo:          i + 1,
 			})
@@ -681,7 +687,7 @@ func (b *builder) getLocalVariable(variable *types.Var) llvm.Metadata {
 		Name:           variable.Name(),
 		File:           b.getDIFile(pos.Filename),
 		Line:           pos.Line,
-		Type:           b.getDIType(variable.Type()),
+		Type:           b.getDIType(typ),
 		AlwaysPreserve: true,
 	})
 	b.dilocals[variable] = dilocal
@@ -765,6 +771,10 @@ func (c *compilerContext) createPackage(irbuilder llvm.Builder, pkg *ssa.Package
 		member := pkg.Members[name]
 		switch member := member.(type) {
 		case *ssa.Function:
+			if member.Synthetic == "generic function" {
+				// Generic functions are only created when instantiated.
+				continue
+			}
 			// Create the function definition.
 			b := newBuilder(c, irbuilder, member)
 			if member.Blocks == nil {
@@ -785,6 +795,9 @@ func (c *compilerContext) createPackage(irbuilder llvm.Builder, pkg *ssa.Package
 			for _, method := range methods {
 				// Parse this method.
 				fn := pkg.Prog.MethodValue(method)
+				if fn == nil {
+					continue // method on a generic (uninstantiated) type
+				}
 				if fn.Blocks == nil {
 					continue // external function
 				}
@@ -944,7 +957,7 @@ func (b *builder) createFunction() {
 
 		// Add debug information to this parameter (if available)
 		if b.Debug && b.fn.Syntax() != nil {
-			dbgParam := b.getLocalVariable(param.Object().(*types.Var))
+			dbgParam := b.getLocalVariable(param.Object().(*types.Var), param.Type())
 			loc := b.GetCurrentDebugLocation()
 			if len(fields) == 1 {
 				expr := b.dibuilder.CreateExpression(nil)
@@ -1015,7 +1028,7 @@ func (b *builder) createFunction() {
 					// for example.
 					continue
 				}
-				dbgVar := b.getLocalVariable(variable)
+				dbgVar := b.getLocalVariable(variable, instr.X.Type())
 				pos := b.program.Fset.Position(instr.Pos())
 				b.dibuilder.InsertValueAtEnd(b.getValue(instr.X), dbgVar, b.dibuilder.CreateExpression(nil), llvm.DebugLoc{
 					Line:  uint(pos.Line),
@@ -1038,6 +1051,12 @@ func (b *builder) createFunction() {
 		}
 	}
 
+	if b.hasDeferFrame() {
+		// Create the landing pad block, where control continues after a
+		// panic.
+		b.createLandingPad()
+	}
+
 	// Resolve phi nodes
 	for _, phi := range b.phis {
 		block := phi.ssa.Block()
@@ -1168,6 +1187,9 @@ func (b *builder) createInstruction(instr ssa.Instruction) {
 		b.createRuntimeCall("_panic", []llvm.Value{value}, "")
 		b.CreateUnreachable()
 	case *ssa.Return:
+		if b.hasDeferFrame() {
+			b.createRuntimeCall("destroyDeferFrame", []llvm.Value{b.deferFrame}, "")
+		}
 		if len(instr.Results) == 0 {
 			b.CreateRetVoid()
 		} else if len(instr.Results) == 1 {
@@ -1356,7 +1378,15 @@ func (b *builder) createBuiltin(argTypes []types.Type, argValues []llvm.Value, c
 		cplx := argValues[0]
 		return b.CreateExtractValue(cplx, 0, "real"), nil
 	case "recover":
-		return b.createRuntimeCall("_recover", nil, ""), nil
+		useParentFrame := uint64(0)
+		if b.hasDeferFrame() {
+			// The defer frame of this function can't be panicking while
+			// this function is running, so check the parent frame instead.
+			useParentFrame = 1
+		}
+		return b.createRuntimeCall("_recover", []llvm.Value{
+			llvm.ConstInt(b.ctx.Int1Type(), useParentFrame, false),
+		}, ""), nil
 	case "ssa:wrapnilchk":
 		// TODO: do an actual nil check?
 		return argValues[0], nil
@@ -1416,7 +1446,7 @@ func (b *builder) createFunctionCall(instr *ssa.CallCommon) (llvm.Value, error)
 		// applied) function call. If it is anonymous, it may be a closure.
 		name := fn.RelString(nil)
 		switch {
-		case name == "runtime.memcpy" || name == "runtime.memmove" || name == "reflect.memcpy":
+		case name == "runtime.memcpy" || name == "runtime.memmove" || name == "reflect.memcpy" || name == "internal/task.memcpy":
 			return b.createMemoryCopyCall(fn, instr.Args)
 		case name == "runtime.memzero":
 			return b.createMemoryZeroCall(instr.Args)
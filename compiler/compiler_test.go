@@ -63,6 +63,8 @@ func TestCompiler(t *testing.T) {
 		{"intrinsics.go", "cortex-m-qemu", ""},
 		{"intrinsics.go", "wasm", ""},
 		{"gc.go", "", ""},
+		{"defer.go", "cortex-m-qemu", ""},
+		{"defer.go", "wasm", "asyncify"},
 	}
 	if llvmMajor >= 12 {
 		tests = append(tests, testCase{"intrinsics.go", "cortex-m-qemu", ""})
@@ -71,6 +73,9 @@ func TestCompiler(t *testing.T) {
 	if goMinor >= 17 {
 		tests = append(tests, testCase{"go1.17.go", "", ""})
 	}
+	if goMinor >= 18 {
+		tests = append(tests, testCase{"generics.go", "", ""})
+	}
 
 	for _, tc := range tests {
 		name := tc.file
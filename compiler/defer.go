@@ -12,9 +12,25 @@ package compiler
 //   * On return, runtime.rundefers is called which calls all deferred functions
 //     from the head of the linked list until it has gone through all defer
 //     frames.
+//
+// On architectures that support it, functions with defer statements also
+// create a runtime.deferFrame in the entry block, which is pushed on a
+// per-goroutine linked list. This frame stores a stack pointer and program
+// counter (similar to setjmp), which are updated after every defer statement.
+// When a panic happens, the runtime jumps back to this checkpoint (similar to
+// longjmp), after which control is transferred to the landing pad that runs
+// all deferred functions and returns from the function. If none of the
+// deferred functions recovered the panic, the panic continues in the caller.
+// WebAssembly can't jump to a different stack frame, so there the checkpoint
+// is made by the runtime using asyncify (see runtime.deferCheckpoint). As this
+// is expensive, only functions that defer a call to a function that may
+// recover() get a defer frame on WebAssembly.
 
 import (
+	"go/token"
 	"go/types"
+	"strconv"
+	"strings"
 
 	"github.com/tinygo-org/tinygo/compiler/llvmutil"
 	"golang.org/x/tools/go/ssa"
@@ -34,10 +50,252 @@ func (b *builder) deferInitFunc() {
 
 	// Create defer list pointer.
 	deferType := llvm.PointerType(b.getLLVMRuntimeType("_defer"), 0)
+	if b.hasDeferFrame() {
+		// Set up the defer frame, and store the defer list pointer in it.
+		// Storing it in the defer frame (which is reachable from the current
+		// goroutine) makes sure that it is always stored in memory, so that
+		// the landing pad sees the latest value after a panic.
+		b.deferFrame = b.CreateAlloca(b.getLLVMRuntimeType("deferFrame"), "deferframe.buf")
+		b.createRuntimeCall("setupDeferFrame", []llvm.Value{b.deferFrame}, "")
+		b.deferPtr = b.CreateBitCast(b.CreateInBoundsGEP(b.deferFrame, []llvm.Value{
+			llvm.ConstInt(b.ctx.Int32Type(), 0, false),
+			llvm.ConstInt(b.ctx.Int32Type(), 3, false), // .DeferPtr field
+		}, ""), llvm.PointerType(deferType, 0), "deferPtr")
+		b.CreateStore(llvm.ConstPointerNull(deferType), b.deferPtr)
+
+		// Create the landing pad block, which is where control transfers after
+		// a panic. The checkpoints that jump there are created after each
+		// defer statement.
+		b.landingpad = b.ctx.AddBasicBlock(b.llvmFn, "lpad")
+		return
+	}
 	b.deferPtr = b.CreateAlloca(deferType, "deferPtr")
 	b.CreateStore(llvm.ConstPointerNull(deferType), b.deferPtr)
 }
 
+// archFamily returns the architecture from the LLVM triple, with similar
+// architectures (for example armv7 and thumbv7em) mapped to a single name.
+func (c *compilerContext) archFamily() string {
+	arch := strings.Split(c.Triple, "-")[0]
+	switch {
+	case arch == "arm64" || strings.HasPrefix(arch, "aarch64"):
+		return "aarch64"
+	case strings.HasPrefix(arch, "arm") || strings.HasPrefix(arch, "thumb"):
+		return "arm"
+	case arch == "i386" || arch == "i686":
+		return "i386"
+	}
+	return arch
+}
+
+// supportsRecover returns whether the compiler supports the recover() builtin
+// for the current architecture. On other architectures, deferred functions are
+// not run on panic and recover() always returns nil.
+// This must be kept in sync with the supportsRecover constant in the runtime.
+func (c *compilerContext) supportsRecover() bool {
+	switch c.archFamily() {
+	case "x86_64", "i386", "arm", "aarch64", "riscv32", "riscv64":
+		return true
+	case "wasm32":
+		// WebAssembly has no way to jump to a different stack frame, so
+		// checkpoints are made by unwinding and copying the goroutine stack.
+		// This needs asyncify, which is only used with this scheduler.
+		return c.Scheduler == "asyncify"
+	default:
+		// AVR and Xtensa have not been implemented yet.
+		return false
+	}
+}
+
+// hasDeferFrame returns whether the current function needs to catch panics and
+// run defers.
+func (b *builder) hasDeferFrame() bool {
+	if b.fn.Recover == nil || !b.supportsRecover() {
+		return false
+	}
+	if b.archFamily() == "wasm32" {
+		// A checkpoint on WebAssembly copies the whole goroutine stack and
+		// allocates, so only create one when a deferred call may stop the
+		// panic. Other deferred calls are not run on panic.
+		return deferMayRecover(b.fn)
+	}
+	return true
+}
+
+// deferMayRecover returns whether any of the calls deferred in fn may call
+// recover() directly, which is the only way to stop a panic.
+func deferMayRecover(fn *ssa.Function) bool {
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			instr, ok := instr.(*ssa.Defer)
+			if !ok {
+				continue
+			}
+			if _, ok := instr.Call.Value.(*ssa.Builtin); ok {
+				// Builtins (like close) don't call recover().
+				continue
+			}
+			callee := instr.Call.StaticCallee()
+			if callee == nil || callsRecover(callee) {
+				// Either an unknown function (a function value or an
+				// interface method), or one that calls recover().
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// callsRecover returns whether fn contains a call to recover().
+func callsRecover(fn *ssa.Function) bool {
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			call, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			if builtin, ok := call.Common().Value.(*ssa.Builtin); ok && builtin.Name() == "recover" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// createInvokeCheckpoint saves the function state at the current point, so
+// that a panic in the code that follows continues at the landing pad. This is
+// implemented with some inline assembly that works much like setjmp: it stores
+// the stack pointer, the frame pointer and the address just past the inline
+// assembly in the defer frame and returns zero, while tinygo_longjmp (in the
+// runtime) restores both pointers and jumps to that address with a non-zero
+// return value.
+// All other registers are marked as clobbered, so that no state is kept in
+// registers across the checkpoint.
+// On WebAssembly, the checkpoint is made by a call to runtime.deferCheckpoint
+// instead.
+func (b *builder) createInvokeCheckpoint() {
+	returnsTwice := b.ctx.CreateEnumAttribute(llvm.AttributeKindID("returns_twice"), 0)
+	if b.archFamily() == "wasm32" {
+		jumped := b.createRuntimeCall("deferCheckpoint", []llvm.Value{b.deferFrame}, "setjmp")
+		jumped.AddCallSiteAttribute(-1, returnsTwice)
+		continueBB := b.ctx.AddBasicBlock(b.llvmFn, "setjmp.continue")
+		b.CreateCondBr(jumped, b.landingpad, continueBB)
+		b.SetInsertPointAtEnd(continueBB)
+		b.blockExits[b.currentBlock] = continueBB
+		return
+	}
+	var asmString, constraints string
+	switch b.archFamily() {
+	case "x86_64":
+		asmString = `
+movq %rsp, 0(%rbx)
+leaq 1f(%rip), %rax
+movq %rax, 8(%rbx)
+movq %rbp, 16(%rbx)
+xorq %rax, %rax
+1:`
+		constraints = "={rax},{rbx},~{rbx},~{rcx},~{rdx},~{rsi},~{rdi},~{r8},~{r9},~{r10},~{r11},~{r12},~{r13},~{r14},~{r15},~{xmm0},~{xmm1},~{xmm2},~{xmm3},~{xmm4},~{xmm5},~{xmm6},~{xmm7},~{xmm8},~{xmm9},~{xmm10},~{xmm11},~{xmm12},~{xmm13},~{xmm14},~{xmm15},~{fpsr},~{fpcr},~{flags},~{dirflag},~{memory}"
+	case "i386":
+		asmString = `
+movl %esp, 0(%ebx)
+movl $$1f, 4(%ebx)
+movl %ebp, 8(%ebx)
+xorl %eax, %eax
+1:`
+		constraints = "={eax},{ebx},~{ebx},~{ecx},~{edx},~{esi},~{edi},~{xmm0},~{xmm1},~{xmm2},~{xmm3},~{xmm4},~{xmm5},~{xmm6},~{xmm7},~{fpsr},~{fpcr},~{flags},~{dirflag},~{memory}"
+	case "arm":
+		if strings.HasPrefix(b.Triple, "thumb") {
+			// Reading the PC returns the address of the current instruction
+			// plus 4, which is the instruction just after the inline assembly.
+			// tinygo_longjmp jumps there with r0 set to the (non-zero) defer
+			// frame pointer.
+			// The frame pointer register is r7 in Thumb mode.
+			asmString = `
+str r7, [r1, #8]
+movs r0, #0
+mov r2, sp
+str r2, [r1, #0]
+mov r2, pc
+str r2, [r1, #4]`
+			constraints = "={r0},{r1},~{r1},~{r2},~{r3},~{r4},~{r5},~{r6},~{r8},~{r9},~{r10},~{r11},"
+		} else {
+			// The frame pointer register is r11 in ARM mode.
+			asmString = `
+str sp, [r1, #0]
+adr r2, 1f
+str r2, [r1, #4]
+str r11, [r1, #8]
+movs r0, #0
+1:`
+			constraints = "={r0},{r1},~{r1},~{r2},~{r3},~{r4},~{r5},~{r6},~{r7},~{r8},~{r9},~{r10},"
+		}
+		constraints += "~{r12},~{lr},~{q0},~{q1},~{q2},~{q3},~{q4},~{q5},~{q6},~{q7},~{q8},~{q9},~{q10},~{q11},~{q12},~{q13},~{q14},~{q15},~{cpsr},~{memory}"
+	case "aarch64":
+		asmString = `
+mov x2, sp
+str x2, [x1, #0]
+adr x2, 1f
+str x2, [x1, #8]
+str x29, [x1, #16]
+mov x0, #0
+1:`
+		constraints = "={x0},{x1},~{x1},~{x2},~{x3},~{x4},~{x5},~{x6},~{x7},~{x8},~{x9},~{x10},~{x11},~{x12},~{x13},~{x14},~{x15},~{x16},~{x17},~{x19},~{x20},~{x21},~{x22},~{x23},~{x24},~{x25},~{x26},~{x27},~{x28},~{lr},~{q0},~{q1},~{q2},~{q3},~{q4},~{q5},~{q6},~{q7},~{q8},~{q9},~{q10},~{q11},~{q12},~{q13},~{q14},~{q15},~{q16},~{q17},~{q18},~{q19},~{q20},~{q21},~{q22},~{q23},~{q24},~{q25},~{q26},~{q27},~{q28},~{q29},~{q30},~{q31},~{nzcv},~{memory}"
+		if b.GOOS != "darwin" && b.GOOS != "windows" {
+			// x18 is reserved on these platforms.
+			constraints += ",~{x18}"
+		}
+	case "riscv32", "riscv64":
+		store := "sw"
+		if b.archFamily() == "riscv64" {
+			store = "sd"
+		}
+		ptrSize := int(b.targetData.PointerSize())
+		asmString = `
+` + store + ` sp, 0(a1)
+la a2, 1f
+` + store + ` a2, ` + strconv.Itoa(ptrSize) + `(a1)
+` + store + ` s0, ` + strconv.Itoa(ptrSize*2) + `(a1)
+li a0, 0
+1:`
+		constraints = "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"
+	default:
+		// This case should have been handled by b.supportsRecover().
+		b.addError(b.fn.Pos(), "unknown architecture for defer: "+b.archFamily())
+		return
+	}
+	asmType := llvm.FunctionType(b.uintptrType, []llvm.Type{b.deferFrame.Type()}, false)
+	asm := llvm.InlineAsm(asmType, asmString, constraints, true, false, 0, false)
+	result := b.CreateCall(asm, []llvm.Value{b.deferFrame}, "setjmp")
+	result.AddCallSiteAttribute(-1, returnsTwice)
+	isZero := b.CreateICmp(llvm.IntEQ, result, llvm.ConstInt(b.uintptrType, 0, false), "setjmp.result")
+	continueBB := b.ctx.AddBasicBlock(b.llvmFn, "setjmp.continue")
+	b.CreateCondBr(isZero, continueBB, b.landingpad)
+	b.SetInsertPointAtEnd(continueBB)
+	b.blockExits[b.currentBlock] = continueBB
+}
+
+// createLandingPad fills in the landing pad block. This block runs the deferred
+// functions and continues at the recover block, which returns from the
+// function. If the function is still panicking after the deferred functions
+// have run, the panic will be raised again in destroyDeferFrame.
+func (b *builder) createLandingPad() {
+	b.SetInsertPointAtEnd(b.landingpad)
+
+	// Use the closing brace of the function as the debug location.
+	if b.Debug {
+		pos := token.NoPos
+		if syntax := b.fn.Syntax(); syntax != nil {
+			pos = syntax.End()
+		}
+		b.setDebugLocation(pos)
+	}
+
+	b.createRunDefers()
+
+	// Continue at the recover block, which returns to the parent.
+	b.CreateBr(b.blockEntries[b.fn.Recover])
+}
+
 // isInLoop checks if there is a path from a basic block to itself.
 func isInLoop(start *ssa.BasicBlock) bool {
 	// Use a breadth-first search to scan backwards through the block graph.
@@ -229,6 +487,11 @@ func (b *builder) createDefer(instr *ssa.Defer) {
 	// Push it on top of the linked list by replacing deferPtr.
 	allocaCast := b.CreateBitCast(alloca, next.Type(), "defer.alloca.cast")
 	b.CreateStore(allocaCast, b.deferPtr)
+
+	if b.hasDeferFrame() {
+		// Make sure a panic from now on also runs this deferred call.
+		b.createInvokeCheckpoint()
+	}
 }
 
 // createRunDefers emits code to run all deferred functions.
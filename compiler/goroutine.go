@@ -55,10 +55,11 @@ func (b *builder) createGo(instr *ssa.Go) {
 		//     when it is finished.
 		//   - panic: the error message would appear in the parent goroutine.
 		//     But because `go panic("err")` would halt the program anyway
-		//     (there is no recover), panicking right away would give the same
-		//     behavior as creating a goroutine, switching the scheduler to that
-		//     goroutine, and panicking there. So this optimization seems
-		//     correct.
+		//     (the new goroutine has no deferred calls that could recover),
+		//     panicking right away would give nearly the same behavior as
+		//     creating a goroutine, switching the scheduler to that goroutine,
+		//     and panicking there. The only difference is that deferred calls
+		//     in the parent goroutine are run first.
 		//   - recover: because it runs in a new goroutine, it is never a
 		//     deferred function. Thus this is a no-op.
 		if builtin.Name() == "recover" {
@@ -54,12 +54,14 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 		// reflect lowering simpler.
 		var references llvm.Value
 		var length int64
+		var typeName llvm.Value
 		var methodSet llvm.Value
 		var ptrTo llvm.Value
 		var typeAssert llvm.Value
 		switch typ := typ.(type) {
 		case *types.Named:
 			references = c.getTypeCode(typ.Underlying())
+			typeName = llvm.ConstPtrToInt(c.makeTypeName(typ), c.uintptrType)
 		case *types.Chan:
 			references = c.getTypeCode(typ.Elem())
 		case *types.Pointer:
@@ -73,6 +75,14 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 			// Take a pointer to the typecodeID of the first field (if it exists).
 			structGlobal := c.makeStructTypeFields(typ)
 			references = llvm.ConstBitCast(structGlobal, global.Type())
+		case *types.Map:
+			// Store the key and element type in a separate global.
+			mapGlobal := c.makeMapTypeElems(typ)
+			references = llvm.ConstBitCast(mapGlobal, global.Type())
+		case *types.Signature:
+			// Store the parameter and result types in a separate global.
+			funcGlobal := c.makeFuncTypeElems(typ)
+			references = llvm.ConstBitCast(funcGlobal, global.Type())
 		case *types.Interface:
 			methodSetGlobal := c.getInterfaceMethodSet(typ)
 			references = llvm.ConstBitCast(methodSetGlobal, global.Type())
@@ -94,6 +104,9 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 			lengthValue := llvm.ConstInt(c.uintptrType, uint64(length), false)
 			globalValue = llvm.ConstInsertValue(globalValue, lengthValue, []uint32{1})
 		}
+		if !typeName.IsNil() {
+			globalValue = llvm.ConstInsertValue(globalValue, typeName, []uint32{1})
+		}
 		if !methodSet.IsNil() {
 			globalValue = llvm.ConstInsertValue(globalValue, methodSet, []uint32{2})
 		}
@@ -144,6 +157,16 @@ func (c *compilerContext) makeStructTypeFields(typ *types.Struct) llvm.Value {
 			fieldEmbedded := llvm.ConstInt(c.ctx.Int1Type(), 1, false)
 			fieldGlobalValue = llvm.ConstInsertValue(fieldGlobalValue, fieldEmbedded, []uint32{3})
 		}
+		if !typ.Field(i).Exported() {
+			fieldPkgPath := c.makeGlobalArray([]byte(typ.Field(i).Pkg().Path()), "reflect/types.structFieldPkgPath", c.ctx.Int8Type())
+			fieldPkgPath.SetLinkage(llvm.PrivateLinkage)
+			fieldPkgPath.SetUnnamedAddr(true)
+			fieldPkgPath = llvm.ConstGEP(fieldPkgPath, []llvm.Value{
+				llvm.ConstInt(c.ctx.Int32Type(), 0, false),
+				llvm.ConstInt(c.ctx.Int32Type(), 0, false),
+			})
+			fieldGlobalValue = llvm.ConstInsertValue(fieldGlobalValue, fieldPkgPath, []uint32{4})
+		}
 		structGlobalValue = llvm.ConstInsertValue(structGlobalValue, fieldGlobalValue, []uint32{uint32(i)})
 	}
 	structGlobal.SetInitializer(structGlobalValue)
@@ -152,6 +175,69 @@ func (c *compilerContext) makeStructTypeFields(typ *types.Struct) llvm.Value {
 	return structGlobal
 }
 
+// makeTypeName creates a new global that stores the name of this named type
+// as used by reflect.Type.String(): the type name qualified by the package
+// name (not the package path), for example "base64.Encoding". The package path
+// is not stored as it is already part of the typecode name.
+func (c *compilerContext) makeTypeName(typ *types.Named) llvm.Value {
+	name := types.TypeString(typ, func(pkg *types.Package) string {
+		return pkg.Name()
+	})
+	nameGlobal := c.makeGlobalArray([]byte(name), "reflect/types.typeName", c.ctx.Int8Type())
+	nameGlobal.SetLinkage(llvm.PrivateLinkage)
+	nameGlobal.SetUnnamedAddr(true)
+	return nameGlobal
+}
+
+// makeMapTypeElems creates a new global that stores the key and element type
+// of this map type, as an array of two typecodeID pointers.
+func (c *compilerContext) makeMapTypeElems(typ *types.Map) llvm.Value {
+	keyType := c.getTypeCode(typ.Key())
+	elemType := c.getTypeCode(typ.Elem())
+	mapGlobalValue := llvm.ConstArray(keyType.Type(), []llvm.Value{keyType, elemType})
+	mapGlobal := llvm.AddGlobal(c.mod, mapGlobalValue.Type(), "reflect/types.mapElems")
+	mapGlobal.SetInitializer(mapGlobalValue)
+	mapGlobal.SetUnnamedAddr(true)
+	mapGlobal.SetLinkage(llvm.PrivateLinkage)
+	return mapGlobal
+}
+
+// makeFuncTypeElems creates a new global that stores the parameter and result
+// types of this function type, whether it is variadic, and the wrappers that
+// the reflect package needs to call such a function (reflect.Value.Call) or to
+// create one (reflect.MakeFunc).
+func (c *compilerContext) makeFuncTypeElems(typ *types.Signature) llvm.Value {
+	typecodePtrType := llvm.PointerType(c.getLLVMRuntimeType("typecodeID"), 0)
+	params := make([]llvm.Value, typ.Params().Len())
+	for i := range params {
+		params[i] = c.getTypeCode(typ.Params().At(i).Type())
+	}
+	results := make([]llvm.Value, typ.Results().Len())
+	for i := range results {
+		results[i] = c.getTypeCode(typ.Results().At(i).Type())
+	}
+	variadic := llvm.ConstInt(c.ctx.Int1Type(), 0, false)
+	if typ.Variadic() {
+		variadic = llvm.ConstInt(c.ctx.Int1Type(), 1, false)
+	}
+	makeFunc := llvm.ConstNull(c.rawVoidFuncType)
+	if wrapper := c.getReflectMakeFuncWrapper(typ); !wrapper.IsNil() {
+		makeFunc = llvm.ConstBitCast(wrapper, c.rawVoidFuncType)
+	}
+	funcGlobalValue := c.ctx.ConstStruct([]llvm.Value{
+		llvm.ConstArray(typecodePtrType, params),
+		llvm.ConstArray(typecodePtrType, results),
+		variadic,
+		llvm.ConstBitCast(c.getReflectCallWrapper(typ), c.rawVoidFuncType),
+		makeFunc,
+	}, false)
+	funcGlobal := llvm.AddGlobal(c.mod, funcGlobalValue.Type(), "reflect/types.funcElems")
+	funcGlobal.SetInitializer(funcGlobalValue)
+	funcGlobal.SetUnnamedAddr(true)
+	funcGlobal.SetLinkage(llvm.PrivateLinkage)
+	return funcGlobal
+}
+
 // getTypeCodeName returns a name for this type that can be used in the
 // interface lowering pass to assign type codes as expected by the reflect
 // package. See getTypeCodeNum.
@@ -241,7 +327,13 @@ func getTypeCodeName(t types.Type) string {
 			if t.Field(i).Embedded() {
 				embedded = "#"
 			}
-			elems[i] = embedded + t.Field(i).Name() + ":" + getTypeCodeName(t.Field(i).Type())
+			name := t.Field(i).Name()
+			if !t.Field(i).Exported() {
+				// Unexported fields of struct types declared in different
+				// packages are different, so include the package path.
+				name = t.Field(i).Pkg().Path() + "." + name
+			}
+			elems[i] = embedded + name + ":" + getTypeCodeName(t.Field(i).Type())
 			if t.Tag(i) != "" {
 				elems[i] += "`" + t.Tag(i) + "`"
 			}
@@ -280,9 +372,20 @@ func (c *compilerContext) getTypeMethodSet(typ types.Type) llvm.Value {
 			panic("cannot find function: " + c.getFunctionInfo(fn).linkName)
 		}
 		wrapper := c.getInterfaceInvokeWrapper(fn, llvmFn)
+		// Exported methods can be called using reflection. Store the method
+		// itself and its type as a function with the receiver as the first
+		// parameter, so that it can be called like a regular function.
+		funcType := llvm.ConstNull(llvm.PointerType(c.getLLVMRuntimeType("typecodeID"), 0))
+		function := llvm.ConstNull(c.uintptrType)
+		if method.Obj().Exported() {
+			funcType = c.getTypeCode(methodFuncType(fn.Signature))
+			function = llvm.ConstPtrToInt(llvmFn, c.uintptrType)
+		}
 		methodInfo := llvm.ConstNamedStruct(interfaceMethodInfoType, []llvm.Value{
 			signatureGlobal,
 			llvm.ConstPtrToInt(wrapper, c.uintptrType),
+			funcType,
+			function,
 		})
 		methods[i] = methodInfo
 	}
@@ -311,14 +414,16 @@ func (c *compilerContext) getInterfaceMethodSet(typ types.Type) llvm.Value {
 		return llvm.ConstGEP(global, []llvm.Value{zero, zero})
 	}
 
-	// Every method is a *i8 reference indicating the signature of this method.
+	// Every method is a reference to a global indicating the signature of
+	// this method.
 	methods := make([]llvm.Value, typ.Underlying().(*types.Interface).NumMethods())
 	for i := range methods {
 		method := typ.Underlying().(*types.Interface).Method(i)
 		methods[i] = c.getMethodSignature(method)
 	}
 
-	value := llvm.ConstArray(c.i8ptrType, methods)
+	typecodePtrType := llvm.PointerType(c.getLLVMRuntimeType("typecodeID"), 0)
+	value := llvm.ConstArray(llvm.PointerType(typecodePtrType, 0), methods)
 	global = llvm.AddGlobal(c.mod, value.Type(), name+"$interface")
 	global.SetInitializer(value)
 	global.SetGlobalConstant(true)
@@ -339,24 +444,39 @@ func (c *compilerContext) getMethodSignatureName(method *types.Func) string {
 	return globalName
 }
 
-// getMethodSignature returns a global variable which is a reference to an
-// external *i8 indicating the indicating the signature of this method. It is
-// used during the interface lowering pass.
+// getMethodSignature returns a global variable which is a reference to the
+// type code of the signature of this method (without receiver). The name of
+// the global is used during the interface lowering pass to identify the
+// method, the type code is used by the reflect package.
 func (c *compilerContext) getMethodSignature(method *types.Func) llvm.Value {
 	globalName := c.getMethodSignatureName(method)
 	signatureGlobal := c.mod.NamedGlobal(globalName)
 	if signatureGlobal.IsNil() {
-		// TODO: put something useful in these globals, such as the method
-		// signature. Useful to one day implement reflect.Value.Method(n).
-		signatureGlobal = llvm.AddGlobal(c.mod, c.ctx.Int8Type(), globalName)
-		signatureGlobal.SetInitializer(llvm.ConstInt(c.ctx.Int8Type(), 0, false))
+		// Create the global before the type code, as the type code may refer
+		// back to this method (for example, in the method set of a parameter
+		// type).
+		typecodePtrType := llvm.PointerType(c.getLLVMRuntimeType("typecodeID"), 0)
+		signatureGlobal = llvm.AddGlobal(c.mod, typecodePtrType, globalName)
+		sig := method.Type().(*types.Signature)
+		sig = types.NewSignature(nil, sig.Params(), sig.Results(), sig.Variadic())
+		signatureGlobal.SetInitializer(c.getTypeCode(sig))
 		signatureGlobal.SetLinkage(llvm.LinkOnceODRLinkage)
 		signatureGlobal.SetGlobalConstant(true)
-		signatureGlobal.SetAlignment(1)
 	}
 	return signatureGlobal
 }
 
+// methodFuncType returns the signature of a method as a regular function, with
+// the receiver as the first parameter. This is the type of method expressions
+// like T.Method.
+func methodFuncType(sig *types.Signature) *types.Signature {
+	params := []*types.Var{sig.Recv()}
+	for i := 0; i < sig.Params().Len(); i++ {
+		params = append(params, sig.Params().At(i))
+	}
+	return types.NewSignature(nil, types.NewTuple(params...), sig.Results(), sig.Variadic())
+}
+
 // createTypeAssert will emit the code for a typeassert, used in if statements
 // and in type switches (Go SSA does not have type switches, only if/else
 // chains). Note that even though the Go SSA does not contain type switches,
@@ -66,6 +66,10 @@ func (c *compilerContext) makeGlobalArray(buf []byte, name string, elementType l
 // which words contain a pointer (indicated by setting the given bit to 1). For
 // arrays, only the element is stored. This works because the GC knows the
 // object size and can therefore know how this value is repeated in the object.
+//
+// If the layout is stored in a global, it is stored as a uintptr with the
+// number of words followed by the bitmap as a byte array, least significant
+// bit first. See src/runtime/gc_precise.go for how it is decoded.
 func (c *compilerContext) createObjectLayout(t llvm.Type, pos token.Pos) llvm.Value {
 	// Use the element type for arrays. This works even for nested arrays.
 	for {
@@ -148,6 +152,7 @@ func (c *compilerContext) createObjectLayout(t llvm.Type, pos token.Pos) llvm.Va
 	// Create the global initializer.
 	bitmapBytes := make([]byte, int(objectSizeWords+7)/8)
 	bitmap.FillBytes(bitmapBytes)
+	reverseBytes(bitmapBytes) // big-endian to little-endian
 	var bitmapByteValues []llvm.Value
 	for _, b := range bitmapBytes {
 		bitmapByteValues = append(bitmapByteValues, llvm.ConstInt(c.ctx.Int8Type(), uint64(b), false))
@@ -253,3 +258,10 @@ func (c *compilerContext) getPointerBitmap(typ llvm.Type, pos token.Pos) *big.In
 		panic("unknown LLVM type")
 	}
 }
+
+// reverseBytes reverses the given byte slice in place.
+func reverseBytes(buf []byte) {
+	for i := 0; i < len(buf)/2; i++ {
+		buf[i], buf[len(buf)-1-i] = buf[len(buf)-1-i], buf[i]
+	}
+}
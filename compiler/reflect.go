@@ -0,0 +1,168 @@
+package compiler
+
+// This file creates wrapper functions used by the reflect package to call
+// functions of arbitrary signatures (reflect.Value.Call) and to create new
+// functions at runtime (reflect.MakeFunc). Parameters and results are passed
+// in memory, laid out like a struct with one field per parameter or result,
+// which is easy to construct and read from within the reflect package.
+
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+	"tinygo.org/x/go-llvm"
+)
+
+// getReflectCallWrapper returns a function that calls a function value of the
+// given signature with parameters loaded from memory and that stores the
+// results back in memory. The wrapper has the following Go signature:
+//
+//     func(fn, args, results unsafe.Pointer)
+//
+// where fn points to the function value to call, and args and results point
+// to a struct with all parameters and all results respectively.
+func (c *compilerContext) getReflectCallWrapper(sig *types.Signature) llvm.Value {
+	wrapperName := getTypeCodeName(sig) + "$reflectcall"
+	wrapper := c.mod.NamedFunction(wrapperName)
+	if !wrapper.IsNil() {
+		return wrapper
+	}
+
+	wrapperType := llvm.FunctionType(c.ctx.VoidType(), []llvm.Type{c.i8ptrType, c.i8ptrType, c.i8ptrType, c.i8ptrType}, false)
+	wrapper = llvm.AddFunction(c.mod, wrapperName, wrapperType)
+	c.addStandardAttributes(wrapper)
+	wrapper.SetLinkage(llvm.LinkOnceODRLinkage)
+	wrapper.SetUnnamedAddr(true)
+
+	// Create a new builder just to create this wrapper.
+	b := builder{
+		compilerContext: c,
+		Builder:         c.ctx.NewBuilder(),
+	}
+	defer b.Builder.Dispose()
+	block := b.ctx.AddBasicBlock(wrapper, "entry")
+	b.SetInsertPointAtEnd(block)
+
+	// Load the function value to call.
+	funcValueType := c.getFuncType(sig)
+	funcValuePtr := b.CreateBitCast(wrapper.Param(0), llvm.PointerType(funcValueType, 0), "")
+	funcPtr, context := b.decodeFuncValue(b.CreateLoad(funcValuePtr, "fn"), sig)
+
+	// Load all parameters from the args struct.
+	argsType := c.getReflectArgsType(sig.Params())
+	argsPtr := b.CreateBitCast(wrapper.Param(1), llvm.PointerType(argsType, 0), "")
+	var params []llvm.Value
+	for i := 0; i < sig.Params().Len(); i++ {
+		gep := b.CreateInBoundsGEP(argsPtr, []llvm.Value{
+			llvm.ConstInt(c.ctx.Int32Type(), 0, false),
+			llvm.ConstInt(c.ctx.Int32Type(), uint64(i), false),
+		}, "")
+		param := b.CreateLoad(gep, "")
+		params = append(params, b.expandFormalParam(param)...)
+	}
+	params = append(params, context)
+
+	// Do the call and store the results in the results struct.
+	result := b.CreateCall(funcPtr, params, "")
+	resultsType := c.getReflectArgsType(sig.Results())
+	resultsPtr := b.CreateBitCast(wrapper.Param(2), llvm.PointerType(resultsType, 0), "")
+	for i := 0; i < sig.Results().Len(); i++ {
+		value := result
+		if sig.Results().Len() > 1 {
+			value = b.CreateExtractValue(result, i, "")
+		}
+		gep := b.CreateInBoundsGEP(resultsPtr, []llvm.Value{
+			llvm.ConstInt(c.ctx.Int32Type(), 0, false),
+			llvm.ConstInt(c.ctx.Int32Type(), uint64(i), false),
+		}, "")
+		b.CreateStore(value, gep)
+	}
+	b.CreateRetVoid()
+
+	return wrapper
+}
+
+// getReflectMakeFuncWrapper returns a function with the given signature that
+// stores all parameters in memory and calls reflect.makeFuncStub, which calls
+// the function passed to reflect.MakeFunc. The context parameter is passed
+// through to reflect.makeFuncStub. It returns nil if the reflect package is not
+// part of the program, as reflect.MakeFunc cannot be called in that case.
+func (c *compilerContext) getReflectMakeFuncWrapper(sig *types.Signature) llvm.Value {
+	reflectPkg := c.program.ImportedPackage("reflect")
+	if reflectPkg == nil {
+		return llvm.Value{}
+	}
+	wrapperName := getTypeCodeName(sig) + "$makefunc"
+	wrapper := c.mod.NamedFunction(wrapperName)
+	if !wrapper.IsNil() {
+		return wrapper
+	}
+
+	wrapper = llvm.AddFunction(c.mod, wrapperName, c.getRawFuncType(sig).ElementType())
+	c.addStandardAttributes(wrapper)
+	wrapper.SetLinkage(llvm.LinkOnceODRLinkage)
+	wrapper.SetUnnamedAddr(true)
+
+	// Create a new builder just to create this wrapper.
+	b := builder{
+		compilerContext: c,
+		Builder:         c.ctx.NewBuilder(),
+	}
+	defer b.Builder.Dispose()
+	block := b.ctx.AddBasicBlock(wrapper, "entry")
+	b.SetInsertPointAtEnd(block)
+
+	// Store all parameters in the args struct.
+	argsType := c.getReflectArgsType(sig.Params())
+	args := b.CreateAlloca(argsType, "args")
+	fields := wrapper.Params()
+	for i := 0; i < sig.Params().Len(); i++ {
+		paramType := argsType.StructElementTypes()[i]
+		numFields := len(c.expandFormalParamType(paramType, "", nil))
+		param := b.collapseFormalParam(paramType, fields[:numFields])
+		fields = fields[numFields:]
+		gep := b.CreateInBoundsGEP(args, []llvm.Value{
+			llvm.ConstInt(c.ctx.Int32Type(), 0, false),
+			llvm.ConstInt(c.ctx.Int32Type(), uint64(i), false),
+		}, "")
+		b.CreateStore(param, gep)
+	}
+	context := fields[0] // the last parameter
+
+	// Call reflect.makeFuncStub, which does the real work.
+	resultsType := c.getReflectArgsType(sig.Results())
+	results := b.CreateAlloca(resultsType, "results")
+	stub := c.getFunction(reflectPkg.Members["makeFuncStub"].(*ssa.Function))
+	b.createCall(stub, []llvm.Value{
+		context,
+		b.CreateBitCast(args, c.i8ptrType, ""),
+		b.CreateBitCast(results, c.i8ptrType, ""),
+		llvm.Undef(c.i8ptrType),
+	}, "")
+
+	// Return the results that were stored by reflect.makeFuncStub.
+	switch sig.Results().Len() {
+	case 0:
+		b.CreateRetVoid()
+	case 1:
+		gep := b.CreateInBoundsGEP(results, []llvm.Value{
+			llvm.ConstInt(c.ctx.Int32Type(), 0, false),
+			llvm.ConstInt(c.ctx.Int32Type(), 0, false),
+		}, "")
+		b.CreateRet(b.CreateLoad(gep, ""))
+	default:
+		b.CreateRet(b.CreateLoad(results, ""))
+	}
+
+	return wrapper
+}
+
+// getReflectArgsType returns the struct type in which the reflect package
+// stores parameters or results of a call.
+func (c *compilerContext) getReflectArgsType(tuple *types.Tuple) llvm.Type {
+	fields := make([]llvm.Type, tuple.Len())
+	for i := range fields {
+		fields[i] = c.getLLVMType(tuple.At(i).Type())
+	}
+	return c.ctx.StructType(fields, false)
+}
@@ -223,6 +223,9 @@ func (info *functionInfo) parsePragmas(f *ssa.Function) {
 		return
 	}
 	if decl, ok := f.Syntax().(*ast.FuncDecl); ok && decl.Doc != nil {
+		// Note: f.Pkg is nil for instantiated generic functions, so use the
+		// package of the declared object instead.
+		pkg := f.Object().Pkg()
 
 		// Our importName for a wasm module (if we are compiling to wasm), or llvm link name
 		var importName string
@@ -247,7 +250,7 @@ func (info *functionInfo) parsePragmas(f *ssa.Function) {
 				importName = parts[1]
 				info.exported = true
 			case "//go:interrupt":
-				if hasUnsafeImport(f.Pkg.Pkg) {
+				if hasUnsafeImport(pkg) {
 					info.interrupt = true
 				}
 			case "//go:wasm-module":
@@ -268,11 +271,11 @@ func (info *functionInfo) parsePragmas(f *ssa.Function) {
 				// This is a slightly looser requirement than what gc uses: gc
 				// requires the file to import "unsafe", not the package as a
 				// whole.
-				if hasUnsafeImport(f.Pkg.Pkg) {
+				if hasUnsafeImport(pkg) {
 					info.linkName = parts[2]
 				}
 			case "//go:section":
-				if len(parts) == 2 && hasUnsafeImport(f.Pkg.Pkg) {
+				if len(parts) == 2 && hasUnsafeImport(pkg) {
 					info.section = parts[1]
 				}
 			case "//go:nobounds":
@@ -280,7 +283,7 @@ func (info *functionInfo) parsePragmas(f *ssa.Function) {
 				// runtime functions.
 				// This is somewhat dangerous and thus only imported in packages
 				// that import unsafe.
-				if hasUnsafeImport(f.Pkg.Pkg) {
+				if hasUnsafeImport(pkg) {
 					info.nobounds = true
 				}
 			case "//go:variadic":
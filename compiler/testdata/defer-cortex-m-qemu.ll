@@ -0,0 +1,362 @@
+; ModuleID = 'defer.go'
+source_filename = "defer.go"
+target datalayout = "e-m:e-p:32:32-Fi8-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "thumbv7m-unknown-unknown-eabi"
+
+%runtime._defer = type { i32, %runtime._defer* }
+%runtime.deferFrame = type { i8*, i8*, i8*, i8*, %runtime.deferFrame*, i1, %runtime._interface }
+%runtime._interface = type { i32, i8* }
+
+declare noalias nonnull i8* @runtime.alloc(i32, i8*, i8*)
+
+; Function Attrs: nounwind
+define hidden void @main.init(i8* %context) unnamed_addr #0 {
+entry:
+  ret void
+}
+
+declare void @main.external(i8*)
+
+; Function Attrs: nounwind
+define hidden void @main.deferSimple(i8* %context) unnamed_addr #0 {
+entry:
+  %defer.alloca = alloca { i32, %runtime._defer* }, align 4
+  %deferframe.buf = alloca %runtime.deferFrame, align 4
+  call void @runtime.setupDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  %0 = getelementptr inbounds %runtime.deferFrame, %runtime.deferFrame* %deferframe.buf, i32 0, i32 3
+  %deferPtr = bitcast i8** %0 to %runtime._defer**
+  store %runtime._defer* null, %runtime._defer** %deferPtr, align 4
+  %defer.alloca.repack = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca, i32 0, i32 0
+  store i32 0, i32* %defer.alloca.repack, align 4
+  %defer.alloca.repack12 = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca, i32 0, i32 1
+  store %runtime._defer* null, %runtime._defer** %defer.alloca.repack12, align 4
+  %1 = bitcast i8** %0 to { i32, %runtime._defer* }**
+  store { i32, %runtime._defer* }* %defer.alloca, { i32, %runtime._defer* }** %1, align 4
+  %setjmp = call i32 asm sideeffect "\0Astr r7, [r1, #8]\0Amovs r0, #0\0Amov r2, sp\0Astr r2, [r1, #0]\0Amov r2, pc\0Astr r2, [r1, #4]", "={r0},{r1},~{r1},~{r2},~{r3},~{r4},~{r5},~{r6},~{r8},~{r9},~{r10},~{r11},~{r12},~{lr},~{q0},~{q1},~{q2},~{q3},~{q4},~{q5},~{q6},~{q7},~{q8},~{q9},~{q10},~{q11},~{q12},~{q13},~{q14},~{q15},~{cpsr},~{memory}"(%runtime.deferFrame* nonnull %deferframe.buf) #1
+  %setjmp.result = icmp eq i32 %setjmp, 0
+  br i1 %setjmp.result, label %setjmp.continue, label %lpad
+
+recover:                                          ; preds = %rundefers.end4
+  call void @runtime.destroyDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  ret void
+
+lpad:                                             ; preds = %entry
+  br label %rundefers.loophead1
+
+setjmp.continue:                                  ; preds = %entry
+  call void @main.external(i8* undef) #0
+  br label %rundefers.loophead
+
+rundefers.loophead:                               ; preds = %rundefers.callback, %setjmp.continue
+  %2 = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %stackIsNil = icmp eq %runtime._defer* %2, null
+  br i1 %stackIsNil, label %rundefers.end, label %rundefers.loop
+
+rundefers.loop:                                   ; preds = %rundefers.loophead
+  %stack.next.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %2, i32 0, i32 1
+  %stack.next = load %runtime._defer*, %runtime._defer** %stack.next.gep, align 4
+  store %runtime._defer* %stack.next, %runtime._defer** %deferPtr, align 4
+  %callback.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %2, i32 0, i32 0
+  %callback = load i32, i32* %callback.gep, align 4
+  switch i32 %callback, label %rundefers.default [
+    i32 0, label %rundefers.callback
+  ]
+
+rundefers.default:                                ; preds = %rundefers.loop
+  unreachable
+
+rundefers.end:                                    ; preds = %rundefers.loophead
+  call void @runtime.destroyDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  ret void
+
+rundefers.callback:                               ; preds = %rundefers.loop
+  call void @"main.deferSimple$1"(i8* undef)
+  br label %rundefers.loophead
+
+rundefers.loophead1:                              ; preds = %rundefers.callback10, %lpad
+  %3 = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %stackIsNil5 = icmp eq %runtime._defer* %3, null
+  br i1 %stackIsNil5, label %rundefers.end4, label %rundefers.loop2
+
+rundefers.loop2:                                  ; preds = %rundefers.loophead1
+  %stack.next.gep6 = getelementptr inbounds %runtime._defer, %runtime._defer* %3, i32 0, i32 1
+  %stack.next7 = load %runtime._defer*, %runtime._defer** %stack.next.gep6, align 4
+  store %runtime._defer* %stack.next7, %runtime._defer** %deferPtr, align 4
+  %callback.gep8 = getelementptr inbounds %runtime._defer, %runtime._defer* %3, i32 0, i32 0
+  %callback9 = load i32, i32* %callback.gep8, align 4
+  switch i32 %callback9, label %rundefers.default3 [
+    i32 0, label %rundefers.callback10
+  ]
+
+rundefers.default3:                               ; preds = %rundefers.loop2
+  unreachable
+
+rundefers.end4:                                   ; preds = %rundefers.loophead1
+  br label %recover
+
+rundefers.callback10:                             ; preds = %rundefers.loop2
+  call void @"main.deferSimple$1"(i8* undef)
+  br label %rundefers.loophead1
+}
+
+declare void @runtime.setupDeferFrame(%runtime.deferFrame* dereferenceable_or_null(32), i8*)
+
+; Function Attrs: nounwind
+define hidden void @"main.deferSimple$1"(i8* %context) unnamed_addr #0 {
+entry:
+  call void @runtime.printint32(i32 3, i8* undef) #0
+  ret void
+}
+
+declare void @runtime.destroyDeferFrame(%runtime.deferFrame* dereferenceable_or_null(32), i8*)
+
+declare void @runtime.printint32(i32, i8*)
+
+; Function Attrs: nounwind
+define hidden void @main.deferMultiple(i8* %context) unnamed_addr #0 {
+entry:
+  %defer.alloca2 = alloca { i32, %runtime._defer* }, align 4
+  %defer.alloca = alloca { i32, %runtime._defer* }, align 4
+  %deferframe.buf = alloca %runtime.deferFrame, align 4
+  call void @runtime.setupDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  %0 = getelementptr inbounds %runtime.deferFrame, %runtime.deferFrame* %deferframe.buf, i32 0, i32 3
+  %deferPtr = bitcast i8** %0 to %runtime._defer**
+  store %runtime._defer* null, %runtime._defer** %deferPtr, align 4
+  %defer.alloca.repack = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca, i32 0, i32 0
+  store i32 0, i32* %defer.alloca.repack, align 4
+  %defer.alloca.repack22 = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca, i32 0, i32 1
+  store %runtime._defer* null, %runtime._defer** %defer.alloca.repack22, align 4
+  %1 = bitcast i8** %0 to { i32, %runtime._defer* }**
+  store { i32, %runtime._defer* }* %defer.alloca, { i32, %runtime._defer* }** %1, align 4
+  %setjmp = call i32 asm sideeffect "\0Astr r7, [r1, #8]\0Amovs r0, #0\0Amov r2, sp\0Astr r2, [r1, #0]\0Amov r2, pc\0Astr r2, [r1, #4]", "={r0},{r1},~{r1},~{r2},~{r3},~{r4},~{r5},~{r6},~{r8},~{r9},~{r10},~{r11},~{r12},~{lr},~{q0},~{q1},~{q2},~{q3},~{q4},~{q5},~{q6},~{q7},~{q8},~{q9},~{q10},~{q11},~{q12},~{q13},~{q14},~{q15},~{cpsr},~{memory}"(%runtime.deferFrame* nonnull %deferframe.buf) #1
+  %setjmp.result = icmp eq i32 %setjmp, 0
+  br i1 %setjmp.result, label %setjmp.continue, label %lpad
+
+recover:                                          ; preds = %rundefers.end12
+  call void @runtime.destroyDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  ret void
+
+lpad:                                             ; preds = %setjmp.continue, %entry
+  br label %rundefers.loophead9
+
+setjmp.continue:                                  ; preds = %entry
+  %defer.next1 = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %defer.alloca2.repack = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca2, i32 0, i32 0
+  store i32 1, i32* %defer.alloca2.repack, align 4
+  %defer.alloca2.repack23 = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca2, i32 0, i32 1
+  store %runtime._defer* %defer.next1, %runtime._defer** %defer.alloca2.repack23, align 4
+  %2 = bitcast i8** %0 to { i32, %runtime._defer* }**
+  store { i32, %runtime._defer* }* %defer.alloca2, { i32, %runtime._defer* }** %2, align 4
+  %setjmp4 = call i32 asm sideeffect "\0Astr r7, [r1, #8]\0Amovs r0, #0\0Amov r2, sp\0Astr r2, [r1, #0]\0Amov r2, pc\0Astr r2, [r1, #4]", "={r0},{r1},~{r1},~{r2},~{r3},~{r4},~{r5},~{r6},~{r8},~{r9},~{r10},~{r11},~{r12},~{lr},~{q0},~{q1},~{q2},~{q3},~{q4},~{q5},~{q6},~{q7},~{q8},~{q9},~{q10},~{q11},~{q12},~{q13},~{q14},~{q15},~{cpsr},~{memory}"(%runtime.deferFrame* nonnull %deferframe.buf) #1
+  %setjmp.result5 = icmp eq i32 %setjmp4, 0
+  br i1 %setjmp.result5, label %setjmp.continue6, label %lpad
+
+setjmp.continue6:                                 ; preds = %setjmp.continue
+  call void @main.external(i8* undef) #0
+  br label %rundefers.loophead
+
+rundefers.loophead:                               ; preds = %rundefers.callback7, %rundefers.callback, %setjmp.continue6
+  %3 = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %stackIsNil = icmp eq %runtime._defer* %3, null
+  br i1 %stackIsNil, label %rundefers.end, label %rundefers.loop
+
+rundefers.loop:                                   ; preds = %rundefers.loophead
+  %stack.next.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %3, i32 0, i32 1
+  %stack.next = load %runtime._defer*, %runtime._defer** %stack.next.gep, align 4
+  store %runtime._defer* %stack.next, %runtime._defer** %deferPtr, align 4
+  %callback.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %3, i32 0, i32 0
+  %callback = load i32, i32* %callback.gep, align 4
+  switch i32 %callback, label %rundefers.default [
+    i32 0, label %rundefers.callback
+    i32 1, label %rundefers.callback7
+  ]
+
+rundefers.default:                                ; preds = %rundefers.loop
+  unreachable
+
+rundefers.end:                                    ; preds = %rundefers.loophead
+  call void @runtime.destroyDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  ret void
+
+rundefers.callback:                               ; preds = %rundefers.loop
+  call void @"main.deferMultiple$1"(i8* undef)
+  br label %rundefers.loophead
+
+rundefers.callback7:                              ; preds = %rundefers.loop
+  call void @"main.deferMultiple$2"(i8* undef)
+  br label %rundefers.loophead
+
+rundefers.loophead9:                              ; preds = %rundefers.callback20, %rundefers.callback18, %lpad
+  %4 = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %stackIsNil13 = icmp eq %runtime._defer* %4, null
+  br i1 %stackIsNil13, label %rundefers.end12, label %rundefers.loop10
+
+rundefers.loop10:                                 ; preds = %rundefers.loophead9
+  %stack.next.gep14 = getelementptr inbounds %runtime._defer, %runtime._defer* %4, i32 0, i32 1
+  %stack.next15 = load %runtime._defer*, %runtime._defer** %stack.next.gep14, align 4
+  store %runtime._defer* %stack.next15, %runtime._defer** %deferPtr, align 4
+  %callback.gep16 = getelementptr inbounds %runtime._defer, %runtime._defer* %4, i32 0, i32 0
+  %callback17 = load i32, i32* %callback.gep16, align 4
+  switch i32 %callback17, label %rundefers.default11 [
+    i32 0, label %rundefers.callback18
+    i32 1, label %rundefers.callback20
+  ]
+
+rundefers.default11:                              ; preds = %rundefers.loop10
+  unreachable
+
+rundefers.end12:                                  ; preds = %rundefers.loophead9
+  br label %recover
+
+rundefers.callback18:                             ; preds = %rundefers.loop10
+  call void @"main.deferMultiple$1"(i8* undef)
+  br label %rundefers.loophead9
+
+rundefers.callback20:                             ; preds = %rundefers.loop10
+  call void @"main.deferMultiple$2"(i8* undef)
+  br label %rundefers.loophead9
+}
+
+; Function Attrs: nounwind
+define hidden void @"main.deferMultiple$1"(i8* %context) unnamed_addr #0 {
+entry:
+  call void @runtime.printint32(i32 3, i8* undef) #0
+  ret void
+}
+
+; Function Attrs: nounwind
+define hidden void @"main.deferMultiple$2"(i8* %context) unnamed_addr #0 {
+entry:
+  call void @runtime.printint32(i32 5, i8* undef) #0
+  ret void
+}
+
+; Function Attrs: nounwind
+define hidden %runtime._interface @main.deferRecover(i8* %context) unnamed_addr #0 {
+entry:
+  %defer.alloca = alloca { i32, %runtime._defer*, i8* }, align 4
+  %deferframe.buf = alloca %runtime.deferFrame, align 4
+  call void @runtime.setupDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  %0 = getelementptr inbounds %runtime.deferFrame, %runtime.deferFrame* %deferframe.buf, i32 0, i32 3
+  %deferPtr = bitcast i8** %0 to %runtime._defer**
+  store %runtime._defer* null, %runtime._defer** %deferPtr, align 4
+  %err = call i8* @runtime.alloc(i32 8, i8* nonnull inttoptr (i32 133 to i8*), i8* undef) #0
+  %defer.next = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %defer.alloca.repack = getelementptr inbounds { i32, %runtime._defer*, i8* }, { i32, %runtime._defer*, i8* }* %defer.alloca, i32 0, i32 0
+  store i32 0, i32* %defer.alloca.repack, align 4
+  %defer.alloca.repack13 = getelementptr inbounds { i32, %runtime._defer*, i8* }, { i32, %runtime._defer*, i8* }* %defer.alloca, i32 0, i32 1
+  store %runtime._defer* %defer.next, %runtime._defer** %defer.alloca.repack13, align 4
+  %defer.alloca.repack15 = getelementptr inbounds { i32, %runtime._defer*, i8* }, { i32, %runtime._defer*, i8* }* %defer.alloca, i32 0, i32 2
+  store i8* %err, i8** %defer.alloca.repack15, align 4
+  %1 = bitcast i8** %0 to { i32, %runtime._defer*, i8* }**
+  store { i32, %runtime._defer*, i8* }* %defer.alloca, { i32, %runtime._defer*, i8* }** %1, align 4
+  %setjmp = call i32 asm sideeffect "\0Astr r7, [r1, #8]\0Amovs r0, #0\0Amov r2, sp\0Astr r2, [r1, #0]\0Amov r2, pc\0Astr r2, [r1, #4]", "={r0},{r1},~{r1},~{r2},~{r3},~{r4},~{r5},~{r6},~{r8},~{r9},~{r10},~{r11},~{r12},~{lr},~{q0},~{q1},~{q2},~{q3},~{q4},~{q5},~{q6},~{q7},~{q8},~{q9},~{q10},~{q11},~{q12},~{q13},~{q14},~{q15},~{cpsr},~{memory}"(%runtime.deferFrame* nonnull %deferframe.buf) #1
+  %setjmp.result = icmp eq i32 %setjmp, 0
+  br i1 %setjmp.result, label %setjmp.continue, label %lpad
+
+recover:                                          ; preds = %rundefers.end4
+  %.elt = bitcast i8* %err to i32*
+  %.unpack = load i32, i32* %.elt, align 4
+  %2 = insertvalue %runtime._interface undef, i32 %.unpack, 0
+  %.elt17 = getelementptr inbounds i8, i8* %err, i32 4
+  %3 = bitcast i8* %.elt17 to i8**
+  %.unpack18 = load i8*, i8** %3, align 4
+  %4 = insertvalue %runtime._interface %2, i8* %.unpack18, 1
+  call void @runtime.destroyDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  ret %runtime._interface %4
+
+lpad:                                             ; preds = %entry
+  br label %rundefers.loophead1
+
+setjmp.continue:                                  ; preds = %entry
+  call void @main.external(i8* undef) #0
+  %.repack = bitcast i8* %err to i32*
+  store i32 0, i32* %.repack, align 4
+  %.repack19 = getelementptr inbounds i8, i8* %err, i32 4
+  %5 = bitcast i8* %.repack19 to i8**
+  store i8* null, i8** %5, align 4
+  br label %rundefers.loophead
+
+rundefers.loophead:                               ; preds = %rundefers.callback, %setjmp.continue
+  %6 = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %stackIsNil = icmp eq %runtime._defer* %6, null
+  br i1 %stackIsNil, label %rundefers.end, label %rundefers.loop
+
+rundefers.loop:                                   ; preds = %rundefers.loophead
+  %stack.next.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %6, i32 0, i32 1
+  %stack.next = load %runtime._defer*, %runtime._defer** %stack.next.gep, align 4
+  store %runtime._defer* %stack.next, %runtime._defer** %deferPtr, align 4
+  %callback.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %6, i32 0, i32 0
+  %callback = load i32, i32* %callback.gep, align 4
+  switch i32 %callback, label %rundefers.default [
+    i32 0, label %rundefers.callback
+  ]
+
+rundefers.default:                                ; preds = %rundefers.loop
+  unreachable
+
+rundefers.end:                                    ; preds = %rundefers.loophead
+  %.elt20 = bitcast i8* %err to i32*
+  %.unpack21 = load i32, i32* %.elt20, align 4
+  %7 = insertvalue %runtime._interface undef, i32 %.unpack21, 0
+  %.elt22 = getelementptr inbounds i8, i8* %err, i32 4
+  %8 = bitcast i8* %.elt22 to i8**
+  %.unpack23 = load i8*, i8** %8, align 4
+  %9 = insertvalue %runtime._interface %7, i8* %.unpack23, 1
+  call void @runtime.destroyDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  ret %runtime._interface %9
+
+rundefers.callback:                               ; preds = %rundefers.loop
+  %10 = getelementptr inbounds %runtime._defer, %runtime._defer* %6, i32 1
+  %11 = bitcast %runtime._defer* %10 to i8**
+  %param = load i8*, i8** %11, align 4
+  call void @"main.deferRecover$1"(i8* %param)
+  br label %rundefers.loophead
+
+rundefers.loophead1:                              ; preds = %rundefers.callback10, %lpad
+  %12 = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %stackIsNil5 = icmp eq %runtime._defer* %12, null
+  br i1 %stackIsNil5, label %rundefers.end4, label %rundefers.loop2
+
+rundefers.loop2:                                  ; preds = %rundefers.loophead1
+  %stack.next.gep6 = getelementptr inbounds %runtime._defer, %runtime._defer* %12, i32 0, i32 1
+  %stack.next7 = load %runtime._defer*, %runtime._defer** %stack.next.gep6, align 4
+  store %runtime._defer* %stack.next7, %runtime._defer** %deferPtr, align 4
+  %callback.gep8 = getelementptr inbounds %runtime._defer, %runtime._defer* %12, i32 0, i32 0
+  %callback9 = load i32, i32* %callback.gep8, align 4
+  switch i32 %callback9, label %rundefers.default3 [
+    i32 0, label %rundefers.callback10
+  ]
+
+rundefers.default3:                               ; preds = %rundefers.loop2
+  unreachable
+
+rundefers.end4:                                   ; preds = %rundefers.loophead1
+  br label %recover
+
+rundefers.callback10:                             ; preds = %rundefers.loop2
+  %13 = getelementptr inbounds %runtime._defer, %runtime._defer* %12, i32 1
+  %14 = bitcast %runtime._defer* %13 to i8**
+  %param12 = load i8*, i8** %14, align 4
+  call void @"main.deferRecover$1"(i8* %param12)
+  br label %rundefers.loophead1
+}
+
+; Function Attrs: nounwind
+define hidden void @"main.deferRecover$1"(i8* %context) unnamed_addr #0 {
+entry:
+  %0 = call %runtime._interface @runtime._recover(i1 false, i8* undef) #0
+  %unpack.ptr.repack = bitcast i8* %context to i32*
+  %.elt = extractvalue %runtime._interface %0, 0
+  store i32 %.elt, i32* %unpack.ptr.repack, align 4
+  %unpack.ptr.repack1 = getelementptr inbounds i8, i8* %context, i32 4
+  %1 = bitcast i8* %unpack.ptr.repack1 to i8**
+  %.elt2 = extractvalue %runtime._interface %0, 1
+  store i8* %.elt2, i8** %1, align 4
+  ret void
+}
+
+declare %runtime._interface @runtime._recover(i1, i8*)
+
+attributes #0 = { nounwind }
+attributes #1 = { nounwind returns_twice }
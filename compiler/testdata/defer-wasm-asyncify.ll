@@ -0,0 +1,294 @@
+; ModuleID = 'defer.go'
+source_filename = "defer.go"
+target datalayout = "e-m:e-p:32:32-i64:64-n32:64-S128-ni:1:10:20"
+target triple = "wasm32-unknown-wasi"
+
+%runtime._defer = type { i32, %runtime._defer* }
+%runtime._interface = type { i32, i8* }
+%runtime.deferFrame = type { i8*, i8*, i8*, i8*, %runtime.deferFrame*, i1, %runtime._interface }
+
+declare noalias nonnull i8* @runtime.alloc(i32, i8*, i8*)
+
+declare void @runtime.trackPointer(i8* nocapture readonly, i8*)
+
+; Function Attrs: nounwind
+define hidden void @main.init(i8* %context) unnamed_addr #0 {
+entry:
+  ret void
+}
+
+declare void @main.external(i8*)
+
+; Function Attrs: nounwind
+define hidden void @main.deferSimple(i8* %context) unnamed_addr #0 {
+entry:
+  %defer.alloca = alloca { i32, %runtime._defer* }, align 8
+  %deferPtr = alloca %runtime._defer*, align 4
+  store %runtime._defer* null, %runtime._defer** %deferPtr, align 4
+  %0 = bitcast { i32, %runtime._defer* }* %defer.alloca to i8*
+  call void @runtime.trackPointer(i8* nonnull %0, i8* undef) #0
+  %defer.alloca.repack = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca, i32 0, i32 0
+  store i32 0, i32* %defer.alloca.repack, align 8
+  %defer.alloca.repack1 = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca, i32 0, i32 1
+  store %runtime._defer* null, %runtime._defer** %defer.alloca.repack1, align 4
+  %1 = bitcast %runtime._defer** %deferPtr to { i32, %runtime._defer* }**
+  store { i32, %runtime._defer* }* %defer.alloca, { i32, %runtime._defer* }** %1, align 4
+  call void @main.external(i8* undef) #0
+  br label %rundefers.loophead
+
+recover:                                          ; No predecessors!
+  ret void
+
+rundefers.loophead:                               ; preds = %rundefers.callback, %entry
+  %2 = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %stackIsNil = icmp eq %runtime._defer* %2, null
+  br i1 %stackIsNil, label %rundefers.end, label %rundefers.loop
+
+rundefers.loop:                                   ; preds = %rundefers.loophead
+  %stack.next.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %2, i32 0, i32 1
+  %stack.next = load %runtime._defer*, %runtime._defer** %stack.next.gep, align 4
+  store %runtime._defer* %stack.next, %runtime._defer** %deferPtr, align 4
+  %callback.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %2, i32 0, i32 0
+  %callback = load i32, i32* %callback.gep, align 4
+  switch i32 %callback, label %rundefers.default [
+    i32 0, label %rundefers.callback
+  ]
+
+rundefers.default:                                ; preds = %rundefers.loop
+  unreachable
+
+rundefers.end:                                    ; preds = %rundefers.loophead
+  ret void
+
+rundefers.callback:                               ; preds = %rundefers.loop
+  call void @"main.deferSimple$1"(i8* undef)
+  br label %rundefers.loophead
+}
+
+; Function Attrs: nounwind
+define hidden void @"main.deferSimple$1"(i8* %context) unnamed_addr #0 {
+entry:
+  call void @runtime.printint32(i32 3, i8* undef) #0
+  ret void
+}
+
+declare void @runtime.printint32(i32, i8*)
+
+; Function Attrs: nounwind
+define hidden void @main.deferMultiple(i8* %context) unnamed_addr #0 {
+entry:
+  %defer.alloca2 = alloca { i32, %runtime._defer* }, align 8
+  %defer.alloca = alloca { i32, %runtime._defer* }, align 8
+  %deferPtr = alloca %runtime._defer*, align 4
+  store %runtime._defer* null, %runtime._defer** %deferPtr, align 4
+  %0 = bitcast { i32, %runtime._defer* }* %defer.alloca to i8*
+  call void @runtime.trackPointer(i8* nonnull %0, i8* undef) #0
+  %defer.alloca.repack = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca, i32 0, i32 0
+  store i32 0, i32* %defer.alloca.repack, align 8
+  %defer.alloca.repack6 = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca, i32 0, i32 1
+  store %runtime._defer* null, %runtime._defer** %defer.alloca.repack6, align 4
+  %1 = bitcast %runtime._defer** %deferPtr to { i32, %runtime._defer* }**
+  store { i32, %runtime._defer* }* %defer.alloca, { i32, %runtime._defer* }** %1, align 4
+  %2 = bitcast { i32, %runtime._defer* }* %defer.alloca2 to i8*
+  call void @runtime.trackPointer(i8* nonnull %2, i8* undef) #0
+  %defer.alloca2.repack = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca2, i32 0, i32 0
+  store i32 1, i32* %defer.alloca2.repack, align 8
+  %defer.alloca2.repack7 = getelementptr inbounds { i32, %runtime._defer* }, { i32, %runtime._defer* }* %defer.alloca2, i32 0, i32 1
+  %3 = bitcast %runtime._defer** %defer.alloca2.repack7 to { i32, %runtime._defer* }**
+  store { i32, %runtime._defer* }* %defer.alloca, { i32, %runtime._defer* }** %3, align 4
+  %4 = bitcast %runtime._defer** %deferPtr to { i32, %runtime._defer* }**
+  store { i32, %runtime._defer* }* %defer.alloca2, { i32, %runtime._defer* }** %4, align 4
+  call void @main.external(i8* undef) #0
+  br label %rundefers.loophead
+
+recover:                                          ; No predecessors!
+  ret void
+
+rundefers.loophead:                               ; preds = %rundefers.callback4, %rundefers.callback, %entry
+  %5 = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %stackIsNil = icmp eq %runtime._defer* %5, null
+  br i1 %stackIsNil, label %rundefers.end, label %rundefers.loop
+
+rundefers.loop:                                   ; preds = %rundefers.loophead
+  %stack.next.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %5, i32 0, i32 1
+  %stack.next = load %runtime._defer*, %runtime._defer** %stack.next.gep, align 4
+  store %runtime._defer* %stack.next, %runtime._defer** %deferPtr, align 4
+  %callback.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %5, i32 0, i32 0
+  %callback = load i32, i32* %callback.gep, align 4
+  switch i32 %callback, label %rundefers.default [
+    i32 0, label %rundefers.callback
+    i32 1, label %rundefers.callback4
+  ]
+
+rundefers.default:                                ; preds = %rundefers.loop
+  unreachable
+
+rundefers.end:                                    ; preds = %rundefers.loophead
+  ret void
+
+rundefers.callback:                               ; preds = %rundefers.loop
+  call void @"main.deferMultiple$1"(i8* undef)
+  br label %rundefers.loophead
+
+rundefers.callback4:                              ; preds = %rundefers.loop
+  call void @"main.deferMultiple$2"(i8* undef)
+  br label %rundefers.loophead
+}
+
+; Function Attrs: nounwind
+define hidden void @"main.deferMultiple$1"(i8* %context) unnamed_addr #0 {
+entry:
+  call void @runtime.printint32(i32 3, i8* undef) #0
+  ret void
+}
+
+; Function Attrs: nounwind
+define hidden void @"main.deferMultiple$2"(i8* %context) unnamed_addr #0 {
+entry:
+  call void @runtime.printint32(i32 5, i8* undef) #0
+  ret void
+}
+
+; Function Attrs: nounwind
+define hidden %runtime._interface @main.deferRecover(i8* %context) unnamed_addr #0 {
+entry:
+  %defer.alloca = alloca { i32, %runtime._defer*, i8* }, align 8
+  %deferframe.buf = alloca %runtime.deferFrame, align 8
+  call void @runtime.setupDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  %0 = getelementptr inbounds %runtime.deferFrame, %runtime.deferFrame* %deferframe.buf, i32 0, i32 3
+  %deferPtr = bitcast i8** %0 to %runtime._defer**
+  store %runtime._defer* null, %runtime._defer** %deferPtr, align 4
+  %err = call i8* @runtime.alloc(i32 8, i8* nonnull inttoptr (i32 133 to i8*), i8* undef) #0
+  call void @runtime.trackPointer(i8* nonnull %err, i8* undef) #0
+  call void @runtime.trackPointer(i8* nonnull %err, i8* undef) #0
+  call void @runtime.trackPointer(i8* bitcast (void (i8*)* @"main.deferRecover$1" to i8*), i8* undef) #0
+  %defer.next = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %1 = bitcast { i32, %runtime._defer*, i8* }* %defer.alloca to i8*
+  call void @runtime.trackPointer(i8* nonnull %1, i8* undef) #0
+  %defer.alloca.repack = getelementptr inbounds { i32, %runtime._defer*, i8* }, { i32, %runtime._defer*, i8* }* %defer.alloca, i32 0, i32 0
+  store i32 0, i32* %defer.alloca.repack, align 8
+  %defer.alloca.repack13 = getelementptr inbounds { i32, %runtime._defer*, i8* }, { i32, %runtime._defer*, i8* }* %defer.alloca, i32 0, i32 1
+  store %runtime._defer* %defer.next, %runtime._defer** %defer.alloca.repack13, align 4
+  %defer.alloca.repack15 = getelementptr inbounds { i32, %runtime._defer*, i8* }, { i32, %runtime._defer*, i8* }* %defer.alloca, i32 0, i32 2
+  store i8* %err, i8** %defer.alloca.repack15, align 8
+  %2 = bitcast i8** %0 to { i32, %runtime._defer*, i8* }**
+  store { i32, %runtime._defer*, i8* }* %defer.alloca, { i32, %runtime._defer*, i8* }** %2, align 4
+  %setjmp = call i1 @runtime.deferCheckpoint(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #1
+  br i1 %setjmp, label %lpad, label %setjmp.continue
+
+recover:                                          ; preds = %rundefers.end4
+  %.elt20 = bitcast i8* %err to i32*
+  %.unpack21 = load i32, i32* %.elt20, align 4
+  %3 = insertvalue %runtime._interface undef, i32 %.unpack21, 0
+  %.elt22 = getelementptr inbounds i8, i8* %err, i32 4
+  %4 = bitcast i8* %.elt22 to i8**
+  %.unpack23 = load i8*, i8** %4, align 4
+  %5 = insertvalue %runtime._interface %3, i8* %.unpack23, 1
+  call void @runtime.trackPointer(i8* %.unpack23, i8* undef) #0
+  call void @runtime.destroyDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  ret %runtime._interface %5
+
+lpad:                                             ; preds = %entry
+  br label %rundefers.loophead1
+
+setjmp.continue:                                  ; preds = %entry
+  call void @main.external(i8* undef) #0
+  %.repack = bitcast i8* %err to i32*
+  store i32 0, i32* %.repack, align 4
+  %.repack17 = getelementptr inbounds i8, i8* %err, i32 4
+  %6 = bitcast i8* %.repack17 to i8**
+  store i8* null, i8** %6, align 4
+  br label %rundefers.loophead
+
+rundefers.loophead:                               ; preds = %rundefers.callback, %setjmp.continue
+  %7 = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %stackIsNil = icmp eq %runtime._defer* %7, null
+  br i1 %stackIsNil, label %rundefers.end, label %rundefers.loop
+
+rundefers.loop:                                   ; preds = %rundefers.loophead
+  %stack.next.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %7, i32 0, i32 1
+  %stack.next = load %runtime._defer*, %runtime._defer** %stack.next.gep, align 4
+  store %runtime._defer* %stack.next, %runtime._defer** %deferPtr, align 4
+  %callback.gep = getelementptr inbounds %runtime._defer, %runtime._defer* %7, i32 0, i32 0
+  %callback = load i32, i32* %callback.gep, align 4
+  switch i32 %callback, label %rundefers.default [
+    i32 0, label %rundefers.callback
+  ]
+
+rundefers.default:                                ; preds = %rundefers.loop
+  unreachable
+
+rundefers.end:                                    ; preds = %rundefers.loophead
+  %.elt = bitcast i8* %err to i32*
+  %.unpack = load i32, i32* %.elt, align 4
+  %8 = insertvalue %runtime._interface undef, i32 %.unpack, 0
+  %.elt18 = getelementptr inbounds i8, i8* %err, i32 4
+  %9 = bitcast i8* %.elt18 to i8**
+  %.unpack19 = load i8*, i8** %9, align 4
+  %10 = insertvalue %runtime._interface %8, i8* %.unpack19, 1
+  call void @runtime.trackPointer(i8* %.unpack19, i8* undef) #0
+  call void @runtime.destroyDeferFrame(%runtime.deferFrame* nonnull %deferframe.buf, i8* undef) #0
+  ret %runtime._interface %10
+
+rundefers.callback:                               ; preds = %rundefers.loop
+  %11 = getelementptr inbounds %runtime._defer, %runtime._defer* %7, i32 1
+  %12 = bitcast %runtime._defer* %11 to i8**
+  %param = load i8*, i8** %12, align 4
+  call void @"main.deferRecover$1"(i8* %param)
+  br label %rundefers.loophead
+
+rundefers.loophead1:                              ; preds = %rundefers.callback10, %lpad
+  %13 = load %runtime._defer*, %runtime._defer** %deferPtr, align 4
+  %stackIsNil5 = icmp eq %runtime._defer* %13, null
+  br i1 %stackIsNil5, label %rundefers.end4, label %rundefers.loop2
+
+rundefers.loop2:                                  ; preds = %rundefers.loophead1
+  %stack.next.gep6 = getelementptr inbounds %runtime._defer, %runtime._defer* %13, i32 0, i32 1
+  %stack.next7 = load %runtime._defer*, %runtime._defer** %stack.next.gep6, align 4
+  store %runtime._defer* %stack.next7, %runtime._defer** %deferPtr, align 4
+  %callback.gep8 = getelementptr inbounds %runtime._defer, %runtime._defer* %13, i32 0, i32 0
+  %callback9 = load i32, i32* %callback.gep8, align 4
+  switch i32 %callback9, label %rundefers.default3 [
+    i32 0, label %rundefers.callback10
+  ]
+
+rundefers.default3:                               ; preds = %rundefers.loop2
+  unreachable
+
+rundefers.end4:                                   ; preds = %rundefers.loophead1
+  br label %recover
+
+rundefers.callback10:                             ; preds = %rundefers.loop2
+  %14 = getelementptr inbounds %runtime._defer, %runtime._defer* %13, i32 1
+  %15 = bitcast %runtime._defer* %14 to i8**
+  %param12 = load i8*, i8** %15, align 4
+  call void @"main.deferRecover$1"(i8* %param12)
+  br label %rundefers.loophead1
+}
+
+declare void @runtime.setupDeferFrame(%runtime.deferFrame* dereferenceable_or_null(32), i8*)
+
+; Function Attrs: nounwind
+define hidden void @"main.deferRecover$1"(i8* %context) unnamed_addr #0 {
+entry:
+  %0 = call %runtime._interface @runtime._recover(i1 false, i8* undef) #0
+  %1 = extractvalue %runtime._interface %0, 1
+  call void @runtime.trackPointer(i8* %1, i8* undef) #0
+  %unpack.ptr.repack = bitcast i8* %context to i32*
+  %.elt = extractvalue %runtime._interface %0, 0
+  store i32 %.elt, i32* %unpack.ptr.repack, align 4
+  %unpack.ptr.repack1 = getelementptr inbounds i8, i8* %context, i32 4
+  %2 = bitcast i8* %unpack.ptr.repack1 to i8**
+  %.elt2 = extractvalue %runtime._interface %0, 1
+  store i8* %.elt2, i8** %2, align 4
+  ret void
+}
+
+declare i1 @runtime.deferCheckpoint(%runtime.deferFrame* dereferenceable_or_null(32), i8*)
+
+declare void @runtime.destroyDeferFrame(%runtime.deferFrame* dereferenceable_or_null(32), i8*)
+
+declare %runtime._interface @runtime._recover(i1, i8*)
+
+attributes #0 = { nounwind }
+attributes #1 = { nounwind returns_twice }
@@ -0,0 +1,37 @@
+package main
+
+// Test type parameters (generics), introduced in Go 1.18.
+// These tests should be merged into the regular tests once Go 1.18 is the
+// minimum Go version for TinyGo.
+
+type Coord interface {
+	int | float32
+}
+
+type Point[T Coord] struct {
+	X, Y T
+}
+
+func Add[T Coord](a, b Point[T]) Point[T] {
+	return Point[T]{
+		X: a.X + b.X,
+		Y: a.Y + b.Y,
+	}
+}
+
+func (p Point[T]) Sum() T {
+	return p.X + p.Y
+}
+
+type Summer[T Coord] interface {
+	Sum() T
+}
+
+func main() {
+	var af, bf Point[float32]
+	Add(af, bf)
+
+	var ai, bi Point[int]
+	var s Summer[int] = Add(ai, bi)
+	s.Sum()
+}
@@ -0,0 +1,456 @@
+; ModuleID = 'generics.go'
+source_filename = "generics.go"
+target datalayout = "e-m:e-p:32:32-i64:64-n32:64-S128-ni:1:10:20"
+target triple = "wasm32-unknown-wasi"
+
+%runtime.typecodeID = type { %runtime.typecodeID*, i32, %runtime.interfaceMethodInfo*, %runtime.typecodeID*, i32 }
+%runtime.interfaceMethodInfo = type { %runtime.typecodeID**, i32, %runtime.typecodeID*, i32 }
+%runtime.structField = type { %runtime.typecodeID*, i8*, i8*, i1, i8* }
+%"main.Point[int]" = type { i32, i32 }
+%"main.Point[float32]" = type { float, float }
+
+@"reflect/types.type:named:main.Point[int]" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:struct:{X:basic:int,Y:basic:int}", i32 ptrtoint ([15 x i8]* @"reflect/types.typeName" to i32), %runtime.interfaceMethodInfo* getelementptr inbounds ([1 x %runtime.interfaceMethodInfo], [1 x %runtime.interfaceMethodInfo]* @"main.Point[int]$methodset", i32 0, i32 0), %runtime.typecodeID* @"reflect/types.type:pointer:named:main.Point[int]", i32 0 }
+@"reflect/types.type:struct:{X:basic:int,Y:basic:int}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* bitcast ([2 x %runtime.structField]* @"reflect/types.structFields" to %runtime.typecodeID*), i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:struct:{X:basic:int,Y:basic:int}", i32 0 }
+@"reflect/types.structFields" = private unnamed_addr global [2 x %runtime.structField] [%runtime.structField { %runtime.typecodeID* @"reflect/types.type:basic:int", i8* getelementptr inbounds ([1 x i8], [1 x i8]* @"reflect/types.structFieldName", i32 0, i32 0), i8* null, i1 false, i8* null }, %runtime.structField { %runtime.typecodeID* @"reflect/types.type:basic:int", i8* getelementptr inbounds ([1 x i8], [1 x i8]* @"reflect/types.structFieldName.1", i32 0, i32 0), i8* null, i1 false, i8* null }]
+@"reflect/types.type:basic:int" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* null, i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:basic:int", i32 0 }
+@"reflect/types.type:pointer:basic:int" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:basic:int", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* null, i32 0 }
+@"reflect/types.structFieldName" = private unnamed_addr global [1 x i8] c"X"
+@"reflect/types.structFieldName.1" = private unnamed_addr global [1 x i8] c"Y"
+@"reflect/types.type:pointer:struct:{X:basic:int,Y:basic:int}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:struct:{X:basic:int,Y:basic:int}", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* null, i32 0 }
+@"reflect/types.typeName" = private unnamed_addr global [15 x i8] c"main.Point[int]"
+@"reflect/methods.Sum() int" = linkonce_odr constant %runtime.typecodeID* @"reflect/types.type:func:{}{basic:int}"
+@"reflect/types.type:func:{}{basic:int}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* bitcast ({ [0 x %runtime.typecodeID*], [1 x %runtime.typecodeID*], i1, void ()*, void ()* }* @"reflect/types.funcElems" to %runtime.typecodeID*), i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:func:{}{basic:int}", i32 0 }
+@"reflect/types.funcElems" = private unnamed_addr global { [0 x %runtime.typecodeID*], [1 x %runtime.typecodeID*], i1, void ()*, void ()* } { [0 x %runtime.typecodeID*] zeroinitializer, [1 x %runtime.typecodeID*] [%runtime.typecodeID* @"reflect/types.type:basic:int"], i1 false, void ()* bitcast (void (i8*, i8*, i8*, i8*)* @"func:{}{basic:int}$reflectcall" to void ()*), void ()* bitcast (i32 (i8*)* @"func:{}{basic:int}$makefunc" to void ()*) }
+@"reflect/types.type:pointer:func:{}{basic:int}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:func:{}{basic:int}", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* null, i32 0 }
+@"reflect/types.type:func:{named:main.Point[int]}{basic:int}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* bitcast ({ [1 x %runtime.typecodeID*], [1 x %runtime.typecodeID*], i1, void ()*, void ()* }* @"reflect/types.funcElems.2" to %runtime.typecodeID*), i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:func:{named:main.Point[int]}{basic:int}", i32 0 }
+@"reflect/types.funcElems.2" = private unnamed_addr global { [1 x %runtime.typecodeID*], [1 x %runtime.typecodeID*], i1, void ()*, void ()* } { [1 x %runtime.typecodeID*] [%runtime.typecodeID* @"reflect/types.type:named:main.Point[int]"], [1 x %runtime.typecodeID*] [%runtime.typecodeID* @"reflect/types.type:basic:int"], i1 false, void ()* bitcast (void (i8*, i8*, i8*, i8*)* @"func:{named:main.Point[int]}{basic:int}$reflectcall" to void ()*), void ()* bitcast (i32 (i32, i32, i8*)* @"func:{named:main.Point[int]}{basic:int}$makefunc" to void ()*) }
+@"reflect/types.type:pointer:func:{named:main.Point[int]}{basic:int}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:func:{named:main.Point[int]}{basic:int}", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* null, i32 0 }
+@"main.Point[int]$methodset" = linkonce_odr constant [1 x %runtime.interfaceMethodInfo] [%runtime.interfaceMethodInfo { %runtime.typecodeID** @"reflect/methods.Sum() int", i32 ptrtoint (i32 (i8*, i8*)* @"(main.Point[int]).Sum[int]$invoke" to i32), %runtime.typecodeID* @"reflect/types.type:func:{named:main.Point[int]}{basic:int}", i32 ptrtoint (i32 (i32, i32, i8*)* @"(main.Point[int]).Sum[int]" to i32) }]
+@"reflect/types.type:pointer:named:main.Point[int]" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:named:main.Point[int]", i32 0, %runtime.interfaceMethodInfo* getelementptr inbounds ([1 x %runtime.interfaceMethodInfo], [1 x %runtime.interfaceMethodInfo]* @"*main.Point[int]$methodset", i32 0, i32 0), %runtime.typecodeID* null, i32 0 }
+@"main$string" = internal unnamed_addr constant [15 x i8] c"main.Point[int]", align 1
+@"main$string.3" = internal unnamed_addr constant [3 x i8] c"Sum", align 1
+@"reflect/types.type:func:{pointer:named:main.Point[int]}{basic:int}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* bitcast ({ [1 x %runtime.typecodeID*], [1 x %runtime.typecodeID*], i1, void ()*, void ()* }* @"reflect/types.funcElems.4" to %runtime.typecodeID*), i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:func:{pointer:named:main.Point[int]}{basic:int}", i32 0 }
+@"reflect/types.funcElems.4" = private unnamed_addr global { [1 x %runtime.typecodeID*], [1 x %runtime.typecodeID*], i1, void ()*, void ()* } { [1 x %runtime.typecodeID*] [%runtime.typecodeID* @"reflect/types.type:pointer:named:main.Point[int]"], [1 x %runtime.typecodeID*] [%runtime.typecodeID* @"reflect/types.type:basic:int"], i1 false, void ()* bitcast (void (i8*, i8*, i8*, i8*)* @"func:{pointer:named:main.Point[int]}{basic:int}$reflectcall" to void ()*), void ()* bitcast (i32 (%"main.Point[int]"*, i8*)* @"func:{pointer:named:main.Point[int]}{basic:int}$makefunc" to void ()*) }
+@"reflect/types.type:pointer:func:{pointer:named:main.Point[int]}{basic:int}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:func:{pointer:named:main.Point[int]}{basic:int}", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* null, i32 0 }
+@"*main.Point[int]$methodset" = linkonce_odr constant [1 x %runtime.interfaceMethodInfo] [%runtime.interfaceMethodInfo { %runtime.typecodeID** @"reflect/methods.Sum() int", i32 ptrtoint (i32 (%"main.Point[int]"*, i8*)* @"(*main.Point[int]).Sum" to i32), %runtime.typecodeID* @"reflect/types.type:func:{pointer:named:main.Point[int]}{basic:int}", i32 ptrtoint (i32 (%"main.Point[int]"*, i8*)* @"(*main.Point[int]).Sum" to i32) }]
+
+declare noalias nonnull i8* @runtime.alloc(i32, i8*, i8*)
+
+declare void @runtime.trackPointer(i8* nocapture readonly, i8*)
+
+; Function Attrs: nounwind
+define hidden void @main.init(i8* %context) unnamed_addr #0 {
+entry:
+  ret void
+}
+
+; Function Attrs: nounwind
+define hidden void @main.main(i8* %context) unnamed_addr #0 {
+entry:
+  %bi = alloca %"main.Point[int]", align 8
+  %ai = alloca %"main.Point[int]", align 8
+  %bf = alloca %"main.Point[float32]", align 8
+  %af = alloca %"main.Point[float32]", align 8
+  %af.repack = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %af, i32 0, i32 0
+  store float 0.000000e+00, float* %af.repack, align 8
+  %af.repack1 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %af, i32 0, i32 1
+  store float 0.000000e+00, float* %af.repack1, align 4
+  %0 = bitcast %"main.Point[float32]"* %af to i8*
+  call void @runtime.trackPointer(i8* nonnull %0, i8* undef) #0
+  %bf.repack = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %bf, i32 0, i32 0
+  store float 0.000000e+00, float* %bf.repack, align 8
+  %bf.repack2 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %bf, i32 0, i32 1
+  store float 0.000000e+00, float* %bf.repack2, align 4
+  %1 = bitcast %"main.Point[float32]"* %bf to i8*
+  call void @runtime.trackPointer(i8* nonnull %1, i8* undef) #0
+  %.elt = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %af, i32 0, i32 0
+  %.unpack = load float, float* %.elt, align 8
+  %.elt3 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %af, i32 0, i32 1
+  %.unpack4 = load float, float* %.elt3, align 4
+  %.elt5 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %bf, i32 0, i32 0
+  %.unpack6 = load float, float* %.elt5, align 8
+  %.elt7 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %bf, i32 0, i32 1
+  %.unpack8 = load float, float* %.elt7, align 4
+  %2 = call %"main.Point[float32]" @"main.Add[float32]"(float %.unpack, float %.unpack4, float %.unpack6, float %.unpack8, i8* undef)
+  %ai.repack = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %ai, i32 0, i32 0
+  store i32 0, i32* %ai.repack, align 8
+  %ai.repack9 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %ai, i32 0, i32 1
+  store i32 0, i32* %ai.repack9, align 4
+  %3 = bitcast %"main.Point[int]"* %ai to i8*
+  call void @runtime.trackPointer(i8* nonnull %3, i8* undef) #0
+  %bi.repack = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %bi, i32 0, i32 0
+  store i32 0, i32* %bi.repack, align 8
+  %bi.repack10 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %bi, i32 0, i32 1
+  store i32 0, i32* %bi.repack10, align 4
+  %4 = bitcast %"main.Point[int]"* %bi to i8*
+  call void @runtime.trackPointer(i8* nonnull %4, i8* undef) #0
+  %.elt11 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %ai, i32 0, i32 0
+  %.unpack12 = load i32, i32* %.elt11, align 8
+  %.elt13 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %ai, i32 0, i32 1
+  %.unpack14 = load i32, i32* %.elt13, align 4
+  %.elt15 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %bi, i32 0, i32 0
+  %.unpack16 = load i32, i32* %.elt15, align 8
+  %.elt17 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %bi, i32 0, i32 1
+  %.unpack18 = load i32, i32* %.elt17, align 4
+  %5 = call %"main.Point[int]" @"main.Add[int]"(i32 %.unpack12, i32 %.unpack14, i32 %.unpack16, i32 %.unpack18, i8* undef)
+  %6 = call i8* @runtime.alloc(i32 8, i8* null, i8* undef) #0
+  call void @runtime.trackPointer(i8* nonnull %6, i8* undef) #0
+  %.repack = bitcast i8* %6 to i32*
+  %.elt19 = extractvalue %"main.Point[int]" %5, 0
+  store i32 %.elt19, i32* %.repack, align 4
+  %.repack20 = getelementptr inbounds i8, i8* %6, i32 4
+  %7 = bitcast i8* %.repack20 to i32*
+  %.elt21 = extractvalue %"main.Point[int]" %5, 1
+  store i32 %.elt21, i32* %7, align 4
+  call void @runtime.trackPointer(i8* nonnull %6, i8* undef) #0
+  %8 = call i32 @"interface:{Sum:func:{}{basic:int}}.Sum$invoke"(i8* nonnull %6, i32 ptrtoint (%runtime.typecodeID* @"reflect/types.type:named:main.Point[int]" to i32), i8* undef) #0
+  ret void
+}
+
+; Function Attrs: nounwind
+define linkonce_odr hidden %"main.Point[float32]" @"main.Add[float32]"(float %a.X, float %a.Y, float %b.X, float %b.Y, i8* %context) unnamed_addr #0 {
+entry:
+  %complit = alloca %"main.Point[float32]", align 8
+  %b = alloca %"main.Point[float32]", align 8
+  %a = alloca %"main.Point[float32]", align 8
+  %a.repack = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %a, i32 0, i32 0
+  store float 0.000000e+00, float* %a.repack, align 8
+  %a.repack9 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %a, i32 0, i32 1
+  store float 0.000000e+00, float* %a.repack9, align 4
+  %0 = bitcast %"main.Point[float32]"* %a to i8*
+  call void @runtime.trackPointer(i8* nonnull %0, i8* undef) #0
+  %a.repack10 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %a, i32 0, i32 0
+  store float %a.X, float* %a.repack10, align 8
+  %a.repack11 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %a, i32 0, i32 1
+  store float %a.Y, float* %a.repack11, align 4
+  %b.repack = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %b, i32 0, i32 0
+  store float 0.000000e+00, float* %b.repack, align 8
+  %b.repack13 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %b, i32 0, i32 1
+  store float 0.000000e+00, float* %b.repack13, align 4
+  %1 = bitcast %"main.Point[float32]"* %b to i8*
+  call void @runtime.trackPointer(i8* nonnull %1, i8* undef) #0
+  %b.repack14 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %b, i32 0, i32 0
+  store float %b.X, float* %b.repack14, align 8
+  %b.repack15 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %b, i32 0, i32 1
+  store float %b.Y, float* %b.repack15, align 4
+  %complit.repack = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %complit, i32 0, i32 0
+  store float 0.000000e+00, float* %complit.repack, align 8
+  %complit.repack17 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %complit, i32 0, i32 1
+  store float 0.000000e+00, float* %complit.repack17, align 4
+  %2 = bitcast %"main.Point[float32]"* %complit to i8*
+  call void @runtime.trackPointer(i8* nonnull %2, i8* undef) #0
+  %3 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %complit, i32 0, i32 0
+  br i1 false, label %deref.throw, label %deref.next
+
+deref.throw:                                      ; preds = %entry
+  unreachable
+
+deref.next:                                       ; preds = %entry
+  br i1 false, label %deref.throw1, label %deref.next2
+
+deref.throw1:                                     ; preds = %deref.next
+  unreachable
+
+deref.next2:                                      ; preds = %deref.next
+  %4 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %b, i32 0, i32 0
+  %5 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %a, i32 0, i32 0
+  %6 = load float, float* %5, align 8
+  %7 = load float, float* %4, align 8
+  %8 = fadd float %6, %7
+  br i1 false, label %deref.throw3, label %deref.next4
+
+deref.throw3:                                     ; preds = %deref.next2
+  unreachable
+
+deref.next4:                                      ; preds = %deref.next2
+  br i1 false, label %deref.throw5, label %deref.next6
+
+deref.throw5:                                     ; preds = %deref.next4
+  unreachable
+
+deref.next6:                                      ; preds = %deref.next4
+  %9 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %b, i32 0, i32 1
+  %10 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %a, i32 0, i32 1
+  %11 = load float, float* %10, align 4
+  %12 = load float, float* %9, align 4
+  br i1 false, label %store.throw, label %store.next
+
+store.throw:                                      ; preds = %deref.next6
+  unreachable
+
+store.next:                                       ; preds = %deref.next6
+  store float %8, float* %3, align 8
+  br i1 false, label %store.throw7, label %store.next8
+
+store.throw7:                                     ; preds = %store.next
+  unreachable
+
+store.next8:                                      ; preds = %store.next
+  %13 = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %complit, i32 0, i32 1
+  %14 = fadd float %11, %12
+  store float %14, float* %13, align 4
+  %.elt = getelementptr inbounds %"main.Point[float32]", %"main.Point[float32]"* %complit, i32 0, i32 0
+  %.unpack = load float, float* %.elt, align 8
+  %15 = insertvalue %"main.Point[float32]" undef, float %.unpack, 0
+  %16 = insertvalue %"main.Point[float32]" %15, float %14, 1
+  ret %"main.Point[float32]" %16
+}
+
+declare void @runtime.nilPanic(i8*)
+
+; Function Attrs: nounwind
+define linkonce_odr hidden %"main.Point[int]" @"main.Add[int]"(i32 %a.X, i32 %a.Y, i32 %b.X, i32 %b.Y, i8* %context) unnamed_addr #0 {
+entry:
+  %complit = alloca %"main.Point[int]", align 8
+  %b = alloca %"main.Point[int]", align 8
+  %a = alloca %"main.Point[int]", align 8
+  %a.repack = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %a, i32 0, i32 0
+  store i32 0, i32* %a.repack, align 8
+  %a.repack9 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %a, i32 0, i32 1
+  store i32 0, i32* %a.repack9, align 4
+  %0 = bitcast %"main.Point[int]"* %a to i8*
+  call void @runtime.trackPointer(i8* nonnull %0, i8* undef) #0
+  %a.repack10 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %a, i32 0, i32 0
+  store i32 %a.X, i32* %a.repack10, align 8
+  %a.repack11 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %a, i32 0, i32 1
+  store i32 %a.Y, i32* %a.repack11, align 4
+  %b.repack = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %b, i32 0, i32 0
+  store i32 0, i32* %b.repack, align 8
+  %b.repack13 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %b, i32 0, i32 1
+  store i32 0, i32* %b.repack13, align 4
+  %1 = bitcast %"main.Point[int]"* %b to i8*
+  call void @runtime.trackPointer(i8* nonnull %1, i8* undef) #0
+  %b.repack14 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %b, i32 0, i32 0
+  store i32 %b.X, i32* %b.repack14, align 8
+  %b.repack15 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %b, i32 0, i32 1
+  store i32 %b.Y, i32* %b.repack15, align 4
+  %complit.repack = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %complit, i32 0, i32 0
+  store i32 0, i32* %complit.repack, align 8
+  %complit.repack17 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %complit, i32 0, i32 1
+  store i32 0, i32* %complit.repack17, align 4
+  %2 = bitcast %"main.Point[int]"* %complit to i8*
+  call void @runtime.trackPointer(i8* nonnull %2, i8* undef) #0
+  %3 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %complit, i32 0, i32 0
+  br i1 false, label %deref.throw, label %deref.next
+
+deref.throw:                                      ; preds = %entry
+  unreachable
+
+deref.next:                                       ; preds = %entry
+  br i1 false, label %deref.throw1, label %deref.next2
+
+deref.throw1:                                     ; preds = %deref.next
+  unreachable
+
+deref.next2:                                      ; preds = %deref.next
+  %4 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %b, i32 0, i32 0
+  %5 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %a, i32 0, i32 0
+  %6 = load i32, i32* %5, align 8
+  %7 = load i32, i32* %4, align 8
+  %8 = add i32 %6, %7
+  br i1 false, label %deref.throw3, label %deref.next4
+
+deref.throw3:                                     ; preds = %deref.next2
+  unreachable
+
+deref.next4:                                      ; preds = %deref.next2
+  br i1 false, label %deref.throw5, label %deref.next6
+
+deref.throw5:                                     ; preds = %deref.next4
+  unreachable
+
+deref.next6:                                      ; preds = %deref.next4
+  %9 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %b, i32 0, i32 1
+  %10 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %a, i32 0, i32 1
+  %11 = load i32, i32* %10, align 4
+  %12 = load i32, i32* %9, align 4
+  br i1 false, label %store.throw, label %store.next
+
+store.throw:                                      ; preds = %deref.next6
+  unreachable
+
+store.next:                                       ; preds = %deref.next6
+  store i32 %8, i32* %3, align 8
+  br i1 false, label %store.throw7, label %store.next8
+
+store.throw7:                                     ; preds = %store.next
+  unreachable
+
+store.next8:                                      ; preds = %store.next
+  %13 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %complit, i32 0, i32 1
+  %14 = add i32 %11, %12
+  store i32 %14, i32* %13, align 4
+  %.elt = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %complit, i32 0, i32 0
+  %.unpack = load i32, i32* %.elt, align 8
+  %15 = insertvalue %"main.Point[int]" undef, i32 %.unpack, 0
+  %16 = insertvalue %"main.Point[int]" %15, i32 %14, 1
+  ret %"main.Point[int]" %16
+}
+
+; Function Attrs: nounwind
+define linkonce_odr i32 @"func:{}{basic:int}$makefunc"(i8* %0) unnamed_addr #0 {
+entry:
+  %args = alloca {}, align 8
+  %results = alloca { i32 }, align 8
+  %1 = bitcast {}* %args to i8*
+  %2 = bitcast { i32 }* %results to i8*
+  call void @reflect.makeFuncStub(i8* %0, i8* nonnull %1, i8* nonnull %2, i8* undef) #0
+  %3 = getelementptr inbounds { i32 }, { i32 }* %results, i32 0, i32 0
+  %4 = load i32, i32* %3, align 8
+  ret i32 %4
+}
+
+declare void @reflect.makeFuncStub(i8*, i8*, i8*, i8*)
+
+; Function Attrs: nounwind
+define linkonce_odr void @"func:{}{basic:int}$reflectcall"(i8* %0, i8* %1, i8* %2, i8* %3) unnamed_addr #0 {
+entry:
+  %fn.elt = bitcast i8* %0 to i8**
+  %fn.unpack = load i8*, i8** %fn.elt, align 4
+  %fn.elt1 = getelementptr inbounds i8, i8* %0, i32 4
+  %4 = bitcast i8* %fn.elt1 to i32 (i8*)**
+  %fn.unpack24 = load i32 (i8*)*, i32 (i8*)** %4, align 4
+  %5 = call i32 %fn.unpack24(i8* %fn.unpack) #0
+  %6 = bitcast i8* %2 to i32*
+  store i32 %5, i32* %6, align 4
+  ret void
+}
+
+; Function Attrs: nounwind
+define linkonce_odr hidden i32 @"(main.Point[int]).Sum[int]"(i32 %p.X, i32 %p.Y, i8* %context) unnamed_addr #0 {
+entry:
+  %p = alloca %"main.Point[int]", align 8
+  %p.repack = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %p, i32 0, i32 0
+  store i32 0, i32* %p.repack, align 8
+  %p.repack3 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %p, i32 0, i32 1
+  store i32 0, i32* %p.repack3, align 4
+  %0 = bitcast %"main.Point[int]"* %p to i8*
+  call void @runtime.trackPointer(i8* nonnull %0, i8* undef) #0
+  %p.repack4 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %p, i32 0, i32 0
+  store i32 %p.X, i32* %p.repack4, align 8
+  %p.repack5 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %p, i32 0, i32 1
+  store i32 %p.Y, i32* %p.repack5, align 4
+  br i1 false, label %deref.throw, label %deref.next
+
+deref.throw:                                      ; preds = %entry
+  unreachable
+
+deref.next:                                       ; preds = %entry
+  br i1 false, label %deref.throw1, label %deref.next2
+
+deref.throw1:                                     ; preds = %deref.next
+  unreachable
+
+deref.next2:                                      ; preds = %deref.next
+  %1 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %p, i32 0, i32 1
+  %2 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %p, i32 0, i32 0
+  %3 = load i32, i32* %2, align 8
+  %4 = load i32, i32* %1, align 4
+  %5 = add i32 %3, %4
+  ret i32 %5
+}
+
+; Function Attrs: nounwind
+define linkonce_odr i32 @"(main.Point[int]).Sum[int]$invoke"(i8* %0, i8* %1) unnamed_addr #0 {
+entry:
+  %.elt = bitcast i8* %0 to i32*
+  %.unpack = load i32, i32* %.elt, align 4
+  %.elt1 = getelementptr inbounds i8, i8* %0, i32 4
+  %2 = bitcast i8* %.elt1 to i32*
+  %.unpack2 = load i32, i32* %2, align 4
+  %ret = call i32 @"(main.Point[int]).Sum[int]"(i32 %.unpack, i32 %.unpack2, i8* %1)
+  ret i32 %ret
+}
+
+; Function Attrs: nounwind
+define linkonce_odr i32 @"func:{named:main.Point[int]}{basic:int}$makefunc"(i32 %0, i32 %1, i8* %2) unnamed_addr #0 {
+entry:
+  %args = alloca { %"main.Point[int]" }, align 8
+  %.repack = getelementptr inbounds { %"main.Point[int]" }, { %"main.Point[int]" }* %args, i32 0, i32 0, i32 0
+  store i32 %0, i32* %.repack, align 8
+  %.repack1 = getelementptr inbounds { %"main.Point[int]" }, { %"main.Point[int]" }* %args, i32 0, i32 0, i32 1
+  store i32 %1, i32* %.repack1, align 4
+  %results = alloca { i32 }, align 8
+  %3 = bitcast { %"main.Point[int]" }* %args to i8*
+  %4 = bitcast { i32 }* %results to i8*
+  call void @reflect.makeFuncStub(i8* %2, i8* nonnull %3, i8* nonnull %4, i8* undef) #0
+  %5 = getelementptr inbounds { i32 }, { i32 }* %results, i32 0, i32 0
+  %6 = load i32, i32* %5, align 8
+  ret i32 %6
+}
+
+; Function Attrs: nounwind
+define linkonce_odr void @"func:{named:main.Point[int]}{basic:int}$reflectcall"(i8* %0, i8* %1, i8* %2, i8* %3) unnamed_addr #0 {
+entry:
+  %fn.elt = bitcast i8* %0 to i8**
+  %fn.unpack = load i8*, i8** %fn.elt, align 4
+  %fn.elt1 = getelementptr inbounds i8, i8* %0, i32 4
+  %4 = bitcast i8* %fn.elt1 to i32 (i32, i32, i8*)**
+  %fn.unpack24 = load i32 (i32, i32, i8*)*, i32 (i32, i32, i8*)** %4, align 4
+  %.elt = bitcast i8* %1 to i32*
+  %.unpack = load i32, i32* %.elt, align 4
+  %.elt5 = getelementptr inbounds i8, i8* %1, i32 4
+  %5 = bitcast i8* %.elt5 to i32*
+  %.unpack6 = load i32, i32* %5, align 4
+  %6 = call i32 %fn.unpack24(i32 %.unpack, i32 %.unpack6, i8* %fn.unpack) #0
+  %7 = bitcast i8* %2 to i32*
+  store i32 %6, i32* %7, align 4
+  ret void
+}
+
+; Function Attrs: nounwind
+define linkonce_odr hidden i32 @"(*main.Point[int]).Sum"(%"main.Point[int]"* dereferenceable_or_null(8) %p, i8* %context) unnamed_addr #0 {
+entry:
+  %0 = bitcast %"main.Point[int]"* %p to i8*
+  call void @runtime.trackPointer(i8* %0, i8* undef) #0
+  %1 = icmp eq %"main.Point[int]"* %p, null
+  br i1 %1, label %deref.throw, label %deref.next
+
+deref.throw:                                      ; preds = %entry
+  call void @runtime.nilPanic(i8* undef) #0
+  unreachable
+
+deref.next:                                       ; preds = %entry
+  %.elt = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %p, i32 0, i32 0
+  %.unpack = load i32, i32* %.elt, align 4
+  %.elt1 = getelementptr inbounds %"main.Point[int]", %"main.Point[int]"* %p, i32 0, i32 1
+  %.unpack2 = load i32, i32* %.elt1, align 4
+  %2 = call i32 @"(main.Point[int]).Sum[int]"(i32 %.unpack, i32 %.unpack2, i8* undef)
+  ret i32 %2
+}
+
+; Function Attrs: nounwind
+define linkonce_odr i32 @"func:{pointer:named:main.Point[int]}{basic:int}$makefunc"(%"main.Point[int]"* %0, i8* %1) unnamed_addr #0 {
+entry:
+  %args = alloca { %"main.Point[int]"* }, align 8
+  %2 = getelementptr inbounds { %"main.Point[int]"* }, { %"main.Point[int]"* }* %args, i32 0, i32 0
+  store %"main.Point[int]"* %0, %"main.Point[int]"** %2, align 8
+  %results = alloca { i32 }, align 8
+  %3 = bitcast { %"main.Point[int]"* }* %args to i8*
+  %4 = bitcast { i32 }* %results to i8*
+  call void @reflect.makeFuncStub(i8* %1, i8* nonnull %3, i8* nonnull %4, i8* undef) #0
+  %5 = getelementptr inbounds { i32 }, { i32 }* %results, i32 0, i32 0
+  %6 = load i32, i32* %5, align 8
+  ret i32 %6
+}
+
+; Function Attrs: nounwind
+define linkonce_odr void @"func:{pointer:named:main.Point[int]}{basic:int}$reflectcall"(i8* %0, i8* %1, i8* %2, i8* %3) unnamed_addr #0 {
+entry:
+  %fn.elt = bitcast i8* %0 to i8**
+  %fn.unpack = load i8*, i8** %fn.elt, align 4
+  %fn.elt1 = getelementptr inbounds i8, i8* %0, i32 4
+  %4 = bitcast i8* %fn.elt1 to i32 (%"main.Point[int]"*, i8*)**
+  %fn.unpack24 = load i32 (%"main.Point[int]"*, i8*)*, i32 (%"main.Point[int]"*, i8*)** %4, align 4
+  %5 = bitcast i8* %1 to %"main.Point[int]"**
+  %6 = load %"main.Point[int]"*, %"main.Point[int]"** %5, align 4
+  %7 = call i32 %fn.unpack24(%"main.Point[int]"* %6, i8* %fn.unpack) #0
+  %8 = bitcast i8* %2 to i32*
+  store i32 %7, i32* %8, align 4
+  ret void
+}
+
+declare i32 @"interface:{Sum:func:{}{basic:int}}.Sum$invoke"(i8*, i32, i8*) #1
+
+attributes #0 = { nounwind }
+attributes #1 = { "tinygo-invoke"="reflect/methods.Sum() int" "tinygo-methods"="reflect/methods.Sum() int" }
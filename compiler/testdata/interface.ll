@@ -4,22 +4,28 @@ target datalayout = "e-m:e-p:32:32-i64:64-n32:64-S128-ni:1:10:20"
 target triple = "wasm32-unknown-wasi"
 
 %runtime.typecodeID = type { %runtime.typecodeID*, i32, %runtime.interfaceMethodInfo*, %runtime.typecodeID*, i32 }
-%runtime.interfaceMethodInfo = type { i8*, i32 }
+%runtime.interfaceMethodInfo = type { %runtime.typecodeID**, i32, %runtime.typecodeID*, i32 }
 %runtime._interface = type { i32, i8* }
 %runtime._string = type { i8*, i32 }
 
 @"reflect/types.type:basic:int" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* null, i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:basic:int", i32 0 }
 @"reflect/types.type:pointer:basic:int" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:basic:int", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* null, i32 0 }
 @"reflect/types.type:pointer:named:error" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:named:error", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* null, i32 0 }
-@"reflect/types.type:named:error" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:interface:{Error:func:{}{basic:string}}", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:named:error", i32 ptrtoint (i1 (i32)* @"interface:{Error:func:{}{basic:string}}.$typeassert" to i32) }
-@"reflect/types.type:interface:{Error:func:{}{basic:string}}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* bitcast ([1 x i8*]* @"reflect/types.interface:interface{Error() string}$interface" to %runtime.typecodeID*), i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:interface:{Error:func:{}{basic:string}}", i32 ptrtoint (i1 (i32)* @"interface:{Error:func:{}{basic:string}}.$typeassert" to i32) }
-@"reflect/methods.Error() string" = linkonce_odr constant i8 0, align 1
-@"reflect/types.interface:interface{Error() string}$interface" = linkonce_odr constant [1 x i8*] [i8* @"reflect/methods.Error() string"]
+@"reflect/types.type:named:error" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:interface:{Error:func:{}{basic:string}}", i32 ptrtoint ([5 x i8]* @"reflect/types.typeName" to i32), %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:named:error", i32 ptrtoint (i1 (i32)* @"interface:{Error:func:{}{basic:string}}.$typeassert" to i32) }
+@"reflect/types.type:interface:{Error:func:{}{basic:string}}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* bitcast ([1 x %runtime.typecodeID**]* @"reflect/types.interface:interface{Error() string}$interface" to %runtime.typecodeID*), i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:interface:{Error:func:{}{basic:string}}", i32 ptrtoint (i1 (i32)* @"interface:{Error:func:{}{basic:string}}.$typeassert" to i32) }
+@"reflect/methods.Error() string" = linkonce_odr constant %runtime.typecodeID* @"reflect/types.type:func:{}{basic:string}"
+@"reflect/types.type:func:{}{basic:string}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* bitcast ({ [0 x %runtime.typecodeID*], [1 x %runtime.typecodeID*], i1, void ()*, void ()* }* @"reflect/types.funcElems" to %runtime.typecodeID*), i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:func:{}{basic:string}", i32 0 }
+@"reflect/types.type:basic:string" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* null, i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:basic:string", i32 0 }
+@"reflect/types.type:pointer:basic:string" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:basic:string", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* null, i32 0 }
+@"reflect/types.funcElems" = private unnamed_addr global { [0 x %runtime.typecodeID*], [1 x %runtime.typecodeID*], i1, void ()*, void ()* } { [0 x %runtime.typecodeID*] zeroinitializer, [1 x %runtime.typecodeID*] [%runtime.typecodeID* @"reflect/types.type:basic:string"], i1 false, void ()* bitcast (void (i8*, i8*, i8*, i8*)* @"func:{}{basic:string}$reflectcall" to void ()*), void ()* bitcast (%runtime._string (i8*)* @"func:{}{basic:string}$makefunc" to void ()*) }
+@"reflect/types.type:pointer:func:{}{basic:string}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:func:{}{basic:string}", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* null, i32 0 }
+@"reflect/types.interface:interface{Error() string}$interface" = linkonce_odr constant [1 x %runtime.typecodeID**] [%runtime.typecodeID** @"reflect/methods.Error() string"]
 @"reflect/types.type:pointer:interface:{Error:func:{}{basic:string}}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:interface:{Error:func:{}{basic:string}}", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* null, i32 0 }
+@"reflect/types.typeName" = private unnamed_addr global [5 x i8] c"error"
 @"reflect/types.type:pointer:interface:{String:func:{}{basic:string}}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* @"reflect/types.type:interface:{String:func:{}{basic:string}}", i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* null, i32 0 }
-@"reflect/types.type:interface:{String:func:{}{basic:string}}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* bitcast ([1 x i8*]* @"reflect/types.interface:interface{String() string}$interface" to %runtime.typecodeID*), i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:interface:{String:func:{}{basic:string}}", i32 ptrtoint (i1 (i32)* @"interface:{String:func:{}{basic:string}}.$typeassert" to i32) }
-@"reflect/methods.String() string" = linkonce_odr constant i8 0, align 1
-@"reflect/types.interface:interface{String() string}$interface" = linkonce_odr constant [1 x i8*] [i8* @"reflect/methods.String() string"]
+@"reflect/types.type:interface:{String:func:{}{basic:string}}" = linkonce_odr constant %runtime.typecodeID { %runtime.typecodeID* bitcast ([1 x %runtime.typecodeID**]* @"reflect/types.interface:interface{String() string}$interface" to %runtime.typecodeID*), i32 0, %runtime.interfaceMethodInfo* null, %runtime.typecodeID* @"reflect/types.type:pointer:interface:{String:func:{}{basic:string}}", i32 ptrtoint (i1 (i32)* @"interface:{String:func:{}{basic:string}}.$typeassert" to i32) }
+@"reflect/methods.String() string" = linkonce_odr constant %runtime.typecodeID* @"reflect/types.type:func:{}{basic:string}"
+@"reflect/types.interface:interface{String() string}$interface" = linkonce_odr constant [1 x %runtime.typecodeID**] [%runtime.typecodeID** @"reflect/methods.String() string"]
 @"reflect/types.typeid:basic:int" = external constant i8
 
 declare noalias nonnull i8* @runtime.alloc(i32, i8*, i8*)
@@ -53,6 +59,44 @@ entry:
   ret %runtime._interface { i32 ptrtoint (%runtime.typecodeID* @"reflect/types.type:pointer:named:error" to i32), i8* null }
 }
 
+; Function Attrs: nounwind
+define linkonce_odr %runtime._string @"func:{}{basic:string}$makefunc"(i8* %0) unnamed_addr #0 {
+entry:
+  %args = alloca {}, align 8
+  %results = alloca { %runtime._string }, align 8
+  %1 = bitcast {}* %args to i8*
+  %2 = bitcast { %runtime._string }* %results to i8*
+  call void @reflect.makeFuncStub(i8* %0, i8* nonnull %1, i8* nonnull %2, i8* undef) #0
+  %.elt = getelementptr inbounds { %runtime._string }, { %runtime._string }* %results, i32 0, i32 0, i32 0
+  %.unpack = load i8*, i8** %.elt, align 8
+  %3 = insertvalue %runtime._string undef, i8* %.unpack, 0
+  %.elt1 = getelementptr inbounds { %runtime._string }, { %runtime._string }* %results, i32 0, i32 0, i32 1
+  %.unpack2 = load i32, i32* %.elt1, align 4
+  %4 = insertvalue %runtime._string %3, i32 %.unpack2, 1
+  ret %runtime._string %4
+}
+
+declare void @reflect.makeFuncStub(i8*, i8*, i8*, i8*)
+
+; Function Attrs: nounwind
+define linkonce_odr void @"func:{}{basic:string}$reflectcall"(i8* %0, i8* %1, i8* %2, i8* %3) unnamed_addr #0 {
+entry:
+  %fn.elt = bitcast i8* %0 to i8**
+  %fn.unpack = load i8*, i8** %fn.elt, align 4
+  %fn.elt1 = getelementptr inbounds i8, i8* %0, i32 4
+  %4 = bitcast i8* %fn.elt1 to %runtime._string (i8*)**
+  %fn.unpack24 = load %runtime._string (i8*)*, %runtime._string (i8*)** %4, align 4
+  %5 = call %runtime._string %fn.unpack24(i8* %fn.unpack) #0
+  %.repack = bitcast i8* %2 to i8**
+  %.elt = extractvalue %runtime._string %5, 0
+  store i8* %.elt, i8** %.repack, align 4
+  %.repack5 = getelementptr inbounds i8, i8* %2, i32 4
+  %6 = bitcast i8* %.repack5 to i32*
+  %.elt6 = extractvalue %runtime._string %5, 1
+  store i32 %.elt6, i32* %6, align 4
+  ret void
+}
+
 declare i1 @"interface:{Error:func:{}{basic:string}}.$typeassert"(i32) #1
 
 ; Function Attrs: nounwind
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// These lines surround the coverage profile in the output of a test binary.
+// They must be kept in sync with src/testing/cover.go.
+const (
+	coverProfileStart = "--- tinygo coverprofile start"
+	coverProfileEnd   = "--- tinygo coverprofile end"
+)
+
+// coverFilter extracts the coverage profile from the output of a test binary
+// and passes on all other output. The profile is written to stdout by the
+// testing package, so that it can also be obtained from tests running on a
+// microcontroller (or in an emulator).
+type coverFilter struct {
+	w         io.Writer
+	line      []byte // incomplete line
+	inProfile bool
+	profile   bytes.Buffer // coverage profile, including the mode line
+	coverage  string       // coverage summary, like "coverage: 50.0% of statements"
+	err       error
+}
+
+func (f *coverFilter) Write(data []byte) (int, error) {
+	f.line = append(f.line, data...)
+	for {
+		i := bytes.IndexByte(f.line, '\n')
+		if i < 0 {
+			break
+		}
+		f.writeLine(f.line[:i+1])
+		f.line = f.line[i+1:]
+	}
+	return len(data), f.err
+}
+
+// writeLine processes a single line of output, including the newline.
+func (f *coverFilter) writeLine(line []byte) {
+	// Output from emulators or a serial port may use \r\n line endings.
+	text := string(bytes.TrimRight(line, "\r\n"))
+	switch {
+	case text == coverProfileStart:
+		f.inProfile = true
+	case text == coverProfileEnd:
+		f.inProfile = false
+	case f.inProfile:
+		f.profile.WriteString(text + "\n")
+	default:
+		if strings.HasPrefix(text, "coverage: ") {
+			f.coverage = text
+		}
+		if f.err == nil {
+			_, f.err = f.w.Write(line)
+		}
+	}
+}
+
+// Flush writes any remaining output that didn't end in a newline.
+func (f *coverFilter) Flush() error {
+	if len(f.line) != 0 {
+		f.writeLine(f.line)
+		f.line = nil
+	}
+	return f.err
+}
+
+// coverProfiles keeps track of the coverage profile files written by this
+// process. When testing multiple packages, the profiles of all packages are
+// merged into a single file, like go test does.
+var coverProfiles struct {
+	sync.Mutex
+	started map[string]bool
+}
+
+// writeCoverProfile writes the coverage profile of a single package to the
+// given file. The file is truncated the first time it is written to.
+func writeCoverProfile(path string, profile []byte) error {
+	if len(profile) == 0 {
+		// The test binary didn't produce a profile, for example because it
+		// crashed.
+		return nil
+	}
+
+	coverProfiles.Lock()
+	defer coverProfiles.Unlock()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !coverProfiles.started[path] {
+		flags |= os.O_TRUNC
+	} else if i := bytes.IndexByte(profile, '\n'); i >= 0 && bytes.HasPrefix(profile, []byte("mode: ")) {
+		// Only the first profile in the file has a mode line.
+		profile = profile[i+1:]
+	}
+	f, err := os.OpenFile(path, flags, 0666)
+	if err != nil {
+		return err
+	}
+	if coverProfiles.started == nil {
+		coverProfiles.started = make(map[string]bool)
+	}
+	coverProfiles.started[path] = true
+	_, err = f.Write(profile)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -193,12 +193,29 @@ func (r *runner) run(fn *function, params []value, parentMem *memoryView, indent
 			}
 		case llvm.Call:
 			// A call instruction can either be a regular call or a runtime intrinsic.
+			if asm, ok := operands[0].(localValue); ok && !asm.value.IsAInlineAsm().IsNil() && inst.name == "setjmp" {
+				// This is a checkpoint created in a function with a defer
+				// frame (see compiler/defer.go). A panic can't be recovered
+				// while interpreting, so simply continue with the regular
+				// (non-panicking) control flow.
+				if r.pointerSize == 8 {
+					locals[inst.localIndex] = literalValue{uint64(0)}
+				} else {
+					locals[inst.localIndex] = literalValue{uint32(0)}
+				}
+				continue
+			}
 			fnPtr, err := operands[0].asPointer(r)
 			if err != nil {
 				return nil, mem, r.errorAt(inst, err)
 			}
 			callFn := r.getFunction(fnPtr.llvmValue(&mem))
 			switch {
+			case callFn.name == "runtime.setupDeferFrame" || callFn.name == "runtime.destroyDeferFrame":
+				// Defer frames are only used to recover from a panic. A panic
+				// aborts interpretation of the init function (which is then
+				// run at runtime instead), so these calls can be ignored.
+				continue
 			case callFn.name == "runtime.trackPointer":
 				// Allocas and such are created as globals, so don't need a
 				// runtime.trackPointer.
@@ -371,6 +388,12 @@ func (r *runner) run(fn *function, params []value, parentMem *memoryView, indent
 					elementType := llvm.ConstExtractValue(typecodeID.Initializer(), []uint32{0})
 					uintptrType := r.mod.Context().IntType(int(mem.r.pointerSize) * 8)
 					locals[inst.localIndex] = r.getValue(llvm.ConstPtrToInt(elementType, uintptrType))
+				case "map":
+					// The key and element type are stored in a separate global.
+					mapElems := llvm.ConstExtractValue(typecodeID.Initializer(), []uint32{0}).Operand(0).Initializer()
+					elementType := llvm.ConstExtractValue(mapElems, []uint32{1})
+					uintptrType := r.mod.Context().IntType(int(mem.r.pointerSize) * 8)
+					locals[inst.localIndex] = r.getValue(llvm.ConstPtrToInt(elementType, uintptrType))
 				default:
 					return nil, mem, r.errorAt(inst, fmt.Errorf("(reflect.Type).Elem() called on %s type", class))
 				}
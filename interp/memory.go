@@ -1248,6 +1248,11 @@ func (r *runner) readObjectLayout(layoutValue value) (uint64, *big.Int) {
 		}
 		rawBytes[i] = byte(v)
 	}
+	// The bitmap is stored least significant byte first, while SetBytes
+	// expects the most significant byte first.
+	for i := 0; i < len(rawBytes)/2; i++ {
+		rawBytes[i], rawBytes[len(rawBytes)-1-i] = rawBytes[len(rawBytes)-1-i], rawBytes[i]
+	}
 	bitmap := new(big.Int).SetBytes(rawBytes)
 	return objectSizeWords, bitmap
 }
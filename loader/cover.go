@@ -0,0 +1,91 @@
+package loader
+
+// This file implements test coverage instrumentation. Source files are
+// rewritten using 'go tool cover' (just like 'go test -cover' did before Go
+// 1.20), and an extra file is added to the package that registers all
+// coverage counters with the testing package.
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/goenv"
+)
+
+// coverVar is a single instrumented source file in a package.
+type coverVar struct {
+	name string // variable that holds the counters, like GoCover_0
+	file string // file name as it appears in the coverage profile
+}
+
+// shouldCover returns whether this package needs to be instrumented for
+// coverage. Like 'go test -cover', only the package under test is
+// instrumented.
+func (p *Package) shouldCover() bool {
+	config := p.program.config
+	if config.TestConfig.CoverMode == "" {
+		return false
+	}
+	mainPkg := p.program.MainPkg()
+	return p != mainPkg && p.ImportPath == strings.TrimSuffix(mainPkg.ImportPath, ".test")
+}
+
+// parseCoverFile is like parseFile, but instruments the given file with
+// coverage counters stored in the given variable name first.
+func (p *Package) parseCoverFile(file, varName string) (*ast.File, error) {
+	mode := p.program.config.TestConfig.CoverMode
+	if mode == "atomic" {
+		// Goroutines never run in parallel in TinyGo, so there is no need for
+		// atomic operations (and sync/atomic may not be a dependency of this
+		// package). The profile still reports "atomic" as the mode.
+		mode = "count"
+	}
+	originalPath := p.program.getOriginalPath(file)
+	cmd := exec.Command(filepath.Join(goenv.Get("GOROOT"), "bin", "go"), "tool", "cover", "-mode="+mode, "-var="+varName, file)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to instrument %s for coverage: %s\n%s", originalPath, err, stderr.String())
+	}
+	sum := sha512.Sum512_224(data)
+	p.FileHashes[originalPath] = sum[:]
+	return parser.ParseFile(p.program.fset, originalPath, data, parser.ParseComments)
+}
+
+// parseCoverRegistration creates an extra file for this package that registers
+// the coverage counters of all instrumented files with the testing package at
+// init time. It uses //go:linkname as the package under test usually does not
+// import the testing package itself.
+func (p *Package) parseCoverRegistration(vars []coverVar) (*ast.File, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "package %s\n\n", p.Name)
+	buf.WriteString("import _ \"unsafe\"\n\n")
+	buf.WriteString("//go:linkname _tinygo_registerCover testing.registerCover\n")
+	buf.WriteString("func _tinygo_registerCover(mode, name string, counters, pos []uint32, numStmt []uint16)\n\n")
+	buf.WriteString("func init() {\n")
+	mode := strconv.Quote(p.program.config.TestConfig.CoverMode)
+	for _, v := range vars {
+		fmt.Fprintf(buf, "\t_tinygo_registerCover(%s, %s, %s.Count[:], %s.Pos[:], %s.NumStmt[:])\n", mode, strconv.Quote(v.file), v.name, v.name, v.name)
+	}
+	buf.WriteString("}\n")
+
+	file := filepath.Join(p.Dir, "_tinygo_cover.go")
+	sum := sha512.Sum512_224(buf.Bytes())
+	p.FileHashes[file] = sum[:]
+	return parser.ParseFile(p.program.fset, file, buf.Bytes(), parser.ParseComments)
+}
+
+// coverFileName returns the name of the file as it is shown in the coverage
+// profile: the import path followed by the file name.
+func (p *Package) coverFileName(file string) string {
+	return path.Join(p.ImportPath, filepath.Base(file))
+}
@@ -0,0 +1,69 @@
+package loader
+
+// This file embeds the seed corpus of fuzz tests (stored in testdata/fuzz) in
+// test binaries. The testing package cannot read it from the filesystem
+// itself, as reading directories is not supported on most systems and there is
+// no filesystem at all on microcontrollers.
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// parseFuzzCorpus creates an extra file for the main package of a test binary
+// that registers every file in testdata/fuzz/<FuzzTest> with the testing
+// package. It returns nil if there is no seed corpus.
+func (p *Package) parseFuzzCorpus() (*ast.File, error) {
+	corpusDir := filepath.Join(p.Dir, "testdata", "fuzz")
+	targets, err := ioutil.ReadDir(corpusDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("package main\n\n")
+	buf.WriteString("import _ \"unsafe\"\n\n")
+	buf.WriteString("//go:linkname _tinygo_registerFuzzCorpus testing.registerFuzzCorpus\n")
+	buf.WriteString("func _tinygo_registerFuzzCorpus(target, name, data string)\n\n")
+	buf.WriteString("func init() {\n")
+	found := false
+	for _, target := range targets {
+		if !target.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(corpusDir, target.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if !file.Mode().IsRegular() {
+				continue
+			}
+			path := filepath.Join(corpusDir, target.Name(), file.Name())
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			sum := sha512.Sum512_224(data)
+			p.FileHashes[path] = sum[:]
+			fmt.Fprintf(buf, "\t_tinygo_registerFuzzCorpus(%s, %s, %s)\n", strconv.Quote(target.Name()), strconv.Quote(file.Name()), strconv.Quote(string(data)))
+			found = true
+		}
+	}
+	buf.WriteString("}\n")
+	if !found {
+		return nil, nil
+	}
+
+	return parser.ParseFile(p.program.fset, filepath.Join(p.Dir, "_tinygo_fuzz_corpus.go"), buf.Bytes(), parser.ParseComments)
+}
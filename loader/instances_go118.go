@@ -0,0 +1,16 @@
+//go:build go1.18
+// +build go1.18
+
+package loader
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// initInstances allocates the map in which the type checker records all
+// instantiations of generic functions and types. The go/ssa package needs it
+// to instantiate (monomorphize) generic code.
+func initInstances(info *types.Info) {
+	info.Instances = make(map[*ast.Ident]types.Instance)
+}
@@ -0,0 +1,11 @@
+//go:build !go1.18
+// +build !go1.18
+
+package loader
+
+import "go/types"
+
+// initInstances does nothing: generics are not supported by the Go toolchain
+// TinyGo was built with.
+func initInstances(info *types.Info) {
+}
@@ -143,6 +143,7 @@ func Load(config *compileopts.Config, inputPkgs []string, clangHeaders string, t
 				Selections: make(map[*ast.SelectorExpr]*types.Selection),
 			},
 		}
+		initInstances(&pkg.info)
 		err := decoder.Decode(&pkg.PackageJSON)
 		if err != nil {
 			if err == io.EOF {
@@ -386,11 +387,25 @@ func (p *Package) parseFiles() ([]*ast.File, error) {
 	var fileErrs []error
 
 	// Parse all files (incuding CgoFiles).
-	parseFile := func(file string) {
+	shouldCover := p.shouldCover()
+	var coverVars []coverVar
+	parseFile := func(file string, cover bool) {
 		if !filepath.IsAbs(file) {
 			file = filepath.Join(p.Dir, file)
 		}
-		f, err := p.parseFile(file, parser.ParseComments)
+		var f *ast.File
+		var err error
+		if cover {
+			// Instrument this file for code coverage.
+			v := coverVar{
+				name: "GoCover_" + strconv.Itoa(len(coverVars)),
+				file: p.coverFileName(file),
+			}
+			f, err = p.parseCoverFile(file, v.name)
+			coverVars = append(coverVars, v)
+		} else {
+			f, err = p.parseFile(file, parser.ParseComments)
+		}
 		if err != nil {
 			fileErrs = append(fileErrs, err)
 			return
@@ -398,10 +413,29 @@ func (p *Package) parseFiles() ([]*ast.File, error) {
 		files = append(files, f)
 	}
 	for _, file := range p.GoFiles {
-		parseFile(file)
+		parseFile(file, shouldCover && !strings.HasSuffix(file, "_test.go"))
 	}
 	for _, file := range p.CgoFiles {
-		parseFile(file)
+		// Files using CGo are not instrumented, as the CGo processing below
+		// needs the original source.
+		parseFile(file, false)
+	}
+	if len(coverVars) != 0 && len(fileErrs) == 0 {
+		f, err := p.parseCoverRegistration(coverVars)
+		if err != nil {
+			fileErrs = append(fileErrs, err)
+		} else {
+			files = append(files, f)
+		}
+	}
+	if p.program.config.TestConfig.CompileTestBinary && p == p.program.MainPkg() {
+		// Embed the seed corpus of fuzz tests.
+		f, err := p.parseFuzzCorpus()
+		if err != nil {
+			fileErrs = append(fileErrs, err)
+		} else if f != nil {
+			files = append(files, f)
+		}
 	}
 
 	// Do CGo processing.
@@ -178,16 +178,35 @@ func Build(pkgName, outpath string, options *compileopts.Options) error {
 
 // Test runs the tests in the given package. Returns whether the test passed and
 // possibly an error if the test failed to run.
-func Test(pkgName string, stdout, stderr io.Writer, options *compileopts.Options, testCompileOnly, testVerbose, testShort bool, testRunRegexp string, testBenchRegexp string, testBenchTime string, outpath string) (bool, error) {
+func Test(pkgName string, stdout, stderr io.Writer, options *compileopts.Options, outpath string) (bool, error) {
 	options.TestConfig.CompileTestBinary = true
+	if options.TestConfig.Timeout != 0 && !options.TestConfig.CompileOnly && outpath == "" {
+		// Let the testing package report which tests are running, for the
+		// message printed when the timeout expires. This is not done for
+		// binaries written with -o, as they may be run by hand later and the
+		// reports would end up in their output. The options are copied as
+		// they may be shared with other packages being tested.
+		opts := *options
+		opts.GlobalValues = make(map[string]map[string]string)
+		for pkgPath, values := range options.GlobalValues {
+			opts.GlobalValues[pkgPath] = values
+		}
+		testingValues := map[string]string{"reportRunning": "1"}
+		for name, value := range options.GlobalValues["testing"] {
+			testingValues[name] = value
+		}
+		opts.GlobalValues["testing"] = testingValues
+		options = &opts
+	}
 	config, err := builder.NewConfig(options)
 	if err != nil {
 		return false, err
 	}
+	testConfig := &config.TestConfig
 
 	passed := false
 	err = builder.Build(pkgName, outpath, config, func(result builder.BuildResult) error {
-		if testCompileOnly || outpath != "" {
+		if testConfig.CompileOnly || outpath != "" {
 			// Write test binary to the specified file name.
 			if outpath == "" {
 				// No -o path was given, so create one now.
@@ -196,12 +215,33 @@ func Test(pkgName string, stdout, stderr io.Writer, options *compileopts.Options
 			}
 			copyFile(result.Binary, outpath)
 		}
-		if testCompileOnly {
+		if testConfig.CompileOnly {
 			// Do not run the test.
 			passed = true
 			return nil
 		}
 
+		importPath := strings.TrimSuffix(result.ImportPath, ".test")
+		testOut, testErr := stdout, stderr
+		if testConfig.JSON {
+			// Convert all output of the test (including the final ok/FAIL
+			// line) to JSON events, just like go test -json.
+			conv, err := startTest2JSON(importPath, stdout, stderr)
+			if err != nil {
+				return err
+			}
+			defer conv.Close()
+			testOut, testErr = conv, conv
+		}
+
+		binaryOut := testOut
+		var cover *coverFilter
+		if testConfig.CoverMode != "" {
+			// Take the coverage profile out of the test output.
+			cover = &coverFilter{w: testOut}
+			binaryOut = cover
+		}
+
 		// Run the test.
 		config.Options.Semaphore <- struct{}{}
 		defer func() {
@@ -209,29 +249,104 @@ func Test(pkgName string, stdout, stderr io.Writer, options *compileopts.Options
 		}()
 		start := time.Now()
 		var err error
-		passed, err = runPackageTest(config, stdout, stderr, result, testVerbose, testShort, testRunRegexp, testBenchRegexp, testBenchTime)
+		passed, err = runPackageTest(config, binaryOut, testErr, result)
 		if err != nil {
 			return err
 		}
 		duration := time.Since(start)
 
+		coverage := ""
+		if cover != nil {
+			err := cover.Flush()
+			if err != nil {
+				return err
+			}
+			if cover.coverage != "" {
+				coverage = "\t" + cover.coverage
+			}
+			if testConfig.CoverProfile != "" {
+				err := writeCoverProfile(testConfig.CoverProfile, cover.profile.Bytes())
+				if err != nil {
+					return err
+				}
+			}
+		}
+
 		// Print the result.
-		importPath := strings.TrimSuffix(result.ImportPath, ".test")
 		if passed {
-			fmt.Fprintf(stdout, "ok  \t%s\t%.3fs\n", importPath, duration.Seconds())
+			fmt.Fprintf(testOut, "ok  \t%s\t%.3fs%s\n", importPath, duration.Seconds(), coverage)
 		} else {
-			fmt.Fprintf(stdout, "FAIL\t%s\t%.3fs\n", importPath, duration.Seconds())
+			fmt.Fprintf(testOut, "FAIL\t%s\t%.3fs%s\n", importPath, duration.Seconds(), coverage)
+		}
+		if conv, ok := testOut.(*test2json); ok {
+			return conv.Close()
 		}
 		return nil
 	})
 	if err, ok := err.(loader.NoTestFilesError); ok {
-		fmt.Fprintf(stdout, "?   \t%s\t[no test files]\n", err.ImportPath)
+		out := stdout
+		if testConfig.JSON {
+			conv, err := startTest2JSON(err.ImportPath, stdout, stderr)
+			if err != nil {
+				return false, err
+			}
+			defer conv.Close()
+			out = conv
+		}
+		fmt.Fprintf(out, "?   \t%s\t[no test files]\n", err.ImportPath)
 		// Pretend the test passed - it at least didn't fail.
 		return true, nil
 	}
 	return passed, err
 }
 
+// test2json converts plain text test output to a stream of JSON events by
+// piping it through 'go tool test2json'. This is the same converter that is
+// used by 'go test -json', so the output is fully compatible.
+type test2json struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	closed bool
+}
+
+// startTest2JSON starts the test2json converter for the given package. All
+// output written to the returned converter is written as JSON to stdout once
+// converted.
+func startTest2JSON(importPath string, stdout, stderr io.Writer) (*test2json, error) {
+	cmd := exec.Command(filepath.Join(goenv.Get("GOROOT"), "bin", "go"), "tool", "test2json", "-t", "-p", importPath)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, &commandError{"failed to start test2json for", importPath, err}
+	}
+	return &test2json{cmd: cmd, stdin: stdin}, nil
+}
+
+// Write passes the test output on to test2json.
+func (c *test2json) Write(data []byte) (int, error) {
+	return c.stdin.Write(data)
+}
+
+// Close flushes all remaining output and waits until test2json has exited. It
+// is safe to call Close multiple times.
+func (c *test2json) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.stdin.Close()
+	err := c.cmd.Wait()
+	if err != nil {
+		return &commandError{"failed to run test2json for", c.cmd.Args[len(c.cmd.Args)-1], err}
+	}
+	return nil
+}
+
 func dirsToModuleRoot(maindir, modroot string) []string {
 	var dirs = []string{"."}
 	last := ".."
@@ -248,27 +363,51 @@ func dirsToModuleRoot(maindir, modroot string) []string {
 // runPackageTest runs a test binary that was previously built. The return
 // values are whether the test passed and any errors encountered while trying to
 // run the binary.
-func runPackageTest(config *compileopts.Config, stdout, stderr io.Writer, result builder.BuildResult, testVerbose, testShort bool, testRunRegexp string, testBenchRegexp string, testBenchTime string) (bool, error) {
+func runPackageTest(config *compileopts.Config, stdout, stderr io.Writer, result builder.BuildResult) (bool, error) {
+	// Determine the flags to pass to the test binary.
+	testConfig := &config.TestConfig
+	var flags []string
+	if testConfig.Verbose || testConfig.JSON {
+		// test2json needs the verbose output to know when each test starts
+		// and ends.
+		flags = append(flags, "-test.v")
+	}
+	if testConfig.Short {
+		flags = append(flags, "-test.short")
+	}
+	if testConfig.RunRegexp != "" {
+		flags = append(flags, "-test.run="+testConfig.RunRegexp)
+	}
+	if testConfig.BenchRegexp != "" {
+		flags = append(flags, "-test.bench="+testConfig.BenchRegexp)
+	}
+	if testConfig.BenchTime != "" {
+		flags = append(flags, "-test.benchtime="+testConfig.BenchTime)
+	}
+	if testConfig.FuzzRegexp != "" {
+		flags = append(flags, "-test.fuzz="+testConfig.FuzzRegexp)
+	}
+	if testConfig.FuzzTime != "" {
+		flags = append(flags, "-test.fuzztime="+testConfig.FuzzTime)
+	}
+	if testConfig.Count > 1 {
+		flags = append(flags, "-test.count="+strconv.Itoa(testConfig.Count))
+	}
+	if testConfig.FailFast {
+		flags = append(flags, "-test.failfast")
+	}
+	if testConfig.ListRegexp != "" {
+		flags = append(flags, "-test.list="+testConfig.ListRegexp)
+	}
+
 	var cmd *exec.Cmd
 	emulator := config.Emulator()
+	if testConfig.FuzzRegexp != "" && len(emulator) != 0 && emulator[0] != "wasmtime" {
+		// Flags can't be passed to the test binary in other emulators.
+		return false, errors.New("fuzzing is not supported on this target")
+	}
 	if len(emulator) == 0 {
 		// Run directly.
-		var flags []string
-		if testVerbose {
-			flags = append(flags, "-test.v")
-		}
-		if testShort {
-			flags = append(flags, "-test.short")
-		}
-		if testRunRegexp != "" {
-			flags = append(flags, "-test.run="+testRunRegexp)
-		}
-		if testBenchRegexp != "" {
-			flags = append(flags, "-test.bench="+testBenchRegexp)
-		}
-		if testBenchTime != "" {
-			flags = append(flags, "-test.benchtime="+testBenchTime)
-		}
 		cmd = executeCommand(config.Options, result.Binary, flags...)
 	} else {
 		// Run in an emulator.
@@ -287,28 +426,57 @@ func runPackageTest(config *compileopts.Config, stdout, stderr io.Writer, result
 			for _, d := range dirsToModuleRoot(result.MainDir, result.ModuleRoot) {
 				args = append(args, "--dir="+d)
 			}
+			if testConfig.FuzzRegexp != "" {
+				// Failing inputs are written to testdata/fuzz, relative to
+				// the package directory.
+				args = append(args, "--dir=.")
+			}
 
 			// mark end of wasmtime arguments and start of program ones: --
 			args = append(args, "--")
-			if testVerbose {
-				args = append(args, "-test.v")
-			}
-			if testShort {
-				args = append(args, "-test.short")
-			}
-			if testRunRegexp != "" {
-				args = append(args, "-test.run="+testRunRegexp)
-			}
-			if testBenchRegexp != "" {
-				args = append(args, "-test.bench="+testBenchRegexp)
-			}
+			args = append(args, flags...)
 		}
 		cmd = executeCommand(config.Options, emulator[0], args...)
 	}
 	cmd.Dir = result.MainDir
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
-	err := cmd.Run()
+	if testConfig.Timeout == 0 {
+		err := cmd.Run()
+		return checkTestResult(err, result)
+	}
+
+	// Run the test binary and kill it if it doesn't finish in time. The
+	// testing package reports which tests are running (unless the binary is
+	// also written with -o), so that they can be printed when the timeout
+	// expires.
+	running := newRunningTests(stdout)
+	cmd.Stdout = running
+	err := cmd.Start()
+	if err != nil {
+		return false, &commandError{"failed to run compiled binary", result.Binary, err}
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	timer := time.NewTimer(testConfig.Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if flushErr := running.Flush(); flushErr != nil {
+			return false, flushErr
+		}
+		return checkTestResult(err, result)
+	case killed := <-timer.C:
+		cmd.Process.Kill()
+		<-done
+		return false, running.timedOut(testConfig.Timeout, killed)
+	}
+}
+
+// checkTestResult checks the error returned after running a test binary.
+func checkTestResult(err error, result builder.BuildResult) (bool, error) {
 	if err != nil {
 		if _, ok := err.(*exec.ExitError); ok {
 			// Binary exited with a non-zero exit code, which means the test
@@ -1033,6 +1201,7 @@ func usage(command string) {
 		fmt.Fprintln(os.Stderr, "  clean:   empty cache directory ("+goenv.Get("GOCACHE")+")")
 		fmt.Fprintln(os.Stderr, "  targets: list targets")
 		fmt.Fprintln(os.Stderr, "  info:    show info for specified target")
+		fmt.Fprintln(os.Stderr, "  size-diff: compare the size of two builds")
 		fmt.Fprintln(os.Stderr, "  version: show version")
 		fmt.Fprintln(os.Stderr, "  help:    print this help text")
 
@@ -1183,7 +1352,7 @@ func main() {
 	command := os.Args[1]
 
 	opt := flag.String("opt", "z", "optimization level: 0, 1, 2, s, z")
-	gc := flag.String("gc", "", "garbage collector to use (none, leaking, conservative)")
+	gc := flag.String("gc", "", "garbage collector to use (none, leaking, conservative, precise)")
 	panicStrategy := flag.String("panic", "print", "panic strategy (print, trap)")
 	scheduler := flag.String("scheduler", "", "which scheduler to use (none, tasks, asyncify)")
 	serial := flag.String("serial", "", "which serial output to use (none, uart, usb)")
@@ -1192,12 +1361,15 @@ func main() {
 	verifyIR := flag.Bool("verifyir", false, "run extra verification steps on LLVM IR")
 	tags := flag.String("tags", "", "a space-separated list of extra build tags")
 	target := flag.String("target", "", "chip/board name or JSON target specification file")
-	printSize := flag.String("size", "", "print sizes (none, short, full)")
+	printSize := flag.String("size", "", "print sizes (none, short, full, json, csv, symbols, html)")
 	printStacks := flag.Bool("print-stacks", false, "print stack sizes of goroutines")
+	stackGraph := flag.String("stack-graph", "", "print the call graph of goroutines, with the worst case stack usage highlighted (dot, json)")
+	pcTableSize := flag.Int("pctable-size", 0, "reserve `n` bytes for a table used to print stack traces (0 to disable)")
 	printAllocsString := flag.String("print-allocs", "", "regular expression of functions for which heap allocations should be printed")
 	printCommands := flag.Bool("x", false, "Print commands")
 	parallelism := flag.Int("p", runtime.GOMAXPROCS(0), "the number of build jobs that can run in parallel")
 	nodebug := flag.Bool("no-debug", false, "strip debug information")
+	noReflectNames := flag.Bool("no-reflect-names", false, "strip the names of named types, as returned by reflect.Type.Name and String (only saves the size of the names)")
 	ocdCommandsString := flag.String("ocd-commands", "", "OpenOCD commands, overriding target spec (can specify multiple separated by commas)")
 	ocdOutput := flag.Bool("ocd-output", false, "print OCD daemon output during debug")
 	port := flag.String("port", "", "flash port (can specify multiple candidates separated by commas)")
@@ -1208,7 +1380,7 @@ func main() {
 	cpuprofile := flag.String("cpuprofile", "", "cpuprofile output")
 
 	var flagJSON, flagDeps, flagTest *bool
-	if command == "help" || command == "list" || command == "info" {
+	if command == "help" || command == "list" || command == "info" || command == "test" {
 		flagJSON = flag.Bool("json", false, "print data in JSON format")
 	}
 	if command == "help" || command == "list" {
@@ -1219,17 +1391,24 @@ func main() {
 	if command == "help" || command == "build" || command == "build-library" || command == "test" {
 		flag.StringVar(&outpath, "o", "", "output filename")
 	}
-	var testCompileOnlyFlag, testVerboseFlag, testShortFlag *bool
-	var testBenchRegexp *string
-	var testBenchTime *string
-	var testRunRegexp *string
+	var testConfig compileopts.TestConfig
+	var testCover *bool
 	if command == "help" || command == "test" {
-		testCompileOnlyFlag = flag.Bool("c", false, "compile the test binary but do not run it")
-		testVerboseFlag = flag.Bool("v", false, "verbose: print additional output")
-		testShortFlag = flag.Bool("short", false, "short: run smaller test suite to save time")
-		testRunRegexp = flag.String("run", "", "run: regexp of tests to run")
-		testBenchRegexp = flag.String("bench", "", "run: regexp of benchmarks to run")
-		testBenchTime = flag.String("benchtime", "", "run each benchmark for duration `d`")
+		flag.BoolVar(&testConfig.CompileOnly, "c", false, "compile the test binary but do not run it")
+		flag.BoolVar(&testConfig.Verbose, "v", false, "verbose: print additional output")
+		flag.BoolVar(&testConfig.Short, "short", false, "short: run smaller test suite to save time")
+		flag.StringVar(&testConfig.RunRegexp, "run", "", "run: regexp of tests to run")
+		flag.StringVar(&testConfig.BenchRegexp, "bench", "", "run: regexp of benchmarks to run")
+		flag.StringVar(&testConfig.BenchTime, "benchtime", "", "run each benchmark for duration `d`")
+		testCover = flag.Bool("cover", false, "enable coverage analysis")
+		flag.StringVar(&testConfig.CoverMode, "covermode", "", "coverage mode: set, count, atomic")
+		flag.StringVar(&testConfig.CoverProfile, "coverprofile", "", "write a coverage profile to `file`")
+		flag.StringVar(&testConfig.FuzzRegexp, "fuzz", "", "run the fuzz test matching `regexp`")
+		flag.StringVar(&testConfig.FuzzTime, "fuzztime", "", "time to spend fuzzing, or `N`x for a number of iterations")
+		flag.IntVar(&testConfig.Count, "count", 1, "run each test and benchmark `n` times")
+		flag.BoolVar(&testConfig.FailFast, "failfast", false, "do not start new tests after the first test failure")
+		flag.StringVar(&testConfig.ListRegexp, "list", "", "list tests, examples, and benchmarks matching `regexp` then exit")
+		flag.DurationVar(&testConfig.Timeout, "timeout", defaultTestTimeout, "kill the test binary after duration `d` (0 to disable)")
 	}
 
 	// Early command processing, before commands are interpreted by the Go flag
@@ -1265,6 +1444,11 @@ func main() {
 		ocdCommands = strings.Split(*ocdCommandsString, ",")
 	}
 
+	if testCover != nil && testConfig.CoverMode == "" && (*testCover || testConfig.CoverProfile != "") {
+		// -cover and -coverprofile imply -covermode=set, like go test.
+		testConfig.CoverMode = "set"
+	}
+
 	options := &compileopts.Options{
 		GOOS:            goenv.Get("GOOS"),
 		GOARCH:          goenv.Get("GOARCH"),
@@ -1282,6 +1466,9 @@ func main() {
 		Debug:           !*nodebug,
 		PrintSizes:      *printSize,
 		PrintStacks:     *printStacks,
+		StackGraph:      *stackGraph,
+		PCTableSize:     *pcTableSize,
+		NoReflectNames:  *noReflectNames,
 		PrintAllocs:     printAllocs,
 		Tags:            *tags,
 		GlobalValues:    globalVarValues,
@@ -1289,6 +1476,7 @@ func main() {
 		Programmer:      *programmer,
 		OpenOCDCommands: ocdCommands,
 		LLVMFeatures:    *llvmFeatures,
+		TestConfig:      testConfig,
 	}
 	if *printCommands {
 		options.PrintCommands = printCommand
@@ -1409,10 +1597,15 @@ func main() {
 		if len(pkgNames) == 0 {
 			pkgNames = []string{"."}
 		}
-		if *testCompileOnlyFlag && len(pkgNames) > 1 {
+		options.TestConfig.JSON = *flagJSON
+		if options.TestConfig.CompileOnly && len(pkgNames) > 1 {
 			fmt.Println("cannot use -c flag with multiple packages")
 			os.Exit(1)
 		}
+		if options.TestConfig.FuzzRegexp != "" && len(pkgNames) > 1 {
+			fmt.Println("cannot use -fuzz flag with multiple packages")
+			os.Exit(1)
+		}
 
 		fail := make(chan struct{}, 1)
 		var wg sync.WaitGroup
@@ -1453,7 +1646,7 @@ func main() {
 				defer close(buf.done)
 				stdout := (*testStdout)(buf)
 				stderr := (*testStderr)(buf)
-				passed, err := Test(pkgName, stdout, stderr, options, *testCompileOnlyFlag, *testVerboseFlag, *testShortFlag, *testRunRegexp, *testBenchRegexp, *testBenchTime, outpath)
+				passed, err := Test(pkgName, stdout, stderr, options, outpath)
 				if err != nil {
 					printCompilerError(func(args ...interface{}) {
 						fmt.Fprintln(stderr, args...)
@@ -1472,7 +1665,11 @@ func main() {
 		wg.Wait()
 		close(fail)
 		if _, fail := <-fail; fail {
-			fmt.Println("FAIL")
+			if !options.TestConfig.JSON {
+				// The JSON output already includes a fail event for each
+				// package that failed.
+				fmt.Println("FAIL")
+			}
 			os.Exit(1)
 		}
 	case "targets":
@@ -1495,7 +1692,14 @@ func main() {
 				os.Exit(1)
 				return
 			}
-			if spec.FlashMethod == "" && spec.FlashCommand == "" && spec.Emulator == nil {
+			isSimulator := false
+			for _, tag := range spec.BuildTags {
+				if tag == "sim" {
+					// Simulated boards run directly on the host system.
+					isSimulator = true
+				}
+			}
+			if spec.FlashMethod == "" && spec.FlashCommand == "" && spec.Emulator == nil && !isSimulator {
 				// This doesn't look like a regular target file, but rather like
 				// a parent target (such as targets/cortex-m.json).
 				continue
@@ -1591,6 +1795,17 @@ func main() {
 			fmt.Fprintln(os.Stderr, "failed to run `go list`:", err)
 			os.Exit(1)
 		}
+	case "size-diff":
+		if flag.NArg() != 2 {
+			fmt.Fprintln(os.Stderr, "size-diff needs two arguments: the old and the new binary (or -size=json output)")
+			usage(command)
+			os.Exit(1)
+		}
+		err := builder.SizeDiff(flag.Arg(0), flag.Arg(1), os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 	case "clean":
 		// remove cache directory
 		err := os.RemoveAll(goenv.Get("GOCACHE"))
@@ -7,6 +7,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -59,7 +60,8 @@ func TestBuild(t *testing.T) {
 		"stdlib.go",
 		"string.go",
 		"structs.go",
-		"testing.go",
+		"testing/",
+		"timers.go",
 		"zeroalloc.go",
 	}
 	_, minor, err := goenv.GetGorootVersion(goenv.Get("GOROOT"))
@@ -69,6 +71,9 @@ func TestBuild(t *testing.T) {
 	if minor >= 17 {
 		tests = append(tests, "go1.17.go")
 	}
+	if minor >= 18 {
+		tests = append(tests, "generics.go")
+	}
 
 	if *testTarget != "" {
 		// This makes it possible to run one specific test (instead of all),
@@ -114,6 +119,31 @@ func TestBuild(t *testing.T) {
 			}
 			runTestWithConfig("ldflags.go", t, opts, nil, nil)
 		})
+
+		// Test the precise GC, which uses the object layout of heap objects.
+		t.Run("gc=precise", func(t *testing.T) {
+			t.Parallel()
+			opts := optionsFromTarget("", sema)
+			opts.GC = "precise"
+			runTestWithConfig("gc.go", t, opts, nil, nil)
+		})
+
+		if runtime.GOOS == "linux" {
+			// Stack traces are only supported in ELF files.
+			t.Run("pctable", func(t *testing.T) {
+				t.Parallel()
+				opts := optionsFromTarget("", sema)
+				opts.PCTableSize = 16384
+				runTestWithConfig("stacktrace.go", t, opts, nil, nil)
+			})
+		}
+
+		// Test the simulated board, which runs on the host system.
+		t.Run("sim", func(t *testing.T) {
+			t.Parallel()
+			opts := optionsFromTarget("sim-arduino", sema)
+			runTestWithConfig("sim.go", t, opts, nil, nil)
+		})
 	})
 
 	if testing.Short() {
@@ -151,7 +181,7 @@ func TestBuild(t *testing.T) {
 			case "gc.go":
 				// Does not pass due to high mark false positive rate.
 
-			case "json.go", "stdlib.go", "testing.go":
+			case "json.go", "stdlib.go", "testing/":
 				// Breaks interp.
 
 			case "map.go":
@@ -244,6 +274,20 @@ func runPlatTests(options compileopts.Options, tests []string, t *testing.T) {
 			runTest("rand.go", options, t, nil, nil)
 		})
 	}
+	if strings.HasPrefix(spec.Triple, "wasm") {
+		// Deferred calls that can't recover() are not run on panic on
+		// WebAssembly, so only test recovering deferred calls there.
+		t.Run("recover-wasm.go", func(t *testing.T) {
+			t.Parallel()
+			runTest("recover-wasm.go", options, t, nil, nil)
+		})
+	} else if !strings.HasPrefix(spec.Triple, "avr") {
+		// recover() is not yet supported on AVR.
+		t.Run("recover.go", func(t *testing.T) {
+			t.Parallel()
+			runTest("recover.go", options, t, nil, nil)
+		})
+	}
 }
 
 func emuCheck(t *testing.T, options compileopts.Options) {
@@ -433,7 +477,7 @@ func runTestWithConfig(name string, t *testing.T, options compileopts.Options, c
 		actual = bytes.Replace(actual, []byte{0x1b, '[', '0', 'm'}, nil, -1)
 		actual = bytes.Replace(actual, []byte{'.', '.', '\n'}, []byte{'\n'}, -1)
 	}
-	if name == "testing.go" {
+	if name == "testing/" {
 		// Strip actual time.
 		re := regexp.MustCompile(`\([0-9]\.[0-9][0-9]s\)`)
 		actual = re.ReplaceAllLiteral(actual, []byte{'(', '0', '.', '0', '0', 's', ')'})
@@ -512,7 +556,7 @@ func TestTest(t *testing.T) {
 				defer out.Close()
 
 				opts := targ.opts
-				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/pass", out, out, &opts, false, false, false, "", "", "", "")
+				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/pass", out, out, &opts, "")
 				if err != nil {
 					t.Errorf("test error: %v", err)
 				}
@@ -533,7 +577,7 @@ func TestTest(t *testing.T) {
 				defer out.Close()
 
 				opts := targ.opts
-				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/fail", out, out, &opts, false, false, false, "", "", "", "")
+				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/fail", out, out, &opts, "")
 				if err != nil {
 					t.Errorf("test error: %v", err)
 				}
@@ -542,6 +586,60 @@ func TestTest(t *testing.T) {
 				}
 			})
 
+			t.Run("Cover", func(t *testing.T) {
+				t.Parallel()
+
+				// Test a package with coverage enabled. This is also tested
+				// on emulated targets, as the profile is sent over the
+				// standard output.
+
+				var wg sync.WaitGroup
+				defer wg.Wait()
+
+				out := ioLogger(t, &wg)
+				defer out.Close()
+
+				tmpdir, err := ioutil.TempDir("", "tinygo-cover")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer os.RemoveAll(tmpdir)
+
+				var output bytes.Buffer
+				opts := targ.opts
+				opts.TestConfig.CoverMode = "set"
+				opts.TestConfig.CoverProfile = filepath.Join(tmpdir, "cover.out")
+				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/cover", io.MultiWriter(&output, out), out, &opts, "")
+				if err != nil {
+					t.Fatalf("test error: %v", err)
+				}
+				if !passed {
+					t.Error("test failed")
+				}
+				if !strings.Contains(output.String(), "coverage: 66.7% of statements") {
+					t.Error("missing coverage percentage in output")
+				}
+				if strings.Contains(output.String(), "mode: set") {
+					t.Error("coverage profile was not removed from the output")
+				}
+				profile, err := ioutil.ReadFile(opts.TestConfig.CoverProfile)
+				if err != nil {
+					t.Fatal("could not read coverage profile:", err)
+				}
+				lines := strings.Split(strings.TrimSpace(string(profile)), "\n")
+				if lines[0] != "mode: set" {
+					t.Errorf("unexpected first line in coverage profile: %q", lines[0])
+				}
+				if len(lines) != 4 {
+					t.Errorf("expected 3 blocks in coverage profile, got:\n%s", profile)
+				}
+				for _, line := range lines[1:] {
+					if !strings.HasPrefix(line, "github.com/tinygo-org/tinygo/tests/testing/cover/cover.go:") {
+						t.Errorf("unexpected line in coverage profile: %q", line)
+					}
+				}
+			})
+
 			if targ.name != "Host" {
 				// Emulated tests are somewhat slow, and these do not need to be run across every platform.
 				return
@@ -560,7 +658,7 @@ func TestTest(t *testing.T) {
 
 				var output bytes.Buffer
 				opts := targ.opts
-				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/nothing", io.MultiWriter(&output, out), out, &opts, false, false, false, "", "", "", "")
+				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/nothing", io.MultiWriter(&output, out), out, &opts, "")
 				if err != nil {
 					t.Errorf("test error: %v", err)
 				}
@@ -572,6 +670,216 @@ func TestTest(t *testing.T) {
 				}
 			})
 
+			t.Run("JSON", func(t *testing.T) {
+				t.Parallel()
+
+				// Test a package with -json, which should print test2json
+				// events instead of plain text.
+
+				var wg sync.WaitGroup
+				defer wg.Wait()
+
+				out := ioLogger(t, &wg)
+				defer out.Close()
+
+				var output bytes.Buffer
+				opts := targ.opts
+				opts.TestConfig.JSON = true
+				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/pass", io.MultiWriter(&output, out), out, &opts, "")
+				if err != nil {
+					t.Fatalf("test error: %v", err)
+				}
+				if !passed {
+					t.Error("test failed")
+				}
+				var sawTestPass, sawPackagePass bool
+				for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+					var event struct {
+						Action  string
+						Package string
+						Test    string
+						Elapsed float64
+					}
+					if err := json.Unmarshal([]byte(line), &event); err != nil {
+						t.Fatalf("could not parse JSON event %q: %v", line, err)
+					}
+					if event.Package != "github.com/tinygo-org/tinygo/tests/testing/pass" {
+						t.Errorf("unexpected package in event: %q", line)
+					}
+					if event.Action == "pass" {
+						if event.Test == "TestPass" {
+							sawTestPass = true
+						} else if event.Test == "" {
+							sawPackagePass = true
+						}
+					}
+				}
+				if !sawTestPass {
+					t.Error("missing pass event for TestPass")
+				}
+				if !sawPackagePass {
+					t.Error("missing pass event for the package")
+				}
+			})
+
+			t.Run("Fuzz", func(t *testing.T) {
+				t.Parallel()
+
+				// Test a package with a fuzz test. The seed corpus (from
+				// f.Add and from testdata/fuzz) is run first, after which the
+				// fuzz target is run with a fixed number of mutated inputs.
+
+				_, minor, err := goenv.GetGorootVersion(goenv.Get("GOROOT"))
+				if err != nil {
+					t.Fatal("could not read Go version:", err)
+				}
+				if minor < 18 {
+					t.Skip("fuzz tests need Go 1.18")
+				}
+
+				var wg sync.WaitGroup
+				defer wg.Wait()
+
+				out := ioLogger(t, &wg)
+				defer out.Close()
+
+				var output bytes.Buffer
+				opts := targ.opts
+				opts.TestConfig.Verbose = true
+				opts.TestConfig.FuzzRegexp = "FuzzReverse"
+				opts.TestConfig.FuzzTime = "100x"
+				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/fuzz", io.MultiWriter(&output, out), out, &opts, "")
+				if err != nil {
+					t.Fatalf("test error: %v", err)
+				}
+				if !passed {
+					t.Error("test failed")
+				}
+				for _, name := range []string{"FuzzReverse/seed#0", "FuzzReverse/seed-tinygo"} {
+					if !strings.Contains(output.String(), "--- PASS: "+name) {
+						t.Errorf("seed corpus entry %s was not run", name)
+					}
+				}
+				if !strings.Contains(output.String(), "execs: 100 ") {
+					t.Error("fuzz target was not run 100 times")
+				}
+			})
+
+			t.Run("List", func(t *testing.T) {
+				t.Parallel()
+
+				// Test that -list prints the tests without running them.
+
+				var wg sync.WaitGroup
+				defer wg.Wait()
+
+				out := ioLogger(t, &wg)
+				defer out.Close()
+
+				var output bytes.Buffer
+				opts := targ.opts
+				opts.TestConfig.ListRegexp = "Pass"
+				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/pass", io.MultiWriter(&output, out), out, &opts, "")
+				if err != nil {
+					t.Fatalf("test error: %v", err)
+				}
+				if !passed {
+					t.Error("test failed")
+				}
+				lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+				if len(lines) != 2 || lines[0] != "TestPass" {
+					t.Errorf("unexpected output:\n%s", output.String())
+				}
+			})
+
+			t.Run("DefaultTimeout", func(t *testing.T) {
+				t.Parallel()
+
+				// Test a package with the default timeout of tinygo test,
+				// both when only running it and when also writing the test
+				// binary with -o.
+
+				var wg sync.WaitGroup
+				defer wg.Wait()
+
+				out := ioLogger(t, &wg)
+				defer out.Close()
+
+				var output bytes.Buffer
+				opts := targ.opts
+				opts.TestConfig.Timeout = defaultTestTimeout
+				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/pass", io.MultiWriter(&output, out), out, &opts, "")
+				if err != nil {
+					t.Fatalf("test error: %v", err)
+				}
+				if !passed {
+					t.Error("test failed")
+				}
+				if strings.Contains(output.String(), "--- tinygo ") {
+					t.Error("running test reports were not removed from the output")
+				}
+
+				tmpdir, err := ioutil.TempDir("", "tinygo-timeout")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer os.RemoveAll(tmpdir)
+
+				output.Reset()
+				binary := filepath.Join(tmpdir, "pass.test")
+				passed, err = Test("github.com/tinygo-org/tinygo/tests/testing/pass", io.MultiWriter(&output, out), out, &opts, binary)
+				if err != nil {
+					t.Fatalf("test error: %v", err)
+				}
+				if !passed {
+					t.Error("test failed")
+				}
+				if strings.Contains(output.String(), "--- tinygo ") {
+					t.Error("running test reports were not removed from the output")
+				}
+				if targ.name != "Host" {
+					// The test binary can only be run by hand on the host.
+					return
+				}
+				binaryOutput, err := exec.Command(binary, "-test.v").CombinedOutput()
+				if err != nil {
+					t.Fatalf("could not run test binary: %v\n%s", err, binaryOutput)
+				}
+				if strings.Contains(string(binaryOutput), "--- tinygo ") {
+					t.Errorf("test binary written with -o reports running tests:\n%s", binaryOutput)
+				}
+			})
+
+			t.Run("Timeout", func(t *testing.T) {
+				t.Parallel()
+
+				// Test a package with a test that never finishes, which
+				// should be killed after the timeout.
+
+				var wg sync.WaitGroup
+				defer wg.Wait()
+
+				out := ioLogger(t, &wg)
+				defer out.Close()
+
+				var output bytes.Buffer
+				opts := targ.opts
+				opts.TestConfig.Timeout = time.Second
+				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/timeout", io.MultiWriter(&output, out), out, &opts, "")
+				if err != nil {
+					t.Fatalf("test error: %v", err)
+				}
+				if passed {
+					t.Error("test passed")
+				}
+				if !strings.Contains(output.String(), "panic: test timed out after 1s\nrunning tests:\n\tTestHang (") {
+					t.Error("missing timeout message with the running test in output")
+				}
+				if strings.Contains(output.String(), "--- tinygo ") {
+					t.Error("running test reports were not removed from the output")
+				}
+			})
+
 			t.Run("BuildErr", func(t *testing.T) {
 				t.Parallel()
 
@@ -584,7 +892,7 @@ func TestTest(t *testing.T) {
 				defer out.Close()
 
 				opts := targ.opts
-				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/builderr", out, out, &opts, false, false, false, "", "", "", "")
+				passed, err := Test("github.com/tinygo-org/tinygo/tests/testing/builderr", out, out, &opts, "")
 				if err == nil {
 					t.Error("test did not error")
 				}
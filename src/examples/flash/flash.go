@@ -0,0 +1,46 @@
+package main
+
+// This example counts how often the board has been reset, by storing a counter
+// in the flash memory that is not used by the program.
+
+import (
+	"machine"
+	"time"
+)
+
+func main() {
+	time.Sleep(2 * time.Second) // wait for the serial port to be ready
+
+	println("flash data size:", machine.Flash.Size())
+	if machine.Flash.Size() == 0 {
+		println("no flash available to store data")
+		return
+	}
+
+	// Read the previous value of the counter. Erased flash reads as 0xff.
+	buf := make([]byte, 4)
+	_, err := machine.Flash.ReadAt(buf, 0)
+	if err != nil {
+		println("could not read flash:", err.Error())
+		return
+	}
+	count := uint32(buf[0]) | uint32(buf[1])<<8 | uint32(buf[2])<<16 | uint32(buf[3])<<24
+	if count == 0xffffffff {
+		count = 0
+	}
+	count++
+	println("number of resets:", count)
+
+	// Store the new value: flash must be erased before it can be written.
+	err = machine.Flash.EraseBlocks(0, 1)
+	if err != nil {
+		println("could not erase flash:", err.Error())
+		return
+	}
+	buf[0], buf[1], buf[2], buf[3] = byte(count), byte(count>>8), byte(count>>16), byte(count>>24)
+	_, err = machine.Flash.WriteAt(buf, 0)
+	if err != nil {
+		println("could not write flash:", err.Error())
+		return
+	}
+}
@@ -0,0 +1,37 @@
+// This example types a line of text on the host computer every time the
+// button is pressed, and moves the mouse pointer in a small square.
+package main
+
+import (
+	"machine"
+	"machine/usb/hid"
+	"time"
+)
+
+const button = machine.BUTTON
+
+var (
+	keyboard hid.Keyboard
+	mouse    hid.Mouse
+)
+
+func main() {
+	button.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+
+	for {
+		// The button is active low.
+		if !button.Get() {
+			keyboard.Write([]byte("Hello from TinyGo!\n"))
+			mouse.Move(50, 0)
+			mouse.Move(0, 50)
+			mouse.Move(-50, 0)
+			mouse.Move(0, -50)
+
+			// Wait until the button is released.
+			for !button.Get() {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,25 @@
+// This example exposes the flash memory that is not used by the program to the
+// host computer as a USB drive. The drive must be formatted (for example with
+// FAT) the first time it is used.
+package main
+
+import (
+	"machine"
+	"time"
+)
+
+func init() {
+	// The mass storage interface must be added before the host enumerates
+	// the USB device.
+	err := machine.EnableMSC(machine.Flash)
+	if err != nil {
+		println("could not enable USB mass storage:", err.Error())
+	}
+}
+
+func main() {
+	// All the work is done in the USB interrupt.
+	for {
+		time.Sleep(time.Hour)
+	}
+}
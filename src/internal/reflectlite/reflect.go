@@ -33,6 +33,7 @@ const (
 	Func          Kind = reflect.Func
 	Interface     Kind = reflect.Interface
 	Map           Kind = reflect.Map
+	Pointer       Kind = reflect.Pointer
 	Ptr           Kind = reflect.Ptr
 	Slice         Kind = reflect.Slice
 	String        Kind = reflect.String
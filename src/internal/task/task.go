@@ -15,6 +15,10 @@ type Task struct {
 	// Data is a field which can be used for storing state information.
 	Data uint64
 
+	// DeferFrame points to the topmost (stack allocated) defer frame of this
+	// goroutine. It is used to implement panic and recover.
+	DeferFrame unsafe.Pointer
+
 	// gcData holds data for the GC.
 	gcData gcData
 
@@ -26,3 +30,6 @@ type Task struct {
 // the given function and falls back to the default stack size. It is replaced
 // with a load from a special section just before codegen.
 func getGoroutineStackSize(fn uintptr) uintptr
+
+//go:linkname runtime_alloc runtime.alloc
+func runtime_alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer
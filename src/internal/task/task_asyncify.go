@@ -29,6 +29,19 @@ type state struct {
 	stackState
 
 	launched bool
+
+	// stack is the allocation that holds both stacks. The asyncify and C stack
+	// pointers are integers, so this pointer keeps the stack alive with the
+	// precise GC.
+	stack unsafe.Pointer
+
+	// saveCheckpoint and jumpTo are set when the task unwinds its stack to make
+	// a checkpoint or to jump to one (see MakeCheckpoint and Jump).
+	saveCheckpoint *unsafe.Pointer
+	jumpTo         *checkpoint
+
+	// jumped is set when the task is rewound to a checkpoint by Jump.
+	jumped bool
 }
 
 // stackState is the saved state of a stack while unwound.
@@ -63,13 +76,15 @@ func (s *state) initialize(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
 	s.entry = fn
 	s.args = args
 
-	// Create a stack.
-	stack := make([]uintptr, stackSize/unsafe.Sizeof(uintptr(0)))
+	// Create a stack. It is allocated without an object layout so that it is
+	// always scanned conservatively, even by the precise GC.
+	stack := runtime_alloc(stackSize, nil)
 
 	// Calculate stack base addresses.
-	s.asyncifysp = uintptr(unsafe.Pointer(&stack[0]))
-	s.csp = uintptr(unsafe.Pointer(&stack[0])) + uintptr(len(stack))*unsafe.Sizeof(uintptr(0))
-	stack[0] = stackCanary
+	s.stack = stack
+	s.asyncifysp = uintptr(stack)
+	s.csp = uintptr(stack) + stackSize
+	*(*uintptr)(stack) = stackCanary
 }
 
 //go:linkname runqueuePushBack runtime.runqueuePushBack
@@ -104,19 +119,38 @@ func (*stackState) unwind()
 func (t *Task) Resume() {
 	// The current task must be saved and restored because this can nest on WASM with JS.
 	prevTask := currentTask
-	t.gcData.swap()
 	currentTask = t
-	if !t.state.launched {
-		t.state.launch()
-		t.state.launched = true
-	} else {
-		t.state.rewind()
+	for {
+		t.gcData.swap()
+		if !t.state.launched {
+			t.state.launch()
+			t.state.launched = true
+		} else {
+			t.state.rewind()
+		}
+		t.gcData.swap()
+		if t.state.asyncifysp > t.state.csp {
+			runtimePanic("stack overflow")
+		}
+		if t.state.saveCheckpoint != nil {
+			// The task unwound its stack to make a checkpoint. Save a copy of
+			// the unwound stack and continue running the task.
+			*t.state.saveCheckpoint = unsafe.Pointer(t.state.save(t.gcData))
+			t.state.saveCheckpoint = nil
+		} else if t.state.jumpTo != nil {
+			// The task unwound its stack to jump to a checkpoint. Replace the
+			// unwound stack with the one saved in the checkpoint, so that the
+			// task continues in MakeCheckpoint.
+			t.state.restore(t.state.jumpTo)
+			t.gcData = t.state.jumpTo.gcData
+			t.state.jumpTo = nil
+			t.state.jumped = true
+		} else {
+			// The task paused or completed.
+			break
+		}
 	}
 	currentTask = prevTask
-	t.gcData.swap()
-	if t.state.asyncifysp > t.state.csp {
-		runtimePanic("stack overflow")
-	}
 }
 
 //export tinygo_rewind
@@ -127,3 +161,77 @@ func OnSystemStack() bool {
 	// If there is not an active goroutine, then this must be running on the system stack.
 	return Current() == nil
 }
+
+// A checkpoint is a copy of the unwound stack of a task, which can be used to
+// continue at the point where it was made (much like setjmp and longjmp).
+type checkpoint struct {
+	// data is a copy of the asyncify stack, which holds the locals of all
+	// functions on the goroutine stack.
+	data unsafe.Pointer
+	size uintptr
+
+	// csp is the C stack pointer at the checkpoint.
+	csp uintptr
+
+	// gcData is the GC state at the checkpoint. The stack chain must be reset,
+	// as it points into the stack frames that are dropped by a jump.
+	gcData gcData
+}
+
+// MakeCheckpoint saves the state of the current goroutine in a new checkpoint
+// and stores a pointer to it in dst. It returns false after saving the
+// checkpoint, and true when execution continues here after a call to Jump.
+// It does nothing (and returns false) when not running in a goroutine.
+//
+// Making a checkpoint unwinds and copies the entire goroutine stack, so it is
+// relatively expensive. On a jump, the stack chain of the GC is reset to its
+// state at the checkpoint, but the stack objects of the callers of this
+// function may have been overwritten in the meantime: these callers must return
+// without allocating heap memory after this function returns.
+func MakeCheckpoint(dst *unsafe.Pointer) bool {
+	t := currentTask
+	if t == nil {
+		return false
+	}
+	t.state.saveCheckpoint = dst
+	t.state.unwind()
+
+	// The task was rewound, either right after saving the checkpoint or by a
+	// jump.
+	*(*uintptr)(unsafe.Pointer(t.state.asyncifysp)) = stackCanary
+	jumped := t.state.jumped
+	t.state.jumped = false
+	return jumped
+}
+
+// Jump continues the current goroutine at the given checkpoint, where
+// MakeCheckpoint returns true. The function that made the checkpoint must still
+// be running. Jump does not return.
+func Jump(cp unsafe.Pointer) {
+	currentTask.state.jumpTo = (*checkpoint)(cp)
+	currentTask.state.unwind()
+}
+
+// save copies the unwound stack into a new checkpoint.
+func (s *state) save(gcData gcData) *checkpoint {
+	size := s.asyncifysp - uintptr(s.stack)
+	data := runtime_alloc(size, nil)
+	memcpy(data, s.stack, size)
+	return &checkpoint{
+		data:   data,
+		size:   size,
+		csp:    s.csp,
+		gcData: gcData,
+	}
+}
+
+// restore replaces the unwound stack with the one saved in the checkpoint.
+func (s *state) restore(cp *checkpoint) {
+	memcpy(s.stack, cp.data, cp.size)
+	s.asyncifysp = uintptr(s.stack) + cp.size
+	s.csp = cp.csp
+}
+
+// Calls to this function are converted to LLVM intrinsic calls such as
+// llvm.memcpy.p0i8.p0i8.i32().
+func memcpy(dst, src unsafe.Pointer, size uintptr)
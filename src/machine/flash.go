@@ -0,0 +1,148 @@
+//go:build nrf52 || nrf52840 || nrf52833 || (sam && atsamd21) || (sam && atsamd51) || (sam && atsame5x) || rp2040 || stm32f4 || stm32l4
+// +build nrf52 nrf52840 nrf52833 sam,atsamd21 sam,atsamd51 sam,atsame5x rp2040 stm32f4 stm32l4
+
+package machine
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// The flash area that can be used to store data, as defined in the linker
+// script. It starts right after the program (including the initial values of
+// global variables) and ends at the end of the flash area of the program.
+//go:extern __flash_data_start
+var flashDataStartSymbol [0]byte
+
+//go:extern __flash_data_end
+var flashDataEndSymbol [0]byte
+
+var (
+	errFlashOutOfRange = errors.New("flash: address out of range")
+	errFlashUnaligned  = errors.New("flash: address is not aligned to a block")
+	errFlashWrite      = errors.New("flash: write failed")
+	errFlashErase      = errors.New("flash: erase failed")
+)
+
+// BlockDevice is a storage device that is read and written in blocks, such as
+// on-chip or external flash. Before data can be written, the blocks it is
+// written to must be erased.
+type BlockDevice interface {
+	// ReadAt reads len(p) bytes at offset off from the start of the device,
+	// like io.ReaderAt.
+	ReadAt(p []byte, off int64) (n int, err error)
+
+	// WriteAt writes p at offset off, like io.WriterAt. The offset must be a
+	// multiple of WriteBlockSize and the area written to must have been erased
+	// first.
+	WriteAt(p []byte, off int64) (n int, err error)
+
+	// Size returns the number of bytes in this block device.
+	Size() int64
+
+	// WriteBlockSize returns the smallest unit that can be written. Writes
+	// must start at a multiple of this size, the last block is padded with
+	// 0xff bytes.
+	WriteBlockSize() int64
+
+	// EraseBlockSize returns the smallest unit that can be erased.
+	EraseBlockSize() int64
+
+	// EraseBlocks erases count blocks, starting at block number start. After
+	// erasing, all bytes in these blocks read as 0xff.
+	EraseBlocks(start, count int64) error
+}
+
+// Flash is the on-chip flash memory that is not used by the program. It can be
+// used to store data that should survive a reset, such as settings. Offset 0 is
+// the start of the first erase block after the program, so the program itself
+// can't be overwritten: note that reflashing the program may still erase the
+// stored data.
+var Flash flashBlockDevice
+
+var _ BlockDevice = Flash
+
+type flashBlockDevice struct{}
+
+// start returns the address of the first byte of the flash data area, which is
+// aligned to an erase block.
+func (f flashBlockDevice) start() uintptr {
+	blockSize := uintptr(flashEraseBlockSize)
+	start := uintptr(unsafe.Pointer(&flashDataStartSymbol))
+	return (start + blockSize - 1) &^ (blockSize - 1)
+}
+
+// Size returns the number of bytes of flash that can be used to store data.
+func (f flashBlockDevice) Size() int64 {
+	start := f.start()
+	end := uintptr(unsafe.Pointer(&flashDataEndSymbol))
+	if start >= end {
+		// The program uses all the available flash.
+		return 0
+	}
+	return int64(end - start)
+}
+
+// WriteBlockSize returns the smallest unit that can be written to flash.
+func (f flashBlockDevice) WriteBlockSize() int64 {
+	return flashWriteBlockSize
+}
+
+// EraseBlockSize returns the smallest unit that can be erased.
+func (f flashBlockDevice) EraseBlockSize() int64 {
+	return flashEraseBlockSize
+}
+
+// ReadAt reads len(p) bytes from flash, starting at offset off. Flash is
+// memory mapped, so this is just a copy.
+func (f flashBlockDevice) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 || off+int64(len(p)) > f.Size() {
+		return 0, errFlashOutOfRange
+	}
+	data := (*[1 << 30]byte)(unsafe.Pointer(f.start() + uintptr(off)))[:len(p):len(p)]
+	return copy(p, data), nil
+}
+
+// WriteAt writes p to flash, starting at offset off. The offset must be a
+// multiple of WriteBlockSize. If p isn't a multiple of WriteBlockSize, the last
+// block is padded with 0xff bytes (which leaves the flash unchanged). The flash
+// must have been erased before it can be written.
+func (f flashBlockDevice) WriteAt(p []byte, off int64) (n int, err error) {
+	if off < 0 || off+int64(len(p)) > f.Size() {
+		return 0, errFlashOutOfRange
+	}
+	if off%flashWriteBlockSize != 0 {
+		return 0, errFlashUnaligned
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	data := p
+	if padding := len(p) % flashWriteBlockSize; padding != 0 {
+		data = make([]byte, len(p)+flashWriteBlockSize-padding)
+		copy(data, p)
+		for i := len(p); i < len(data); i++ {
+			data[i] = 0xff
+		}
+	}
+	err = flashWrite(f.start()+uintptr(off), data)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// EraseBlocks erases count blocks, starting at block number start. The size of
+// a block is returned by EraseBlockSize.
+func (f flashBlockDevice) EraseBlocks(start, count int64) error {
+	if start < 0 || count < 0 || (start+count)*flashEraseBlockSize > f.Size() {
+		return errFlashOutOfRange
+	}
+	for block := start; block < start+count; block++ {
+		err := flashEraseBlock(f.start() + uintptr(block*flashEraseBlockSize))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1872,12 +1872,13 @@ var (
 	udd_ep_in_cache_buffer  [7][128]uint8
 	udd_ep_out_cache_buffer [7][128]uint8
 
+	// The control endpoint has a separate buffer, as descriptors (in
+	// particular HID report descriptors) may not fit in the regular buffers.
+	// The hardware splits the data in packets.
+	udd_ep_control_cache_buffer [usbControlBufferSize]uint8
+
 	isEndpointHalt        = false
 	isRemoteWakeUpEnabled = false
-	endPoints             = []uint32{usb_ENDPOINT_TYPE_CONTROL,
-		(usb_ENDPOINT_TYPE_INTERRUPT | usbEndpointIn),
-		(usb_ENDPOINT_TYPE_BULK | usbEndpointOut),
-		(usb_ENDPOINT_TYPE_BULK | usbEndpointIn)}
 
 	usbConfiguration uint8
 	usbSetInterface  uint8
@@ -2010,9 +2011,7 @@ func handleUSB(intr interrupt.Interrupt) {
 			ok = handleStandardSetup(setup)
 		} else {
 			// Class Interface Requests
-			if setup.wIndex == usb_CDC_ACM_INTERFACE {
-				ok = cdcSetup(setup)
-			}
+			ok = usbClassSetup(setup)
 		}
 
 		if ok {
@@ -2034,23 +2033,18 @@ func handleUSB(intr interrupt.Interrupt) {
 
 	// Now the actual transfer handlers, ignore endpoint number 0 (setup)
 	var i uint32
-	for i = 1; i < uint32(len(endPoints)); i++ {
+	for i = 1; i < usbEndpointCount; i++ {
 		// Check if endpoint has a pending interrupt
 		epFlags := getEPINTFLAG(i)
-		if (epFlags&sam.USB_DEVICE_EPINTFLAG_TRCPT0) > 0 ||
-			(epFlags&sam.USB_DEVICE_EPINTFLAG_TRCPT1) > 0 {
-			switch i {
-			case usb_CDC_ENDPOINT_OUT:
+		if usbEndpointTypes[i]&usbEndpointIn == 0 {
+			if (epFlags & sam.USB_DEVICE_EPINTFLAG_TRCPT0) > 0 {
 				handleEndpoint(i)
 				setEPINTFLAG(i, epFlags)
-			case usb_CDC_ENDPOINT_IN, usb_CDC_ENDPOINT_ACM:
-				setEPSTATUSCLR(i, sam.USB_DEVICE_EPSTATUSCLR_BK1RDY)
-				setEPINTFLAG(i, sam.USB_DEVICE_EPINTFLAG_TRCPT1)
-
-				if i == usb_CDC_ENDPOINT_IN {
-					USB.waitTxc = false
-				}
 			}
+		} else if (epFlags & sam.USB_DEVICE_EPINTFLAG_TRCPT1) > 0 {
+			setEPSTATUSCLR(i, sam.USB_DEVICE_EPSTATUSCLR_BK1RDY)
+			setEPINTFLAG(i, sam.USB_DEVICE_EPINTFLAG_TRCPT1)
+			usbClassTx(i)
 		}
 	}
 }
@@ -2208,18 +2202,20 @@ func handleStandardSetup(setup usbSetup) bool {
 
 	case usb_SET_CONFIGURATION:
 		if setup.bmRequestType&usb_REQUEST_RECIPIENT == usb_REQUEST_DEVICE {
-			for i := 1; i < len(endPoints); i++ {
-				initEndpoint(uint32(i), endPoints[i])
+			for i := uint32(1); i < usbEndpointCount; i++ {
+				initEndpoint(i, usbEndpointTypes[i])
+
+				// Enable interrupts for data from the host and for data picked
+				// up by the host.
+				if usbEndpointTypes[i]&usbEndpointIn == 0 {
+					setEPINTENSET(i, sam.USB_DEVICE_EPINTENSET_TRCPT0)
+				} else {
+					setEPINTENSET(i, sam.USB_DEVICE_EPINTENSET_TRCPT1)
+				}
 			}
 
 			usbConfiguration = setup.wValueL
 
-			// Enable interrupt for CDC control messages from host (OUT packet)
-			setEPINTENSET(usb_CDC_ENDPOINT_ACM, sam.USB_DEVICE_EPINTENSET_TRCPT1)
-
-			// Enable interrupt for CDC data messages from host
-			setEPINTENSET(usb_CDC_ENDPOINT_OUT, sam.USB_DEVICE_EPINTENSET_TRCPT0)
-
 			sendZlp()
 			return true
 		} else {
@@ -2299,10 +2295,14 @@ func cdcSetup(setup usbSetup) bool {
 
 //go:noinline
 func sendUSBPacket(ep uint32, data []byte) {
-	copy(udd_ep_in_cache_buffer[ep][:], data)
+	buf := udd_ep_in_cache_buffer[ep][:]
+	if ep == 0 {
+		buf = udd_ep_control_cache_buffer[:]
+	}
+	copy(buf, data)
 
 	// Set endpoint address for sending data
-	usbEndpointDescriptors[ep].DeviceDescBank[1].ADDR.Set(uint32(uintptr(unsafe.Pointer(&udd_ep_in_cache_buffer[ep]))))
+	usbEndpointDescriptors[ep].DeviceDescBank[1].ADDR.Set(uint32(uintptr(unsafe.Pointer(&buf[0]))))
 
 	// clear multi-packet size which is total bytes already sent
 	usbEndpointDescriptors[ep].DeviceDescBank[1].PCKSIZE.ClearBits(usb_DEVICE_PCKSIZE_MULTI_PACKET_SIZE_Mask << usb_DEVICE_PCKSIZE_MULTI_PACKET_SIZE_Pos)
@@ -2312,6 +2312,28 @@ func sendUSBPacket(ep uint32, data []byte) {
 	usbEndpointDescriptors[ep].DeviceDescBank[1].PCKSIZE.SetBits(uint32((len(data) & usb_DEVICE_PCKSIZE_BYTE_COUNT_Mask) << usb_DEVICE_PCKSIZE_BYTE_COUNT_Pos))
 }
 
+// sendUSBInPacket sends a packet on an interrupt or bulk IN endpoint, after the
+// host has picked up the previous packet on that endpoint. It returns false if
+// that didn't happen in time.
+func sendUSBInPacket(ep uint32, data []byte) bool {
+	timeout := 300000
+	for (getEPSTATUS(ep) & sam.USB_DEVICE_EPSTATUS_BK1RDY) != 0 {
+		timeout--
+		if timeout == 0 {
+			return false
+		}
+	}
+
+	sendUSBPacket(ep, data)
+
+	// clear transfer complete flag
+	setEPINTFLAG(ep, sam.USB_DEVICE_EPINTFLAG_TRCPT1)
+
+	// set Bank1 ready
+	setEPSTATUSSET(ep, sam.USB_DEVICE_EPSTATUSSET_BK1RDY)
+	return true
+}
+
 func receiveUSBControlPacket() ([cdcLineInfoSize]byte, error) {
 	var b [cdcLineInfoSize]byte
 
@@ -2360,10 +2382,8 @@ func handleEndpoint(ep uint32) {
 	count := int((usbEndpointDescriptors[ep].DeviceDescBank[0].PCKSIZE.Get() >>
 		usb_DEVICE_PCKSIZE_BYTE_COUNT_Pos) & usb_DEVICE_PCKSIZE_BYTE_COUNT_Mask)
 
-	// move to ring buffer
-	for i := 0; i < count; i++ {
-		USB.Receive(byte((udd_ep_out_cache_buffer[ep][i] & 0xFF)))
-	}
+	// pass to the class of the endpoint
+	usbClassRx(ep, udd_ep_out_cache_buffer[ep][:count])
 
 	// set byte count to zero
 	usbEndpointDescriptors[ep].DeviceDescBank[0].PCKSIZE.ClearBits(usb_DEVICE_PCKSIZE_BYTE_COUNT_Mask << usb_DEVICE_PCKSIZE_BYTE_COUNT_Pos)
@@ -2375,6 +2395,13 @@ func handleEndpoint(ep uint32) {
 	setEPSTATUSCLR(ep, sam.USB_DEVICE_EPSTATUSCLR_BK0RDY)
 }
 
+// cdcTx is called when the host has picked up data sent by the CDC serial port.
+func cdcTx(ep uint32) {
+	if ep == usb_CDC_ENDPOINT_IN {
+		USB.waitTxc = false
+	}
+}
+
 func sendZlp() {
 	usbEndpointDescriptors[0].DeviceDescBank[1].PCKSIZE.ClearBits(usb_DEVICE_PCKSIZE_BYTE_COUNT_Mask << usb_DEVICE_PCKSIZE_BYTE_COUNT_Pos)
 }
@@ -0,0 +1,68 @@
+//go:build sam && atsamd21
+// +build sam,atsamd21
+
+package machine
+
+import (
+	"device/sam"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// Flash on the atsamd21 is written through a page buffer of 64 bytes and
+// erased in rows of 4 pages. Data is written to the page buffer in 32-bit
+// words: bytes that are not written keep their old value.
+const (
+	flashWriteBlockSize = 4
+	flashEraseBlockSize = 256
+	flashPageSize       = 64
+)
+
+func flashWrite(address uintptr, data []byte) error {
+	for len(data) != 0 {
+		// Write at most one page (up to the next page boundary) at a time.
+		n := flashPageSize - int(address%flashPageSize)
+		if n > len(data) {
+			n = len(data)
+		}
+		if !flashCommand(address, sam.NVMCTRL_CTRLA_CMD_PBC) {
+			return errFlashWrite
+		}
+		for i := 0; i < n; i += 4 {
+			word := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+			(*volatile.Register32)(unsafe.Pointer(address + uintptr(i))).Set(word)
+		}
+		// Automatic page writes are disabled in the runtime (the MANW bit),
+		// so write the page buffer explicitly.
+		if !flashCommand(address, sam.NVMCTRL_CTRLA_CMD_WP) {
+			return errFlashWrite
+		}
+		address += uintptr(n)
+		data = data[n:]
+	}
+	return nil
+}
+
+func flashEraseBlock(address uintptr) error {
+	if !flashCommand(address, sam.NVMCTRL_CTRLA_CMD_ER) {
+		return errFlashErase
+	}
+	return nil
+}
+
+// flashCommand runs a single NVMCTRL command on the given address, waits until
+// it is finished and returns whether it succeeded.
+func flashCommand(address uintptr, cmd uint16) bool {
+	for !sam.NVMCTRL.INTFLAG.HasBits(sam.NVMCTRL_INTFLAG_READY) {
+	}
+	// Clear the error flags of a previous command.
+	sam.NVMCTRL.STATUS.Set(sam.NVMCTRL_STATUS_PROGE | sam.NVMCTRL_STATUS_LOCKE | sam.NVMCTRL_STATUS_NVME)
+	sam.NVMCTRL.INTFLAG.Set(sam.NVMCTRL_INTFLAG_ERROR)
+
+	// The ADDR register uses 16-bit addresses.
+	sam.NVMCTRL.ADDR.Set(uint32(address / 2))
+	sam.NVMCTRL.CTRLA.Set(sam.NVMCTRL_CTRLA_CMDEX_KEY<<sam.NVMCTRL_CTRLA_CMDEX_Pos | cmd)
+	for !sam.NVMCTRL.INTFLAG.HasBits(sam.NVMCTRL_INTFLAG_READY) {
+	}
+	return !sam.NVMCTRL.INTFLAG.HasBits(sam.NVMCTRL_INTFLAG_ERROR)
+}
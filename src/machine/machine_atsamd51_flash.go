@@ -0,0 +1,58 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+// +build sam,atsamd51 sam,atsame5x
+
+package machine
+
+import (
+	"device/sam"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// Flash on the atsamd51 is protected by ECC, which means that every 128-bit
+// quad word can only be written once after it is erased. Erasing is done in
+// blocks of 8kB.
+const (
+	flashWriteBlockSize = 16
+	flashEraseBlockSize = 8192
+)
+
+func flashWrite(address uintptr, data []byte) error {
+	for i := 0; i < len(data); i += flashWriteBlockSize {
+		if !flashCommand(address, sam.NVMCTRL_CTRLB_CMD_PBC) {
+			return errFlashWrite
+		}
+		// Fill the page buffer with a single quad word and write it.
+		for j := i; j < i+flashWriteBlockSize; j += 4 {
+			word := uint32(data[j]) | uint32(data[j+1])<<8 | uint32(data[j+2])<<16 | uint32(data[j+3])<<24
+			(*volatile.Register32)(unsafe.Pointer(address + uintptr(j-i))).Set(word)
+		}
+		if !flashCommand(address, sam.NVMCTRL_CTRLB_CMD_WQW) {
+			return errFlashWrite
+		}
+		address += flashWriteBlockSize
+	}
+	return nil
+}
+
+func flashEraseBlock(address uintptr) error {
+	if !flashCommand(address, sam.NVMCTRL_CTRLB_CMD_EB) {
+		return errFlashErase
+	}
+	return nil
+}
+
+// flashCommand runs a single NVMCTRL command on the given address, waits until
+// it is finished and returns whether it succeeded.
+func flashCommand(address uintptr, cmd uint16) bool {
+	for !sam.NVMCTRL.STATUS.HasBits(sam.NVMCTRL_STATUS_READY) {
+	}
+	// Clear the flags of a previous command.
+	sam.NVMCTRL.INTFLAG.Set(sam.NVMCTRL_INTFLAG_DONE | sam.NVMCTRL_INTFLAG_ADDRE | sam.NVMCTRL_INTFLAG_PROGE | sam.NVMCTRL_INTFLAG_LOCKE | sam.NVMCTRL_INTFLAG_NVME)
+
+	sam.NVMCTRL.ADDR.Set(uint32(address))
+	sam.NVMCTRL.CTRLB.Set(sam.NVMCTRL_CTRLB_CMDEX_KEY<<sam.NVMCTRL_CTRLB_CMDEX_Pos | cmd)
+	for !sam.NVMCTRL.INTFLAG.HasBits(sam.NVMCTRL_INTFLAG_DONE) {
+	}
+	return !sam.NVMCTRL.INTFLAG.HasBits(sam.NVMCTRL_INTFLAG_ADDRE | sam.NVMCTRL_INTFLAG_PROGE | sam.NVMCTRL_INTFLAG_LOCKE | sam.NVMCTRL_INTFLAG_NVME)
+}
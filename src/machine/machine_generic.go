@@ -2,7 +2,9 @@
 
 package machine
 
-// Dummy machine package that calls out to external functions.
+// Dummy machine package that calls out to external functions (see
+// machine_generic_extern.go), or to a simulated board (see
+// machine_generic_sim.go).
 
 const deviceName = "generic"
 
@@ -36,15 +38,6 @@ func (p Pin) Get() bool {
 	return gpioGet(p)
 }
 
-//export __tinygo_gpio_configure
-func gpioConfigure(pin Pin, config PinConfig)
-
-//export __tinygo_gpio_set
-func gpioSet(pin Pin, value bool)
-
-//export __tinygo_gpio_get
-func gpioGet(pin Pin) bool
-
 type SPI struct {
 	Bus uint8
 }
@@ -66,12 +59,6 @@ func (spi SPI) Transfer(w byte) (byte, error) {
 	return spiTransfer(spi.Bus, w), nil
 }
 
-//export __tinygo_spi_configure
-func spiConfigure(bus uint8, sck Pin, SDO Pin, SDI Pin)
-
-//export __tinygo_spi_transfer
-func spiTransfer(bus uint8, w uint8) uint8
-
 // InitADC enables support for ADC peripherals.
 func InitADC() {
 	// Nothing to do here.
@@ -86,9 +73,6 @@ func (adc ADC) Get() uint16 {
 	return adcRead(adc.Pin)
 }
 
-//export __tinygo_adc_read
-func adcRead(pin Pin) uint16
-
 // I2C is a generic implementation of the Inter-IC communication protocol.
 type I2C struct {
 	Bus uint8
@@ -107,19 +91,6 @@ func (i2c *I2C) Configure(config I2CConfig) error {
 	return nil
 }
 
-// Tx does a single I2C transaction at the specified address.
-func (i2c *I2C) Tx(addr uint16, w, r []byte) error {
-	i2cTransfer(i2c.Bus, &w[0], len(w), &r[0], len(r))
-	// TODO: do something with the returned error code.
-	return nil
-}
-
-//export __tinygo_i2c_configure
-func i2cConfigure(bus uint8, scl Pin, sda Pin)
-
-//export __tinygo_i2c_transfer
-func i2cTransfer(bus uint8, w *byte, wlen int, r *byte, rlen int) int
-
 type UART struct {
 	Bus uint8
 }
@@ -129,43 +100,6 @@ func (uart *UART) Configure(config UARTConfig) {
 	uartConfigure(uart.Bus, config.TX, config.RX)
 }
 
-// Read from the UART.
-func (uart *UART) Read(data []byte) (n int, err error) {
-	return uartRead(uart.Bus, &data[0], len(data)), nil
-}
-
-// Write to the UART.
-func (uart *UART) Write(data []byte) (n int, err error) {
-	return uartWrite(uart.Bus, &data[0], len(data)), nil
-}
-
-// Buffered returns the number of bytes currently stored in the RX buffer.
-func (uart *UART) Buffered() int {
-	return 0
-}
-
-// ReadByte reads a single byte from the UART.
-func (uart *UART) ReadByte() (byte, error) {
-	var b byte
-	uartRead(uart.Bus, &b, 1)
-	return b, nil
-}
-
-// WriteByte writes a single byte to the UART.
-func (uart *UART) WriteByte(b byte) error {
-	uartWrite(uart.Bus, &b, 1)
-	return nil
-}
-
-//export __tinygo_uart_configure
-func uartConfigure(bus uint8, tx Pin, rx Pin)
-
-//export __tinygo_uart_read
-func uartRead(bus uint8, buf *byte, bufLen int) int
-
-//export __tinygo_uart_write
-func uartWrite(bus uint8, buf *byte, bufLen int) int
-
 // Some objects used by Atmel SAM D chips (samd21, samd51).
 // Defined here (without build tag) for convenience.
 var (
@@ -0,0 +1,76 @@
+//go:build !baremetal && !sim
+// +build !baremetal,!sim
+
+package machine
+
+// This file implements the generic machine package by calling external
+// functions, which are provided by the environment (for example, by JavaScript
+// when running in a browser).
+
+//export __tinygo_gpio_configure
+func gpioConfigure(pin Pin, config PinConfig)
+
+//export __tinygo_gpio_set
+func gpioSet(pin Pin, value bool)
+
+//export __tinygo_gpio_get
+func gpioGet(pin Pin) bool
+
+//export __tinygo_spi_configure
+func spiConfigure(bus uint8, sck Pin, SDO Pin, SDI Pin)
+
+//export __tinygo_spi_transfer
+func spiTransfer(bus uint8, w uint8) uint8
+
+//export __tinygo_adc_read
+func adcRead(pin Pin) uint16
+
+// Tx does a single I2C transaction at the specified address.
+func (i2c *I2C) Tx(addr uint16, w, r []byte) error {
+	i2cTransfer(i2c.Bus, &w[0], len(w), &r[0], len(r))
+	// TODO: do something with the returned error code.
+	return nil
+}
+
+//export __tinygo_i2c_configure
+func i2cConfigure(bus uint8, scl Pin, sda Pin)
+
+//export __tinygo_i2c_transfer
+func i2cTransfer(bus uint8, w *byte, wlen int, r *byte, rlen int) int
+
+// Read from the UART.
+func (uart *UART) Read(data []byte) (n int, err error) {
+	return uartRead(uart.Bus, &data[0], len(data)), nil
+}
+
+// Write to the UART.
+func (uart *UART) Write(data []byte) (n int, err error) {
+	return uartWrite(uart.Bus, &data[0], len(data)), nil
+}
+
+// Buffered returns the number of bytes currently stored in the RX buffer.
+func (uart *UART) Buffered() int {
+	return 0
+}
+
+// ReadByte reads a single byte from the UART.
+func (uart *UART) ReadByte() (byte, error) {
+	var b byte
+	uartRead(uart.Bus, &b, 1)
+	return b, nil
+}
+
+// WriteByte writes a single byte to the UART.
+func (uart *UART) WriteByte(b byte) error {
+	uartWrite(uart.Bus, &b, 1)
+	return nil
+}
+
+//export __tinygo_uart_configure
+func uartConfigure(bus uint8, tx Pin, rx Pin)
+
+//export __tinygo_uart_read
+func uartRead(bus uint8, buf *byte, bufLen int) int
+
+//export __tinygo_uart_write
+func uartWrite(bus uint8, buf *byte, bufLen int) int
@@ -0,0 +1,82 @@
+//go:build !baremetal && sim
+// +build !baremetal,sim
+
+package machine
+
+// This file implements the generic machine package on a simulated board, which
+// runs on the host. See the machine/sim package for how to interact with the
+// simulated hardware.
+
+import (
+	"errors"
+	"machine/sim"
+)
+
+var errUARTBufferEmpty = errors.New("UART buffer empty")
+
+func gpioConfigure(pin Pin, config PinConfig) {
+	sim.GPIOConfigure(sim.Pin(pin), sim.PinMode(config.Mode))
+}
+
+func gpioSet(pin Pin, value bool) {
+	sim.GPIOSet(sim.Pin(pin), value)
+}
+
+func gpioGet(pin Pin) bool {
+	return sim.GPIOGet(sim.Pin(pin))
+}
+
+func spiConfigure(bus uint8, sck Pin, SDO Pin, SDI Pin) {
+	sim.SPIConfigure(bus)
+}
+
+func spiTransfer(bus uint8, w uint8) uint8 {
+	return sim.SPITransfer(bus, w)
+}
+
+func adcRead(pin Pin) uint16 {
+	return sim.ADCRead(sim.Pin(pin))
+}
+
+func i2cConfigure(bus uint8, scl Pin, sda Pin) {
+	sim.I2CConfigure(bus)
+}
+
+// Tx does a single I2C transaction at the specified address.
+func (i2c *I2C) Tx(addr uint16, w, r []byte) error {
+	return sim.I2CTx(i2c.Bus, addr, w, r)
+}
+
+func uartConfigure(bus uint8, tx Pin, rx Pin) {
+	sim.UARTConfigure(bus)
+}
+
+// Read from the UART.
+func (uart *UART) Read(data []byte) (n int, err error) {
+	return sim.UARTRead(uart.Bus, data), nil
+}
+
+// Write to the UART.
+func (uart *UART) Write(data []byte) (n int, err error) {
+	return sim.UARTWrite(uart.Bus, data), nil
+}
+
+// Buffered returns the number of bytes currently stored in the RX buffer.
+func (uart *UART) Buffered() int {
+	return sim.UARTBuffered(uart.Bus)
+}
+
+// ReadByte reads a single byte from the UART.
+func (uart *UART) ReadByte() (byte, error) {
+	var buf [1]byte
+	if sim.UARTRead(uart.Bus, buf[:]) == 0 {
+		return 0, errUARTBufferEmpty
+	}
+	return buf[0], nil
+}
+
+// WriteByte writes a single byte to the UART.
+func (uart *UART) WriteByte(b byte) error {
+	sim.UARTWrite(uart.Bus, []byte{b})
+	return nil
+}
@@ -128,16 +128,16 @@ var (
 	udd_ep_in_cache_buffer  [7][128]uint8
 	udd_ep_out_cache_buffer [7][128]uint8
 
+	// The control endpoint has a separate buffer, as descriptors (in
+	// particular HID report descriptors) may not fit in the regular buffers.
+	udd_ep_control_cache_buffer [usbControlBufferSize]uint8
+
 	sendOnEP0DATADONE struct {
 		ptr   *byte
 		count int
 	}
 	isEndpointHalt        = false
 	isRemoteWakeUpEnabled = false
-	endPoints             = []uint32{usb_ENDPOINT_TYPE_CONTROL,
-		(usb_ENDPOINT_TYPE_INTERRUPT | usbEndpointIn),
-		(usb_ENDPOINT_TYPE_BULK | usbEndpointOut),
-		(usb_ENDPOINT_TYPE_BULK | usbEndpointIn)}
 
 	usbConfiguration         uint8
 	usbSetInterface          uint8
@@ -145,6 +145,8 @@ var (
 	epinen                   uint32
 	epouten                  uint32
 	easyDMABusy              volatile.Register8
+	epinBusy                 volatile.Register8 // IN endpoints with a packet the host didn't pick up yet
+	epoutPending             uint32             // OUT endpoints with data that EasyDMA didn't copy yet
 	epout0data_setlinecoding bool
 )
 
@@ -216,6 +218,8 @@ func (usbcdc *USBCDC) handleInterrupt(interrupt.Interrupt) {
 			nrf.USBD.USBPULLUP.Set(1)
 
 			usbConfiguration = 0
+			epinBusy.Set(0)
+			epoutPending = 0
 		}
 		nrf.USBD.EVENTCAUSE.Set(0)
 	}
@@ -230,15 +234,18 @@ func (usbcdc *USBCDC) handleInterrupt(interrupt.Interrupt) {
 			return
 		}
 		if sendOnEP0DATADONE.ptr != nil {
-			// previous data was too big for one packet, so send a second
-			sendViaEPIn(
-				0,
-				sendOnEP0DATADONE.ptr,
-				sendOnEP0DATADONE.count,
-			)
-
-			// clear, so we know we're done
-			sendOnEP0DATADONE.ptr = nil
+			// previous data was too big for one packet, so send the next one
+			ptr := sendOnEP0DATADONE.ptr
+			count := sendOnEP0DATADONE.count
+			if count > usbEndpointPacketSize {
+				sendOnEP0DATADONE.ptr = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(ptr)) + usbEndpointPacketSize))
+				sendOnEP0DATADONE.count = count - usbEndpointPacketSize
+				count = usbEndpointPacketSize
+			} else {
+				// clear, so we know we're done
+				sendOnEP0DATADONE.ptr = nil
+			}
+			sendViaEPIn(0, ptr, count)
 		} else {
 			// no more data, so set status stage
 			nrf.USBD.TASKS_EP0STATUS.Set(1)
@@ -259,9 +266,7 @@ func (usbcdc *USBCDC) handleInterrupt(interrupt.Interrupt) {
 			// Standard Requests
 			ok = handleStandardSetup(setup)
 		} else {
-			if setup.wIndex == usb_CDC_ACM_INTERFACE {
-				ok = cdcSetup(setup)
-			}
+			ok = usbClassSetup(setup)
 		}
 
 		if !ok {
@@ -276,35 +281,29 @@ func (usbcdc *USBCDC) handleInterrupt(interrupt.Interrupt) {
 		epDataStatus := nrf.USBD.EPDATASTATUS.Get()
 		nrf.USBD.EPDATASTATUS.Set(epDataStatus)
 		var i uint32
-		for i = 1; i < uint32(len(endPoints)); i++ {
+		for i = 1; i < usbEndpointCount; i++ {
 			// Check if endpoint has a pending interrupt
 			inDataDone := epDataStatus&(nrf.USBD_EPDATASTATUS_EPIN1<<(i-1)) > 0
 			outDataDone := epDataStatus&(nrf.USBD_EPDATASTATUS_EPOUT1<<(i-1)) > 0
-			if inDataDone || outDataDone {
-				switch i {
-				case usb_CDC_ENDPOINT_OUT:
-					// setup buffer to receive from host
-					if outDataDone {
-						enterCriticalSection()
-						nrf.USBD.EPOUT[i].PTR.Set(uint32(uintptr(unsafe.Pointer(&udd_ep_out_cache_buffer[i]))))
-						count := nrf.USBD.SIZE.EPOUT[i].Get()
-						nrf.USBD.EPOUT[i].MAXCNT.Set(count)
-						nrf.USBD.TASKS_STARTEPOUT[i].Set(1)
-					}
-				case usb_CDC_ENDPOINT_IN: //, usb_CDC_ENDPOINT_ACM:
-					if inDataDone {
-						usbcdc.waitTxc = false
-						exitCriticalSection()
-					}
-				}
+			if inDataDone {
+				epinBusy.ClearBits(1 << i)
+				usbClassTx(i)
+			}
+			if outDataDone && usbEndpointTypes[i]&usbEndpointIn == 0 {
+				// the data is copied to the buffer by startEPOut
+				epoutPending |= 1 << i
 			}
 		}
 	}
 
 	// ENDEPOUT[n] events
-	for i := 0; i < len(endPoints); i++ {
+	for i := uint32(0); i < usbEndpointCount; i++ {
 		if nrf.USBD.EVENTS_ENDEPOUT[i].Get() > 0 {
 			nrf.USBD.EVENTS_ENDEPOUT[i].Set(0)
+
+			// EasyDMA is done, so the class of the endpoint can use it to
+			// send a reply.
+			exitCriticalSection()
 			if i == 0 && epout0data_setlinecoding {
 				epout0data_setlinecoding = false
 				count := int(nrf.USBD.SIZE.EPOUT[0].Get())
@@ -314,10 +313,33 @@ func (usbcdc *USBCDC) handleInterrupt(interrupt.Interrupt) {
 				}
 				nrf.USBD.TASKS_EP0STATUS.Set(1)
 			}
-			if i == usb_CDC_ENDPOINT_OUT {
-				usbcdc.handleEndpoint(uint32(i))
+			if i > 0 {
+				handleEndpoint(i)
 			}
-			exitCriticalSection()
+		}
+	}
+
+	startEPOut()
+}
+
+// startEPOut copies the data received on one of the OUT endpoints to its
+// buffer, if EasyDMA isn't in use. EasyDMA can only do one transfer at a time,
+// so the other endpoints are handled after the ENDEPOUT event of this one.
+func startEPOut() {
+	if epoutPending == 0 || easyDMABusy.HasBits(1) {
+		return
+	}
+	for i := uint32(1); i < usbEndpointCount; i++ {
+		if epoutPending&(1<<i) != 0 {
+			epoutPending &^= 1 << i
+
+			// setup buffer to receive from host
+			enterCriticalSection()
+			nrf.USBD.EPOUT[i].PTR.Set(uint32(uintptr(unsafe.Pointer(&udd_ep_out_cache_buffer[i]))))
+			count := nrf.USBD.SIZE.EPOUT[i].Get()
+			nrf.USBD.EPOUT[i].MAXCNT.Set(count)
+			nrf.USBD.TASKS_STARTEPOUT[i].Set(1)
+			return
 		}
 	}
 }
@@ -417,8 +439,8 @@ func handleStandardSetup(setup usbSetup) bool {
 	case usb_SET_CONFIGURATION:
 		if setup.bmRequestType&usb_REQUEST_RECIPIENT == usb_REQUEST_DEVICE {
 			nrf.USBD.TASKS_EP0STATUS.Set(1)
-			for i := 1; i < len(endPoints); i++ {
-				initEndpoint(uint32(i), endPoints[i])
+			for i := uint32(1); i < usbEndpointCount; i++ {
+				initEndpoint(i, usbEndpointTypes[i])
 			}
 
 			usbConfiguration = setup.wValueL
@@ -484,32 +506,74 @@ func cdcSetup(setup usbSetup) bool {
 //go:noinline
 func sendUSBPacket(ep uint32, data []byte) {
 	count := len(data)
-	copy(udd_ep_in_cache_buffer[ep][:], data)
+	buf := udd_ep_in_cache_buffer[ep][:]
+	if ep == 0 {
+		buf = udd_ep_control_cache_buffer[:]
+	}
+	copy(buf, data)
 	if ep == 0 && count > usbEndpointPacketSize {
-		sendOnEP0DATADONE.ptr = &udd_ep_in_cache_buffer[ep][usbEndpointPacketSize]
+		sendOnEP0DATADONE.ptr = &buf[usbEndpointPacketSize]
 		sendOnEP0DATADONE.count = count - usbEndpointPacketSize
 		count = usbEndpointPacketSize
 	}
 	sendViaEPIn(
 		ep,
-		&udd_ep_in_cache_buffer[ep][0],
+		&buf[0],
 		count,
 	)
 }
 
-func (usbcdc *USBCDC) handleEndpoint(ep uint32) {
+// sendUSBInPacket sends a packet on an interrupt or bulk IN endpoint, after the
+// host has picked up the previous packet on that endpoint. It returns false if
+// that didn't happen in time.
+func sendUSBInPacket(ep uint32, data []byte) bool {
+	timeout := 300000
+	for {
+		mask := interrupt.Disable()
+		if !epinBusy.HasBits(1<<ep) && !easyDMABusy.HasBits(1) {
+			epinBusy.SetBits(1 << ep)
+			copy(udd_ep_in_cache_buffer[ep][:], data)
+			nrf.USBD.EVENTS_ENDEPIN[ep].Set(0)
+			sendViaEPIn(ep, &udd_ep_in_cache_buffer[ep][0], len(data))
+
+			// Wait until EasyDMA has copied the packet, so that EasyDMA can
+			// be used for other endpoints while the host hasn't picked up
+			// this packet yet.
+			for nrf.USBD.EVENTS_ENDEPIN[ep].Get() == 0 {
+			}
+			nrf.USBD.EVENTS_ENDEPIN[ep].Set(0)
+			interrupt.Restore(mask)
+			return true
+		}
+		interrupt.Restore(mask)
+
+		timeout--
+		if timeout == 0 {
+			return false
+		}
+	}
+}
+
+func handleEndpoint(ep uint32) {
 	// get data
 	count := int(nrf.USBD.EPOUT[ep].AMOUNT.Get())
 
-	// move to ring buffer
-	for i := 0; i < count; i++ {
-		usbcdc.Receive(byte(udd_ep_out_cache_buffer[ep][i]))
-	}
+	// pass the data to the class of the endpoint
+	usbClassRx(ep, udd_ep_out_cache_buffer[ep][:count])
 
 	// set ready for next data
 	nrf.USBD.SIZE.EPOUT[ep].Set(0)
 }
 
+// cdcTx is called when the host has read the data sent on one of the CDC IN
+// endpoints.
+func cdcTx(ep uint32) {
+	if ep == usb_CDC_ENDPOINT_IN {
+		USB.waitTxc = false
+		exitCriticalSection()
+	}
+}
+
 func sendZlp() {
 	nrf.USBD.TASKS_EP0STATUS.Set(1)
 }
@@ -0,0 +1,46 @@
+//go:build nrf52 || nrf52840 || nrf52833
+// +build nrf52 nrf52840 nrf52833
+
+package machine
+
+import (
+	"device/nrf"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// Flash on the nrf52 is written in 32-bit words and erased in 4kB pages. The
+// CPU is halted while the NVMC is busy, so the program can keep running from
+// flash.
+//
+// Note that when the SoftDevice is enabled, the NVMC may not be used directly:
+// flash must then be written through the SoftDevice API instead.
+const (
+	flashWriteBlockSize = 4
+	flashEraseBlockSize = 4096
+)
+
+func flashWrite(address uintptr, data []byte) error {
+	nrf.NVMC.CONFIG.Set(nrf.NVMC_CONFIG_WEN_Wen)
+	for i := 0; i < len(data); i += 4 {
+		word := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		(*volatile.Register32)(unsafe.Pointer(address + uintptr(i))).Set(word)
+		flashWaitReady()
+	}
+	nrf.NVMC.CONFIG.Set(nrf.NVMC_CONFIG_WEN_Ren)
+	return nil
+}
+
+func flashEraseBlock(address uintptr) error {
+	nrf.NVMC.CONFIG.Set(nrf.NVMC_CONFIG_WEN_Een)
+	nrf.NVMC.ERASEPAGE.Set(uint32(address))
+	flashWaitReady()
+	nrf.NVMC.CONFIG.Set(nrf.NVMC_CONFIG_WEN_Ren)
+	return nil
+}
+
+// flashWaitReady waits until the NVMC has finished the current operation.
+func flashWaitReady() {
+	for nrf.NVMC.READY.Get() == nrf.NVMC_READY_READY_Busy {
+	}
+}
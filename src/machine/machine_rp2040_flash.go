@@ -0,0 +1,126 @@
+//go:build rp2040
+// +build rp2040
+
+package machine
+
+// Flash on the rp2040 is an external QSPI chip that is normally used in XIP
+// (execute in place) mode. While it is being written or erased, no code can run
+// from flash: the functions below that do the actual work are therefore placed
+// in RAM (see the .ramfuncs section in arm.ld) and use the flash functions of
+// the boot ROM. This is the same approach as used in the Pico SDK.
+
+/*
+typedef unsigned char uint8_t;
+typedef unsigned short uint16_t;
+typedef unsigned int uint32_t;
+typedef unsigned int uintptr_t;
+
+#define XIP_BASE 0x10000000
+#define FLASH_BLOCK_SIZE (1u << 16)
+#define FLASH_BLOCK_ERASE_CMD 0xd8
+#define BOOT2_SIZE_WORDS 64
+
+#define ROM_TABLE_CODE(c1, c2) ((c1) | ((c2) << 8))
+#define ROM_FUNC_CONNECT_INTERNAL_FLASH ROM_TABLE_CODE('I', 'F')
+#define ROM_FUNC_FLASH_EXIT_XIP         ROM_TABLE_CODE('E', 'X')
+#define ROM_FUNC_FLASH_RANGE_ERASE      ROM_TABLE_CODE('R', 'E')
+#define ROM_FUNC_FLASH_RANGE_PROGRAM    ROM_TABLE_CODE('R', 'P')
+#define ROM_FUNC_FLASH_FLUSH_CACHE      ROM_TABLE_CODE('F', 'C')
+
+#define ramfunc __attribute__((noinline, section(".ramfuncs")))
+
+typedef void *(*rom_table_lookup_fn)(uint16_t *table, uint32_t code);
+typedef void (*flash_init_fn)(void);
+typedef void (*flash_range_erase_fn)(uint32_t, uint32_t, uint32_t, uint8_t);
+typedef void (*flash_range_program_fn)(uint32_t, const uint8_t *, uint32_t);
+
+// Look up a function in the boot ROM. This must be inlined, as it is called
+// from functions in RAM while flash is not accessible.
+static inline __attribute__((always_inline)) void *rom_func_lookup(uint32_t code) {
+	rom_table_lookup_fn rom_table_lookup = (rom_table_lookup_fn)(uintptr_t)(*(uint16_t *)0x18);
+	uint16_t *func_table = (uint16_t *)(uintptr_t)(*(uint16_t *)0x14);
+	return rom_table_lookup(func_table, code);
+}
+
+// A copy of the second stage bootloader, which is used to restore the fast XIP
+// mode after flash was written or erased.
+static uint32_t boot2_copyout[BOOT2_SIZE_WORDS];
+static int boot2_copyout_valid = 0;
+
+static void flash_init_boot2_copyout(void) {
+	if (boot2_copyout_valid) {
+		return;
+	}
+	for (int i = 0; i < BOOT2_SIZE_WORDS; i++) {
+		boot2_copyout[i] = ((volatile uint32_t *)XIP_BASE)[i];
+	}
+	boot2_copyout_valid = 1;
+}
+
+static inline __attribute__((always_inline)) void flash_enable_xip_via_boot2(void) {
+	((void (*)(void))((uintptr_t)boot2_copyout + 1))();
+}
+
+ramfunc void tinygo_rp2040_flash_range_erase(uint32_t offset, uint32_t count) {
+	flash_init_fn connect_internal_flash = rom_func_lookup(ROM_FUNC_CONNECT_INTERNAL_FLASH);
+	flash_init_fn flash_exit_xip = rom_func_lookup(ROM_FUNC_FLASH_EXIT_XIP);
+	flash_range_erase_fn flash_range_erase = rom_func_lookup(ROM_FUNC_FLASH_RANGE_ERASE);
+	flash_init_fn flash_flush_cache = rom_func_lookup(ROM_FUNC_FLASH_FLUSH_CACHE);
+	flash_init_boot2_copyout();
+	__asm__ volatile ("" : : : "memory");
+
+	connect_internal_flash();
+	flash_exit_xip();
+	flash_range_erase(offset, count, FLASH_BLOCK_SIZE, FLASH_BLOCK_ERASE_CMD);
+	flash_flush_cache();
+	flash_enable_xip_via_boot2();
+}
+
+ramfunc void tinygo_rp2040_flash_range_program(uint32_t offset, const uint8_t *data, uint32_t count) {
+	flash_init_fn connect_internal_flash = rom_func_lookup(ROM_FUNC_CONNECT_INTERNAL_FLASH);
+	flash_init_fn flash_exit_xip = rom_func_lookup(ROM_FUNC_FLASH_EXIT_XIP);
+	flash_range_program_fn flash_range_program = rom_func_lookup(ROM_FUNC_FLASH_RANGE_PROGRAM);
+	flash_init_fn flash_flush_cache = rom_func_lookup(ROM_FUNC_FLASH_FLUSH_CACHE);
+	flash_init_boot2_copyout();
+	__asm__ volatile ("" : : : "memory");
+
+	connect_internal_flash();
+	flash_exit_xip();
+	flash_range_program(offset, data, count);
+	flash_flush_cache();
+	flash_enable_xip_via_boot2();
+}
+*/
+import "C"
+
+import (
+	"runtime/interrupt"
+	"unsafe"
+)
+
+// Flash is programmed in pages of 256 bytes and erased in sectors of 4kB.
+const (
+	flashWriteBlockSize = 256
+	flashEraseBlockSize = 4096
+	xipBase             = 0x10000000
+)
+
+func flashWrite(address uintptr, data []byte) error {
+	// The data must not be in flash itself, so make a copy if needed.
+	if uintptr(unsafe.Pointer(&data[0])) < 0x20000000 {
+		data = append([]byte(nil), data...)
+	}
+	// Interrupt handlers are normally stored in flash, so they can't run while
+	// flash is written.
+	mask := interrupt.Disable()
+	C.tinygo_rp2040_flash_range_program(C.uint32_t(address-xipBase), (*C.uint8_t)(&data[0]), C.uint32_t(len(data)))
+	interrupt.Restore(mask)
+	return nil
+}
+
+func flashEraseBlock(address uintptr) error {
+	mask := interrupt.Disable()
+	C.tinygo_rp2040_flash_range_erase(C.uint32_t(address-xipBase), flashEraseBlockSize)
+	interrupt.Restore(mask)
+	return nil
+}
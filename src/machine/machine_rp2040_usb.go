@@ -0,0 +1,591 @@
+//go:build rp2040
+// +build rp2040
+
+package machine
+
+import (
+	"device/arm"
+	"device/rp"
+	"runtime/interrupt"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// USBCDC is the USB CDC aka serial over USB interface on the RP2040.
+type USBCDC struct {
+	Buffer            *RingBuffer
+	interrupt         interrupt.Interrupt
+	initcomplete      bool
+	TxIdx             volatile.Register8
+	waitTxc           bool
+	waitTxcRetryCount uint8
+	sent              bool
+}
+
+var (
+	USB  = &_USB
+	_USB = USBCDC{Buffer: NewRingBuffer()}
+)
+
+const (
+	usbcdcTxSizeMask          uint8 = 0x3F
+	usbcdcTxBankMask          uint8 = ^usbcdcTxSizeMask
+	usbcdcTxBank1st           uint8 = 0x00
+	usbcdcTxBank2nd           uint8 = usbcdcTxSizeMask + 1
+	usbcdcTxMaxRetriesAllowed uint8 = 5
+)
+
+// Flush flushes buffered data.
+func (usbcdc *USBCDC) Flush() error {
+	if usbLineInfo.lineState > 0 {
+		idx := usbcdc.TxIdx.Get()
+		sz := idx & usbcdcTxSizeMask
+		bk := idx & usbcdcTxBankMask
+		if 0 < sz {
+
+			if usbcdc.waitTxc || usbDPRAM.bufferControl[usb_CDC_ENDPOINT_IN].in.HasBits(usbBufferAvailable) {
+				// waiting for the next flush(), because the transmission is not complete
+				usbcdc.waitTxcRetryCount++
+				return nil
+			}
+			usbcdc.waitTxc = true
+			usbcdc.waitTxcRetryCount = 0
+
+			// send the data of the current bank, and continue with the other one
+			sendUSBPacket(usb_CDC_ENDPOINT_IN, usbcdcTxBuffer[bk:bk+sz])
+			if bk == usbcdcTxBank1st {
+				usbcdc.TxIdx.Set(usbcdcTxBank2nd)
+			} else {
+				usbcdc.TxIdx.Set(usbcdcTxBank1st)
+			}
+			usbcdc.sent = true
+		}
+	}
+	return nil
+}
+
+// WriteByte writes a byte of data to the USB CDC interface.
+func (usbcdc *USBCDC) WriteByte(c byte) error {
+	// Supposedly to handle problem with Windows USB serial ports?
+	if usbLineInfo.lineState > 0 {
+		ok := false
+		for {
+			mask := interrupt.Disable()
+
+			idx := usbcdc.TxIdx.Get()
+			if (idx & usbcdcTxSizeMask) < usbcdcTxSizeMask {
+				usbcdcTxBuffer[idx] = c
+				usbcdc.TxIdx.Set(idx + 1)
+				ok = true
+			}
+
+			interrupt.Restore(mask)
+
+			if ok {
+				break
+			} else if usbcdcTxMaxRetriesAllowed < usbcdc.waitTxcRetryCount {
+				mask := interrupt.Disable()
+				usbcdc.waitTxc = false
+				usbcdc.waitTxcRetryCount = 0
+				usbcdc.TxIdx.Set(0)
+				usbLineInfo.lineState = 0
+				interrupt.Restore(mask)
+				break
+			} else {
+				mask := interrupt.Disable()
+				if usbcdc.sent {
+					if usbcdc.waitTxc {
+						if !usbDPRAM.bufferControl[usb_CDC_ENDPOINT_IN].in.HasBits(usbBufferAvailable) {
+							usbcdc.waitTxc = false
+							usbcdc.Flush()
+						}
+					} else {
+						usbcdc.Flush()
+					}
+				}
+				interrupt.Restore(mask)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (usbcdc *USBCDC) DTR() bool {
+	return (usbLineInfo.lineState & usb_CDC_LINESTATE_DTR) > 0
+}
+
+func (usbcdc *USBCDC) RTS() bool {
+	return (usbLineInfo.lineState & usb_CDC_LINESTATE_RTS) > 0
+}
+
+// Bits of the endpoint control registers in the USB DPRAM.
+const (
+	usbEndpointEnable             = 1 << 31
+	usbEndpointInterruptPerBuffer = 1 << 29
+	usbEndpointTypePos            = 26
+)
+
+// Bits of the buffer control registers in the USB DPRAM. Only the first buffer
+// of each endpoint is used.
+const (
+	usbBufferFull       = 1 << 15
+	usbBufferLast       = 1 << 14
+	usbBufferDataPID    = 1 << 13
+	usbBufferStall      = 1 << 11
+	usbBufferAvailable  = 1 << 10
+	usbBufferLengthMask = 0x3ff
+)
+
+// usbEndpointRegisters are the control registers of both directions of an
+// endpoint.
+type usbEndpointRegisters struct {
+	in  volatile.Register32
+	out volatile.Register32
+}
+
+// usbDPRAMType is the layout of the dual port RAM of the USB controller, which
+// contains the endpoint configuration and the packet buffers.
+type usbDPRAMType struct {
+	setup         [8]volatile.Register8
+	epControl     [15]usbEndpointRegisters // endpoints 1 to 15
+	bufferControl [16]usbEndpointRegisters
+	ep0Buffer     [usbEndpointPacketSize]byte // shared by EP0 IN and OUT
+	_             [usbEndpointPacketSize]byte
+	epBuffer      [usb_EPT_NUM - 1][usbEndpointPacketSize]byte // endpoints 1 and up
+}
+
+var usbDPRAM = (*usbDPRAMType)(unsafe.Pointer(uintptr(0x50100000)))
+
+var (
+	// Data written to the CDC serial port, in two banks. One is sent to the
+	// host while the other one is filled.
+	usbcdcTxBuffer [2 * usbEndpointPacketSize]uint8
+
+	// The control endpoint has a separate buffer, as descriptors (in
+	// particular the configuration descriptor) may not fit in a single packet.
+	udd_ep_control_cache_buffer [usbControlBufferSize]uint8
+
+	// The data PID (DATA0 or DATA1) of the next packet on each endpoint, in
+	// the format of the buffer control registers.
+	usbInPID  [usb_EPT_NUM]uint32
+	usbOutPID [usb_EPT_NUM]uint32
+
+	// State of the current control transfer: the data that still has to be
+	// sent, whether it must be followed by a zero length packet, and whether
+	// the host expects data (so that the status stage is an OUT packet).
+	usbControlIn           []byte
+	usbControlInZLP        bool
+	usbControlDeviceToHost bool
+	usbControlLength       uint16
+
+	// The device address must be set after the status stage of SET_ADDRESS.
+	usbSetAddress bool
+	usbAddress    uint8
+
+	usbSetLineCoding bool
+
+	isEndpointHalt        = false
+	isRemoteWakeUpEnabled = false
+
+	usbConfiguration uint8
+	usbSetInterface  uint8
+	usbLineInfo      = cdcLineInfo{115200, 0x00, 0x00, 0x08, 0x00}
+)
+
+// Configure the USB CDC interface. The config is here for compatibility with the UART interface.
+func (usbcdc *USBCDC) Configure(config UARTConfig) {
+	if usbcdc.initcomplete {
+		return
+	}
+
+	// reset the USB controller and clear the DPRAM, which isn't cleared by
+	// the reset: this disables all endpoints and marks all buffers as not
+	// available (the DPRAM accepts 8, 16 and 32-bit writes, so a plain
+	// memset is fine)
+	resetBlock(rp.RESETS_RESET_USBCTRL)
+	unresetBlockWait(rp.RESETS_RESET_USBCTRL)
+	*usbDPRAM = usbDPRAMType{}
+
+	// connect the controller to the USB PHY and pretend VBUS is present, as
+	// VBUS isn't routed to the controller on most boards (the Pico senses it
+	// on GPIO24 instead)
+	rp.USBCTRL_REGS.USB_MUXING.Set(rp.USBCTRL_REGS_USB_MUXING_TO_PHY |
+		rp.USBCTRL_REGS_USB_MUXING_SOFTCON)
+	rp.USBCTRL_REGS.USB_PWR.Set(rp.USBCTRL_REGS_USB_PWR_VBUS_DETECT |
+		rp.USBCTRL_REGS_USB_PWR_VBUS_DETECT_OVERRIDE_EN)
+
+	// enable the controller in device mode
+	rp.USBCTRL_REGS.MAIN_CTRL.Set(rp.USBCTRL_REGS_MAIN_CTRL_CONTROLLER_EN)
+
+	// interrupt on every EP0 buffer, as there is no EP0 endpoint control register
+	rp.USBCTRL_REGS.SIE_CTRL.Set(rp.USBCTRL_REGS_SIE_CTRL_EP0_INT_1BUF)
+
+	// enable interrupts for setup packets, buffers, bus reset and start of frame
+	rp.USBCTRL_REGS.INTE.Set(rp.USBCTRL_REGS_INTE_BUFF_STATUS |
+		rp.USBCTRL_REGS_INTE_BUS_RESET |
+		rp.USBCTRL_REGS_INTE_SETUP_REQ |
+		rp.USBCTRL_REGS_INTE_DEV_SOF)
+
+	usbcdc.interrupt = interrupt.New(rp.IRQ_USBCTRL_IRQ, _USB.handleInterrupt)
+	usbcdc.interrupt.Enable()
+
+	// present full speed device to the host
+	rp.USBCTRL_REGS.SIE_CTRL.SetBits(rp.USBCTRL_REGS_SIE_CTRL_PULLUP_EN)
+
+	usbcdc.initcomplete = true
+}
+
+func (usbcdc *USBCDC) handleInterrupt(interrupt.Interrupt) {
+	status := rp.USBCTRL_REGS.INTS.Get()
+
+	// Start of frame
+	if status&rp.USBCTRL_REGS_INTS_DEV_SOF != 0 {
+		// reading the frame number clears the interrupt
+		rp.USBCTRL_REGS.SOF_RD.Get()
+		usbcdc.Flush()
+	}
+
+	// Setup packet received
+	if status&rp.USBCTRL_REGS_INTS_SETUP_REQ != 0 {
+		rp.USBCTRL_REGS.SIE_STATUS.Set(rp.USBCTRL_REGS_SIE_STATUS_SETUP_REC)
+		handleSetup()
+	}
+
+	// Buffers done, bit 2n is IN endpoint n and bit 2n+1 is OUT endpoint n
+	if status&rp.USBCTRL_REGS_INTS_BUFF_STATUS != 0 {
+		buffers := rp.USBCTRL_REGS.BUFF_STATUS.Get()
+		rp.USBCTRL_REGS.BUFF_STATUS.Set(buffers)
+		for i := uint32(0); i < usbEndpointCount; i++ {
+			if buffers&(1<<(2*i)) != 0 {
+				if i == 0 {
+					handleControlIn()
+				} else {
+					usbClassTx(i)
+				}
+			}
+			if buffers&(2<<(2*i)) != 0 {
+				if i == 0 {
+					handleControlOut()
+				} else {
+					handleEndpoint(i)
+				}
+			}
+		}
+	}
+
+	// End of reset
+	if status&rp.USBCTRL_REGS_INTS_BUS_RESET != 0 {
+		rp.USBCTRL_REGS.SIE_STATUS.Set(rp.USBCTRL_REGS_SIE_STATUS_BUS_RESET)
+		rp.USBCTRL_REGS.ADDR_ENDP.Set(0)
+		usbSetAddress = false
+		usbConfiguration = 0
+	}
+}
+
+func handleSetup() {
+	var data [8]byte
+	for i := range data {
+		data[i] = usbDPRAM.setup[i].Get()
+	}
+	setup := newUSBSetup(data[:])
+
+	// The data and status stages of a control transfer start with DATA1.
+	usbInPID[0] = usbBufferDataPID
+	usbOutPID[0] = usbBufferDataPID
+	usbControlIn = nil
+	usbControlInZLP = false
+	usbControlDeviceToHost = setup.bmRequestType&usb_REQUEST_DIRECTION == usb_REQUEST_DEVICETOHOST
+	usbControlLength = setup.wLength
+	usbSetLineCoding = false
+
+	ok := false
+	if (setup.bmRequestType & usb_REQUEST_TYPE) == usb_REQUEST_STANDARD {
+		// Standard Requests
+		ok = handleStandardSetup(setup)
+	} else {
+		ok = usbClassSetup(setup)
+	}
+
+	if !ok {
+		// Stall endpoint, until the next setup packet
+		rp.USBCTRL_REGS.EP_STALL_ARM.Set(rp.USBCTRL_REGS_EP_STALL_ARM_EP0_IN |
+			rp.USBCTRL_REGS_EP_STALL_ARM_EP0_OUT)
+		usbDPRAM.bufferControl[0].in.Set(usbBufferStall)
+		usbDPRAM.bufferControl[0].out.Set(usbBufferStall)
+	}
+}
+
+// handleControlIn is called when the host has picked up a packet sent on the
+// control endpoint.
+func handleControlIn() {
+	switch {
+	case usbSetAddress:
+		// status stage of SET_ADDRESS done
+		usbSetAddress = false
+		rp.USBCTRL_REGS.ADDR_ENDP.Set(uint32(usbAddress))
+	case len(usbControlIn) > 0:
+		sendControlInPacket()
+	case usbControlInZLP:
+		usbControlInZLP = false
+		armUSBIn(0, 0)
+	case usbControlDeviceToHost:
+		// data stage done, receive the status stage
+		usbControlDeviceToHost = false
+		armUSBOut(0)
+	}
+}
+
+// handleControlOut is called when a packet has been received on the control
+// endpoint.
+func handleControlOut() {
+	if !usbSetLineCoding {
+		// status stage
+		return
+	}
+	usbSetLineCoding = false
+
+	count := usbDPRAM.bufferControl[0].out.Get() & usbBufferLengthMask
+	if count >= cdcLineInfoSize {
+		b := usbDPRAM.ep0Buffer[:]
+		usbLineInfo.dwDTERate = uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+		usbLineInfo.bCharFormat = b[4]
+		usbLineInfo.bParityType = b[5]
+		usbLineInfo.bDataBits = b[6]
+	}
+	sendZlp()
+}
+
+func initEndpoint(ep, config uint32) {
+	if ep == 0 {
+		// The control endpoint is always enabled.
+		return
+	}
+
+	offset := uint32(unsafe.Offsetof(usbDPRAM.epBuffer)) + (ep-1)*usbEndpointPacketSize
+	val := usbEndpointEnable | usbEndpointInterruptPerBuffer |
+		(config&^usbEndpointIn)<<usbEndpointTypePos | offset
+	if config&usbEndpointIn != 0 {
+		usbInPID[ep] = 0
+		usbDPRAM.epControl[ep-1].in.Set(val)
+	} else {
+		usbOutPID[ep] = 0
+		usbDPRAM.epControl[ep-1].out.Set(val)
+		armUSBOut(ep)
+	}
+}
+
+func handleStandardSetup(setup usbSetup) bool {
+	switch setup.bRequest {
+	case usb_GET_STATUS:
+		buf := []byte{0, 0}
+
+		if setup.bmRequestType != 0 { // endpoint
+			// TODO: actually check if the endpoint in question is currently halted
+			if isEndpointHalt {
+				buf[0] = 1
+			}
+		}
+
+		sendUSBPacket(0, buf)
+		return true
+
+	case usb_CLEAR_FEATURE:
+		if setup.wValueL == 1 { // DEVICEREMOTEWAKEUP
+			isRemoteWakeUpEnabled = false
+		} else if setup.wValueL == 0 { // ENDPOINTHALT
+			isEndpointHalt = false
+		}
+		sendZlp()
+		return true
+
+	case usb_SET_FEATURE:
+		if setup.wValueL == 1 { // DEVICEREMOTEWAKEUP
+			isRemoteWakeUpEnabled = true
+		} else if setup.wValueL == 0 { // ENDPOINTHALT
+			isEndpointHalt = true
+		}
+		sendZlp()
+		return true
+
+	case usb_SET_ADDRESS:
+		// the address is set when the status stage is done
+		usbAddress = setup.wValueL
+		usbSetAddress = true
+		sendZlp()
+		return true
+
+	case usb_GET_DESCRIPTOR:
+		sendDescriptor(setup)
+		return true
+
+	case usb_SET_DESCRIPTOR:
+		return false
+
+	case usb_GET_CONFIGURATION:
+		buff := []byte{usbConfiguration}
+		sendUSBPacket(0, buff)
+		return true
+
+	case usb_SET_CONFIGURATION:
+		if setup.bmRequestType&usb_REQUEST_RECIPIENT == usb_REQUEST_DEVICE {
+			for i := uint32(1); i < usbEndpointCount; i++ {
+				initEndpoint(i, usbEndpointTypes[i])
+			}
+
+			usbConfiguration = setup.wValueL
+
+			sendZlp()
+			return true
+		} else {
+			return false
+		}
+
+	case usb_GET_INTERFACE:
+		buff := []byte{usbSetInterface}
+		sendUSBPacket(0, buff)
+		return true
+
+	case usb_SET_INTERFACE:
+		usbSetInterface = setup.wValueL
+
+		sendZlp()
+		return true
+
+	default:
+		sendZlp()
+		return true
+	}
+}
+
+func cdcSetup(setup usbSetup) bool {
+	if setup.bmRequestType == usb_REQUEST_DEVICETOHOST_CLASS_INTERFACE {
+		if setup.bRequest == usb_CDC_GET_LINE_CODING {
+			var b [cdcLineInfoSize]byte
+			b[0] = byte(usbLineInfo.dwDTERate)
+			b[1] = byte(usbLineInfo.dwDTERate >> 8)
+			b[2] = byte(usbLineInfo.dwDTERate >> 16)
+			b[3] = byte(usbLineInfo.dwDTERate >> 24)
+			b[4] = byte(usbLineInfo.bCharFormat)
+			b[5] = byte(usbLineInfo.bParityType)
+			b[6] = byte(usbLineInfo.bDataBits)
+
+			sendUSBPacket(0, b[:])
+			return true
+		}
+	}
+
+	if setup.bmRequestType == usb_REQUEST_HOSTTODEVICE_CLASS_INTERFACE {
+		if setup.bRequest == usb_CDC_SET_LINE_CODING {
+			// the line coding is sent in the data stage
+			usbSetLineCoding = true
+			armUSBOut(0)
+			return true
+		}
+
+		if setup.bRequest == usb_CDC_SET_CONTROL_LINE_STATE {
+			usbLineInfo.lineState = setup.wValueL
+			sendZlp()
+		}
+
+		if setup.bRequest == usb_CDC_SEND_BREAK {
+			sendZlp()
+		}
+		return true
+	}
+	return false
+}
+
+//go:noinline
+func sendUSBPacket(ep uint32, data []byte) {
+	if ep == 0 {
+		n := copy(udd_ep_control_cache_buffer[:], data)
+		usbControlIn = udd_ep_control_cache_buffer[:n]
+
+		// A transfer that is shorter than requested ends with a short packet,
+		// which is a zero length packet if it is a multiple of the packet size.
+		usbControlInZLP = n > 0 && n < int(usbControlLength) && n%usbEndpointPacketSize == 0
+		sendControlInPacket()
+		return
+	}
+
+	n := copy(usbDPRAM.epBuffer[ep-1][:], data)
+	armUSBIn(ep, n)
+}
+
+// sendControlInPacket sends the next packet of a control IN transfer.
+func sendControlInPacket() {
+	n := copy(usbDPRAM.ep0Buffer[:], usbControlIn)
+	usbControlIn = usbControlIn[n:]
+	armUSBIn(0, n)
+}
+
+// sendUSBInPacket sends a packet on an interrupt or bulk IN endpoint, after the
+// host has picked up the previous packet on that endpoint. It returns false if
+// that didn't happen in time.
+func sendUSBInPacket(ep uint32, data []byte) bool {
+	timeout := 300000
+	for usbDPRAM.bufferControl[ep].in.HasBits(usbBufferAvailable) {
+		timeout--
+		if timeout == 0 {
+			return false
+		}
+	}
+
+	sendUSBPacket(ep, data)
+	return true
+}
+
+func handleEndpoint(ep uint32) {
+	// get data
+	count := usbDPRAM.bufferControl[ep].out.Get() & usbBufferLengthMask
+
+	// pass the data to the class of the endpoint
+	usbClassRx(ep, usbDPRAM.epBuffer[ep-1][:count])
+
+	// set ready for next data
+	armUSBOut(ep)
+}
+
+// cdcTx is called when the host has picked up data sent by the CDC serial port.
+func cdcTx(ep uint32) {
+	if ep == usb_CDC_ENDPOINT_IN {
+		USB.waitTxc = false
+	}
+}
+
+func sendZlp() {
+	usbControlIn = nil
+	usbControlInZLP = false
+	armUSBIn(0, 0)
+}
+
+// armUSBIn hands the buffer of an IN endpoint, filled with count bytes, to the
+// controller.
+func armUSBIn(ep uint32, count int) {
+	val := uint32(count)&usbBufferLengthMask | usbBufferFull | usbBufferLast | usbInPID[ep]
+	usbInPID[ep] ^= usbBufferDataPID
+	setUSBBufferControl(&usbDPRAM.bufferControl[ep].in, val)
+}
+
+// armUSBOut hands the buffer of an OUT endpoint to the controller, to receive
+// the next packet.
+func armUSBOut(ep uint32) {
+	val := usbEndpointPacketSize | usbBufferLast | usbOutPID[ep]
+	usbOutPID[ep] ^= usbBufferDataPID
+	setUSBBufferControl(&usbDPRAM.bufferControl[ep].out, val)
+}
+
+func setUSBBufferControl(reg *volatile.Register32, val uint32) {
+	reg.Set(val)
+
+	// The controller runs from a slower clock, so the available bit must be
+	// set a few cycles after the rest of the buffer control register.
+	arm.Asm("nop")
+	arm.Asm("nop")
+	arm.Asm("nop")
+	arm.Asm("nop")
+	arm.Asm("nop")
+	arm.Asm("nop")
+	reg.Set(val | usbBufferAvailable)
+}
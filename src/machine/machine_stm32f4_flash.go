@@ -0,0 +1,102 @@
+//go:build stm32f4
+// +build stm32f4
+
+package machine
+
+import (
+	"device/stm32"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// Flash on the stm32f4 is divided in sectors of different sizes: four sectors
+// of 16kB, one of 64kB and the rest of 128kB (per bank on chips with two
+// banks). Only 128kB blocks are exposed, so that the flash data area (which
+// starts at a block boundary) doesn't share a sector with the program. Data is
+// written in 32-bit words, which requires a supply voltage of at least 2.7V.
+const (
+	flashWriteBlockSize = 4
+	flashEraseBlockSize = 128 * 1024
+	flashBase           = 0x08000000
+	flashBankSize       = 1024 * 1024
+)
+
+const flashErrors = stm32.FLASH_SR_OPERR | stm32.FLASH_SR_WRPERR | stm32.FLASH_SR_PGAERR | stm32.FLASH_SR_PGPERR | stm32.FLASH_SR_PGSERR
+
+func flashWrite(address uintptr, data []byte) error {
+	flashUnlock()
+	defer flashLock()
+
+	stm32.FLASH.CR.ReplaceBits(2, 3, stm32.FLASH_CR_PSIZE_Pos) // program 32 bits at a time
+	stm32.FLASH.CR.SetBits(stm32.FLASH_CR_PG)
+	defer stm32.FLASH.CR.ClearBits(stm32.FLASH_CR_PG)
+	for i := 0; i < len(data); i += 4 {
+		word := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		(*volatile.Register32)(unsafe.Pointer(address + uintptr(i))).Set(word)
+		if !flashWaitDone() {
+			return errFlashWrite
+		}
+	}
+	return nil
+}
+
+func flashEraseBlock(address uintptr) error {
+	flashUnlock()
+	defer flashLock()
+
+	// Determine which sectors are part of this block. Usually this is a single
+	// 128kB sector, but the first block of a bank consists of 5 sectors.
+	offset := uint32(address - flashBase)
+	bank := offset / flashBankSize
+	offset %= flashBankSize
+	first, last := uint32(0), uint32(4)
+	if offset != 0 {
+		first = 4 + offset/flashEraseBlockSize
+		last = first
+	}
+	for sector := first; sector <= last; sector++ {
+		// Sectors of the second bank are numbered starting at 16.
+		snb := bank<<4 | sector
+		stm32.FLASH.CR.ReplaceBits(snb, stm32.FLASH_CR_SNB_Msk>>stm32.FLASH_CR_SNB_Pos, stm32.FLASH_CR_SNB_Pos)
+		stm32.FLASH.CR.SetBits(stm32.FLASH_CR_SER)
+		stm32.FLASH.CR.SetBits(stm32.FLASH_CR_STRT)
+		ok := flashWaitDone()
+		stm32.FLASH.CR.ClearBits(stm32.FLASH_CR_SER)
+		if !ok {
+			return errFlashErase
+		}
+	}
+
+	// The data cache may still contain the old contents of the erased sectors.
+	if stm32.FLASH.ACR.HasBits(stm32.FLASH_ACR_DCEN) {
+		stm32.FLASH.ACR.ClearBits(stm32.FLASH_ACR_DCEN)
+		stm32.FLASH.ACR.SetBits(stm32.FLASH_ACR_DCRST)
+		stm32.FLASH.ACR.ClearBits(stm32.FLASH_ACR_DCRST)
+		stm32.FLASH.ACR.SetBits(stm32.FLASH_ACR_DCEN)
+	}
+	return nil
+}
+
+// flashUnlock unlocks the flash control register and clears the error flags of
+// a previous operation.
+func flashUnlock() {
+	for stm32.FLASH.SR.HasBits(stm32.FLASH_SR_BSY) {
+	}
+	if stm32.FLASH.CR.HasBits(stm32.FLASH_CR_LOCK) {
+		stm32.FLASH.KEYR.Set(0x45670123)
+		stm32.FLASH.KEYR.Set(0xCDEF89AB)
+	}
+	stm32.FLASH.SR.Set(flashErrors)
+}
+
+func flashLock() {
+	stm32.FLASH.CR.SetBits(stm32.FLASH_CR_LOCK)
+}
+
+// flashWaitDone waits until the current flash operation is finished and
+// returns whether it succeeded.
+func flashWaitDone() bool {
+	for stm32.FLASH.SR.HasBits(stm32.FLASH_SR_BSY) {
+	}
+	return !stm32.FLASH.SR.HasBits(flashErrors)
+}
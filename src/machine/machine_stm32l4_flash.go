@@ -0,0 +1,95 @@
+//go:build stm32l4
+// +build stm32l4
+
+package machine
+
+import (
+	"device/stm32"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// Flash on the stm32l4 is written in 64-bit double words and erased in pages.
+// The page size (flashEraseBlockSize) depends on the chip.
+const (
+	flashWriteBlockSize = 8
+	flashBase           = 0x08000000
+	flashPagesPerBank   = 256
+)
+
+const flashErrors = stm32.Flash_SR_OPERR | stm32.Flash_SR_PROGERR | stm32.Flash_SR_WRPERR | stm32.Flash_SR_PGAERR | stm32.Flash_SR_SIZERR | stm32.Flash_SR_PGSERR | stm32.Flash_SR_MISERR | stm32.Flash_SR_FASTERR
+
+func flashWrite(address uintptr, data []byte) error {
+	flashUnlock()
+	defer flashLock()
+
+	stm32.FLASH.CR.SetBits(stm32.Flash_CR_PG)
+	defer stm32.FLASH.CR.ClearBits(stm32.Flash_CR_PG)
+	for i := 0; i < len(data); i += 8 {
+		// Both words of a double word must be written directly after each
+		// other.
+		low := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		high := uint32(data[i+4]) | uint32(data[i+5])<<8 | uint32(data[i+6])<<16 | uint32(data[i+7])<<24
+		(*volatile.Register32)(unsafe.Pointer(address + uintptr(i))).Set(low)
+		(*volatile.Register32)(unsafe.Pointer(address + uintptr(i) + 4)).Set(high)
+		if !flashWaitDone() {
+			return errFlashWrite
+		}
+	}
+	return nil
+}
+
+func flashEraseBlock(address uintptr) error {
+	flashUnlock()
+	defer flashLock()
+
+	page := uint32(address-flashBase) / flashEraseBlockSize
+	if page >= flashPagesPerBank {
+		// The page is in the second bank.
+		stm32.FLASH.CR.SetBits(stm32.Flash_CR_BKER)
+		page -= flashPagesPerBank
+	} else {
+		stm32.FLASH.CR.ClearBits(stm32.Flash_CR_BKER)
+	}
+	stm32.FLASH.CR.ReplaceBits(page, stm32.Flash_CR_PNB_Msk>>stm32.Flash_CR_PNB_Pos, stm32.Flash_CR_PNB_Pos)
+	stm32.FLASH.CR.SetBits(stm32.Flash_CR_PER)
+	stm32.FLASH.CR.SetBits(stm32.Flash_CR_STRT)
+	ok := flashWaitDone()
+	stm32.FLASH.CR.ClearBits(stm32.Flash_CR_PER)
+	if !ok {
+		return errFlashErase
+	}
+
+	// The data cache may still contain the old contents of the erased page.
+	if stm32.FLASH.ACR.HasBits(stm32.Flash_ACR_DCEN) {
+		stm32.FLASH.ACR.ClearBits(stm32.Flash_ACR_DCEN)
+		stm32.FLASH.ACR.SetBits(stm32.Flash_ACR_DCRST)
+		stm32.FLASH.ACR.ClearBits(stm32.Flash_ACR_DCRST)
+		stm32.FLASH.ACR.SetBits(stm32.Flash_ACR_DCEN)
+	}
+	return nil
+}
+
+// flashUnlock unlocks the flash control register and clears the error flags of
+// a previous operation.
+func flashUnlock() {
+	for stm32.FLASH.SR.HasBits(stm32.Flash_SR_BSY) {
+	}
+	if stm32.FLASH.CR.HasBits(stm32.Flash_CR_LOCK) {
+		stm32.FLASH.KEYR.Set(0x45670123)
+		stm32.FLASH.KEYR.Set(0xCDEF89AB)
+	}
+	stm32.FLASH.SR.Set(flashErrors)
+}
+
+func flashLock() {
+	stm32.FLASH.CR.SetBits(stm32.Flash_CR_LOCK)
+}
+
+// flashWaitDone waits until the current flash operation is finished and
+// returns whether it succeeded.
+func flashWaitDone() bool {
+	for stm32.FLASH.SR.HasBits(stm32.Flash_SR_BSY) {
+	}
+	return !stm32.FLASH.SR.HasBits(flashErrors)
+}
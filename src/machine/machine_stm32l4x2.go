@@ -15,6 +15,9 @@ func CPUFrequency() uint32 {
 const APB1_TIM_FREQ = 80e6 // 80MHz
 const APB2_TIM_FREQ = 80e6 // 80MHz
 
+// Flash is erased in pages of 2kB.
+const flashEraseBlockSize = 2048
+
 //---------- I2C related code
 
 // Gets the value for TIMINGR register
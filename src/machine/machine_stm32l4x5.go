@@ -15,6 +15,10 @@ func CPUFrequency() uint32 {
 const APB1_TIM_FREQ = 120e6 // 120MHz
 const APB2_TIM_FREQ = 120e6 // 120MHz
 
+// Flash is erased in pages of 4kB (when the flash is in the default dual bank
+// mode).
+const flashEraseBlockSize = 4096
+
 //---------- I2C related code
 
 // Gets the value for TIMINGR register
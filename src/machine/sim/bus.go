@@ -0,0 +1,74 @@
+package sim
+
+import "errors"
+
+// SPIDevice is a model of a device connected to an SPI bus.
+type SPIDevice interface {
+	// Transfer is called for each byte the program sends over the bus, and
+	// returns the byte the device sends back at the same time.
+	Transfer(w byte) byte
+}
+
+// I2CDevice is a model of a device connected to an I2C bus.
+type I2CDevice interface {
+	// Tx is called for every transaction with the device: the program first
+	// writes w to the device and then reads len(r) bytes into r. Either w or r
+	// may be empty.
+	Tx(w, r []byte) error
+}
+
+// ErrNoDevice is returned to the program when it tries to communicate with an
+// I2C address where no device is attached.
+var ErrNoDevice = errors.New("sim: no I2C device at this address")
+
+type i2cAddress struct {
+	bus  uint8
+	addr uint16
+}
+
+var (
+	spiDevices = map[uint8]SPIDevice{}
+	i2cDevices = map[i2cAddress]I2CDevice{}
+)
+
+// AttachSPI connects a device model to the given SPI bus. There can be only one
+// device per bus: use a chip select pin (see Pin.OnChange) in the model to
+// simulate multiple devices on the same bus.
+func AttachSPI(bus uint8, dev SPIDevice) {
+	lock.Lock()
+	spiDevices[bus] = dev
+	lock.Unlock()
+}
+
+// AttachI2C connects a device model to the given I2C bus, at the given
+// address.
+func AttachI2C(bus uint8, addr uint16, dev I2CDevice) {
+	lock.Lock()
+	i2cDevices[i2cAddress{bus, addr}] = dev
+	lock.Unlock()
+}
+
+// Registers is a model of a typical I2C device with up to 256 registers, such
+// as many sensors. The first byte of each write is the register address, which
+// is followed by the data to write. A read reads from the last register address
+// onwards. The address increments after each byte that is read or written.
+type Registers struct {
+	Data    [256]byte
+	address byte
+}
+
+// Tx implements I2CDevice.
+func (regs *Registers) Tx(w, r []byte) error {
+	if len(w) != 0 {
+		regs.address = w[0]
+		for _, b := range w[1:] {
+			regs.Data[regs.address] = b
+			regs.address++
+		}
+	}
+	for i := range r {
+		r[i] = regs.Data[regs.address]
+		regs.address++
+	}
+	return nil
+}
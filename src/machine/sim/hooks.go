@@ -0,0 +1,112 @@
+package sim
+
+// The functions in this file are called by the machine package to access the
+// simulated hardware. They are not meant to be called by tests.
+
+// GPIOConfigure configures the pin in the given mode.
+func GPIOConfigure(pin Pin, mode PinMode) {
+	lock.Lock()
+	pins[pin].mode = mode
+	lock.Unlock()
+}
+
+// GPIOSet sets the output level of the pin, and calls the OnChange callbacks
+// if the level changed.
+func GPIOSet(pin Pin, high bool) {
+	lock.Lock()
+	state := &pins[pin]
+	changed := state.output != high
+	state.output = high
+	callbacks := state.onChange
+	lock.Unlock()
+	if changed {
+		// Call the callbacks without holding the lock, so that they can use
+		// the simulator themselves.
+		for _, fn := range callbacks {
+			fn(high)
+		}
+	}
+}
+
+// GPIOGet returns the current level of the pin.
+func GPIOGet(pin Pin) bool {
+	lock.Lock()
+	defer lock.Unlock()
+	return pins[pin].level()
+}
+
+// ADCRead returns the analog value of the pin, as set by Pin.SetAnalog.
+func ADCRead(pin Pin) uint16 {
+	lock.Lock()
+	defer lock.Unlock()
+	return pins[pin].analog
+}
+
+// SPIConfigure configures the SPI bus. It doesn't do anything at the moment.
+func SPIConfigure(bus uint8) {
+}
+
+// SPITransfer sends a single byte to the device on the SPI bus, and returns
+// the byte it sent back. It returns 0 if no device is attached.
+func SPITransfer(bus uint8, w byte) byte {
+	lock.Lock()
+	dev := spiDevices[bus]
+	lock.Unlock()
+	if dev == nil {
+		return 0
+	}
+	return dev.Transfer(w)
+}
+
+// I2CConfigure configures the I2C bus. It doesn't do anything at the moment.
+func I2CConfigure(bus uint8) {
+}
+
+// I2CTx does a single I2C transaction with the device at the given address.
+func I2CTx(bus uint8, addr uint16, w, r []byte) error {
+	lock.Lock()
+	dev := i2cDevices[i2cAddress{bus, addr}]
+	lock.Unlock()
+	if dev == nil {
+		return ErrNoDevice
+	}
+	return dev.Tx(w, r)
+}
+
+// UARTConfigure configures the UART. It doesn't do anything at the moment.
+func UARTConfigure(bus uint8) {
+}
+
+// UARTRead reads data that was sent to the program (see UART.Send) into buf,
+// and returns the number of bytes read. It doesn't block.
+func UARTRead(bus uint8, buf []byte) int {
+	lock.Lock()
+	defer lock.Unlock()
+	state := getUART(UART(bus))
+	n := copy(buf, state.rx)
+	state.rx = state.rx[n:]
+	return n
+}
+
+// UARTWrite writes data from the program to the UART.
+func UARTWrite(bus uint8, data []byte) int {
+	lock.Lock()
+	defer lock.Unlock()
+	state := getUART(UART(bus))
+	state.tx = append(state.tx, data...)
+	if len(state.tx) > 2*maxUARTOutput {
+		// Drop the oldest output (but not every time something is written).
+		state.tx = append([]byte(nil), state.tx[len(state.tx)-maxUARTOutput:]...)
+	}
+	if state.writer != nil {
+		state.writer.Write(data)
+	}
+	return len(data)
+}
+
+// UARTBuffered returns the number of bytes that can be read from the UART.
+func UARTBuffered(bus uint8) int {
+	lock.Lock()
+	defer lock.Unlock()
+	return len(getUART(UART(bus)).rx)
+}
@@ -0,0 +1,259 @@
+package sim
+
+// This file implements a small scripting language to test a program running on
+// the simulated board. It's meant for quick tests of a program as a whole: for
+// more complicated tests, use the Go API of this package directly.
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is how long the expect and receive commands in a script wait
+// for the program, if no timeout is given.
+const DefaultTimeout = time.Second
+
+// RunScript runs a test script, while the program runs in the background. Each
+// line of the script is a single command, empty lines and lines starting with
+// # are ignored. Pins are given as numbers (as in the machine package) and
+// strings are quoted like in Go. These commands are supported:
+//
+//     wait <duration>                        wait for some time, like "wait 10ms"
+//     drive <pin> high|low                   drive an input pin high or low
+//     release <pin>                          stop driving an input pin
+//     analog <pin> <value>                   set the ADC value of a pin
+//     expect <pin> high|low [timeout]        wait until the pin has this level
+//     send <uart> "text"                     send text to the program
+//     receive <uart> "text" [timeout]        wait until the program wrote text
+//
+// The receive command consumes all output of the UART up to and including the
+// text, so that the next receive command only looks at output after it.
+//
+// RunScript returns an error for the first command that failed (for example, a
+// timeout in an expect command), or nil if all commands succeeded.
+func RunScript(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		args, err := splitScriptLine(line)
+		if err == nil {
+			err = runScriptCommand(args)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %w", lineNumber, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// splitScriptLine splits a line of a script into separate words, where quoted
+// strings are a single word.
+func splitScriptLine(line string) ([]string, error) {
+	var args []string
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return args, nil
+		}
+		if line[0] != '"' {
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			args = append(args, line[:end])
+			line = line[end:]
+			continue
+		}
+		// Find the end of the quoted string, skipping escaped characters.
+		end := 1
+		for end < len(line) && line[end] != '"' {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(line) {
+			return nil, errors.New("unterminated string")
+		}
+		s, err := strconv.Unquote(line[:end+1])
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, s)
+		line = line[end+1:]
+	}
+}
+
+// runScriptCommand runs a single script command.
+func runScriptCommand(args []string) error {
+	command := args[0]
+	args = args[1:]
+	switch command {
+	case "wait":
+		if len(args) != 1 {
+			return errors.New("expected: wait <duration>")
+		}
+		duration, err := time.ParseDuration(args[0])
+		if err != nil {
+			return err
+		}
+		time.Sleep(duration)
+	case "drive":
+		if len(args) != 2 {
+			return errors.New("expected: drive <pin> high|low")
+		}
+		pin, err := parsePin(args[0])
+		if err != nil {
+			return err
+		}
+		high, err := parseLevel(args[1])
+		if err != nil {
+			return err
+		}
+		pin.Drive(high)
+	case "release":
+		if len(args) != 1 {
+			return errors.New("expected: release <pin>")
+		}
+		pin, err := parsePin(args[0])
+		if err != nil {
+			return err
+		}
+		pin.Release()
+	case "analog":
+		if len(args) != 2 {
+			return errors.New("expected: analog <pin> <value>")
+		}
+		pin, err := parsePin(args[0])
+		if err != nil {
+			return err
+		}
+		value, err := strconv.ParseUint(args[1], 0, 16)
+		if err != nil {
+			return err
+		}
+		pin.SetAnalog(uint16(value))
+	case "expect":
+		if len(args) != 2 && len(args) != 3 {
+			return errors.New("expected: expect <pin> high|low [timeout]")
+		}
+		pin, err := parsePin(args[0])
+		if err != nil {
+			return err
+		}
+		high, err := parseLevel(args[1])
+		if err != nil {
+			return err
+		}
+		timeout, err := parseTimeout(args[2:])
+		if err != nil {
+			return err
+		}
+		if !waitFor(timeout, func() bool {
+			return pins[pin].level() == high
+		}) {
+			return fmt.Errorf("pin %d is not %s after %v", pin, args[1], timeout)
+		}
+	case "send":
+		if len(args) != 2 {
+			return errors.New(`expected: send <uart> "text"`)
+		}
+		uart, err := parseUART(args[0])
+		if err != nil {
+			return err
+		}
+		uart.Send([]byte(args[1]))
+	case "receive":
+		if len(args) != 2 && len(args) != 3 {
+			return errors.New(`expected: receive <uart> "text" [timeout]`)
+		}
+		uart, err := parseUART(args[0])
+		if err != nil {
+			return err
+		}
+		text := []byte(args[1])
+		timeout, err := parseTimeout(args[2:])
+		if err != nil {
+			return err
+		}
+		if !waitFor(timeout, func() bool {
+			state := getUART(uart)
+			index := bytes.Index(state.tx, text)
+			if index < 0 {
+				return false
+			}
+			state.tx = state.tx[index+len(text):]
+			return true
+		}) {
+			return fmt.Errorf("did not receive %q after %v", text, timeout)
+		}
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	return nil
+}
+
+// waitFor calls the condition function (with the lock held) until it returns
+// true, or until the timeout expires. It returns whether the condition became
+// true.
+func waitFor(timeout time.Duration, condition func() bool) bool {
+	start := time.Now()
+	for {
+		lock.Lock()
+		ok := condition()
+		lock.Unlock()
+		if ok {
+			return true
+		}
+		if time.Since(start) >= timeout {
+			return false
+		}
+		// Let the program run for a bit.
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func parsePin(s string) (Pin, error) {
+	n, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid pin %q", s)
+	}
+	return Pin(n), nil
+}
+
+func parseUART(s string) (UART, error) {
+	n, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid UART %q", s)
+	}
+	return UART(n), nil
+}
+
+func parseLevel(s string) (bool, error) {
+	switch s {
+	case "high":
+		return true, nil
+	case "low":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid level %q, expected high or low", s)
+	}
+}
+
+func parseTimeout(args []string) (time.Duration, error) {
+	if len(args) == 0 {
+		return DefaultTimeout, nil
+	}
+	return time.ParseDuration(args[0])
+}
@@ -0,0 +1,35 @@
+//go:build !scheduler.none
+// +build !scheduler.none
+
+package sim
+
+import (
+	"fmt"
+	"os"
+)
+
+// When the TINYGO_SIM_SCRIPT environment variable is set, the script in this
+// file is run in the background while the program runs. The program exits once
+// the script finishes: with exit code 0 if all commands succeeded and 1
+// otherwise. This makes it possible to test a program without writing any Go
+// code, for example:
+//
+//     TINYGO_SIM_SCRIPT=blinky.txt tinygo run -target=sim-arduino ./blinky
+func init() {
+	path := os.Getenv("TINYGO_SIM_SCRIPT")
+	if path == "" {
+		return
+	}
+	go func() {
+		f, err := os.Open(path)
+		if err == nil {
+			err = RunScript(f)
+			f.Close()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "sim: %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}()
+}
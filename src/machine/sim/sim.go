@@ -0,0 +1,106 @@
+// Package sim implements a simulated board, so that programs and drivers can
+// be tested on a regular computer without any hardware.
+//
+// When a program is built for a simulator target (such as sim-arduino), the
+// machine package doesn't access any hardware but uses the simulated board in
+// this package instead. A test can then drive input pins, attach models of SPI
+// and I2C devices and check what the program wrote to output pins and UARTs,
+// either directly using this package or using a script (see RunScript).
+//
+// Pins and buses are identified by the same numbers as in the machine package,
+// for example the simulated LED can be inspected using sim.Pin(machine.LED).
+package sim
+
+import (
+	"sync"
+)
+
+// lock protects all state of the simulated board, which is shared between the
+// program and the device models or test script.
+var lock sync.Mutex
+
+// PinMode is the mode a pin has been configured in by the program. The values
+// are the same as the PinMode constants in the machine package.
+type PinMode uint8
+
+const (
+	PinInput PinMode = iota
+	PinOutput
+	PinInputPullup
+	PinInputPulldown
+)
+
+// Pin is a single GPIO pin of the simulated board.
+type Pin uint8
+
+type pinState struct {
+	mode     PinMode
+	output   bool   // level set by the program, for output pins
+	driven   bool   // whether the pin is driven from outside (see Drive)
+	input    bool   // level the pin is driven at, if driven is true
+	analog   uint16 // value returned by the ADC
+	onChange []func(high bool)
+}
+
+var pins [256]pinState
+
+// Drive drives the pin high or low from outside, as would happen when a button
+// is pressed or when another chip drives the pin. This only affects input pins.
+func (p Pin) Drive(high bool) {
+	lock.Lock()
+	pins[p].driven = true
+	pins[p].input = high
+	lock.Unlock()
+}
+
+// Release stops driving the pin from outside, so that it reads as the level of
+// the pull-up or pull-down resistor (if any).
+func (p Pin) Release() {
+	lock.Lock()
+	pins[p].driven = false
+	lock.Unlock()
+}
+
+// Level returns the current level of the pin: the level set by the program for
+// output pins, and the level it is driven at (or pulled to) for input pins.
+func (p Pin) Level() bool {
+	lock.Lock()
+	defer lock.Unlock()
+	return pins[p].level()
+}
+
+func (state *pinState) level() bool {
+	switch {
+	case state.mode == PinOutput:
+		return state.output
+	case state.driven:
+		return state.input
+	default:
+		return state.mode == PinInputPullup
+	}
+}
+
+// Mode returns the mode the pin has been configured in. Pins that haven't been
+// configured are inputs.
+func (p Pin) Mode() PinMode {
+	lock.Lock()
+	defer lock.Unlock()
+	return pins[p].mode
+}
+
+// OnChange registers a function that is called each time the program changes
+// the level of this (output) pin. It can be used to model a device that reacts
+// to a pin, such as the chip select pin of an SPI device.
+func (p Pin) OnChange(fn func(high bool)) {
+	lock.Lock()
+	pins[p].onChange = append(pins[p].onChange, fn)
+	lock.Unlock()
+}
+
+// SetAnalog sets the value that is read by the program when using this pin as
+// an ADC.
+func (p Pin) SetAnalog(value uint16) {
+	lock.Lock()
+	pins[p].analog = value
+	lock.Unlock()
+}
@@ -0,0 +1,62 @@
+package sim
+
+import (
+	"io"
+	"os"
+)
+
+// Only the last part (at least this many bytes) of the output of each UART is
+// kept, so that programs that print a lot of output don't run out of memory.
+const maxUARTOutput = 64 * 1024
+
+// UART is a UART of the simulated board, identified by its bus number.
+type UART uint8
+
+type uartState struct {
+	rx     []byte // data that hasn't been read by the program yet
+	tx     []byte // data written by the program that hasn't been read yet
+	writer io.Writer
+}
+
+var uarts = map[UART]*uartState{
+	// Show the output of the default UART (machine.Serial), like on a real
+	// board with a serial monitor.
+	0: {writer: os.Stdout},
+}
+
+func getUART(uart UART) *uartState {
+	state := uarts[uart]
+	if state == nil {
+		state = &uartState{}
+		uarts[uart] = state
+	}
+	return state
+}
+
+// Send sends data to the program, which it can read from the UART.
+func (uart UART) Send(data []byte) {
+	lock.Lock()
+	state := getUART(uart)
+	state.rx = append(state.rx, data...)
+	lock.Unlock()
+}
+
+// Output returns all data the program has written to the UART since the last
+// call to Output (or since the data was received in a script).
+func (uart UART) Output() []byte {
+	lock.Lock()
+	defer lock.Unlock()
+	state := getUART(uart)
+	data := state.tx
+	state.tx = nil
+	return data
+}
+
+// SetWriter sets the writer to which all data written by the program to this
+// UART is copied, for example os.Stdout. It can be set to nil to stop copying
+// data. By default, data written to UART 0 is copied to os.Stdout.
+func (uart UART) SetWriter(w io.Writer) {
+	lock.Lock()
+	getUART(uart).writer = w
+	lock.Unlock()
+}
@@ -1,4 +1,5 @@
-// +build sam nrf52840
+//go:build sam || nrf52840 || rp2040
+// +build sam nrf52840 rp2040
 
 package machine
 
@@ -400,13 +401,41 @@ func (d CDCDescriptor) Bytes() [cdcSize]byte {
 	return b
 }
 
-// MSCDescriptor is not used yet.
+// MSCDescriptor is the Mass Storage Class (MSC) descriptor.
 type MSCDescriptor struct {
 	msc InterfaceDescriptor
 	in  EndpointDescriptor
 	out EndpointDescriptor
 }
 
+func NewMSCDescriptor(m InterfaceDescriptor, inp EndpointDescriptor, outp EndpointDescriptor) MSCDescriptor {
+	return MSCDescriptor{msc: m, in: inp, out: outp}
+}
+
+const mscSize = interfaceDescriptorSize +
+	endpointDescriptorSize +
+	endpointDescriptorSize
+
+// Bytes returns MSCDescriptor data.
+func (d MSCDescriptor) Bytes() [mscSize]byte {
+	var b [mscSize]byte
+	offset := 0
+
+	msc := d.msc.Bytes()
+	copy(b[offset:], msc[:])
+	offset += len(msc)
+
+	in := d.in.Bytes()
+	copy(b[offset:], in[:])
+	offset += len(in)
+
+	out := d.out.Bytes()
+	copy(b[offset:], out[:])
+	offset += len(out)
+
+	return b
+}
+
 const cdcLineInfoSize = 7
 
 type cdcLineInfo struct {
@@ -460,6 +489,7 @@ const (
 
 	usbEndpointPacketSize = 64 // 64 for Full Speed, EPT size max is 1024
 	usb_EPT_NUM           = 7
+	usbControlBufferSize  = 256 // size of the control endpoint buffer for descriptors
 
 	// standard requests
 	usb_GET_STATUS        = 0
@@ -531,6 +561,18 @@ const (
 
 	usb_CDC_LINESTATE_DTR = 0x01
 	usb_CDC_LINESTATE_RTS = 0x02
+
+	// HID
+	usb_HID_DESCRIPTOR_TYPE        = 0x21
+	usb_HID_REPORT_DESCRIPTOR_TYPE = 0x22
+
+	// HID Class requests
+	usb_HID_GET_REPORT   = 0x01
+	usb_HID_GET_IDLE     = 0x02
+	usb_HID_GET_PROTOCOL = 0x03
+	usb_HID_SET_REPORT   = 0x09
+	usb_HID_SET_IDLE     = 0x0A
+	usb_HID_SET_PROTOCOL = 0x0B
 )
 
 // usbDeviceDescBank is the USB device endpoint descriptor.
@@ -696,59 +738,83 @@ func sendDescriptor(setup usbSetup) {
 		return
 	}
 
+	// class specific descriptors
+	if c := usbRequestClass(setup); c != nil && c.getDescriptor != nil && c.getDescriptor(setup) {
+		return
+	}
+
 	// do not know how to handle this message, so return zero
 	sendZlp()
 	return
 }
 
-// sendConfiguration creates and sends the configuration packet to the host.
+// sendConfiguration sends the configuration descriptor, which was assembled
+// while the USB classes were registered, to the host.
 func sendConfiguration(setup usbSetup) {
-	if setup.wLength == 9 {
-		sz := uint16(configDescriptorSize + cdcSize)
-		config := NewConfigDescriptor(sz, 2)
-		configBuf := config.Bytes()
-		sendUSBPacket(0, configBuf[:])
-	} else {
-		iad := NewIADDescriptor(0, 2, usb_CDC_COMMUNICATION_INTERFACE_CLASS, usb_CDC_ABSTRACT_CONTROL_MODEL, 0)
+	// The host usually asks for the configuration descriptor only first, to
+	// find out the total size, and then for the whole configuration.
+	buf := usbConfigDescriptor
+	if int(setup.wLength) < len(buf) {
+		buf = buf[:setup.wLength]
+	}
+	sendUSBPacket(0, buf)
+}
 
-		cif := NewInterfaceDescriptor(usb_CDC_ACM_INTERFACE, 1, usb_CDC_COMMUNICATION_INTERFACE_CLASS, usb_CDC_ABSTRACT_CONTROL_MODEL, 0)
+// usbCDCClass is the CDC serial port, which is always the first class of the
+// USB device.
+var usbCDCClass = usbClass{
+	interfaces: 2,
+	endpoints: []uint32{
+		usb_ENDPOINT_TYPE_INTERRUPT | usbEndpointIn,
+		usb_ENDPOINT_TYPE_BULK | usbEndpointOut,
+		usb_ENDPOINT_TYPE_BULK | usbEndpointIn,
+	},
+	descriptor: cdcDescriptor,
+	setup:      cdcSetup,
+	rx:         cdcRx,
+	tx:         cdcTx,
+}
+
+// cdcDescriptor returns the interfaces of the CDC serial port.
+func cdcDescriptor(c *usbClass) []byte {
+	iad := NewIADDescriptor(0, 2, usb_CDC_COMMUNICATION_INTERFACE_CLASS, usb_CDC_ABSTRACT_CONTROL_MODEL, 0)
 
-		header := NewCDCCSInterfaceDescriptor(usb_CDC_HEADER, usb_CDC_V1_10&0xFF, (usb_CDC_V1_10>>8)&0x0FF)
+	cif := NewInterfaceDescriptor(usb_CDC_ACM_INTERFACE, 1, usb_CDC_COMMUNICATION_INTERFACE_CLASS, usb_CDC_ABSTRACT_CONTROL_MODEL, 0)
 
-		controlManagement := NewACMFunctionalDescriptor(usb_CDC_ABSTRACT_CONTROL_MANAGEMENT, 6)
+	header := NewCDCCSInterfaceDescriptor(usb_CDC_HEADER, usb_CDC_V1_10&0xFF, (usb_CDC_V1_10>>8)&0x0FF)
 
-		functionalDescriptor := NewCDCCSInterfaceDescriptor(usb_CDC_UNION, usb_CDC_ACM_INTERFACE, usb_CDC_DATA_INTERFACE)
+	controlManagement := NewACMFunctionalDescriptor(usb_CDC_ABSTRACT_CONTROL_MANAGEMENT, 6)
 
-		callManagement := NewCMFunctionalDescriptor(usb_CDC_CALL_MANAGEMENT, 1, 1)
+	functionalDescriptor := NewCDCCSInterfaceDescriptor(usb_CDC_UNION, usb_CDC_ACM_INTERFACE, usb_CDC_DATA_INTERFACE)
 
-		cifin := NewEndpointDescriptor((usb_CDC_ENDPOINT_ACM | usbEndpointIn), usb_ENDPOINT_TYPE_INTERRUPT, 0x10, 0x10)
+	callManagement := NewCMFunctionalDescriptor(usb_CDC_CALL_MANAGEMENT, 1, 1)
 
-		dif := NewInterfaceDescriptor(usb_CDC_DATA_INTERFACE, 2, usb_CDC_DATA_INTERFACE_CLASS, 0, 0)
+	cifin := NewEndpointDescriptor((usb_CDC_ENDPOINT_ACM | usbEndpointIn), usb_ENDPOINT_TYPE_INTERRUPT, 0x10, 0x10)
 
-		out := NewEndpointDescriptor((usb_CDC_ENDPOINT_OUT | usbEndpointOut), usb_ENDPOINT_TYPE_BULK, usbEndpointPacketSize, 0)
+	dif := NewInterfaceDescriptor(usb_CDC_DATA_INTERFACE, 2, usb_CDC_DATA_INTERFACE_CLASS, 0, 0)
 
-		in := NewEndpointDescriptor((usb_CDC_ENDPOINT_IN | usbEndpointIn), usb_ENDPOINT_TYPE_BULK, usbEndpointPacketSize, 0)
+	out := NewEndpointDescriptor((usb_CDC_ENDPOINT_OUT | usbEndpointOut), usb_ENDPOINT_TYPE_BULK, usbEndpointPacketSize, 0)
 
-		cdc := NewCDCDescriptor(iad,
-			cif,
-			header,
-			controlManagement,
-			functionalDescriptor,
-			callManagement,
-			cifin,
-			dif,
-			out,
-			in)
+	in := NewEndpointDescriptor((usb_CDC_ENDPOINT_IN | usbEndpointIn), usb_ENDPOINT_TYPE_BULK, usbEndpointPacketSize, 0)
 
-		sz := uint16(configDescriptorSize + cdcSize)
-		config := NewConfigDescriptor(sz, 2)
+	cdc := NewCDCDescriptor(iad,
+		cif,
+		header,
+		controlManagement,
+		functionalDescriptor,
+		callManagement,
+		cifin,
+		dif,
+		out,
+		in)
 
-		configBuf := config.Bytes()
-		cdcBuf := cdc.Bytes()
-		var buf [configDescriptorSize + cdcSize]byte
-		copy(buf[0:], configBuf[:])
-		copy(buf[configDescriptorSize:], cdcBuf[:])
+	cdcBuf := cdc.Bytes()
+	return cdcBuf[:]
+}
 
-		sendUSBPacket(0, buf[:])
+// cdcRx moves the data received from the host to the RX buffer.
+func cdcRx(ep uint32, data []byte) {
+	for _, c := range data {
+		USB.Receive(c)
 	}
 }
@@ -0,0 +1,90 @@
+//go:build sam || nrf52840 || rp2040
+// +build sam nrf52840 rp2040
+
+package hid
+
+import "machine"
+
+// GamepadAxis is one of the analog axes of a gamepad.
+type GamepadAxis uint8
+
+const (
+	AxisX GamepadAxis = iota
+	AxisY
+	AxisZ
+	AxisRz
+)
+
+// Hat is the direction of the hat switch (D-pad) of a gamepad.
+type Hat uint8
+
+const (
+	HatCentered Hat = iota
+	HatUp
+	HatUpRight
+	HatRight
+	HatDownRight
+	HatDown
+	HatDownLeft
+	HatLeft
+	HatUpLeft
+)
+
+// Gamepad is a USB gamepad with 16 buttons, four axes and a hat switch. The
+// state is changed with the Set methods and sent to the host with Send. There
+// is only one gamepad report, so a program should use a single Gamepad.
+type Gamepad struct {
+	buttons uint16
+	axes    [4]int8
+	hat     Hat
+}
+
+// SetButton sets the state of the given button, numbered 0 to 15.
+func (g *Gamepad) SetButton(button int, pressed bool) {
+	if button < 0 || button >= 16 {
+		return
+	}
+	if pressed {
+		g.buttons |= 1 << button
+	} else {
+		g.buttons &^= 1 << button
+	}
+}
+
+// SetAxis sets the position of the given axis.
+func (g *Gamepad) SetAxis(axis GamepadAxis, value int8) {
+	if int(axis) < len(g.axes) {
+		if value == -128 {
+			value = -127
+		}
+		g.axes[axis] = value
+	}
+}
+
+// SetHat sets the direction of the hat switch.
+func (g *Gamepad) SetHat(hat Hat) {
+	if hat <= HatUpLeft {
+		g.hat = hat
+	}
+}
+
+// Send sends the current state of the gamepad to the host.
+func (g *Gamepad) Send() error {
+	// The hat switch is reported as 0 (up) to 7 (up left), a value outside of
+	// that range means it is centered.
+	hat := byte(8)
+	if g.hat != HatCentered {
+		hat = byte(g.hat - HatUp)
+	}
+	report := [8]byte{
+		gamepadReportID,
+		byte(g.buttons),
+		byte(g.buttons >> 8),
+		byte(g.axes[AxisX]),
+		byte(g.axes[AxisY]),
+		byte(g.axes[AxisZ]),
+		byte(g.axes[AxisRz]),
+		hat,
+	}
+	return machine.SendHIDReport(report[:])
+}
@@ -0,0 +1,130 @@
+//go:build sam || nrf52840 || rp2040
+// +build sam nrf52840 rp2040
+
+// Package hid implements a USB keyboard, mouse and gamepad. Importing this
+// package adds a HID interface to the USB device, next to the USB CDC serial
+// port, which is used by all of them.
+//
+// The USB device is started together with the USB CDC serial port, so on
+// boards that use a UART by default (like the Raspberry Pi Pico) the program
+// must be built with -serial=usb.
+package hid
+
+import (
+	"errors"
+	"machine"
+)
+
+// Report IDs of the reports in the report descriptor.
+const (
+	keyboardReportID = 1
+	mouseReportID    = 2
+	gamepadReportID  = 3
+)
+
+var (
+	errUnsupportedCharacter = errors.New("hid: unsupported character")
+	errTooManyKeys          = errors.New("hid: too many keys pressed")
+)
+
+// reportDescriptor describes the reports of a boot-compatible keyboard, a
+// five-button mouse with a scroll wheel and a gamepad with 16 buttons, 4 axes
+// and a hat switch.
+var reportDescriptor = []byte{
+	// Keyboard
+	0x05, 0x01, // Usage Page (Generic Desktop)
+	0x09, 0x06, // Usage (Keyboard)
+	0xa1, 0x01, // Collection (Application)
+	0x85, keyboardReportID, // Report ID
+	0x05, 0x07, //   Usage Page (Keyboard/Keypad)
+	0x19, 0xe0, //   Usage Minimum (Left Control)
+	0x29, 0xe7, //   Usage Maximum (Right GUI)
+	0x15, 0x00, //   Logical Minimum (0)
+	0x25, 0x01, //   Logical Maximum (1)
+	0x75, 0x01, //   Report Size (1)
+	0x95, 0x08, //   Report Count (8)
+	0x81, 0x02, //   Input (Data, Variable, Absolute): modifiers
+	0x75, 0x08, //   Report Size (8)
+	0x95, 0x01, //   Report Count (1)
+	0x81, 0x01, //   Input (Constant): reserved
+	0x19, 0x00, //   Usage Minimum (0)
+	0x29, 0x73, //   Usage Maximum (F24)
+	0x15, 0x00, //   Logical Minimum (0)
+	0x25, 0x73, //   Logical Maximum (F24)
+	0x75, 0x08, //   Report Size (8)
+	0x95, 0x06, //   Report Count (6)
+	0x81, 0x00, //   Input (Data, Array, Absolute): keys
+	0xc0, // End Collection
+
+	// Mouse
+	0x05, 0x01, // Usage Page (Generic Desktop)
+	0x09, 0x02, // Usage (Mouse)
+	0xa1, 0x01, // Collection (Application)
+	0x85, mouseReportID, // Report ID
+	0x09, 0x01, //   Usage (Pointer)
+	0xa1, 0x00, //   Collection (Physical)
+	0x05, 0x09, //     Usage Page (Button)
+	0x19, 0x01, //     Usage Minimum (1)
+	0x29, 0x05, //     Usage Maximum (5)
+	0x15, 0x00, //     Logical Minimum (0)
+	0x25, 0x01, //     Logical Maximum (1)
+	0x75, 0x01, //     Report Size (1)
+	0x95, 0x05, //     Report Count (5)
+	0x81, 0x02, //     Input (Data, Variable, Absolute): buttons
+	0x75, 0x03, //     Report Size (3)
+	0x95, 0x01, //     Report Count (1)
+	0x81, 0x03, //     Input (Constant, Variable, Absolute): padding
+	0x05, 0x01, //     Usage Page (Generic Desktop)
+	0x09, 0x30, //     Usage (X)
+	0x09, 0x31, //     Usage (Y)
+	0x09, 0x38, //     Usage (Wheel)
+	0x15, 0x81, //     Logical Minimum (-127)
+	0x25, 0x7f, //     Logical Maximum (127)
+	0x75, 0x08, //     Report Size (8)
+	0x95, 0x03, //     Report Count (3)
+	0x81, 0x06, //     Input (Data, Variable, Relative)
+	0xc0, //   End Collection
+	0xc0, // End Collection
+
+	// Gamepad
+	0x05, 0x01, // Usage Page (Generic Desktop)
+	0x09, 0x05, // Usage (Game Pad)
+	0xa1, 0x01, // Collection (Application)
+	0x85, gamepadReportID, // Report ID
+	0x05, 0x09, //   Usage Page (Button)
+	0x19, 0x01, //   Usage Minimum (1)
+	0x29, 0x10, //   Usage Maximum (16)
+	0x15, 0x00, //   Logical Minimum (0)
+	0x25, 0x01, //   Logical Maximum (1)
+	0x75, 0x01, //   Report Size (1)
+	0x95, 0x10, //   Report Count (16)
+	0x81, 0x02, //   Input (Data, Variable, Absolute): buttons
+	0x05, 0x01, //   Usage Page (Generic Desktop)
+	0x09, 0x30, //   Usage (X)
+	0x09, 0x31, //   Usage (Y)
+	0x09, 0x32, //   Usage (Z)
+	0x09, 0x35, //   Usage (Rz)
+	0x15, 0x81, //   Logical Minimum (-127)
+	0x25, 0x7f, //   Logical Maximum (127)
+	0x75, 0x08, //   Report Size (8)
+	0x95, 0x04, //   Report Count (4)
+	0x81, 0x02, //   Input (Data, Variable, Absolute): axes
+	0x09, 0x39, //   Usage (Hat switch)
+	0x15, 0x00, //   Logical Minimum (0)
+	0x25, 0x07, //   Logical Maximum (7)
+	0x35, 0x00, //   Physical Minimum (0)
+	0x46, 0x3b, 0x01, //   Physical Maximum (315)
+	0x65, 0x14, //   Unit (Degrees)
+	0x75, 0x04, //   Report Size (4)
+	0x95, 0x01, //   Report Count (1)
+	0x81, 0x42, //   Input (Data, Variable, Absolute, Null State): hat
+	0x65, 0x00, //   Unit (None)
+	0x81, 0x03, //   Input (Constant, Variable, Absolute): padding
+	0xc0, // End Collection
+}
+
+func init() {
+	if err := machine.EnableHID(reportDescriptor); err != nil {
+		panic("hid: " + err.Error())
+	}
+}
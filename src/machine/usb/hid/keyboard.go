@@ -0,0 +1,250 @@
+//go:build sam || nrf52840 || rp2040
+// +build sam nrf52840 rp2040
+
+package hid
+
+import "machine"
+
+// Keycode is a HID usage ID of the Keyboard/Keypad usage page, which
+// identifies a key on the keyboard (not the character it produces).
+type Keycode uint8
+
+// Keycodes of a US keyboard.
+const (
+	KeyA Keycode = 0x04 + iota
+	KeyB
+	KeyC
+	KeyD
+	KeyE
+	KeyF
+	KeyG
+	KeyH
+	KeyI
+	KeyJ
+	KeyK
+	KeyL
+	KeyM
+	KeyN
+	KeyO
+	KeyP
+	KeyQ
+	KeyR
+	KeyS
+	KeyT
+	KeyU
+	KeyV
+	KeyW
+	KeyX
+	KeyY
+	KeyZ
+	Key1
+	Key2
+	Key3
+	Key4
+	Key5
+	Key6
+	Key7
+	Key8
+	Key9
+	Key0
+	KeyEnter
+	KeyEscape
+	KeyBackspace
+	KeyTab
+	KeySpace
+	KeyMinus
+	KeyEqual
+	KeyLeftBrace
+	KeyRightBrace
+	KeyBackslash
+	KeyNonUSHash
+	KeySemicolon
+	KeyQuote
+	KeyGrave
+	KeyComma
+	KeyPeriod
+	KeySlash
+	KeyCapsLock
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeyPrintScreen
+	KeyScrollLock
+	KeyPause
+	KeyInsert
+	KeyHome
+	KeyPageUp
+	KeyDelete
+	KeyEnd
+	KeyPageDown
+	KeyRight
+	KeyLeft
+	KeyDown
+	KeyUp
+)
+
+// Modifier keys. They are reported separately from the other keys, so any
+// number of them can be pressed at the same time.
+const (
+	KeyLeftCtrl Keycode = 0xe0 + iota
+	KeyLeftShift
+	KeyLeftAlt
+	KeyLeftGUI
+	KeyRightCtrl
+	KeyRightShift
+	KeyRightAlt
+	KeyRightGUI
+)
+
+// Punctuation characters on a US keyboard. The characters in punctuation are
+// typed with the key at the same index in punctuationKeys, the characters in
+// shiftedPunctuation with the same key while shift is held.
+const (
+	punctuation        = " -=[]\\;'`,./"
+	shiftedPunctuation = " _+{}|:\"~<>?"
+	shiftedDigits      = ")!@#$%^&*("
+)
+
+var punctuationKeys = [...]Keycode{KeySpace, KeyMinus, KeyEqual, KeyLeftBrace, KeyRightBrace, KeyBackslash, KeySemicolon, KeyQuote, KeyGrave, KeyComma, KeyPeriod, KeySlash}
+
+// Keyboard is a USB keyboard. Up to six keys and all modifier keys can be
+// pressed at the same time. There is only one keyboard report, so a program
+// should use a single Keyboard.
+type Keyboard struct {
+	modifiers uint8
+	keys      [6]Keycode
+}
+
+// Press presses the given key and sends the new state to the host.
+func (k *Keyboard) Press(key Keycode) error {
+	if key >= KeyLeftCtrl && key <= KeyRightGUI {
+		k.modifiers |= 1 << (key - KeyLeftCtrl)
+		return k.send()
+	}
+	free := -1
+	for i, pressed := range k.keys {
+		if pressed == key {
+			return nil
+		}
+		if pressed == 0 && free < 0 {
+			free = i
+		}
+	}
+	if free < 0 {
+		return errTooManyKeys
+	}
+	k.keys[free] = key
+	return k.send()
+}
+
+// Release releases the given key and sends the new state to the host.
+func (k *Keyboard) Release(key Keycode) error {
+	if key >= KeyLeftCtrl && key <= KeyRightGUI {
+		k.modifiers &^= 1 << (key - KeyLeftCtrl)
+		return k.send()
+	}
+	for i, pressed := range k.keys {
+		if pressed == key {
+			k.keys[i] = 0
+		}
+	}
+	return k.send()
+}
+
+// ReleaseAll releases all keys, including the modifier keys.
+func (k *Keyboard) ReleaseAll() error {
+	*k = Keyboard{}
+	return k.send()
+}
+
+// WriteByte types the given ASCII character, by pressing and releasing the
+// key (and shift if needed) that produces it on a US keyboard. Keys that are
+// pressed with Press stay pressed.
+func (k *Keyboard) WriteByte(c byte) error {
+	key, shift, ok := asciiKey(c)
+	if !ok {
+		return errUnsupportedCharacter
+	}
+	shifted := shift && k.modifiers&(1<<(KeyLeftShift-KeyLeftCtrl)) == 0
+	if shifted {
+		if err := k.Press(KeyLeftShift); err != nil {
+			return err
+		}
+	}
+	if err := k.Press(key); err != nil {
+		return err
+	}
+	if err := k.Release(key); err != nil {
+		return err
+	}
+	if shifted {
+		return k.Release(KeyLeftShift)
+	}
+	return nil
+}
+
+// Write types the given ASCII text. See WriteByte for details.
+func (k *Keyboard) Write(text []byte) (n int, err error) {
+	for _, c := range text {
+		if err := k.WriteByte(c); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (k *Keyboard) send() error {
+	report := [9]byte{keyboardReportID, k.modifiers}
+	for i, key := range k.keys {
+		report[3+i] = byte(key)
+	}
+	return machine.SendHIDReport(report[:])
+}
+
+// asciiKey returns the key that produces the given ASCII character on a US
+// keyboard and whether shift must be held for it.
+func asciiKey(c byte) (key Keycode, shift bool, ok bool) {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return KeyA + Keycode(c-'a'), false, true
+	case c >= 'A' && c <= 'Z':
+		return KeyA + Keycode(c-'A'), true, true
+	case c == '0':
+		return Key0, false, true
+	case c >= '1' && c <= '9':
+		return Key1 + Keycode(c-'1'), false, true
+	case c == '\n':
+		return KeyEnter, false, true
+	case c == '\t':
+		return KeyTab, false, true
+	case c == '\b':
+		return KeyBackspace, false, true
+	case c == 0x1b:
+		return KeyEscape, false, true
+	}
+	for i := 0; i < len(shiftedDigits); i++ {
+		if shiftedDigits[i] == c {
+			key, _, _ := asciiKey('0' + byte(i))
+			return key, true, true
+		}
+	}
+	for i := 0; i < len(punctuation); i++ {
+		if punctuation[i] == c {
+			return punctuationKeys[i], false, true
+		}
+		if shiftedPunctuation[i] == c {
+			return punctuationKeys[i], true, true
+		}
+	}
+	return 0, false, false
+}
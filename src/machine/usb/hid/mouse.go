@@ -0,0 +1,89 @@
+//go:build sam || nrf52840 || rp2040
+// +build sam nrf52840 rp2040
+
+package hid
+
+import "machine"
+
+// MouseButton is a bitmask of mouse buttons.
+type MouseButton uint8
+
+const (
+	MouseLeft MouseButton = 1 << iota
+	MouseRight
+	MouseMiddle
+	MouseBack
+	MouseForward
+)
+
+// Mouse is a USB mouse with five buttons and a scroll wheel. There is only one
+// mouse report, so a program should use a single Mouse.
+type Mouse struct {
+	buttons MouseButton
+}
+
+// Move moves the mouse pointer by the given (relative) amount.
+func (m *Mouse) Move(dx, dy int) error {
+	for {
+		x, y := clamp(dx), clamp(dy)
+		if err := m.send(x, y, 0); err != nil {
+			return err
+		}
+		dx -= int(x)
+		dy -= int(y)
+		if dx == 0 && dy == 0 {
+			return nil
+		}
+	}
+}
+
+// Wheel scrolls the scroll wheel by the given amount. Positive values scroll
+// up.
+func (m *Mouse) Wheel(delta int) error {
+	for {
+		w := clamp(delta)
+		if err := m.send(0, 0, w); err != nil {
+			return err
+		}
+		delta -= int(w)
+		if delta == 0 {
+			return nil
+		}
+	}
+}
+
+// Press presses the given mouse buttons.
+func (m *Mouse) Press(buttons MouseButton) error {
+	m.buttons |= buttons
+	return m.send(0, 0, 0)
+}
+
+// Release releases the given mouse buttons.
+func (m *Mouse) Release(buttons MouseButton) error {
+	m.buttons &^= buttons
+	return m.send(0, 0, 0)
+}
+
+// Click presses and releases the given mouse buttons.
+func (m *Mouse) Click(buttons MouseButton) error {
+	if err := m.Press(buttons); err != nil {
+		return err
+	}
+	return m.Release(buttons)
+}
+
+func (m *Mouse) send(x, y, wheel int8) error {
+	report := [5]byte{mouseReportID, byte(m.buttons), byte(x), byte(y), byte(wheel)}
+	return machine.SendHIDReport(report[:])
+}
+
+// clamp limits v to the range of a relative axis in a report.
+func clamp(v int) int8 {
+	if v > 127 {
+		return 127
+	}
+	if v < -127 {
+		return -127
+	}
+	return int8(v)
+}
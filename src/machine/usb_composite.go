@@ -0,0 +1,145 @@
+//go:build sam || nrf52840 || rp2040
+// +build sam nrf52840 rp2040
+
+package machine
+
+import "errors"
+
+var (
+	errUSBTooManyEndpoints   = errors.New("USB: not enough endpoints left")
+	errUSBDescriptorTooLarge = errors.New("USB: configuration descriptor too large")
+)
+
+// usbClass is a function of the composite USB device, like the CDC serial port
+// or a HID interface. Classes are registered with registerUSBClass, which
+// assigns their interface and endpoint numbers and adds their descriptors to
+// the configuration descriptor.
+type usbClass struct {
+	// interfaces is the number of interfaces of the class.
+	interfaces uint8
+
+	// endpoints contains the type of each endpoint of the class, for example
+	// usb_ENDPOINT_TYPE_BULK | usbEndpointIn.
+	endpoints []uint32
+
+	// descriptor returns the interface descriptors of the class, including the
+	// class specific and endpoint descriptors, as they are included in the
+	// configuration descriptor.
+	descriptor func(c *usbClass) []byte
+
+	// getDescriptor sends a class specific descriptor (for example a HID
+	// report descriptor) and returns whether it knows the descriptor. It may be
+	// nil.
+	getDescriptor func(setup usbSetup) bool
+
+	// setup handles a class or vendor request sent to one of the interfaces or
+	// endpoints of the class. It returns false if the request isn't supported,
+	// which stalls the control endpoint.
+	setup func(setup usbSetup) bool
+
+	// rx is called from the USB interrupt with the data received on an OUT
+	// endpoint. It may be nil.
+	rx func(ep uint32, data []byte)
+
+	// tx is called from the USB interrupt when the host has picked up the
+	// packet sent on an IN endpoint. It may be nil.
+	tx func(ep uint32)
+
+	// The numbers of the first interface and endpoint of the class, assigned by
+	// registerUSBClass.
+	firstInterface uint8
+	firstEndpoint  uint8
+}
+
+var (
+	usbClasses []*usbClass
+
+	// The endpoints of all registered classes. Endpoint 0 is the control
+	// endpoint.
+	usbEndpointCount = uint32(1)
+	usbEndpointTypes [usb_EPT_NUM]uint32
+	usbEndpointClass [usb_EPT_NUM]*usbClass
+
+	// usbConfigDescriptor is the configuration descriptor, which is assembled
+	// while the classes are registered.
+	usbConfigDescriptor = make([]byte, configDescriptorSize, 128)
+	usbInterfaceCount   uint8
+)
+
+func init() {
+	// The CDC serial port is always registered first, so that it uses the
+	// fixed interface and endpoint numbers (usb_CDC_*) of the drivers.
+	registerUSBClass(&usbCDCClass)
+}
+
+// registerUSBClass adds a class to the USB device. It must be called before the
+// host enumerates the device, usually from an init function.
+func registerUSBClass(c *usbClass) error {
+	if int(usbEndpointCount)+len(c.endpoints) > usb_EPT_NUM {
+		return errUSBTooManyEndpoints
+	}
+	c.firstInterface = usbInterfaceCount
+	c.firstEndpoint = uint8(usbEndpointCount)
+	descriptor := c.descriptor(c)
+	if len(usbConfigDescriptor)+len(descriptor) > usbControlBufferSize {
+		return errUSBDescriptorTooLarge
+	}
+
+	for i, typ := range c.endpoints {
+		usbEndpointTypes[c.firstEndpoint+uint8(i)] = typ
+		usbEndpointClass[c.firstEndpoint+uint8(i)] = c
+	}
+	usbEndpointCount += uint32(len(c.endpoints))
+	usbInterfaceCount += c.interfaces
+	usbClasses = append(usbClasses, c)
+
+	usbConfigDescriptor = append(usbConfigDescriptor, descriptor...)
+	config := NewConfigDescriptor(uint16(len(usbConfigDescriptor)), usbInterfaceCount)
+	configBuf := config.Bytes()
+	copy(usbConfigDescriptor, configBuf[:])
+	return nil
+}
+
+// usbRequestClass returns the class that handles a class or vendor request, or
+// a class specific descriptor, based on the interface or endpoint it is sent
+// to.
+func usbRequestClass(setup usbSetup) *usbClass {
+	switch setup.bmRequestType & usb_REQUEST_RECIPIENT {
+	case usb_REQUEST_INTERFACE:
+		n := uint8(setup.wIndex)
+		for _, c := range usbClasses {
+			if n >= c.firstInterface && n < c.firstInterface+c.interfaces {
+				return c
+			}
+		}
+	case usb_REQUEST_ENDPOINT:
+		if ep := setup.wIndex & 0x0f; ep < usb_EPT_NUM {
+			return usbEndpointClass[ep]
+		}
+	}
+	return nil
+}
+
+// usbClassSetup passes a class or vendor request to the class it is meant for.
+func usbClassSetup(setup usbSetup) bool {
+	c := usbRequestClass(setup)
+	if c == nil || c.setup == nil {
+		return false
+	}
+	return c.setup(setup)
+}
+
+// usbClassRx passes the data received on an OUT endpoint to its class.
+func usbClassRx(ep uint32, data []byte) {
+	if c := usbEndpointClass[ep]; c != nil && c.rx != nil {
+		c.rx(ep, data)
+	}
+}
+
+// usbClassTx tells the class of an IN endpoint that the host picked up the
+// last packet.
+func usbClassTx(ep uint32) {
+	if c := usbEndpointClass[ep]; c != nil && c.tx != nil {
+		c.tx(ep)
+	}
+}
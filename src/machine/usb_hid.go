@@ -0,0 +1,180 @@
+//go:build sam || nrf52840 || rp2040
+// +build sam nrf52840 rp2040
+
+package machine
+
+import "errors"
+
+var (
+	errUSBHIDEnabled       = errors.New("USB-HID already enabled")
+	errUSBHIDNotEnabled    = errors.New("USB-HID not enabled")
+	errUSBHIDNotConfigured = errors.New("USB-HID not configured by host")
+	errUSBHIDReportSize    = errors.New("USB-HID report too large")
+	errUSBHIDWriteTimeout  = errors.New("USB-HID write timeout")
+)
+
+const hidDescriptorSize = 9
+
+// HIDDescriptor implements the HID class descriptor, which follows the
+// interface descriptor of a HID interface.
+//
+// Device Class Definition for HID 1.11, section 6.2.1
+// bLength, bDescriptorType, bcdHID, bCountryCode, bNumDescriptors,
+//    bDescriptorType (report), wDescriptorLength
+//
+type HIDDescriptor struct {
+	bLength                 uint8 // 9
+	bDescriptorType         uint8 // 0x21
+	bcdHID                  uint16
+	bCountryCode            uint8
+	bNumDescriptors         uint8
+	bReportDescriptorType   uint8 // 0x22
+	wReportDescriptorLength uint16
+}
+
+// NewHIDDescriptor returns a USB HIDDescriptor for a single report
+// descriptor of the given length.
+func NewHIDDescriptor(reportDescriptorLength uint16) HIDDescriptor {
+	return HIDDescriptor{hidDescriptorSize, usb_HID_DESCRIPTOR_TYPE, 0x111, 0, 1, usb_HID_REPORT_DESCRIPTOR_TYPE, reportDescriptorLength}
+}
+
+// Bytes returns HIDDescriptor data.
+func (d HIDDescriptor) Bytes() [hidDescriptorSize]byte {
+	var b [hidDescriptorSize]byte
+	b[0] = byte(d.bLength)
+	b[1] = byte(d.bDescriptorType)
+	b[2] = byte(d.bcdHID)
+	b[3] = byte(d.bcdHID >> 8)
+	b[4] = byte(d.bCountryCode)
+	b[5] = byte(d.bNumDescriptors)
+	b[6] = byte(d.bReportDescriptorType)
+	b[7] = byte(d.wReportDescriptorLength)
+	b[8] = byte(d.wReportDescriptorLength >> 8)
+	return b
+}
+
+// hidSize is the size of the HID interface in the configuration descriptor.
+const hidSize = interfaceDescriptorSize + hidDescriptorSize + endpointDescriptorSize
+
+// usbHIDClass is the HID interface, with a single interrupt IN endpoint.
+var usbHIDClass = usbClass{
+	interfaces:    1,
+	endpoints:     []uint32{usb_ENDPOINT_TYPE_INTERRUPT | usbEndpointIn},
+	descriptor:    hidDescriptor,
+	getDescriptor: sendHIDDescriptor,
+	setup:         hidSetup,
+}
+
+var (
+	// usbHIDReportDescriptor is the report descriptor passed to EnableHID, or
+	// nil if the HID interface is not enabled.
+	usbHIDReportDescriptor []byte
+
+	usbHIDIdle     uint8
+	usbHIDProtocol uint8 = 1 // report protocol
+)
+
+// EnableHID adds a HID (human interface device) interface to the USB device,
+// next to the CDC serial port. The report descriptor describes the reports
+// that are sent with SendHIDReport, see the machine/usb/hid package for an
+// implementation of a keyboard, a mouse and a gamepad.
+//
+// The HID interface must be enabled before the host enumerates the device,
+// which means that EnableHID should be called from an init function.
+func EnableHID(reportDescriptor []byte) error {
+	if usbHIDReportDescriptor != nil {
+		return errUSBHIDEnabled
+	}
+	usbHIDReportDescriptor = reportDescriptor
+	if err := registerUSBClass(&usbHIDClass); err != nil {
+		usbHIDReportDescriptor = nil
+		return err
+	}
+	return nil
+}
+
+// SendHIDReport sends an input report to the host over the interrupt IN
+// endpoint of the HID interface. If the report descriptor uses report IDs, the
+// first byte of the report is the report ID. It waits until the host has
+// picked up the previous report.
+func SendHIDReport(report []byte) error {
+	if usbHIDReportDescriptor == nil {
+		return errUSBHIDNotEnabled
+	}
+	if len(report) > usbEndpointPacketSize {
+		return errUSBHIDReportSize
+	}
+	if usbConfiguration == 0 {
+		return errUSBHIDNotConfigured
+	}
+	if !sendUSBInPacket(uint32(usbHIDClass.firstEndpoint), report) {
+		return errUSBHIDWriteTimeout
+	}
+	return nil
+}
+
+// hidDescriptor returns the HID interface as it is included in the
+// configuration descriptor.
+func hidDescriptor(c *usbClass) []byte {
+	hif := NewInterfaceDescriptor(c.firstInterface, 1, usb_DEVICE_CLASS_HUMAN_INTERFACE, 0, 0)
+	hid := NewHIDDescriptor(uint16(len(usbHIDReportDescriptor)))
+	in := NewEndpointDescriptor((c.firstEndpoint | usbEndpointIn), usb_ENDPOINT_TYPE_INTERRUPT, usbEndpointPacketSize, 1)
+
+	b := make([]byte, hidSize)
+	hifBuf := hif.Bytes()
+	hidBuf := hid.Bytes()
+	inBuf := in.Bytes()
+	copy(b[0:], hifBuf[:])
+	copy(b[interfaceDescriptorSize:], hidBuf[:])
+	copy(b[interfaceDescriptorSize+hidDescriptorSize:], inBuf[:])
+	return b
+}
+
+// sendHIDDescriptor sends the HID class descriptor or the report descriptor.
+func sendHIDDescriptor(setup usbSetup) bool {
+	var buf []byte
+	switch setup.wValueH {
+	case usb_HID_DESCRIPTOR_TYPE:
+		hid := NewHIDDescriptor(uint16(len(usbHIDReportDescriptor)))
+		hidBuf := hid.Bytes()
+		buf = hidBuf[:]
+	case usb_HID_REPORT_DESCRIPTOR_TYPE:
+		buf = usbHIDReportDescriptor
+	default:
+		return false
+	}
+	if int(setup.wLength) < len(buf) {
+		buf = buf[:setup.wLength]
+	}
+	sendUSBPacket(0, buf)
+	return true
+}
+
+// hidSetup handles the HID class requests. Reports are only sent over the
+// interrupt IN endpoint, so GET_REPORT and SET_REPORT are not supported.
+func hidSetup(setup usbSetup) bool {
+	if setup.bmRequestType == usb_REQUEST_DEVICETOHOST_CLASS_INTERFACE {
+		switch setup.bRequest {
+		case usb_HID_GET_IDLE:
+			sendUSBPacket(0, []byte{usbHIDIdle})
+			return true
+		case usb_HID_GET_PROTOCOL:
+			sendUSBPacket(0, []byte{usbHIDProtocol})
+			return true
+		}
+	}
+
+	if setup.bmRequestType == usb_REQUEST_HOSTTODEVICE_CLASS_INTERFACE {
+		switch setup.bRequest {
+		case usb_HID_SET_IDLE:
+			usbHIDIdle = setup.wValueH
+			sendZlp()
+			return true
+		case usb_HID_SET_PROTOCOL:
+			usbHIDProtocol = setup.wValueL
+			sendZlp()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,549 @@
+//go:build sam || nrf52840 || rp2040
+// +build sam nrf52840 rp2040
+
+package machine
+
+import "errors"
+
+var (
+	errUSBMSCEnabled    = errors.New("USB-MSC already enabled")
+	errUSBMSCDeviceSize = errors.New("USB-MSC device is smaller than a sector")
+)
+
+// MSCDevice is a storage device that can be exposed to the host with
+// EnableMSC, such as Flash or an SD card.
+//
+// If the device must be erased before it is written, like a BlockDevice, it
+// should also implement the EraseBlockSize and EraseBlocks methods of
+// BlockDevice. Sectors written by the host are then merged into their erase
+// block, which is erased and written back as a whole.
+type MSCDevice interface {
+	ReadAt(p []byte, off int64) (n int, err error)
+	WriteAt(p []byte, off int64) (n int, err error)
+	Size() int64
+}
+
+// mscEraser is implemented by devices that must be erased before they are
+// written.
+type mscEraser interface {
+	EraseBlockSize() int64
+	EraseBlocks(start, count int64) error
+}
+
+const (
+	mscSectorSize = 512
+
+	// Bulk-only transport, see the USB Mass Storage Class Bulk-Only Transport
+	// specification 1.0.
+	mscCBWSignature = 0x43425355 // "USBC"
+	mscCSWSignature = 0x53425355 // "USBS"
+	mscCBWSize      = 31
+	mscCSWSize      = 13
+
+	mscStatusPassed     = 0
+	mscStatusFailed     = 1
+	mscStatusPhaseError = 2
+
+	// class requests
+	usb_MSC_GET_MAX_LUN = 0xFE
+	usb_MSC_RESET       = 0xFF
+
+	usb_MSC_SUBCLASS_SCSI = 0x06
+	usb_MSC_PROTOCOL_BBB  = 0x50 // bulk-only transport
+)
+
+// SCSI commands, from the SCSI Primary Commands (SPC) and SCSI Block Commands
+// (SBC) standards.
+const (
+	scsiTestUnitReady            = 0x00
+	scsiRequestSense             = 0x03
+	scsiInquiry                  = 0x12
+	scsiModeSense6               = 0x1A
+	scsiStartStopUnit            = 0x1B
+	scsiPreventAllowMediumRemove = 0x1E
+	scsiReadFormatCapacities     = 0x23
+	scsiReadCapacity10           = 0x25
+	scsiRead10                   = 0x28
+	scsiWrite10                  = 0x2A
+	scsiVerify10                 = 0x2F
+	scsiSynchronizeCache10       = 0x35
+	scsiModeSense10              = 0x5A
+)
+
+// SCSI sense keys and additional sense codes (ASC) that are reported with
+// REQUEST SENSE after a command failed.
+const (
+	scsiSenseNone           = 0x00
+	scsiSenseMediumError    = 0x03
+	scsiSenseIllegalRequest = 0x05
+
+	scsiASCNone            = 0x00
+	scsiASCWriteFault      = 0x03
+	scsiASCUnrecoveredRead = 0x11
+	scsiASCInvalidCommand  = 0x20
+	scsiASCLBAOutOfRange   = 0x21
+)
+
+// States of the bulk-only transport.
+const (
+	mscStateCommand = iota // waiting for a command block wrapper (CBW)
+	mscStateDataIn         // sending data to the host
+	mscStateDataOut        // receiving data from the host
+	mscStateStatus         // sending the command status wrapper (CSW)
+)
+
+// usbMSCClass is the mass storage interface, with a bulk IN and a bulk OUT
+// endpoint.
+var usbMSCClass = usbClass{
+	interfaces: 1,
+	endpoints: []uint32{
+		usb_ENDPOINT_TYPE_BULK | usbEndpointIn,
+		usb_ENDPOINT_TYPE_BULK | usbEndpointOut,
+	},
+	descriptor: mscDescriptor,
+	setup:      mscSetup,
+	rx:         mscRx,
+	tx:         mscTx,
+}
+
+// usbMSC is the state of the mass storage interface. All commands are handled
+// in the USB interrupt.
+var usbMSC struct {
+	dev     MSCDevice
+	eraser  mscEraser
+	sectors uint32 // size of the device in sectors
+	ep      uint32 // the bulk IN endpoint
+
+	// The command that is being handled.
+	state   uint8
+	tag     uint32
+	residue uint32 // number of bytes of the data stage not transferred yet
+	dirIn   bool   // whether the host expects data from the device
+	status  uint8
+	short   bool // whether the data stage has been ended with a short packet
+
+	// Data to send to the host, or sector that is being received.
+	buf    [mscSectorSize]byte
+	data   []byte
+	offset int
+
+	// The sectors that are read or written by READ(10) and WRITE(10).
+	lba   uint32
+	count uint32
+
+	// The sense data of the last failed command.
+	senseKey uint8
+	asc      uint8
+
+	// The erase block(s) that sectors are written to, for devices that must be
+	// erased before they are written.
+	block      []byte
+	blockIndex int64 // -1 if not cached
+	blockDirty bool
+}
+
+// EnableMSC adds a USB mass storage interface to the USB device, next to the
+// CDC serial port, which exposes dev to the host as a drive. The host reads and
+// writes the device in sectors of 512 bytes, the file system on it (usually
+// FAT) is managed by the host. A new device must be formatted by the host
+// before it can be used.
+//
+// The device is read and written from the USB interrupt, so the program
+// shouldn't access it while the host is using it.
+//
+// The mass storage interface must be enabled before the host enumerates the
+// device, which means that EnableMSC should be called from an init function.
+// The USB device is started together with the USB CDC serial port, so on boards
+// that use a UART by default the program must be built with -serial=usb.
+func EnableMSC(dev MSCDevice) error {
+	if usbMSC.dev != nil {
+		return errUSBMSCEnabled
+	}
+	sectors := dev.Size() / mscSectorSize
+	if sectors == 0 {
+		return errUSBMSCDeviceSize
+	}
+	if err := registerUSBClass(&usbMSCClass); err != nil {
+		return err
+	}
+
+	usbMSC.dev = dev
+	usbMSC.sectors = uint32(sectors)
+	usbMSC.ep = uint32(usbMSCClass.firstEndpoint)
+	if eraser, ok := dev.(mscEraser); ok {
+		// Sectors are written through a buffer that contains whole erase
+		// blocks and whole sectors.
+		size := eraser.EraseBlockSize()
+		if size < mscSectorSize {
+			size = mscSectorSize
+		}
+		usbMSC.eraser = eraser
+		usbMSC.block = make([]byte, size)
+		usbMSC.blockIndex = -1
+	}
+	return nil
+}
+
+// mscDescriptor returns the mass storage interface as it is included in the
+// configuration descriptor.
+func mscDescriptor(c *usbClass) []byte {
+	mif := NewInterfaceDescriptor(c.firstInterface, 2, usb_DEVICE_CLASS_STORAGE, usb_MSC_SUBCLASS_SCSI, usb_MSC_PROTOCOL_BBB)
+	in := NewEndpointDescriptor((c.firstEndpoint | usbEndpointIn), usb_ENDPOINT_TYPE_BULK, usbEndpointPacketSize, 0)
+	out := NewEndpointDescriptor(((c.firstEndpoint + 1) | usbEndpointOut), usb_ENDPOINT_TYPE_BULK, usbEndpointPacketSize, 0)
+
+	msc := NewMSCDescriptor(mif, in, out)
+	mscBuf := msc.Bytes()
+	return mscBuf[:]
+}
+
+// mscSetup handles the mass storage class requests.
+func mscSetup(setup usbSetup) bool {
+	if setup.bmRequestType == usb_REQUEST_DEVICETOHOST_CLASS_INTERFACE && setup.bRequest == usb_MSC_GET_MAX_LUN {
+		// there is a single logical unit
+		sendUSBPacket(0, []byte{0})
+		return true
+	}
+
+	if setup.bmRequestType == usb_REQUEST_HOSTTODEVICE_CLASS_INTERFACE && setup.bRequest == usb_MSC_RESET {
+		// wait for the next command
+		usbMSC.state = mscStateCommand
+		sendZlp()
+		return true
+	}
+	return false
+}
+
+// mscRx handles a packet received on the bulk OUT endpoint.
+func mscRx(ep uint32, data []byte) {
+	switch usbMSC.state {
+	case mscStateCommand, mscStateStatus:
+		mscCommand(data)
+	case mscStateDataOut:
+		mscReceive(data)
+	}
+}
+
+// mscTx is called when the host has picked up a packet sent on the bulk IN
+// endpoint.
+func mscTx(ep uint32) {
+	switch usbMSC.state {
+	case mscStateDataIn:
+		mscSendNext()
+	case mscStateStatus:
+		usbMSC.state = mscStateCommand
+	}
+}
+
+// mscCommand handles a command block wrapper (CBW), which contains a SCSI
+// command.
+func mscCommand(cbw []byte) {
+	if len(cbw) != mscCBWSize || mscUint32LE(cbw[0:]) != mscCBWSignature {
+		// not a valid CBW, ignore it
+		return
+	}
+	usbMSC.tag = mscUint32LE(cbw[4:])
+	usbMSC.residue = mscUint32LE(cbw[8:])
+	usbMSC.dirIn = cbw[12]&usbEndpointIn != 0
+	usbMSC.status = mscStatusPassed
+	usbMSC.short = false
+	usbMSC.data = nil
+	usbMSC.offset = 0
+	usbMSC.count = 0
+
+	mscSCSICommand(cbw[15:])
+
+	switch {
+	case usbMSC.residue == 0:
+		// no data stage
+		mscSendStatus()
+	case usbMSC.dirIn:
+		usbMSC.state = mscStateDataIn
+		mscSendNext()
+	default:
+		usbMSC.state = mscStateDataOut
+	}
+}
+
+// mscSCSICommand handles a SCSI command. It prepares the data stage, if any,
+// and sets the status of the command.
+func mscSCSICommand(cb []byte) {
+	switch cb[0] {
+	case scsiTestUnitReady, scsiStartStopUnit, scsiPreventAllowMediumRemove, scsiVerify10:
+		// nothing to do
+
+	case scsiSynchronizeCache10:
+		if err := mscFlush(); err != nil {
+			mscFail(scsiSenseMediumError, scsiASCWriteFault)
+		}
+
+	case scsiRequestSense:
+		b := usbMSC.buf[:18]
+		for i := range b {
+			b[i] = 0
+		}
+		b[0] = 0x70 // current errors, fixed format
+		b[2] = usbMSC.senseKey
+		b[7] = 10 // additional sense length
+		b[12] = usbMSC.asc
+		usbMSC.senseKey = scsiSenseNone
+		usbMSC.asc = scsiASCNone
+		mscReply(b, int(cb[4]))
+
+	case scsiInquiry:
+		b := usbMSC.buf[:36]
+		for i := range b {
+			b[i] = ' '
+		}
+		b[0] = 0x00 // direct access block device
+		b[1] = 0x80 // removable
+		b[2] = 0x04 // SPC-2
+		b[3] = 0x02 // response data format
+		b[4] = byte(len(b) - 5)
+		b[5] = 0
+		b[6] = 0
+		b[7] = 0
+		copy(b[8:16], usb_STRING_MANUFACTURER)
+		copy(b[16:32], usb_STRING_PRODUCT)
+		copy(b[32:36], "1.0")
+		mscReply(b, int(cb[3])<<8|int(cb[4]))
+
+	case scsiModeSense6:
+		// no mode pages, and not write protected
+		b := usbMSC.buf[:4]
+		b[0] = 3 // mode data length
+		b[1] = 0
+		b[2] = 0
+		b[3] = 0
+		mscReply(b, int(cb[4]))
+
+	case scsiModeSense10:
+		b := usbMSC.buf[:8]
+		for i := range b {
+			b[i] = 0
+		}
+		b[1] = 6 // mode data length
+		mscReply(b, int(cb[7])<<8|int(cb[8]))
+
+	case scsiReadFormatCapacities:
+		b := usbMSC.buf[:12]
+		b[0] = 0
+		b[1] = 0
+		b[2] = 0
+		b[3] = 8 // capacity list length
+		mscPutUint32(b[4:], usbMSC.sectors)
+		mscPutUint32(b[8:], mscSectorSize)
+		b[8] = 0x02 // formatted media
+		mscReply(b, int(cb[7])<<8|int(cb[8]))
+
+	case scsiReadCapacity10:
+		b := usbMSC.buf[:8]
+		mscPutUint32(b[0:], usbMSC.sectors-1) // last LBA
+		mscPutUint32(b[4:], mscSectorSize)
+		mscReply(b, len(b))
+
+	case scsiRead10, scsiWrite10:
+		lba := mscUint32(cb[2:])
+		count := uint32(cb[7])<<8 | uint32(cb[8])
+		if lba > usbMSC.sectors || count > usbMSC.sectors-lba {
+			mscFail(scsiSenseIllegalRequest, scsiASCLBAOutOfRange)
+			return
+		}
+		if usbMSC.dirIn != (cb[0] == scsiRead10) || count*mscSectorSize > usbMSC.residue {
+			// The host expects data in the other direction, or less
+			// data than this command transfers.
+			usbMSC.status = mscStatusPhaseError
+			return
+		}
+		usbMSC.lba = lba
+		usbMSC.count = count
+
+	default:
+		mscFail(scsiSenseIllegalRequest, scsiASCInvalidCommand)
+	}
+}
+
+// mscReply sends data to the host in the data stage of a command, but not more
+// than the allocation length of the command.
+func mscReply(b []byte, allocationLength int) {
+	if len(b) > allocationLength {
+		b = b[:allocationLength]
+	}
+	if len(b) > int(usbMSC.residue) {
+		b = b[:usbMSC.residue]
+	}
+	if len(b) > 0 && !usbMSC.dirIn {
+		usbMSC.status = mscStatusPhaseError
+		return
+	}
+	usbMSC.data = b
+}
+
+// mscFail fails the current command. The reason can be read by the host with
+// REQUEST SENSE.
+func mscFail(senseKey, asc uint8) {
+	usbMSC.status = mscStatusFailed
+	usbMSC.senseKey = senseKey
+	usbMSC.asc = asc
+}
+
+// mscSendNext sends the next packet of the data stage, or the status when all
+// data has been sent.
+func mscSendNext() {
+	if len(usbMSC.data) == 0 && usbMSC.count > 0 {
+		// read the next sector
+		_, err := usbMSC.dev.ReadAt(usbMSC.buf[:], int64(usbMSC.lba)*mscSectorSize)
+		if err != nil {
+			mscFail(scsiSenseMediumError, scsiASCUnrecoveredRead)
+			usbMSC.count = 0
+		} else {
+			usbMSC.data = usbMSC.buf[:]
+			usbMSC.lba++
+			usbMSC.count--
+		}
+	}
+
+	if len(usbMSC.data) > 0 {
+		n := len(usbMSC.data)
+		if n > usbEndpointPacketSize {
+			n = usbEndpointPacketSize
+		}
+		sendUSBInPacket(usbMSC.ep, usbMSC.data[:n])
+		usbMSC.data = usbMSC.data[n:]
+		usbMSC.residue -= uint32(n)
+		usbMSC.short = n < usbEndpointPacketSize
+		return
+	}
+
+	if usbMSC.residue > 0 && !usbMSC.short {
+		// The host expects more data than was sent, so end the data stage
+		// with a short (zero length) packet.
+		usbMSC.short = true
+		sendUSBInPacket(usbMSC.ep, nil)
+		return
+	}
+
+	mscSendStatus()
+}
+
+// mscReceive handles a packet of the data stage from the host. Data that isn't
+// written to the device (for example after an error) is dropped.
+func mscReceive(data []byte) {
+	if len(data) > int(usbMSC.residue) {
+		data = data[:usbMSC.residue]
+	}
+	usbMSC.residue -= uint32(len(data))
+
+	if usbMSC.count > 0 {
+		usbMSC.offset += copy(usbMSC.buf[usbMSC.offset:], data)
+		if usbMSC.offset == mscSectorSize {
+			usbMSC.offset = 0
+			if err := mscWriteSector(usbMSC.lba, usbMSC.buf[:]); err != nil {
+				mscFail(scsiSenseMediumError, scsiASCWriteFault)
+				usbMSC.count = 0
+			} else {
+				usbMSC.lba++
+				usbMSC.count--
+			}
+		}
+	}
+
+	if usbMSC.residue == 0 || len(data) < usbEndpointPacketSize {
+		// end of the data stage
+		if err := mscFlush(); err != nil {
+			mscFail(scsiSenseMediumError, scsiASCWriteFault)
+		}
+		mscSendStatus()
+	}
+}
+
+// mscSendStatus sends the command status wrapper (CSW) of the current command.
+func mscSendStatus() {
+	b := usbMSC.buf[:mscCSWSize]
+	mscPutUint32LE(b[0:], mscCSWSignature)
+	mscPutUint32LE(b[4:], usbMSC.tag)
+	mscPutUint32LE(b[8:], usbMSC.residue)
+	b[12] = usbMSC.status
+	usbMSC.state = mscStateStatus
+	sendUSBInPacket(usbMSC.ep, b)
+}
+
+// mscWriteSector writes a sector to the device. For devices that must be
+// erased first, the sector is written to a copy of its erase block, which is
+// written back by mscFlush.
+func mscWriteSector(lba uint32, data []byte) error {
+	off := int64(lba) * mscSectorSize
+	if usbMSC.eraser == nil {
+		_, err := usbMSC.dev.WriteAt(data, off)
+		return err
+	}
+
+	blockSize := int64(len(usbMSC.block))
+	index := off / blockSize
+	if index != usbMSC.blockIndex {
+		if err := mscFlush(); err != nil {
+			return err
+		}
+		if _, err := usbMSC.dev.ReadAt(usbMSC.block, index*blockSize); err != nil {
+			return err
+		}
+		usbMSC.blockIndex = index
+	}
+
+	// Only erase and write the block if the data actually changed, which saves
+	// flash wear when the host rewrites the same data.
+	block := usbMSC.block[off-index*blockSize:]
+	for i, c := range data {
+		if block[i] != c {
+			copy(block, data)
+			usbMSC.blockDirty = true
+			break
+		}
+	}
+	return nil
+}
+
+// mscFlush writes back the erase block that sectors were written to, if any.
+func mscFlush() error {
+	if !usbMSC.blockDirty {
+		usbMSC.blockIndex = -1
+		return nil
+	}
+	usbMSC.blockDirty = false
+
+	// The program may change the device when the host isn't using it, so
+	// don't keep the block around.
+	blockSize := int64(len(usbMSC.block))
+	start := usbMSC.blockIndex * blockSize
+	usbMSC.blockIndex = -1
+
+	eraseBlockSize := usbMSC.eraser.EraseBlockSize()
+	if err := usbMSC.eraser.EraseBlocks(start/eraseBlockSize, blockSize/eraseBlockSize); err != nil {
+		return err
+	}
+	_, err := usbMSC.dev.WriteAt(usbMSC.block, start)
+	return err
+}
+
+// SCSI uses big endian numbers, the bulk-only transport little endian.
+
+func mscUint32(b []byte) uint32 {
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+func mscPutUint32(b []byte, v uint32) {
+	b[0] = byte(v >> 24)
+	b[1] = byte(v >> 16)
+	b[2] = byte(v >> 8)
+	b[3] = byte(v)
+}
+
+func mscUint32LE(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+func mscPutUint32LE(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+	b[3] = byte(v >> 24)
+}
@@ -254,3 +254,44 @@ func Getwd() (string, error) {
 func TempDir() string {
 	return tempDir()
 }
+
+// bufferFileHandle is a FileHandle that keeps everything written to it in
+// memory. Reads return the written data, starting at the beginning.
+type bufferFileHandle struct {
+	data   []byte
+	offset int
+}
+
+// newBufferFile returns a File that is not backed by the operating system but
+// keeps all data in memory. It is used by the testing package (using
+// go:linkname) to capture the output of examples, which must work on all
+// targets including those without a filesystem or pipes.
+func newBufferFile(name string) *File {
+	return &File{handle: &bufferFileHandle{}, name: name}
+}
+
+func (f *bufferFileHandle) Read(b []byte) (n int, err error) {
+	if f.offset >= len(f.data) {
+		return 0, io.EOF
+	}
+	n = copy(b, f.data[f.offset:])
+	f.offset += n
+	return n, nil
+}
+
+func (f *bufferFileHandle) ReadAt(b []byte, offset int64) (n int, err error) {
+	return 0, ErrNotImplemented
+}
+
+func (f *bufferFileHandle) Seek(offset int64, whence int) (newoffset int64, err error) {
+	return 0, ErrNotImplemented
+}
+
+func (f *bufferFileHandle) Write(b []byte) (n int, err error) {
+	f.data = append(f.data, b...)
+	return len(b), nil
+}
+
+func (f *bufferFileHandle) Close() error {
+	return nil
+}
@@ -1,5 +1,74 @@
 package reflect
 
+import "unsafe"
+
+// makeFuncImpl is the context of a function created by MakeFunc.
+type makeFuncImpl struct {
+	typ rawType
+	fn  func([]Value) []Value
+}
+
+// MakeFunc returns a new function of the given Type that wraps the function fn.
+// When called, that new function does the following:
+//
+//	- converts its arguments to a slice of Values.
+//	- runs results := fn(args).
+//	- returns the results as a slice of Values, one per formal result.
 func MakeFunc(typ Type, fn func(args []Value) (results []Value)) Value {
-	panic("unimplemented: reflect.MakeFunc()")
+	t := typ.(rawType)
+	if t.Kind() != Func {
+		panic("reflect: call of MakeFunc with non-Func type")
+	}
+	// The function pointer is a wrapper created by the compiler for this
+	// function type, which calls makeFuncStub with the context (the
+	// makeFuncImpl) and all parameters stored in memory.
+	_, wrapper := t.funcWrappers()
+	return Value{
+		typecode: t,
+		value: unsafe.Pointer(&funcHeader{
+			Context: unsafe.Pointer(&makeFuncImpl{typ: t, fn: fn}),
+			Code:    wrapper,
+		}),
+		flags: valueFlagExported,
+	}
+}
+
+// makeFuncStub is called from the wrapper functions created by the compiler for
+// MakeFunc (see getReflectMakeFuncWrapper). The args and results point to the
+// parameters and results of the call, each laid out like a struct.
+func makeFuncStub(impl, args, results unsafe.Pointer) {
+	f := (*makeFuncImpl)(impl)
+	t := f.typ
+
+	// Read all arguments. The args buffer lives on the stack of the wrapper,
+	// so values that are stored indirectly must be copied to the heap.
+	in := make([]Value, t.NumIn())
+	offset := uintptr(0)
+	for i := range in {
+		argType := t.in(i)
+		offset = align(offset, uintptr(argType.Align()))
+		ptr := unsafe.Pointer(uintptr(args) + offset)
+		if size := argType.Size(); size > unsafe.Sizeof(uintptr(0)) {
+			buf := alloc(size, nil)
+			memcpy(buf, ptr, size)
+			ptr = buf
+		}
+		in[i] = loadFromPointer(argType, ptr, valueFlagExported)
+		offset += argType.Size()
+	}
+
+	out := f.fn(in)
+
+	// Store all results.
+	numOut := t.NumOut()
+	if len(out) != numOut {
+		panic("reflect: wrong return count from function created by MakeFunc")
+	}
+	offset = 0
+	for i, x := range out {
+		resultType := t.out(i)
+		offset = align(offset, uintptr(resultType.Align()))
+		storeValue(resultType, unsafe.Pointer(uintptr(results)+offset), x, "MakeFunc")
+		offset += resultType.Size()
+	}
 }
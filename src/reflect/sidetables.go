@@ -19,6 +19,24 @@ var structNamesSidetable byte
 //go:extern reflect.arrayTypesSidetable
 var arrayTypesSidetable byte
 
+//go:extern reflect.mapTypesSidetable
+var mapTypesSidetable byte
+
+//go:extern reflect.funcTypesSidetable
+var funcTypesSidetable byte
+
+//go:extern reflect.methodsSidetable
+var methodsSidetable byte
+
+//go:extern reflect.typeNamesSidetable
+var typeNamesSidetable byte
+
+// This stores function pointers (as uintptr) of wrappers used to call
+// functions and of methods, referenced from funcTypesSidetable and
+// methodsSidetable.
+//go:extern reflect.funcPointersSidetable
+var funcPointersSidetable uintptr
+
 // readStringSidetable reads a string from the given table (like
 // structNamesSidetable) and returns this string. No heap allocation is
 // necessary because it makes the string point directly to the raw bytes of the
@@ -31,6 +49,26 @@ func readStringSidetable(table unsafe.Pointer, index uintptr) string {
 	}))
 }
 
+// readFuncPointer returns the function pointer at the given index in the func
+// pointers sidetable.
+func readFuncPointer(index uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(uintptr(unsafe.Pointer(&funcPointersSidetable)) + index*unsafe.Sizeof(uintptr(0))))
+}
+
+// readVarintAt returns the i'th varint in the list of varints starting at buf.
+func readVarintAt(buf unsafe.Pointer, i int) uintptr {
+	n, _ := readVarint(skipVarints(buf, i))
+	return n
+}
+
+// skipVarints returns the pointer just after the first n varints in buf.
+func skipVarints(buf unsafe.Pointer, n int) unsafe.Pointer {
+	for ; n != 0; n-- {
+		_, buf = readVarint(buf)
+	}
+	return buf
+}
+
 // readVarint decodes a varint as used in the encoding/binary package.
 // It has an input pointer and returns the read varint and the pointer
 // incremented to the next field in the data structure, just after the varint.
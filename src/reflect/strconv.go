@@ -246,3 +246,45 @@ func indexByteString(s string, c byte) int {
 	}
 	return -1
 }
+
+// quote returns a double-quoted Go string literal representing s, like
+// strconv.Quote. Non-printable ASCII characters are escaped, all other
+// characters are copied as-is.
+func quote(s string) string {
+	const hex = "0123456789abcdef"
+	buf := make([]byte, 0, len(s)+2)
+	buf = append(buf, '"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c == '\n':
+			buf = append(buf, '\\', 'n')
+		case c == '\t':
+			buf = append(buf, '\\', 't')
+		case c < ' ' || c == 0x7f:
+			buf = append(buf, '\\', 'x', hex[c>>4], hex[c&0xf])
+		default:
+			buf = append(buf, c)
+		}
+	}
+	buf = append(buf, '"')
+	return string(buf)
+}
+
+// itoa converts a non-negative integer to its decimal representation, like
+// strconv.Itoa.
+func itoa(n int) string {
+	var buf [20]byte
+	i := len(buf)
+	for {
+		i--
+		buf[i] = byte('0' + n%10)
+		n /= 10
+		if n == 0 {
+			break
+		}
+	}
+	return string(buf[i:])
+}
@@ -54,7 +54,7 @@ const (
 	UnsafePointer
 	Chan
 	Interface
-	Ptr
+	Pointer
 	Slice
 	Array
 	Func
@@ -62,6 +62,9 @@ const (
 	Struct
 )
 
+// Ptr is the old name for the Pointer kind.
+const Ptr = Pointer
+
 func (k Kind) String() string {
 	switch k {
 	case Bool:
@@ -163,7 +166,7 @@ type Type interface {
 	//
 	// Only exported methods are accessible and they are sorted in
 	// lexicographic order.
-	//Method(int) Method
+	Method(int) Method
 
 	// MethodByName returns the method with that name in the type's
 	// method set and a boolean indicating if the method was found.
@@ -173,7 +176,7 @@ type Type interface {
 	//
 	// For an interface type, the returned Method's Type field gives the
 	// method signature, without a receiver, and the Func field is nil.
-	//MethodByName(string) (Method, bool)
+	MethodByName(string) (Method, bool)
 
 	// NumMethod returns the number of exported methods in the type's method set.
 	NumMethod() int
@@ -187,7 +190,7 @@ type Type interface {
 	// If the type was predeclared (string, error) or not defined (*T, struct{},
 	// []int, or A where A is an alias for a non-defined type), the package path
 	// will be the empty string.
-	//PkgPath() string
+	PkgPath() string
 
 	// Size returns the number of bytes needed to store
 	// a value of the given type; it is analogous to unsafe.Sizeof.
@@ -248,7 +251,7 @@ type Type interface {
 	//	t.IsVariadic() == true
 	//
 	// IsVariadic panics if the type's Kind is not Func.
-	//IsVariadic() bool
+	IsVariadic() bool
 
 	// Elem returns a type's element type.
 	// It panics if the type's Kind is not Array, Chan, Map, Ptr, or Slice.
@@ -286,7 +289,7 @@ type Type interface {
 	// In returns the type of a function type's i'th input parameter.
 	// It panics if the type's Kind is not Func.
 	// It panics if i is not in the range [0, NumIn()).
-	//In(i int) Type
+	In(i int) Type
 
 	// Key returns a map type's key type.
 	// It panics if the type's Kind is not Map.
@@ -302,16 +305,16 @@ type Type interface {
 
 	// NumIn returns a function type's input parameter count.
 	// It panics if the type's Kind is not Func.
-	//NumIn() int
+	NumIn() int
 
 	// NumOut returns a function type's output parameter count.
 	// It panics if the type's Kind is not Func.
-	//NumOut() int
+	NumOut() int
 
 	// Out returns the type of a function type's i'th output parameter.
 	// It panics if the type's Kind is not Func.
 	// It panics if i is not in the range [0, NumOut()).
-	//Out(i int) Type
+	Out(i int) Type
 }
 
 // The typecode as used in an interface{}.
@@ -332,8 +335,136 @@ func PtrTo(t Type) Type {
 	return ptrType
 }
 
+// String returns a string representation of this type, like "int",
+// "[]*main.T" or "map[string]interface {}". Named types are qualified with
+// their package name. If the program was built without type names (see the
+// -no-reflect-names flag), the kind of a named type is returned instead.
 func (t rawType) String() string {
-	return "T"
+	if t.isNamed() {
+		if _, name, ok := t.typeName(); ok {
+			return name
+		}
+		// Type names were stripped. Don't look at the underlying type as it
+		// may refer back to this named type, which would recurse forever.
+		return t.Kind().String()
+	}
+	switch t.Kind() {
+	case Chan:
+		return "chan " + t.elem().String()
+	case Ptr:
+		return "*" + t.elem().String()
+	case Slice:
+		return "[]" + t.elem().String()
+	case Array:
+		return "[" + itoa(t.Len()) + "]" + t.elem().String()
+	case Map:
+		return "map[" + t.key().String() + "]" + t.elem().String()
+	case Func:
+		return "func" + t.signatureString()
+	case Interface:
+		numMethod := t.NumMethod()
+		if numMethod == 0 {
+			return "interface {}"
+		}
+		s := "interface {"
+		for i := 0; i < numMethod; i++ {
+			if i != 0 {
+				s += ";"
+			}
+			m := t.rawMethod(i)
+			s += " " + m.name + m.methodType.signatureString()
+		}
+		return s + " }"
+	case Struct:
+		numField := t.NumField()
+		if numField == 0 {
+			return "struct {}"
+		}
+		s := "struct {"
+		for i := 0; i < numField; i++ {
+			if i != 0 {
+				s += ";"
+			}
+			field := t.rawField(i)
+			s += " "
+			if !field.Anonymous {
+				s += field.Name + " "
+			}
+			s += field.Type.String()
+			if field.Tag != "" {
+				s += " " + quote(string(field.Tag))
+			}
+		}
+		return s + " }"
+	default:
+		return t.Kind().String()
+	}
+}
+
+// signatureString returns the parameters and results of a func type as used in
+// String, for example "(int, ...string) (bool, error)".
+func (t rawType) signatureString() string {
+	s := "("
+	numIn := t.NumIn()
+	for i := 0; i < numIn; i++ {
+		if i != 0 {
+			s += ", "
+		}
+		if i == numIn-1 && t.IsVariadic() {
+			s += "..." + t.in(i).elem().String()
+		} else {
+			s += t.in(i).String()
+		}
+	}
+	s += ")"
+	switch numOut := t.NumOut(); numOut {
+	case 0:
+	case 1:
+		s += " " + t.out(0).String()
+	default:
+		s += " ("
+		for i := 0; i < numOut; i++ {
+			if i != 0 {
+				s += ", "
+			}
+			s += t.out(i).String()
+		}
+		s += ")"
+	}
+	return s
+}
+
+// isNamed returns whether this is a named (defined) type.
+func (t rawType) isNamed() bool {
+	if t%2 == 0 {
+		// Basic type: the bits above the kind contain the name, if any.
+		return t>>6 != 0
+	}
+	// Non-basic type: look at the 'n' bit.
+	return (t>>4)%2 != 0
+}
+
+// typeName returns the package path and the name (qualified with the package
+// name) of this named type. The last return value is false if this is not a
+// named type or if type names were not included in the program.
+func (t rawType) typeName() (pkgPath, name string, ok bool) {
+	// This is a linear search through all named types, like in methods().
+	p := unsafe.Pointer(&typeNamesSidetable)
+	for {
+		var typecode, pkgPathNum, nameNum uintptr
+		typecode, p = readVarint(p)
+		if typecode == 0 {
+			// End of the list.
+			return "", "", false
+		}
+		pkgPathNum, p = readVarint(p)
+		nameNum, p = readVarint(p)
+		if rawType(typecode) == t {
+			pkgPath = readStringSidetable(unsafe.Pointer(&structNamesSidetable), pkgPathNum)
+			name = readStringSidetable(unsafe.Pointer(&structNamesSidetable), nameNum)
+			return pkgPath, name, true
+		}
+	}
 }
 
 func (t rawType) Kind() Kind {
@@ -345,8 +476,8 @@ func (t rawType) Kind() Kind {
 	}
 }
 
-// Elem returns the element type for channel, slice and array types, the
-// pointed-to value for pointer types, and the key type for map types.
+// Elem returns the element type for channel, slice, array and map types, and
+// the pointed-to value for pointer types.
 func (t rawType) Elem() Type {
 	return t.elem()
 }
@@ -359,9 +490,29 @@ func (t rawType) elem() rawType {
 		index := t.stripPrefix()
 		elem, _ := readVarint(unsafe.Pointer(uintptr(unsafe.Pointer(&arrayTypesSidetable)) + uintptr(index)))
 		return rawType(elem)
-	default: // not implemented: Map
-		panic("unimplemented: (reflect.Type).Elem()")
+	case Map:
+		// skip past the key type
+		index := t.stripPrefix()
+		_, p := readVarint(unsafe.Pointer(uintptr(unsafe.Pointer(&mapTypesSidetable)) + uintptr(index)))
+		elem, _ := readVarint(p)
+		return rawType(elem)
+	default:
+		panic(&TypeError{"Elem"})
+	}
+}
+
+// Key returns the key type of a map type. It panics for other type kinds.
+func (t rawType) Key() Type {
+	return t.key()
+}
+
+func (t rawType) key() rawType {
+	if t.Kind() != Map {
+		panic(&TypeError{"Key"})
 	}
+	index := t.stripPrefix()
+	key, _ := readVarint(unsafe.Pointer(uintptr(unsafe.Pointer(&mapTypesSidetable)) + uintptr(index)))
+	return rawType(key)
 }
 
 // stripPrefix removes the "prefix" (the low 5 bits of the type code) from
@@ -462,11 +613,10 @@ func (t rawType) rawField(i int) rawStructField {
 			// This field is exported.
 			field.PkgPath = ""
 		} else {
-			// This field is unexported.
-			// TODO: list the real package path here. Storing it should not
-			// significantly impact binary size as there is only a limited
-			// number of packages in any program.
-			field.PkgPath = "<unimplemented>"
+			// This field is unexported, and is followed by the package path.
+			var pkgPathNum uintptr
+			pkgPathNum, p = readVarint(p)
+			field.PkgPath = readStringSidetable(unsafe.Pointer(&structNamesSidetable), pkgPathNum)
 		}
 	}
 
@@ -679,16 +829,261 @@ func (t rawType) ConvertibleTo(u Type) bool {
 	panic("unimplemented: (reflect.Type).ConvertibleTo()")
 }
 
+// NumMethod returns the number of exported methods in the method set of this
+// type. For interface types, it returns the number of exported and unexported
+// methods.
 func (t rawType) NumMethod() int {
-	panic("unimplemented: (reflect.Type).NumMethod()")
+	_, n := t.methods()
+	return n
+}
+
+// Method returns the i'th method in the method set of this type. For
+// non-interface types, the Type and Func fields of the returned Method describe
+// a function with the receiver as the first parameter. For interface types,
+// the Type field is the method signature without receiver and the Func field is
+// the zero Value.
+func (t rawType) Method(i int) Method {
+	m := t.rawMethod(i)
+	method := Method{
+		Name:    m.name,
+		PkgPath: m.pkgPath,
+		Index:   i,
+	}
+	if m.funcType == 0 {
+		// Interface method.
+		method.Type = m.methodType
+	} else {
+		method.Type = m.funcType
+		method.Func = Value{
+			typecode: m.funcType,
+			value:    unsafe.Pointer(&funcHeader{Code: m.function}),
+			flags:    valueFlagExported,
+		}
+	}
+	return method
+}
+
+// MethodByName returns the method with the given name in the method set of
+// this type, and whether it was found. See Method for details.
+func (t rawType) MethodByName(name string) (Method, bool) {
+	if i := t.methodIndex(name); i >= 0 {
+		return t.Method(i), true
+	}
+	return Method{}, false
+}
+
+// rawMethod contains the information about a method stored in the methods
+// sidetable. For internal use only.
+type rawMethod struct {
+	name       string
+	pkgPath    string         // package path for unexported methods, or empty
+	methodType rawType        // method signature without receiver
+	funcType   rawType        // method signature with receiver, or 0 for interface methods
+	function   unsafe.Pointer // the method as a function of funcType
+}
+
+// methods returns a pointer to the list of methods of this type in the methods
+// sidetable, and the number of methods in this list. Types without methods are
+// not stored in the sidetable.
+func (t rawType) methods() (unsafe.Pointer, int) {
+	// This is a linear search through all types with methods. It is slow, but
+	// avoids storing an index for each type.
+	p := unsafe.Pointer(&methodsSidetable)
+	for {
+		var typecode, numMethods uintptr
+		typecode, p = readVarint(p)
+		if typecode == 0 {
+			// End of the list.
+			return nil, 0
+		}
+		numMethods, p = readVarint(p)
+		if rawType(typecode) == t {
+			return p, int(numMethods)
+		}
+		for i := uintptr(0); i < numMethods; i++ {
+			p = skipRawMethod(p)
+		}
+	}
+}
+
+// rawMethod returns the i'th method of this type. It panics if the index is out
+// of range.
+func (t rawType) rawMethod(i int) rawMethod {
+	p, numMethods := t.methods()
+	if uint(i) >= uint(numMethods) {
+		panic("reflect: Method index out of range")
+	}
+	for ; i != 0; i-- {
+		p = skipRawMethod(p)
+	}
+	return readRawMethod(p)
+}
+
+// methodIndex returns the index of the method with the given name, or -1 if
+// there is no such method.
+func (t rawType) methodIndex(name string) int {
+	p, numMethods := t.methods()
+	for i := 0; i < numMethods; i++ {
+		if readRawMethod(p).name == name {
+			return i
+		}
+		p = skipRawMethod(p)
+	}
+	return -1
+}
+
+// readRawMethod reads a single method from the methods sidetable. Every method
+// is stored as {flags byte, name, package path (only for unexported methods),
+// method type, func type, func pointer index}.
+func readRawMethod(p unsafe.Pointer) rawMethod {
+	flagsByte := *(*uint8)(p)
+	p = unsafe.Pointer(uintptr(p) + 1)
+	var nameNum, methodType, funcType, funcIndex uintptr
+	nameNum, p = readVarint(p)
+	m := rawMethod{
+		name: readStringSidetable(unsafe.Pointer(&structNamesSidetable), nameNum),
+	}
+	if flagsByte&1 == 0 {
+		// Unexported method, so the package path follows.
+		var pkgPathNum uintptr
+		pkgPathNum, p = readVarint(p)
+		m.pkgPath = readStringSidetable(unsafe.Pointer(&structNamesSidetable), pkgPathNum)
+	}
+	methodType, p = readVarint(p)
+	funcType, p = readVarint(p)
+	funcIndex, _ = readVarint(p)
+	m.methodType = rawType(methodType)
+	m.funcType = rawType(funcType)
+	if funcType != 0 {
+		m.function = readFuncPointer(funcIndex)
+	}
+	return m
+}
+
+// skipRawMethod returns a pointer to the method after the method at p.
+func skipRawMethod(p unsafe.Pointer) unsafe.Pointer {
+	flagsByte := *(*uint8)(p)
+	p = unsafe.Pointer(uintptr(p) + 1)
+	numVarints := 4
+	if flagsByte&1 == 0 {
+		numVarints++ // package path of unexported methods
+	}
+	return skipVarints(p, numVarints)
+}
+
+// funcTypeInfo returns the flags of this func type and a pointer to the rest of
+// the func type in the func types sidetable. Every func type is stored as
+// {flags, number of parameters, parameter types..., number of results, result
+// types..., index of the wrappers in the func pointers sidetable}.
+func (t rawType) funcTypeInfo(method string) (uintptr, unsafe.Pointer) {
+	if t.Kind() != Func {
+		panic(&TypeError{method})
+	}
+	index := t.stripPrefix()
+	return readVarint(unsafe.Pointer(uintptr(unsafe.Pointer(&funcTypesSidetable)) + uintptr(index)))
+}
+
+// IsVariadic returns whether the last input parameter of this func type is a
+// "..." parameter. It panics if the type is not a func type.
+func (t rawType) IsVariadic() bool {
+	flags, _ := t.funcTypeInfo("IsVariadic")
+	return flags&1 != 0
+}
+
+// NumIn returns the number of input parameters of a func type. It panics if
+// the type is not a func type.
+func (t rawType) NumIn() int {
+	_, p := t.funcTypeInfo("NumIn")
+	numIn, _ := readVarint(p)
+	return int(numIn)
 }
 
+// In returns the type of the i'th input parameter of a func type. It panics if
+// the type is not a func type.
+func (t rawType) In(i int) Type {
+	return t.in(i)
+}
+
+func (t rawType) in(i int) rawType {
+	_, p := t.funcTypeInfo("In")
+	numIn, p := readVarint(p)
+	if uint(i) >= uint(numIn) {
+		panic("reflect: In index out of range")
+	}
+	return rawType(readVarintAt(p, i))
+}
+
+// NumOut returns the number of results of a func type. It panics if the type
+// is not a func type.
+func (t rawType) NumOut() int {
+	_, p := t.funcTypeInfo("NumOut")
+	numIn, p := readVarint(p)
+	numOut, _ := readVarint(skipVarints(p, int(numIn)))
+	return int(numOut)
+}
+
+// Out returns the type of the i'th result of a func type. It panics if the
+// type is not a func type.
+func (t rawType) Out(i int) Type {
+	return t.out(i)
+}
+
+func (t rawType) out(i int) rawType {
+	_, p := t.funcTypeInfo("Out")
+	numIn, p := readVarint(p)
+	numOut, p := readVarint(skipVarints(p, int(numIn)))
+	if uint(i) >= uint(numOut) {
+		panic("reflect: Out index out of range")
+	}
+	return rawType(readVarintAt(p, i))
+}
+
+// funcWrappers returns the wrapper functions created by the compiler for this
+// func type: one to call a function of this type (see Value.Call) and one that
+// is used as the function pointer for functions created by MakeFunc.
+func (t rawType) funcWrappers() (call, makeFunc unsafe.Pointer) {
+	_, p := t.funcTypeInfo("funcWrappers")
+	numIn, p := readVarint(p)
+	numOut, p := readVarint(skipVarints(p, int(numIn)))
+	index, _ := readVarint(skipVarints(p, int(numOut)))
+	return readFuncPointer(index), readFuncPointer(index + 1)
+}
+
+// Name returns the name of a named type within its package, or the empty
+// string for other types. Predeclared types like int and error are also named
+// types. It returns the empty string for named types if the program was built
+// without type names.
 func (t rawType) Name() string {
-	panic("unimplemented: (reflect.Type).Name()")
+	if t.isNamed() {
+		_, name, _ := t.typeName()
+		// Strip the package name.
+		for i := 0; i < len(name) && name[i] != '['; i++ {
+			if name[i] == '.' {
+				return name[i+1:]
+			}
+		}
+		return name
+	}
+	switch kind := t.Kind(); kind {
+	case UnsafePointer:
+		return "Pointer"
+	default:
+		if kind < Chan {
+			// Basic types like int and string.
+			return kind.String()
+		}
+		return ""
+	}
 }
 
-func (t rawType) Key() Type {
-	panic("unimplemented: (reflect.Type).Key()")
+// PkgPath returns the import path of the package in which a named type was
+// declared, or the empty string for predeclared and unnamed types.
+func (t rawType) PkgPath() string {
+	if !t.isNamed() {
+		return ""
+	}
+	pkgPath, _, _ := t.typeName()
+	return pkgPath
 }
 
 // A StructField describes a single field in a struct.
@@ -797,6 +1192,25 @@ func (e *TypeError) Error() string {
 	return "reflect: call of reflect.Type." + e.Method + " on invalid type"
 }
 
+// Method represents a single method of a type.
+type Method struct {
+	// Name is the method name.
+	Name string
+
+	// PkgPath is the package path that qualifies a lower case (unexported)
+	// method name. It is empty for upper case (exported) method names.
+	PkgPath string
+
+	Type  Type  // method type
+	Func  Value // func with receiver as first argument
+	Index int   // index for Type.Method
+}
+
+// IsExported reports whether the method is exported.
+func (m Method) IsExported() bool {
+	return m.PkgPath == ""
+}
+
 func align(offset uintptr, alignment uintptr) uintptr {
 	return (offset + alignment - 1) &^ (alignment - 1)
 }
@@ -135,16 +135,22 @@ func (v Value) IsNil() bool {
 // Pointer returns the underlying pointer of the given value for the following
 // types: chan, map, pointer, unsafe.Pointer, slice, func.
 func (v Value) Pointer() uintptr {
+	return uintptr(v.UnsafePointer())
+}
+
+// UnsafePointer returns the underlying pointer of the given value for the
+// following types: chan, map, pointer, unsafe.Pointer, slice, func.
+func (v Value) UnsafePointer() unsafe.Pointer {
 	switch v.Kind() {
 	case Chan, Map, Ptr, UnsafePointer:
-		return uintptr(v.pointer())
+		return v.pointer()
 	case Slice:
 		slice := (*sliceHeader)(v.value)
-		return uintptr(slice.data)
+		return slice.data
 	case Func:
-		panic("unimplemented: (reflect.Value).Pointer()")
+		panic("unimplemented: (reflect.Value).UnsafePointer()")
 	default: // not implemented: Func
-		panic(&ValueError{"Pointer"})
+		panic(&ValueError{"UnsafePointer"})
 	}
 }
 
@@ -325,9 +331,11 @@ func (v Value) String() string {
 		// A string value is always bigger than a pointer as it is made of a
 		// pointer and a length.
 		return *(*string)(v.value)
+	case Invalid:
+		return "<invalid Value>"
 	default:
 		// Special case because of the special treatment of .String() in Go.
-		return "<T>"
+		return "<" + v.typecode.String() + " Value>"
 	}
 }
 
@@ -576,39 +584,252 @@ func maskAndShift(value, offset, size uintptr) uintptr {
 	return (uintptr(value) >> (offset * 8)) & mask
 }
 
-func (v Value) NumMethod() int {
-	return v.typecode.NumMethod()
-}
-
 func (v Value) OverflowFloat(x float64) bool {
 	panic("unimplemented: (reflect.Value).OverflowFloat()")
 }
 
+//go:linkname hashmapMake runtime.hashmapMakeUnsafePointer
+func hashmapMake(keySize, valueSize uint8, sizeHint uintptr) unsafe.Pointer
+
+//go:linkname hashmapNext runtime.hashmapNextUnsafePointer
+func hashmapNext(m unsafe.Pointer, it unsafe.Pointer, key, value unsafe.Pointer) bool
+
+//go:linkname hashmapBinarySet runtime.hashmapBinarySetUnsafePointer
+func hashmapBinarySet(m, key, value unsafe.Pointer)
+
+//go:linkname hashmapBinaryGet runtime.hashmapBinaryGetUnsafePointer
+func hashmapBinaryGet(m, key, value unsafe.Pointer, valueSize uintptr) bool
+
+//go:linkname hashmapBinaryDelete runtime.hashmapBinaryDeleteUnsafePointer
+func hashmapBinaryDelete(m, key unsafe.Pointer)
+
+//go:linkname hashmapStringSet runtime.hashmapStringSetUnsafePointer
+func hashmapStringSet(m unsafe.Pointer, key string, value unsafe.Pointer)
+
+//go:linkname hashmapStringGet runtime.hashmapStringGetUnsafePointer
+func hashmapStringGet(m unsafe.Pointer, key string, value unsafe.Pointer, valueSize uintptr) bool
+
+//go:linkname hashmapStringDelete runtime.hashmapStringDeleteUnsafePointer
+func hashmapStringDelete(m unsafe.Pointer, key string)
+
+//go:linkname hashmapInterfaceSet runtime.hashmapInterfaceSetUnsafePointer
+func hashmapInterfaceSet(m unsafe.Pointer, key interface{}, value unsafe.Pointer)
+
+//go:linkname hashmapInterfaceGet runtime.hashmapInterfaceGetUnsafePointer
+func hashmapInterfaceGet(m unsafe.Pointer, key interface{}, value unsafe.Pointer, valueSize uintptr) bool
+
+//go:linkname hashmapInterfaceDelete runtime.hashmapInterfaceDeleteUnsafePointer
+func hashmapInterfaceDelete(m unsafe.Pointer, key interface{})
+
+// The way keys are stored in a hashmap. This must match the way the compiler
+// chooses a hashmap implementation in compiler/map.go.
+const (
+	mapKeyString    = iota // string keys
+	mapKeyBinary           // keys that can be compared with memequal
+	mapKeyInterface        // all other keys, stored as an interface value
+)
+
+// mapKeyKind returns how keys of the given type are stored in a hashmap.
+func mapKeyKind(key rawType) int {
+	if key.Kind() == String {
+		return mapKeyString
+	}
+	if isBinaryKey(key) {
+		return mapKeyBinary
+	}
+	return mapKeyInterface
+}
+
+// isBinaryKey returns whether the given key type does not contain strings,
+// interfaces etc., so can be compared with memequal. It is the same as
+// hashmapIsBinaryKey in the compiler.
+func isBinaryKey(t rawType) bool {
+	switch t.Kind() {
+	case Bool, Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+		return true
+	case Ptr:
+		return true
+	case Struct:
+		numField := t.NumField()
+		for i := 0; i < numField; i++ {
+			if !isBinaryKey(t.rawField(i).Type) {
+				return false
+			}
+		}
+		return true
+	case Array:
+		return isBinaryKey(t.elem())
+	default:
+		return false
+	}
+}
+
+// mapKeySize returns the size of a key of the given type as it is stored in a
+// hashmap.
+func mapKeySize(key rawType) uintptr {
+	if mapKeyKind(key) == mapKeyInterface {
+		return unsafe.Sizeof(interface{}(nil))
+	}
+	return key.Size()
+}
+
+// dataPointer returns a pointer to the data of this value. If the value is
+// stored directly in v.value, it returns a pointer to a copy of the value.
+func (v Value) dataPointer() unsafe.Pointer {
+	if v.isIndirect() || v.typecode.Size() > unsafe.Sizeof(uintptr(0)) {
+		return v.value
+	}
+	value := v.value
+	return unsafe.Pointer(&value)
+}
+
+// loadFromPointer returns a (non-addressable) Value of the given type for the
+// data at ptr. Small values are loaded, so that they are stored directly in the
+// Value like in an interface.
+func loadFromPointer(typ rawType, ptr unsafe.Pointer, flags valueFlags) Value {
+	if size := typ.Size(); size <= unsafe.Sizeof(uintptr(0)) {
+		ptr = unsafe.Pointer(loadValue(ptr, size))
+	}
+	return Value{
+		typecode: typ,
+		value:    ptr,
+		flags:    flags &^ valueFlagIndirect,
+	}
+}
+
+// loadMapKey returns the map key at ptr, taking into account that some keys are
+// stored as an interface value.
+func loadMapKey(key rawType, ptr unsafe.Pointer, flags valueFlags) Value {
+	if mapKeyKind(key) == mapKeyInterface && key.Kind() != Interface {
+		// The key is stored in an interface value but is not itself of
+		// interface type. Extract the underlying value.
+		typecode, value := decomposeInterface(*(*interface{})(ptr))
+		return Value{
+			typecode: typecode,
+			value:    value,
+			flags:    flags &^ valueFlagIndirect,
+		}
+	}
+	return loadFromPointer(key, ptr, flags)
+}
+
+// MapKeys returns a slice containing all the keys present in the map, in
+// unspecified order. It panics if v's Kind is not Map. It returns an empty
+// slice if v represents a nil map.
 func (v Value) MapKeys() []Value {
-	panic("unimplemented: (reflect.Value).MapKeys()")
+	if v.Kind() != Map {
+		panic(&ValueError{"MapKeys"})
+	}
+	keys := make([]Value, 0, v.Len())
+	it := v.MapRange()
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	return keys
 }
 
+// MapIndex returns the value associated with key in the map v. It panics if
+// v's Kind is not Map. It returns the zero Value if key is not found in the map
+// or if v represents a nil map.
 func (v Value) MapIndex(key Value) Value {
-	panic("unimplemented: (reflect.Value).MapIndex()")
+	if v.Kind() != Map {
+		panic(&ValueError{"MapIndex"})
+	}
+	keyType := v.typecode.key()
+	elemType := v.typecode.elem()
+	m := v.pointer()
+
+	if key.typecode != keyType && keyType.Kind() != Interface {
+		panic("reflect.Value.MapIndex: key type mismatch")
+	}
+
+	// Look up the value in the map, storing it in a new buffer.
+	elemSize := elemType.Size()
+	elem := alloc(elemSize, nil)
+	var ok bool
+	switch mapKeyKind(keyType) {
+	case mapKeyString:
+		ok = hashmapStringGet(m, *(*string)(key.value), elem, elemSize)
+	case mapKeyBinary:
+		ok = hashmapBinaryGet(m, key.dataPointer(), elem, elemSize)
+	default:
+		ok = hashmapInterfaceGet(m, valueInterfaceUnsafe(key), elem, elemSize)
+	}
+	if !ok {
+		return Value{}
+	}
+	return loadFromPointer(elemType, elem, v.flags)
 }
 
+// MapRange returns a range iterator for a map. It panics if v's Kind is not
+// Map.
 func (v Value) MapRange() *MapIter {
-	panic("unimplemented: (reflect.Value).MapRange()")
+	if v.Kind() != Map {
+		panic(&ValueError{"MapRange"})
+	}
+	return &MapIter{m: v}
 }
 
+// A MapIter is an iterator for ranging over a map. See Value.MapRange.
 type MapIter struct {
+	m     Value
+	it    hashmapIterator
+	key   unsafe.Pointer
+	value unsafe.Pointer
+	valid bool // Key and Value can be called
+	done  bool // the iterator is exhausted
 }
 
+// hashmapIterator must have the same layout as runtime.hashmapIterator.
+type hashmapIterator struct {
+	buckets      unsafe.Pointer
+	numBuckets   uintptr
+	bucketNumber uintptr
+	bucket       unsafe.Pointer
+	bucketIndex  uint8
+}
+
+// Key returns the key of iter's current map entry.
 func (it *MapIter) Key() Value {
-	panic("unimplemented: (*reflect.MapIter).Key()")
+	if !it.valid {
+		panic("reflect.MapIter.Key called before Next")
+	}
+	return loadMapKey(it.m.typecode.key(), it.key, it.m.flags)
 }
 
+// Value returns the value of iter's current map entry.
 func (it *MapIter) Value() Value {
-	panic("unimplemented: (*reflect.MapIter).Value()")
+	if !it.valid {
+		panic("reflect.MapIter.Value called before Next")
+	}
+	return loadFromPointer(it.m.typecode.elem(), it.value, it.m.flags)
 }
 
+// Next advances the map iterator and reports whether there is another entry.
+// It returns false when iter is exhausted; subsequent calls to Key, Value, or
+// Next will panic.
 func (it *MapIter) Next() bool {
-	panic("unimplemented: (*reflect.MapIter).Next()")
+	if it.done {
+		panic("reflect.MapIter.Next called on exhausted iterator")
+	}
+	// Copy the key and value into new buffers each time, as the Values
+	// returned by Key and Value may still refer to the previous ones.
+	it.key = alloc(mapKeySize(it.m.typecode.key()), nil)
+	it.value = alloc(it.m.typecode.elem().Size(), nil)
+	it.valid = hashmapNext(it.m.pointer(), unsafe.Pointer(&it.it), it.key, it.value)
+	it.done = !it.valid
+	return it.valid
+}
+
+// Reset modifies iter to iterate over v. It panics if v's Kind is not Map and
+// v is not the zero Value. Reset(Value{}) causes iter to not to refer to any
+// map, which may allow the previously iterated-over map to be garbage
+// collected.
+func (it *MapIter) Reset(v Value) {
+	if v.IsValid() && v.Kind() != Map {
+		panic(&ValueError{"MapIter.Reset"})
+	}
+	*it = MapIter{m: v}
 }
 
 func (v Value) Set(x Value) {
@@ -844,8 +1065,59 @@ func AppendSlice(s, t Value) Value {
 	}
 }
 
+// SetMapIndex sets the element associated with key in the map v to elem. It
+// panics if v's Kind is not Map. If elem is the zero Value, SetMapIndex
+// deletes the key from the map. Otherwise if v holds a nil map, SetMapIndex
+// will panic.
 func (v Value) SetMapIndex(key, elem Value) {
-	panic("unimplemented: (reflect.Value).SetMapIndex()")
+	if v.Kind() != Map {
+		panic(&ValueError{"SetMapIndex"})
+	}
+	if !v.isExported() || !key.isExported() || (elem.IsValid() && !elem.isExported()) {
+		panic("reflect.Value.SetMapIndex: unexported")
+	}
+	keyType := v.typecode.key()
+	keyKind := mapKeyKind(keyType)
+	if key.typecode != keyType && keyType.Kind() != Interface {
+		panic("reflect.Value.SetMapIndex: key type mismatch")
+	}
+	m := v.pointer()
+
+	if !elem.IsValid() {
+		// Delete the key from the map.
+		switch keyKind {
+		case mapKeyString:
+			hashmapStringDelete(m, *(*string)(key.value))
+		case mapKeyBinary:
+			hashmapBinaryDelete(m, key.dataPointer())
+		default:
+			hashmapInterfaceDelete(m, valueInterfaceUnsafe(key))
+		}
+		return
+	}
+
+	if m == nil {
+		panic("assignment to entry in nil map")
+	}
+	elemType := v.typecode.elem()
+	var elemPtr unsafe.Pointer
+	if elemType.Kind() == Interface && elem.typecode.Kind() != Interface {
+		// The value must be stored in the map as an interface.
+		itf := valueInterfaceUnsafe(elem)
+		elemPtr = unsafe.Pointer(&itf)
+	} else if elem.typecode == elemType {
+		elemPtr = elem.dataPointer()
+	} else {
+		panic("reflect.Value.SetMapIndex: element type mismatch")
+	}
+	switch keyKind {
+	case mapKeyString:
+		hashmapStringSet(m, *(*string)(key.value), elemPtr)
+	case mapKeyBinary:
+		hashmapBinarySet(m, key.dataPointer(), elemPtr)
+	default:
+		hashmapInterfaceSet(m, valueInterfaceUnsafe(key), elemPtr)
+	}
 }
 
 // FieldByIndex returns the nested field corresponding to index.
@@ -859,9 +1131,205 @@ func (v Value) FieldByName(name string) Value {
 
 // MakeMap creates a new map with the specified type.
 func MakeMap(typ Type) Value {
-	panic("unimplemented: reflect.MakeMap()")
+	return MakeMapWithSize(typ, 8)
+}
+
+// MakeMapWithSize creates a new map with the specified type and initial space
+// for approximately n elements.
+func MakeMapWithSize(typ Type, n int) Value {
+	t := typ.(rawType)
+	if t.Kind() != Map {
+		panic("reflect.MakeMapWithSize of non-map type")
+	}
+	if n < 0 {
+		panic("reflect.MakeMapWithSize: negative size hint")
+	}
+	keySize := mapKeySize(t.key())
+	elemSize := t.elem().Size()
+	if keySize > 255 || elemSize > 255 {
+		panic("reflect.MakeMapWithSize: key or element type too big")
+	}
+	return Value{
+		typecode: t,
+		value:    hashmapMake(uint8(keySize), uint8(elemSize), uintptr(n)),
+		flags:    valueFlagExported,
+	}
 }
 
+// Call calls the function v with the input arguments in. It panics if v's Kind
+// is not Func. It returns the output results as Values. As in Go, each input
+// argument must be assignable to the type of the function's corresponding
+// input parameter. If v is a variadic function, Call creates the variadic
+// slice parameter itself, copying in the corresponding values.
 func (v Value) Call(in []Value) []Value {
-	panic("unimplemented: (reflect.Value).Call()")
+	return v.call("Call", in, false)
+}
+
+// CallSlice calls the variadic function v with the input arguments in,
+// assigning the slice in[len(in)-1] to v's final variadic argument. It panics
+// if v's Kind is not Func or if v is not variadic.
+func (v Value) CallSlice(in []Value) []Value {
+	return v.call("CallSlice", in, true)
+}
+
+func (v Value) call(op string, in []Value, isSlice bool) []Value {
+	if v.Kind() != Func {
+		panic(&ValueError{op})
+	}
+	if !v.isExported() {
+		panic("reflect.Value." + op + ": unexported")
+	}
+	if v.IsNil() {
+		panic("reflect: call of nil function")
+	}
+	t := v.typecode
+	numIn := t.NumIn()
+	if isSlice {
+		if !t.IsVariadic() {
+			panic("reflect: CallSlice of non-variadic function")
+		}
+		if len(in) != numIn {
+			panic("reflect: CallSlice with wrong number of input arguments")
+		}
+	} else if t.IsVariadic() {
+		// Pack the variadic arguments in a slice.
+		if len(in) < numIn-1 {
+			panic("reflect: Call with too few input arguments")
+		}
+		sliceType := t.in(numIn - 1)
+		elemType := sliceType.elem()
+		elemSize := elemType.Size()
+		extra := in[numIn-1:]
+		slice := &sliceHeader{
+			data: alloc(elemSize*uintptr(len(extra)), nil),
+			len:  uintptr(len(extra)),
+			cap:  uintptr(len(extra)),
+		}
+		for i, x := range extra {
+			storeValue(elemType, unsafe.Pointer(uintptr(slice.data)+elemSize*uintptr(i)), x, op)
+		}
+		in = append(in[:numIn-1:numIn-1], Value{
+			typecode: sliceType,
+			value:    unsafe.Pointer(slice),
+			flags:    valueFlagExported,
+		})
+	} else if len(in) != numIn {
+		if len(in) < numIn {
+			panic("reflect: Call with too few input arguments")
+		}
+		panic("reflect: Call with too many input arguments")
+	}
+
+	// Store all arguments in memory, laid out like a struct.
+	argsSize := uintptr(0)
+	for i := range in {
+		argType := t.in(i)
+		argsSize = align(argsSize, uintptr(argType.Align())) + argType.Size()
+	}
+	args := alloc(argsSize, nil)
+	offset := uintptr(0)
+	for i, x := range in {
+		argType := t.in(i)
+		offset = align(offset, uintptr(argType.Align()))
+		storeValue(argType, unsafe.Pointer(uintptr(args)+offset), x, op)
+		offset += argType.Size()
+	}
+
+	// Allocate space for the results, also laid out like a struct.
+	numOut := t.NumOut()
+	resultsSize := uintptr(0)
+	for i := 0; i < numOut; i++ {
+		resultType := t.out(i)
+		resultsSize = align(resultsSize, uintptr(resultType.Align())) + resultType.Size()
+	}
+	results := alloc(resultsSize, nil)
+
+	// Call the function through a wrapper that loads the arguments and stores
+	// the results (see getReflectCallWrapper in the compiler).
+	callWrapper, _ := t.funcWrappers()
+	call := *(*func(fn, args, results unsafe.Pointer))(unsafe.Pointer(&funcHeader{Code: callWrapper}))
+	call(v.value, args, results)
+
+	// Read the results. The results buffer is not used anymore after this, so
+	// the values can point directly into it.
+	out := make([]Value, numOut)
+	offset = 0
+	for i := range out {
+		resultType := t.out(i)
+		offset = align(offset, uintptr(resultType.Align()))
+		out[i] = loadFromPointer(resultType, unsafe.Pointer(uintptr(results)+offset), valueFlagExported)
+		offset += resultType.Size()
+	}
+	return out
+}
+
+// storeValue stores the value x at ptr, which must point to a value of type
+// typ. Non-interface values are converted to an interface value if typ is an
+// interface type. It panics if x cannot be stored there.
+func storeValue(typ rawType, ptr unsafe.Pointer, x Value, op string) {
+	if !x.IsValid() {
+		panic("reflect: " + op + " using zero Value argument")
+	}
+	if !x.isExported() {
+		panic("reflect: " + op + " using value obtained using unexported field")
+	}
+	if x.typecode == typ {
+		memcpy(ptr, x.dataPointer(), typ.Size())
+	} else if typ.Kind() == Interface {
+		*(*interface{})(ptr) = valueInterfaceUnsafe(x)
+	} else {
+		panic("reflect: " + op + " using value of wrong type")
+	}
+}
+
+// NumMethod returns the number of exported methods in the value's method set.
+// For interface values, it returns the number of methods of the interface
+// type.
+func (v Value) NumMethod() int {
+	if v.typecode == 0 {
+		panic(&ValueError{"NumMethod"})
+	}
+	return v.typecode.NumMethod()
+}
+
+// Method returns a function value corresponding to v's i'th method. The
+// arguments to a Call on the returned function should not include a receiver;
+// the returned function will always use v as the receiver.
+func (v Value) Method(i int) Value {
+	if v.typecode == 0 {
+		panic(&ValueError{"Method"})
+	}
+	if v.typecode.Kind() == Interface {
+		// Look up the method with the same name on the dynamic type.
+		if v.IsNil() {
+			panic("reflect: Method on nil interface value")
+		}
+		name := v.typecode.rawMethod(i).name
+		v = v.Elem()
+		i = v.typecode.methodIndex(name)
+	}
+	m := v.typecode.rawMethod(i)
+	fn := Value{
+		typecode: m.funcType,
+		value:    unsafe.Pointer(&funcHeader{Code: m.function}),
+		flags:    valueFlagExported,
+	}
+	return MakeFunc(m.methodType, func(args []Value) []Value {
+		// Variadic arguments have already been packed in a slice, so don't
+		// do that again.
+		return fn.call("Call", append([]Value{v}, args...), m.methodType.IsVariadic())
+	})
+}
+
+// MethodByName returns a function value corresponding to the method of v with
+// the given name. It returns the zero Value if no method was found.
+func (v Value) MethodByName(name string) Value {
+	if v.typecode == 0 {
+		panic(&ValueError{"MethodByName"})
+	}
+	i := v.typecode.methodIndex(name)
+	if i < 0 {
+		return Value{}
+	}
+	return v.Method(i)
 }
@@ -0,0 +1,29 @@
+package runtime
+
+// This file implements various core algorithms used in the runtime package and
+// standard library.
+
+// fastrand returns a pseudo-random number. It is not cryptographically secure
+// and the sequence is the same on every run, but it is fast and good enough for
+// things like picking a random case in a select statement.
+func fastrand() uint32 {
+	xorshift32State = xorshift32(xorshift32State)
+	return xorshift32State
+}
+
+var xorshift32State uint32 = 1
+
+func xorshift32(x uint32) uint32 {
+	// Algorithm "xor" from p. 4 of Marsaglia, "Xorshift RNGs".
+	x ^= x << 13
+	x ^= x >> 17
+	x ^= x << 5
+	return x
+}
+
+// fastrandn returns a pseudo-random number in the range [0, n).
+func fastrandn(n uint32) uint32 {
+	// This is similar to fastrand() % n, but faster.
+	// See https://lemire.me/blog/2016/06/27/a-fast-alternative-to-the-modulo-reduction/
+	return uint32(uint64(fastrand()) * uint64(n) >> 32)
+}
@@ -351,6 +351,41 @@ func (ch *channel) trySend(value unsafe.Pointer) bool {
 	return false
 }
 
+// canSend returns whether a send operation on this channel can proceed
+// immediately, that is, whether trySend would not return false. This is also
+// true for closed channels, on which a send operation panics.
+// Interrupts must be disabled when calling this function.
+func (ch *channel) canSend() bool {
+	if ch == nil {
+		return false
+	}
+	switch ch.state {
+	case chanStateEmpty, chanStateBuf:
+		return ch.bufUsed < ch.bufSize
+	case chanStateRecv, chanStateClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// canRecv returns whether a receive operation on this channel can proceed
+// immediately, that is, whether tryRecv would return true.
+// Interrupts must be disabled when calling this function.
+func (ch *channel) canRecv() bool {
+	if ch == nil {
+		return false
+	}
+	switch ch.state {
+	case chanStateBuf, chanStateSend:
+		return ch.bufUsed != 0 || ch.blocked != nil
+	case chanStateClosed:
+		return true
+	default:
+		return false
+	}
+}
+
 // try to recieve a value from a channel, without really blocking
 // returns whether a value was recieved
 // second return is the comma-ok value
@@ -570,8 +605,11 @@ func chanClose(ch *channel) {
 // perhaps the most complicated statement in the Go spec. It returns the
 // selected index and the 'comma-ok' value.
 //
-// TODO: do this in a round-robin fashion (as specified in the Go spec) instead
-// of picking the first one that can proceed.
+// If multiple cases can proceed, one of them is picked uniformly at random as
+// required by the Go spec (see tryChanSelect). If none can proceed, the
+// operations are registered on their channels starting at a random case, so
+// that a select with multiple cases on the same channel doesn't always favor
+// the same case either.
 func chanSelect(recvbuf unsafe.Pointer, states []chanSelectState, ops []channelBlockedList) (uintptr, bool) {
 	istate := interrupt.Disable()
 
@@ -582,7 +620,10 @@ func chanSelect(recvbuf unsafe.Pointer, states []chanSelectState, ops []channelB
 	}
 
 	// construct blocked operations
-	for i, v := range states {
+	start := fastrandn(uint32(len(states)))
+	for n := range states {
+		i := (int(start) + n) % len(states)
+		v := states[i]
 		if v.ch == nil {
 			// A nil channel receive will never complete.
 			// A nil channel send would have panicked during tryChanSelect.
@@ -642,23 +683,41 @@ func chanSelect(recvbuf unsafe.Pointer, states []chanSelectState, ops []channelB
 func tryChanSelect(recvbuf unsafe.Pointer, states []chanSelectState) (uintptr, bool) {
 	istate := interrupt.Disable()
 
-	// See whether we can receive from one of the channels.
+	// Pick one of the operations that can proceed immediately, uniformly at
+	// random. This uses reservoir sampling, so that it can be done in a single
+	// pass without allocating memory: the n-th operation that can proceed
+	// replaces the previously picked operation with a probability of 1/n.
+	selected := -1
+	numReady := uint32(0)
 	for i, state := range states {
+		var ready bool
+		if state.value == nil {
+			ready = state.ch.canRecv()
+		} else {
+			ready = state.ch.canSend()
+		}
+		if !ready {
+			continue
+		}
+		numReady++
+		if fastrandn(numReady) == 0 {
+			selected = i
+		}
+	}
+
+	if selected >= 0 {
+		state := states[selected]
+		ok := true
 		if state.value == nil {
 			// A receive operation.
-			if rx, ok := state.ch.tryRecv(recvbuf); rx {
-				chanDebug(state.ch)
-				interrupt.Restore(istate)
-				return uintptr(i), ok
-			}
+			_, ok = state.ch.tryRecv(recvbuf)
 		} else {
 			// A send operation: state.value is not nil.
-			if state.ch.trySend(state.value) {
-				chanDebug(state.ch)
-				interrupt.Restore(istate)
-				return uintptr(i), true
-			}
+			state.ch.trySend(state.value)
 		}
+		chanDebug(state.ch)
+		interrupt.Restore(istate)
+		return uintptr(selected), ok
 	}
 
 	interrupt.Restore(istate)
@@ -1,6 +1,11 @@
-// Package debug is a dummy package that is not yet implemented.
+// Package debug is a very partially implemented package to allow compilation.
 package debug
 
+import (
+	"os"
+	"runtime"
+)
+
 // SetMaxStack sets the maximum amount of memory that can be used by a single
 // goroutine stack.
 //
@@ -9,9 +14,22 @@ func SetMaxStack(n int) int {
 	return n
 }
 
-// Stack returns a formatted stack trace of the goroutine that calls it.
+// PrintStack prints to standard error the stack trace returned by Stack.
+func PrintStack() {
+	os.Stderr.Write(Stack())
+}
+
+// Stack returns a formatted stack trace of the goroutine that calls it. It
+// calls runtime.Stack with a large enough buffer to capture the entire trace.
 //
-// Not implemented.
+// Stack traces are only available when enabled with the -pctable-size flag.
 func Stack() []byte {
-	return nil
+	buf := make([]byte, 1024)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
 }
@@ -6,3 +6,28 @@ type Error interface {
 
 	RuntimeError()
 }
+
+// runtimeError is the type of the values passed to panic() for errors detected
+// by the runtime, such as an out of range index. Only pointers to the global
+// runtimeError values below are used, and the message already includes the
+// "runtime error: " prefix, so that panicking does not need to allocate memory.
+type runtimeError struct {
+	msg string
+}
+
+func (e *runtimeError) Error() string {
+	return e.msg
+}
+
+func (e *runtimeError) RuntimeError() {}
+
+var (
+	errNilPointer          = runtimeError{"runtime error: nil pointer dereference"}
+	errIndexOutOfRange     = runtimeError{"runtime error: index out of range"}
+	errSliceOutOfRange     = runtimeError{"runtime error: slice out of range"}
+	errSliceToArrayPointer = runtimeError{"runtime error: slice smaller than array"}
+	errUnsafeSlice         = runtimeError{"runtime error: unsafe.Slice: len out of range"}
+	errChanMake            = runtimeError{"runtime error: new channel is too big"}
+	errNegativeShift       = runtimeError{"runtime error: negative shift"}
+	errDivideByZero        = runtimeError{"runtime error: divide by zero"}
+)
@@ -1,5 +1,14 @@
 package runtime
 
+// Callers fills the slice pc with the return program counters of function
+// invocations on the calling goroutine's stack. The argument skip is the number
+// of stack frames to skip before recording in pc, with 0 identifying the frame
+// for Callers itself and 1 identifying the caller of Callers. It returns the
+// number of entries written to pc.
+//
+// Only Go functions are included. When stack traces are disabled (see the
+// -pctable-size flag), this function always returns 0.
+//go:noinline
 func Callers(skip int, pc []uintptr) int {
-	return 0
+	return callers(skip, pc)
 }
@@ -1,5 +1,5 @@
-//go:build gc.conservative && (baremetal || tinygo.wasm)
-// +build gc.conservative
+//go:build (gc.conservative || gc.precise) && (baremetal || tinygo.wasm)
+// +build gc.conservative gc.precise
 // +build baremetal tinygo.wasm
 
 package runtime
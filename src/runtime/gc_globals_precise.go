@@ -1,5 +1,6 @@
-//go:build gc.conservative && !baremetal && !tinygo.wasm
-// +build gc.conservative,!baremetal,!tinygo.wasm
+//go:build (gc.conservative || gc.precise) && !baremetal && !tinygo.wasm
+// +build gc.conservative gc.precise
+// +build !baremetal,!tinygo.wasm
 
 package runtime
 
@@ -17,6 +17,8 @@ func alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer {
 	// TODO: this can be optimized by not casting between pointers and ints so
 	// much. And by using platform-native data types (e.g. *uint8 for 8-bit
 	// systems).
+	gcTotalAlloc += uint64(size)
+	gcMallocs++
 	size = align(size)
 	addr := heapptr
 	heapptr += size
@@ -55,6 +57,23 @@ func GC() {
 	// No-op.
 }
 
+// ReadMemStats populates m with memory statistics.
+//
+// The returned memory statistics are up to date as of the
+// call to ReadMemStats. Memory is never freed, so Frees is always 0.
+func ReadMemStats(m *MemStats) {
+	m.HeapInuse = uint64(heapptr - heapStart)
+	m.HeapIdle = uint64(heapEnd - heapptr)
+	m.HeapReleased = 0 // always 0, we don't currently release memory back to the OS.
+	m.HeapSys = m.HeapInuse + m.HeapIdle
+	m.HeapAlloc = m.HeapInuse
+	m.GCSys = 0
+	m.Sys = uint64(heapEnd - heapStart)
+	m.TotalAlloc = gcTotalAlloc
+	m.Mallocs = gcMallocs
+	m.Frees = gcFrees
+}
+
 func KeepAlive(x interface{}) {
 	// Unimplemented. Only required with SetFinalizer().
 }
@@ -22,6 +22,12 @@ func GC() {
 	// Unimplemented.
 }
 
+// ReadMemStats populates m with memory statistics. Memory is not managed by the
+// runtime with this GC, so all statistics are 0.
+func ReadMemStats(m *MemStats) {
+	*m = MemStats{}
+}
+
 func KeepAlive(x interface{}) {
 	// Unimplemented. Only required with SetFinalizer().
 }
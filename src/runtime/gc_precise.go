@@ -0,0 +1,106 @@
+//go:build gc.precise
+// +build gc.precise
+
+package runtime
+
+// This file implements precise scanning of heap objects for the block-based
+// garbage collector in gc_blocks.go. Every object stores its layout (as created
+// by the compiler, see createObjectLayout in compiler/llvm.go) in its last word
+// and only words that are marked as pointers in this layout are scanned. This
+// means that large pointer-free objects, like byte slices, are never scanned
+// and values that only happen to look like pointers don't keep objects alive.
+//
+// The layout describes a repeating pattern of words (where a word has the
+// alignment of a pointer), so that the same layout can be used for slices and
+// arrays of a given element type.
+//
+// Objects without a layout (allocated by the runtime with a nil layout) are
+// scanned conservatively. Globals and stacks are always scanned conservatively.
+
+import "unsafe"
+
+// The object layout is stored in the last word of every heap object.
+const preciseHeap = true
+
+// gcObjectScanner determines which words of a heap object are pointers, using
+// the object layout.
+type gcObjectScanner struct {
+	index      uintptr        // index of the next word in the layout
+	size       uintptr        // number of words in the layout
+	bitmap     uintptr        // the layout bitmap, if it fits in a word
+	bitmapAddr unsafe.Pointer // pointer to the layout bitmap if it doesn't fit in a word
+}
+
+// newGCObjectScanner returns a scanner for the object starting at the given
+// head block.
+func newGCObjectScanner(block gcBlock) gcObjectScanner {
+	if gcAsserts && block != block.findHead() {
+		runtimePanic("gc: object scanner must start at head")
+	}
+	end := block.findNext().address()
+	layout := *(*uintptr)(unsafe.Pointer(end - unsafe.Sizeof(end)))
+	scanner := gcObjectScanner{}
+	if layout == 0 {
+		// Unknown layout. Assume all words in the object could be pointers.
+		scanner.size = 1
+		scanner.bitmap = 1
+	} else if layout&1 != 0 {
+		// The layout is stored directly in the integer value, in the
+		// following format (from least to most significant bit):
+		//   - a single bit that is always set
+		//   - the number of words in the layout
+		//   - the bitmap, where a set bit means the word is a pointer
+		var sizeFieldBits uintptr
+		switch unsafe.Sizeof(layout) * 8 { // resolved at compile time
+		case 16:
+			sizeFieldBits = 4
+		case 32:
+			sizeFieldBits = 5
+		case 64:
+			sizeFieldBits = 6
+		default:
+			runtimePanic("gc: unknown pointer size")
+		}
+		scanner.size = (layout >> 1) & (1<<sizeFieldBits - 1)
+		scanner.bitmap = layout >> (1 + sizeFieldBits)
+	} else {
+		// The layout is stored in a global: the number of words followed by
+		// the bitmap as a byte array (least significant bit first).
+		scanner.size = *(*uintptr)(unsafe.Pointer(layout))
+		scanner.bitmapAddr = unsafe.Pointer(layout + unsafe.Sizeof(layout))
+	}
+	return scanner
+}
+
+// pointerFree returns whether the object is known to not contain any pointers.
+func (scanner *gcObjectScanner) pointerFree() bool {
+	if scanner.bitmapAddr != nil {
+		// The compiler only stores a layout in a global if the object contains
+		// pointers.
+		return false
+	}
+	return scanner.bitmap == 0
+}
+
+// nextIsPointer returns whether the given word (the next word of the object)
+// should be treated as a pointer.
+func (scanner *gcObjectScanner) nextIsPointer(word uintptr) bool {
+	index := scanner.index
+	scanner.index++
+	if scanner.index == scanner.size {
+		// The layout repeats, for example in slices.
+		scanner.index = 0
+	}
+
+	if !looksLikePointer(word) {
+		// Definitely not a pointer.
+		return false
+	}
+
+	// This could be a pointer. Check the object layout to be sure.
+	if scanner.bitmapAddr != nil {
+		bitmapByte := *(*uint8)(unsafe.Pointer(uintptr(scanner.bitmapAddr) + index/8))
+		return (bitmapByte>>(index%8))&1 != 0
+	}
+	return (scanner.bitmap>>index)&1 != 0
+}
@@ -1,5 +1,6 @@
-//go:build gc.conservative && !tinygo.wasm
-// +build gc.conservative,!tinygo.wasm
+//go:build (gc.conservative || gc.precise) && !tinygo.wasm
+// +build gc.conservative gc.precise
+// +build !tinygo.wasm
 
 package runtime
 
@@ -12,14 +12,30 @@ import (
 
 // The underlying hashmap structure for Go.
 type hashmap struct {
-	next       *hashmap       // hashmap after evacuate (for iterators)
 	buckets    unsafe.Pointer // pointer to array of buckets
+	oldBuckets unsafe.Pointer // previous array of buckets while growing, or nil
+	evacuated  uintptr        // number of old buckets moved to the new buckets
 	count      uintptr
-	keySize    uint8 // maybe this can store the key type as well? E.g. keysize == 5 means string?
+	keySize    uint8
 	valueSize  uint8
 	bucketBits uint8
+
+	// The functions to hash and compare keys, used to move keys to the new
+	// buckets after growing. They are set by hashmapGrow, which is only called
+	// from the typed entry points (hashmapBinarySet etc). This way, the
+	// functions for other key types are not linked in.
+	keyHash  func(key unsafe.Pointer, n uintptr) uint32
+	keyEqual func(x, y unsafe.Pointer, n uintptr) bool
 }
 
+// The maximum average number of entries per bucket before the hashmap is grown,
+// as a fraction (loadFactorNum/loadFactorDen). This is the same as in the Go
+// hashmap.
+const (
+	hashmapLoadFactorNum = 13
+	hashmapLoadFactorDen = 2
+)
+
 // A hashmap bucket. A bucket is a container of 8 key/value pairs: first the
 // following two entries, then the 8 keys, then the 8 values. This somewhat odd
 // ordering is to make sure the keys and values are well aligned when one of
@@ -32,6 +48,8 @@ type hashmapBucket struct {
 }
 
 type hashmapIterator struct {
+	buckets      unsafe.Pointer // buckets of the map when the iteration started
+	numBuckets   uintptr        // number of buckets in the buckets array
 	bucketNumber uintptr
 	bucket       *hashmapBucket
 	bucketIndex  uint8
@@ -78,6 +96,130 @@ func hashmapMake(keySize, valueSize uint8, sizeHint uintptr) *hashmap {
 	}
 }
 
+// wrapper for use in reflect
+func hashmapMakeUnsafePointer(keySize, valueSize uint8, sizeHint uintptr) unsafe.Pointer {
+	return unsafe.Pointer(hashmapMake(keySize, valueSize, sizeHint))
+}
+
+// hashmapShouldGrow returns whether the hashmap has too many entries for its
+// number of buckets, so that inserting a new key should grow it first.
+func hashmapShouldGrow(m *hashmap) bool {
+	if m.bucketBits >= uint8(unsafe.Sizeof(uintptr(0))*8-4) {
+		// Don't let the number of buckets overflow.
+		return false
+	}
+	return m.count >= (uintptr(1)<<m.bucketBits)*hashmapLoadFactorNum/hashmapLoadFactorDen
+}
+
+// hashmapGrow doubles the number of buckets in the hashmap. The entries are
+// not moved to the new buckets right away: that is done a few buckets at a time
+// by every following insert and delete (see hashmapEvacuate), so that a single
+// insert never has to rehash the whole hashmap.
+func hashmapGrow(m *hashmap, keyHash func(key unsafe.Pointer, n uintptr) uint32, keyEqual func(x, y unsafe.Pointer, n uintptr) bool) {
+	// The previous growth is normally long finished, as every insert and
+	// delete moves two old buckets. Finish it anyway if it isn't.
+	hashmapFinishGrowing(m)
+
+	m.keyHash = keyHash
+	m.keyEqual = keyEqual
+	bucketSize := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*8
+	m.oldBuckets = m.buckets
+	m.evacuated = 0
+	m.bucketBits++
+	m.buckets = alloc(bucketSize*(uintptr(1)<<m.bucketBits), nil)
+}
+
+// hashmapEvacuate moves the entries of the next old bucket (and its chain) to
+// the new buckets, if the hashmap is growing. The old bucket is left untouched,
+// so that iterators that were started before the hashmap was grown can
+// continue to use it (see hashmapNext).
+//go:nobounds
+func hashmapEvacuate(m *hashmap) {
+	if m.oldBuckets == nil {
+		return
+	}
+	bucketSize := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*8
+	bucket := (*hashmapBucket)(unsafe.Pointer(uintptr(m.oldBuckets) + bucketSize*m.evacuated))
+
+	// Mark the old bucket as evacuated first, so that the keys in it are
+	// looked up in the new buckets from now on.
+	m.evacuated++
+	for bucket != nil {
+		for i := uintptr(0); i < 8; i++ {
+			if bucket.tophash[i] == 0 {
+				continue
+			}
+			slotKeyOffset := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*uintptr(i)
+			slotKey := unsafe.Pointer(uintptr(unsafe.Pointer(bucket)) + slotKeyOffset)
+			slotValueOffset := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*uintptr(i)
+			slotValue := unsafe.Pointer(uintptr(unsafe.Pointer(bucket)) + slotValueOffset)
+			hashmapInsertNew(m, slotKey, slotValue, m.keyHash(slotKey, uintptr(m.keySize)))
+		}
+		bucket = bucket.next
+	}
+
+	if m.evacuated == uintptr(1)<<(m.bucketBits-1) {
+		// All old buckets have been moved.
+		m.oldBuckets = nil
+		m.evacuated = 0
+	}
+}
+
+// hashmapFinishGrowing moves all remaining old buckets to the new buckets.
+func hashmapFinishGrowing(m *hashmap) {
+	for m.oldBuckets != nil {
+		hashmapEvacuate(m)
+	}
+}
+
+// hashmapFirstBucket returns the first bucket of the bucket chain that holds
+// the key with the given hash. While the hashmap is growing, this is the old
+// bucket of the key if that bucket hasn't been moved to the new buckets yet.
+func hashmapFirstBucket(m *hashmap, hash uint32) *hashmapBucket {
+	bucketSize := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*8
+	if m.oldBuckets != nil {
+		oldBucketNumber := uintptr(hash) & (uintptr(1)<<(m.bucketBits-1) - 1)
+		if oldBucketNumber >= m.evacuated {
+			return (*hashmapBucket)(unsafe.Pointer(uintptr(m.oldBuckets) + bucketSize*oldBucketNumber))
+		}
+	}
+	bucketNumber := uintptr(hash) & (uintptr(1)<<m.bucketBits - 1)
+	return (*hashmapBucket)(unsafe.Pointer(uintptr(m.buckets) + bucketSize*bucketNumber))
+}
+
+// hashmapInsertNew stores a key and value that are being moved to the new
+// buckets in the first empty slot of their bucket chain. The key is not yet
+// stored in the new buckets, so there is no need to look for it, and the
+// number of entries stays the same.
+//go:nobounds
+func hashmapInsertNew(m *hashmap, key, value unsafe.Pointer, hash uint32) {
+	tophash := hashmapTopHash(hash)
+	bucketSize := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*8
+	bucketNumber := uintptr(hash) & (uintptr(1)<<m.bucketBits - 1)
+	bucket := (*hashmapBucket)(unsafe.Pointer(uintptr(m.buckets) + bucketSize*bucketNumber))
+	for {
+		for i := uintptr(0); i < 8; i++ {
+			if bucket.tophash[i] != 0 {
+				continue
+			}
+			slotKeyOffset := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*uintptr(i)
+			slotKey := unsafe.Pointer(uintptr(unsafe.Pointer(bucket)) + slotKeyOffset)
+			slotValueOffset := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*uintptr(i)
+			slotValue := unsafe.Pointer(uintptr(unsafe.Pointer(bucket)) + slotValueOffset)
+			memcpy(slotKey, key, uintptr(m.keySize))
+			memcpy(slotValue, value, uintptr(m.valueSize))
+			bucket.tophash[i] = tophash
+			return
+		}
+		if bucket.next == nil {
+			// Add a new bucket to the bucket chain.
+			bucket.next = hashmapInsertIntoNewBucket(m, key, value, tophash)
+			return
+		}
+		bucket = bucket.next
+	}
+}
+
 // Return the number of entries in this hashmap, called from the len builtin.
 // A nil hashmap is defined as having length 0.
 //go:inline
@@ -96,20 +238,23 @@ func hashmapLenUnsafePointer(p unsafe.Pointer) int {
 
 // Set a specified key to a given value. Grow the map if necessary.
 //go:nobounds
-func hashmapSet(m *hashmap, key unsafe.Pointer, value unsafe.Pointer, hash uint32, keyEqual func(x, y unsafe.Pointer, n uintptr) bool) {
+func hashmapSet(m *hashmap, key unsafe.Pointer, value unsafe.Pointer, hash uint32, keyHash func(key unsafe.Pointer, n uintptr) uint32, keyEqual func(x, y unsafe.Pointer, n uintptr) bool) {
 	tophash := hashmapTopHash(hash)
 
 	if m.buckets == nil {
 		// No bucket was allocated yet, do so now.
 		m.buckets = unsafe.Pointer(hashmapInsertIntoNewBucket(m, key, value, tophash))
+		m.count++
 		return
 	}
 
-	numBuckets := uintptr(1) << m.bucketBits
-	bucketNumber := (uintptr(hash) & (numBuckets - 1))
-	bucketSize := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*8
-	bucketAddr := uintptr(m.buckets) + bucketSize*bucketNumber
-	bucket := (*hashmapBucket)(unsafe.Pointer(bucketAddr))
+	// If the hashmap is growing, move two more old buckets to the new
+	// buckets. This is enough to finish growing long before the hashmap has
+	// to grow again.
+	hashmapEvacuate(m)
+	hashmapEvacuate(m)
+
+	bucket := hashmapFirstBucket(m, hash)
 	var lastBucket *hashmapBucket
 
 	// See whether the key already exists somewhere.
@@ -141,10 +286,18 @@ func hashmapSet(m *hashmap, key unsafe.Pointer, value unsafe.Pointer, hash uint3
 		lastBucket = bucket
 		bucket = bucket.next
 	}
+	if hashmapShouldGrow(m) {
+		// The key doesn't exist yet and the hashmap is getting full. Grow it
+		// to keep insert and lookup time constant, and insert the key into
+		// the grown hashmap.
+		hashmapGrow(m, keyHash, keyEqual)
+		hashmapSet(m, key, value, hash, keyHash, keyEqual)
+		return
+	}
 	if emptySlotKey == nil {
 		// Add a new bucket to the bucket chain.
-		// TODO: rebalance if necessary to avoid O(n) insert and lookup time.
 		lastBucket.next = (*hashmapBucket)(hashmapInsertIntoNewBucket(m, key, value, tophash))
+		m.count++
 		return
 	}
 	m.count++
@@ -163,7 +316,6 @@ func hashmapInsertIntoNewBucket(m *hashmap, key, value unsafe.Pointer, tophash u
 	slotKey := unsafe.Pointer(uintptr(bucketBuf) + slotKeyOffset)
 	slotValueOffset := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8
 	slotValue := unsafe.Pointer(uintptr(bucketBuf) + slotValueOffset)
-	m.count++
 	memcpy(slotKey, key, uintptr(m.keySize))
 	memcpy(slotValue, value, uintptr(m.valueSize))
 	bucket := (*hashmapBucket)(bucketBuf)
@@ -181,11 +333,7 @@ func hashmapGet(m *hashmap, key, value unsafe.Pointer, valueSize uintptr, hash u
 		memzero(value, uintptr(valueSize))
 		return false
 	}
-	numBuckets := uintptr(1) << m.bucketBits
-	bucketNumber := (uintptr(hash) & (numBuckets - 1))
-	bucketSize := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*8
-	bucketAddr := uintptr(m.buckets) + bucketSize*bucketNumber
-	bucket := (*hashmapBucket)(unsafe.Pointer(bucketAddr))
+	bucket := hashmapFirstBucket(m, hash)
 
 	tophash := uint8(hash >> 24)
 	if tophash < 1 {
@@ -227,11 +375,13 @@ func hashmapDelete(m *hashmap, key unsafe.Pointer, hash uint32, keyEqual func(x,
 		// > no-op.
 		return
 	}
-	numBuckets := uintptr(1) << m.bucketBits
-	bucketNumber := (uintptr(hash) & (numBuckets - 1))
-	bucketSize := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*8
-	bucketAddr := uintptr(m.buckets) + bucketSize*bucketNumber
-	bucket := (*hashmapBucket)(unsafe.Pointer(bucketAddr))
+
+	// If the hashmap is growing, move two more old buckets to the new
+	// buckets, like hashmapSet does.
+	hashmapEvacuate(m)
+	hashmapEvacuate(m)
+
+	bucket := hashmapFirstBucket(m, hash)
 
 	tophash := uint8(hash >> 24)
 	if tophash < 1 {
@@ -259,6 +409,14 @@ func hashmapDelete(m *hashmap, key unsafe.Pointer, hash uint32, keyEqual func(x,
 }
 
 // Iterate over a hashmap.
+//
+// The iterator keeps using the buckets that the hashmap had when the iteration
+// started, even if the hashmap is grown in the meantime. In that case, every
+// key that is found in the old buckets is looked up in the hashmap to get the
+// current value, or to skip it if it has been deleted. Keys that are inserted
+// after the hashmap was grown may or may not be returned, which is allowed by
+// the Go spec. As the old buckets are not changed when they are moved to the
+// new buckets, they still hold every key that wasn't deleted.
 //go:nobounds
 func hashmapNext(m *hashmap, it *hashmapIterator, key, value unsafe.Pointer) bool {
 	if m == nil {
@@ -268,7 +426,16 @@ func hashmapNext(m *hashmap, it *hashmapIterator, key, value unsafe.Pointer) boo
 		return false
 	}
 
-	numBuckets := uintptr(1) << m.bucketBits
+	if it.buckets == nil {
+		// First call to hashmapNext with this iterator. If the hashmap is
+		// still growing, its entries are spread over the old and the new
+		// buckets, so finish growing first. This doesn't make iterating any
+		// slower than O(n).
+		hashmapFinishGrowing(m)
+		it.buckets = m.buckets
+		it.numBuckets = uintptr(1) << m.bucketBits
+	}
+
 	for {
 		if it.bucketIndex >= 8 {
 			// end of bucket, move to the next in the chain
@@ -276,12 +443,12 @@ func hashmapNext(m *hashmap, it *hashmapIterator, key, value unsafe.Pointer) boo
 			it.bucket = it.bucket.next
 		}
 		if it.bucket == nil {
-			if it.bucketNumber >= numBuckets {
+			if it.bucketNumber >= it.numBuckets {
 				// went through all buckets
 				return false
 			}
 			bucketSize := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*8
-			bucketAddr := uintptr(m.buckets) + bucketSize*it.bucketNumber
+			bucketAddr := uintptr(it.buckets) + bucketSize*it.bucketNumber
 			it.bucket = (*hashmapBucket)(unsafe.Pointer(bucketAddr))
 			it.bucketNumber++ // next bucket
 		}
@@ -297,19 +464,35 @@ func hashmapNext(m *hashmap, it *hashmapIterator, key, value unsafe.Pointer) boo
 		slotValueOffset := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*uintptr(it.bucketIndex)
 		slotValue := unsafe.Pointer(bucketAddr + slotValueOffset)
 		memcpy(key, slotKey, uintptr(m.keySize))
-		memcpy(value, slotValue, uintptr(m.valueSize))
 		it.bucketIndex++
 
+		if it.buckets != m.buckets {
+			// The hashmap was grown during the iteration, so the old buckets
+			// may be out of date. Look up the current value.
+			hash := m.keyHash(key, uintptr(m.keySize))
+			if !hashmapGet(m, key, value, uintptr(m.valueSize), hash, m.keyEqual) {
+				// The key has been deleted since the hashmap was grown.
+				continue
+			}
+			return true
+		}
+
+		memcpy(value, slotValue, uintptr(m.valueSize))
 		return true
 	}
 }
 
+// wrapper for use in reflect
+func hashmapNextUnsafePointer(p unsafe.Pointer, it unsafe.Pointer, key, value unsafe.Pointer) bool {
+	return hashmapNext((*hashmap)(p), (*hashmapIterator)(it), key, value)
+}
+
 // Hashmap with plain binary data keys (not containing strings etc.).
 
 func hashmapBinarySet(m *hashmap, key, value unsafe.Pointer) {
 	// TODO: detect nil map here and throw a better panic message?
 	hash := hashmapHash(key, uintptr(m.keySize))
-	hashmapSet(m, key, value, hash, memequal)
+	hashmapSet(m, key, value, hash, hashmapHash, memequal)
 }
 
 func hashmapBinaryGet(m *hashmap, key, value unsafe.Pointer, valueSize uintptr) bool {
@@ -329,6 +512,20 @@ func hashmapBinaryDelete(m *hashmap, key unsafe.Pointer) {
 	hashmapDelete(m, key, hash, memequal)
 }
 
+// wrappers for use in reflect
+
+func hashmapBinarySetUnsafePointer(p, key, value unsafe.Pointer) {
+	hashmapBinarySet((*hashmap)(p), key, value)
+}
+
+func hashmapBinaryGetUnsafePointer(p, key, value unsafe.Pointer, valueSize uintptr) bool {
+	return hashmapBinaryGet((*hashmap)(p), key, value, valueSize)
+}
+
+func hashmapBinaryDeleteUnsafePointer(p, key unsafe.Pointer) {
+	hashmapBinaryDelete((*hashmap)(p), key)
+}
+
 // Hashmap with string keys (a common case).
 
 func hashmapStringEqual(x, y unsafe.Pointer, n uintptr) bool {
@@ -340,9 +537,13 @@ func hashmapStringHash(s string) uint32 {
 	return hashmapHash(unsafe.Pointer(_s.ptr), uintptr(_s.length))
 }
 
+func hashmapStringPtrHash(sptr unsafe.Pointer, size uintptr) uint32 {
+	return hashmapStringHash(*(*string)(sptr))
+}
+
 func hashmapStringSet(m *hashmap, key string, value unsafe.Pointer) {
 	hash := hashmapStringHash(key)
-	hashmapSet(m, unsafe.Pointer(&key), value, hash, hashmapStringEqual)
+	hashmapSet(m, unsafe.Pointer(&key), value, hash, hashmapStringPtrHash, hashmapStringEqual)
 }
 
 func hashmapStringGet(m *hashmap, key string, value unsafe.Pointer, valueSize uintptr) bool {
@@ -355,6 +556,20 @@ func hashmapStringDelete(m *hashmap, key string) {
 	hashmapDelete(m, unsafe.Pointer(&key), hash, hashmapStringEqual)
 }
 
+// wrappers for use in reflect
+
+func hashmapStringSetUnsafePointer(p unsafe.Pointer, key string, value unsafe.Pointer) {
+	hashmapStringSet((*hashmap)(p), key, value)
+}
+
+func hashmapStringGetUnsafePointer(p unsafe.Pointer, key string, value unsafe.Pointer, valueSize uintptr) bool {
+	return hashmapStringGet((*hashmap)(p), key, value, valueSize)
+}
+
+func hashmapStringDeleteUnsafePointer(p unsafe.Pointer, key string) {
+	hashmapStringDelete((*hashmap)(p), key)
+}
+
 // Hashmap with interface keys (for everything else).
 
 // This is a method that is intentionally unexported in the reflect package. It
@@ -440,13 +655,17 @@ func hashmapInterfaceHash(itf interface{}) uint32 {
 	}
 }
 
+func hashmapInterfacePtrHash(iptr unsafe.Pointer, size uintptr) uint32 {
+	return hashmapInterfaceHash(*(*interface{})(iptr))
+}
+
 func hashmapInterfaceEqual(x, y unsafe.Pointer, n uintptr) bool {
 	return *(*interface{})(x) == *(*interface{})(y)
 }
 
 func hashmapInterfaceSet(m *hashmap, key interface{}, value unsafe.Pointer) {
 	hash := hashmapInterfaceHash(key)
-	hashmapSet(m, unsafe.Pointer(&key), value, hash, hashmapInterfaceEqual)
+	hashmapSet(m, unsafe.Pointer(&key), value, hash, hashmapInterfacePtrHash, hashmapInterfaceEqual)
 }
 
 func hashmapInterfaceGet(m *hashmap, key interface{}, value unsafe.Pointer, valueSize uintptr) bool {
@@ -458,3 +677,17 @@ func hashmapInterfaceDelete(m *hashmap, key interface{}) {
 	hash := hashmapInterfaceHash(key)
 	hashmapDelete(m, unsafe.Pointer(&key), hash, hashmapInterfaceEqual)
 }
+
+// wrappers for use in reflect
+
+func hashmapInterfaceSetUnsafePointer(p unsafe.Pointer, key interface{}, value unsafe.Pointer) {
+	hashmapInterfaceSet((*hashmap)(p), key, value)
+}
+
+func hashmapInterfaceGetUnsafePointer(p unsafe.Pointer, key interface{}, value unsafe.Pointer, valueSize uintptr) bool {
+	return hashmapInterfaceGet((*hashmap)(p), key, value, valueSize)
+}
+
+func hashmapInterfaceDeleteUnsafePointer(p unsafe.Pointer, key interface{}) {
+	hashmapInterfaceDelete((*hashmap)(p), key)
+}
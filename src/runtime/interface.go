@@ -95,8 +95,10 @@ func interfaceTypeAssert(ok bool) {
 // See compiler/interface-lowering.go for details.
 
 type interfaceMethodInfo struct {
-	signature *uint8  // external *i8 with a name identifying the Go function signature
-	funcptr   uintptr // bitcast from the actual function pointer
+	signature **typecodeID // global with a name identifying the Go method signature, pointing to the method type
+	funcptr   uintptr      // bitcast from the actual function pointer
+	funcType  *typecodeID  // function type with the receiver as first parameter (only for exported methods)
+	function  uintptr      // bitcast from the method itself, callable as funcType (only for exported methods)
 }
 
 type typecodeID struct {
@@ -107,10 +109,13 @@ type typecodeID struct {
 	// * interface: null
 	// * chan/pointer/slice/array: the element type
 	// * struct: bitcast of global with structField array
-	// * func/map: TODO
+	// * map: bitcast of global with the key and element type
+	// * func: bitcast of global with the parameter and result types, and
+	//   the wrappers used by reflect.Value.Call and reflect.MakeFunc
 	references *typecodeID
 
-	// The array length, for array types.
+	// The array length, for array types. For named types, this is a ptrtoint
+	// of a global with the type name.
 	length uintptr
 
 	methodSet *interfaceMethodInfo // nil or a GEP of an array
@@ -132,6 +137,7 @@ type structField struct {
 	name     *uint8      // pointer to char array
 	tag      *uint8      // pointer to char array, or nil
 	embedded bool
+	pkgPath  *uint8 // pointer to char array for unexported fields, or nil
 }
 
 // Pseudo function call used during a type assert. It is used during interface
@@ -1,11 +1,17 @@
-// +build gc.conservative
-
 package runtime
 
 // Memory statistics
 
 // Subset of memory statistics from upstream Go.
-// Works with conservative gc only.
+// ReadMemStats is implemented by each GC, using the counters below for the
+// allocation statistics.
+
+// Allocation counters, which must be updated by every GC implementation.
+var (
+	gcTotalAlloc uint64 // total number of bytes allocated
+	gcMallocs    uint64 // total number of allocations
+	gcFrees      uint64 // total number of objects freed
+)
 
 // A MemStats records statistics about the memory allocator.
 type MemStats struct {
@@ -18,8 +24,28 @@ type MemStats struct {
 	// heap, stacks, and other internal data structures.
 	Sys uint64
 
+	// TotalAlloc is cumulative bytes allocated for heap objects.
+	//
+	// TotalAlloc increases as heap objects are allocated, but
+	// unlike HeapAlloc, it does not decrease when objects are
+	// freed.
+	TotalAlloc uint64
+
+	// Mallocs is the cumulative count of heap objects allocated.
+	// The number of live objects is Mallocs - Frees.
+	Mallocs uint64
+
+	// Frees is the cumulative count of heap objects freed.
+	Frees uint64
+
 	// Heap memory statistics.
 
+	// HeapAlloc is bytes of allocated heap objects.
+	//
+	// In TinyGo, this is rounded up to whole heap blocks for GCs that
+	// allocate memory in blocks, so it is the same as HeapInuse.
+	HeapAlloc uint64
+
 	// HeapSys is bytes of heap memory, total.
 	//
 	// In TinyGo unlike upstream Go, we make no distinction between
@@ -46,24 +72,3 @@ type MemStats struct {
 	// GCSys is bytes of memory in garbage collection metadata.
 	GCSys uint64
 }
-
-// ReadMemStats populates m with memory statistics.
-//
-// The returned memory statistics are up to date as of the
-// call to ReadMemStats. This would not do GC implicitly for you.
-func ReadMemStats(m *MemStats) {
-	m.HeapIdle = 0
-	m.HeapInuse = 0
-	for block := gcBlock(0); block < endBlock; block++ {
-		bstate := block.state()
-		if bstate == blockStateFree {
-			m.HeapIdle += uint64(bytesPerBlock)
-		} else {
-			m.HeapInuse += uint64(bytesPerBlock)
-		}
-	}
-	m.HeapReleased = 0 // always 0, we don't currently release memory back to the OS.
-	m.HeapSys = m.HeapInuse + m.HeapIdle
-	m.GCSys = uint64(heapEnd - uintptr(metadataStart))
-	m.Sys = uint64(heapEnd - heapStart)
-}
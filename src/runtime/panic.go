@@ -1,72 +1,180 @@
 package runtime
 
+import (
+	"internal/task"
+	"unsafe"
+)
+
 // trap is a compiler hint that this function cannot be executed. It is
 // translated into either a trap instruction or a call to abort().
 //export llvm.trap
 func trap()
 
+// A deferFrame is a stack allocated object that stores information about the
+// current "defer frame", which is created by every function that uses the defer
+// keyword. These frames form a linked list per goroutine.
+// The compiler (and the assembly in tinygo_longjmp) knows about the layout of
+// the first four fields, so they should not be moved without also updating
+// compiler/defer.go and the assembly files.
+type deferFrame struct {
+	JumpSP     unsafe.Pointer // stack pointer to return to
+	JumpPC     unsafe.Pointer // pc to return to (just past the last checkpoint)
+	JumpFP     unsafe.Pointer // frame pointer to restore
+	DeferPtr   unsafe.Pointer // linked list of deferred calls (*_defer)
+	Previous   *deferFrame    // previous defer frame in this goroutine
+	Panicking  bool           // true iff this defer frame is panicking
+	PanicValue interface{}    // panic value, might be nil for panic(nil)
+}
+
+// systemDeferFrame is the topmost defer frame when not running in a goroutine,
+// for example in an interrupt that happened while the scheduler was idle.
+var systemDeferFrame unsafe.Pointer
+
+// deferFrameHead returns a pointer to the topmost defer frame pointer of the
+// current goroutine.
+func deferFrameHead() *unsafe.Pointer {
+	if currentTask := task.Current(); currentTask != nil {
+		return &currentTask.DeferFrame
+	}
+	return &systemDeferFrame
+}
+
 // Builtin function panic(msg), used as a compiler intrinsic.
 func _panic(message interface{}) {
+	if supportsRecover {
+		head := deferFrameHead()
+		frame := (*deferFrame)(*head)
+		for frame != nil && frame.JumpPC == nil {
+			// No defer statement was executed yet in this function, so there
+			// is nothing to run. Continue with the parent frame.
+			frame = frame.Previous
+		}
+		if frame != nil {
+			// Unwind to the function that created this defer frame, and run
+			// its deferred calls.
+			*head = unsafe.Pointer(frame)
+			frame.PanicValue = message
+			frame.Panicking = true
+			tinygo_longjmp(frame)
+			// unreachable
+		}
+	}
 	printstring("panic: ")
 	printitf(message)
 	printnl()
+	printPanicTraceback()
 	abort()
 }
 
 // Cause a runtime panic, which is (currently) always a string.
+// Unlike the panics raised through runtimeErrorPanic, these panics cannot be
+// recovered as they indicate a broken invariant in the runtime itself.
 func runtimePanic(msg string) {
 	printstring("panic: runtime error: ")
 	println(msg)
+	printPanicTraceback()
 	abort()
 }
 
+// Cause a recoverable runtime panic with the given runtime.Error value.
+func runtimeErrorPanic(err *runtimeError) {
+	_panic(err)
+}
+
+// Called at the start of a function that includes a deferred call. It gets
+// passed in the stack-allocated defer frame and pushes it onto the list of
+// defer frames of the current goroutine. The jump stack pointer and program
+// counter are filled in after each defer statement.
+//go:inline
+func setupDeferFrame(frame *deferFrame) {
+	head := deferFrameHead()
+	frame.JumpPC = nil
+	frame.Previous = (*deferFrame)(*head)
+	frame.Panicking = false
+	*head = unsafe.Pointer(frame)
+}
+
+// Called right before the return instruction of a function with a defer frame.
+// It pops the defer frame from the list of defer frames. If the frame is still
+// panicking (the panic was not recovered by any of the deferred calls), the
+// panic is raised again in the parent frame.
+//go:inline
+func destroyDeferFrame(frame *deferFrame) {
+	*deferFrameHead() = unsafe.Pointer(frame.Previous)
+	if frame.Panicking {
+		// Still panicking, continue unwinding the stack.
+		_panic(frame.PanicValue)
+	}
+}
+
 // Try to recover a panicking goroutine.
-func _recover() interface{} {
-	// Deferred functions are currently not executed during panic, so there is
-	// no way this can return anything besides nil.
+// The useParentFrame parameter is set when the caller of recover() has a defer
+// frame of its own. That frame can't be panicking (it is currently running
+// deferred calls of the parent frame), so look one frame up instead.
+func _recover(useParentFrame bool) interface{} {
+	if !supportsRecover {
+		// Deferred functions are not executed during panic on this
+		// architecture, so there is no way this can return anything besides
+		// nil.
+		return nil
+	}
+	// TODO: check that recover() is called directly by a deferred function, as
+	// required by the Go specification.
+	frame := (*deferFrame)(*deferFrameHead())
+	if useParentFrame && frame != nil {
+		frame = frame.Previous
+	}
+	if frame != nil && frame.Panicking {
+		// Only the first call to recover returns the panic value. It also
+		// stops the panic, so that the function that created the defer frame
+		// returns normally.
+		frame.Panicking = false
+		return frame.PanicValue
+	}
+	// Not panicking, so return a nil interface.
 	return nil
 }
 
 // Panic when trying to dereference a nil pointer.
 func nilPanic() {
-	runtimePanic("nil pointer dereference")
+	runtimeErrorPanic(&errNilPointer)
 }
 
 // Panic when trying to acces an array or slice out of bounds.
 func lookupPanic() {
-	runtimePanic("index out of range")
+	runtimeErrorPanic(&errIndexOutOfRange)
 }
 
 // Panic when trying to slice a slice out of bounds.
 func slicePanic() {
-	runtimePanic("slice out of range")
+	runtimeErrorPanic(&errSliceOutOfRange)
 }
 
 // Panic when trying to convert a slice to an array pointer (Go 1.17+) and the
 // slice is shorter than the array.
 func sliceToArrayPointerPanic() {
-	runtimePanic("slice smaller than array")
+	runtimeErrorPanic(&errSliceToArrayPointer)
 }
 
 // Panic when calling unsafe.Slice() (Go 1.17+) with a len that's too large
 // (which includes if the ptr is nil and len is nonzero).
 func unsafeSlicePanic() {
-	runtimePanic("unsafe.Slice: len out of range")
+	runtimeErrorPanic(&errUnsafeSlice)
 }
 
 // Panic when trying to create a new channel that is too big.
 func chanMakePanic() {
-	runtimePanic("new channel is too big")
+	runtimeErrorPanic(&errChanMake)
 }
 
 // Panic when a shift value is negative.
 func negativeShiftPanic() {
-	runtimePanic("negative shift")
+	runtimeErrorPanic(&errNegativeShift)
 }
 
 // Panic when there is a divide by zero.
 func divideByZeroPanic() {
-	runtimePanic("divide by zero")
+	runtimeErrorPanic(&errDivideByZero)
 }
 
 func blockingPanic() {
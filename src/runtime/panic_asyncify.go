@@ -0,0 +1,40 @@
+//go:build tinygo.wasm && scheduler.asyncify
+// +build tinygo.wasm,scheduler.asyncify
+
+package runtime
+
+import "internal/task"
+
+// WebAssembly can't jump to a different stack frame. Instead, the stack is
+// unwound and rewound using asyncify (like when switching goroutines): the
+// first defer statement in a function saves a copy of the goroutine stack in
+// the defer frame, and a panic replaces the stack with this copy.
+// Copying the stack is expensive, so the compiler only creates a defer frame
+// in functions with a deferred call that may recover the panic. Deferred calls
+// in other functions are not run on panic.
+// This must be kept in sync with supportsRecover in compiler/defer.go.
+const supportsRecover = true
+
+// deferCheckpoint is called by the compiler after each defer statement. It
+// returns false after saving the checkpoint, and true when a panic continued at
+// the checkpoint. The compiler then branches to the landing pad.
+//
+// Only the first checkpoint in each function call is saved, as the landing pad
+// only uses the state in the defer frame. The checkpoint is stored in the
+// JumpPC field, which stays nil when not running in a goroutine: panics can't
+// be recovered there.
+//
+// This function must return without allocating after making the checkpoint, see
+// task.MakeCheckpoint.
+func deferCheckpoint(frame *deferFrame) bool {
+	if frame.JumpPC != nil {
+		return false
+	}
+	return task.MakeCheckpoint(&frame.JumpPC)
+}
+
+// tinygo_longjmp is called when a panic needs to unwind the stack to the
+// nearest defer frame. It continues at the checkpoint stored in the frame.
+func tinygo_longjmp(frame *deferFrame) {
+	task.Jump(frame.JumpPC)
+}
@@ -0,0 +1,14 @@
+//go:build avr || xtensa || (tinygo.wasm && !scheduler.asyncify)
+// +build avr xtensa tinygo.wasm,!scheduler.asyncify
+
+package runtime
+
+// Unwinding the stack on panic has not been implemented for this architecture.
+// On WebAssembly, it needs the asyncify scheduler.
+// Deferred calls are not run when panicking, and recover() always returns nil.
+const supportsRecover = false
+
+func tinygo_longjmp(frame *deferFrame) {
+	// Unreachable, as the compiler doesn't create defer frames.
+	trap()
+}
@@ -0,0 +1,16 @@
+//go:build !avr && !tinygo.wasm && !xtensa
+// +build !avr,!tinygo.wasm,!xtensa
+
+package runtime
+
+// The compiler creates defer frames on this architecture, so that a panic can
+// unwind the stack and run deferred calls.
+// This must be kept in sync with supportsRecover in compiler/defer.go.
+const supportsRecover = true
+
+// tinygo_longjmp is called when a panic needs to unwind the stack to the
+// nearest defer frame. It restores the stack pointer stored in the frame and
+// jumps to the program counter stored in it, which points just past the last
+// checkpoint in the function that created the defer frame.
+//export tinygo_longjmp
+func tinygo_longjmp(frame *deferFrame)
@@ -4,7 +4,8 @@ package runtime
 // cooperative round robin scheduler, with a runqueue that contains a linked
 // list of goroutines (tasks) that should be run next, in order of when they
 // were added to the queue (first-in, first-out). It also contains a sleep queue
-// with sleeping goroutines in order of when they should be re-activated.
+// with sleeping goroutines in order of when they should be re-activated, and a
+// timer queue with active timers (see timer.go).
 //
 // The scheduler is used both for the asyncify based scheduler and for the task
 // based scheduler. In both cases, the 'internal/task.Task' type is used to represent one
@@ -119,7 +120,7 @@ func scheduler() {
 	for !schedulerDone {
 		scheduleLog("")
 		scheduleLog("  schedule")
-		if sleepQueue != nil {
+		if sleepQueue != nil || timerQueue != nil {
 			now = ticks()
 		}
 
@@ -134,9 +135,15 @@ func scheduler() {
 			runqueue.Push(t)
 		}
 
+		// Run the callback of the first timer that has expired, if any.
+		if timerQueue != nil && now >= timerQueue.whenTicks() {
+			scheduleLog("  timer fired")
+			fireTimer()
+		}
+
 		t := runqueue.Pop()
 		if t == nil {
-			if sleepQueue == nil {
+			if sleepQueue == nil && timerQueue == nil {
 				if asyncScheduler {
 					// JavaScript is treated specially, see below.
 					return
@@ -144,7 +151,20 @@ func scheduler() {
 				waitForEvents()
 				continue
 			}
-			timeLeft := timeUnit(sleepQueue.Data) - (now - sleepQueueBaseTime)
+			var timeLeft timeUnit
+			if sleepQueue != nil {
+				timeLeft = timeUnit(sleepQueue.Data) - (now - sleepQueueBaseTime)
+			}
+			if timerQueue != nil {
+				timerLeft := timerQueue.whenTicks() - now
+				if sleepQueue == nil || timerLeft < timeLeft {
+					timeLeft = timerLeft
+				}
+				if timeLeft <= 0 {
+					// Another timer already expired.
+					continue
+				}
+			}
 			if schedulerDebug {
 				println("  sleeping...", sleepQueue, uint(timeLeft))
 				for t := sleepQueue; t != nil; t = t.Next {
@@ -3,13 +3,24 @@
 
 package runtime
 
+// Sleep for the given duration. There is no scheduler to run timers in the
+// background, so timers that expire while sleeping are run here.
 //go:linkname sleep time.Sleep
 func sleep(duration int64) {
 	if duration <= 0 {
 		return
 	}
 
-	sleepTicks(nanosecondsToTicks(duration))
+	wakeup := ticks() + nanosecondsToTicks(duration)
+	for timerQueue != nil && timerQueue.whenTicks() < wakeup {
+		if timeLeft := timerQueue.whenTicks() - ticks(); timeLeft > 0 {
+			sleepTicks(timeLeft)
+		}
+		fireTimer()
+	}
+	if timeLeft := wakeup - ticks(); timeLeft > 0 {
+		sleepTicks(timeLeft)
+	}
 }
 
 // getSystemStackPointer returns the current stack pointer of the system stack.
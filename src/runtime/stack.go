@@ -1,24 +1,82 @@
 package runtime
 
+import "unsafe"
+
+// A Func represents a Go function in the running binary. It points directly
+// into the PC table, so the layout must match builder/pctable.go.
 type Func struct {
+	start     int32 // relative to pcTable
+	size      uint32
+	name      uint32
+	firstLine uint32
 }
 
+// FuncForPC returns a *Func describing the function that contains the given
+// program counter address, or else nil.
 func FuncForPC(pc uintptr) *Func {
-	return nil
+	return findFunc(pc)
 }
 
+// Name returns the name of the function.
 func (f *Func) Name() string {
-	return ""
+	if f == nil {
+		return ""
+	}
+	return pcTableString(f.name)
+}
+
+// Entry returns the entry address of the function.
+func (f *Func) Entry() uintptr {
+	return pcTableBase() + uintptr(int(f.start))
 }
 
+// FileLine returns the file name and line number of the source code
+// corresponding to the program counter pc. The result will not be accurate if
+// pc is not a program counter within f. It returns an empty file name and a
+// zero line number if no line information is available.
 func (f *Func) FileLine(pc uintptr) (file string, line int) {
-	return "", 0
+	// The line entries of this function end where the line entries of the next
+	// function start.
+	numFuncs, numLines := pcTableHeader()
+	index := uint32((uintptr(unsafe.Pointer(f)) - pcTableBase() - pcTableHeaderSize) / pcTableFuncSize)
+	end := numLines
+	if index+1 < numFuncs {
+		end = pcTableFunc(index + 1).firstLine
+	}
+	for i := f.firstLine; i < end; i++ {
+		entry := pcTableLineAt(i)
+		if pcTableBase()+uintptr(int(entry.start)) > pc {
+			break
+		}
+		file = pcTableString(entry.file)
+		line = int(entry.line)
+	}
+	return
 }
 
+// Caller reports file and line number information about function invocations
+// on the calling goroutine's stack. The argument skip is the number of stack
+// frames to ascend, with 0 identifying the caller of Caller.
+// It only returns information when stack traces are enabled.
+//go:noinline
 func Caller(skip int) (pc uintptr, file string, line int, ok bool) {
-	return 0, "", 0, false
+	var pcs [1]uintptr
+	if callers(skip+1, pcs[:]) == 0 {
+		return 0, "", 0, false
+	}
+	pc = pcs[0]
+	f := findFunc(pc - 1)
+	file, line = f.FileLine(pc - 1)
+	return pc, file, line, true
 }
 
+// Stack formats a stack trace of the calling goroutine into buf and returns the
+// number of bytes written to buf. Stack traces of other goroutines are not
+// supported, so the all parameter is ignored.
+// Nothing is written when stack traces are disabled.
+//go:noinline
 func Stack(buf []byte, all bool) int {
-	return 0
+	w := tracebackWriter{buf: buf}
+	traceback(&w, 1)
+	return w.n
 }
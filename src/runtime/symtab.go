@@ -1,21 +1,139 @@
 package runtime
 
+import "unsafe"
+
+// pcTable maps program counters to functions and source locations. It is
+// created by the compiler and filled in after linking, see builder/pctable.go
+// for a description of the format. It is empty (has no functions) when stack
+// traces are disabled.
+//go:extern runtime.pcTable
+var pcTable [0]uint32
+
+// pcTableLine is a single line entry in the PC table.
+type pcTableLine struct {
+	start int32 // relative to pcTable
+	file  uint32
+	line  uint32
+}
+
+const (
+	pcTableHeaderSize = 8
+	pcTableFuncSize   = 16
+	pcTableLineSize   = 12
+)
+
+// Return the address of the PC table. Addresses in the table are relative to
+// this address.
+func pcTableBase() uintptr {
+	return uintptr(unsafe.Pointer(&pcTable))
+}
+
+// Return the number of functions and the number of line entries in the PC
+// table.
+func pcTableHeader() (numFuncs, numLines uint32) {
+	header := (*[2]uint32)(unsafe.Pointer(&pcTable))
+	return header[0], header[1]
+}
+
+// Return the function at the given index in the PC table.
+func pcTableFunc(index uint32) *Func {
+	return (*Func)(unsafe.Pointer(pcTableBase() + pcTableHeaderSize + uintptr(index)*pcTableFuncSize))
+}
+
+// Return the line entry at the given index in the PC table.
+func pcTableLineAt(index uint32) *pcTableLine {
+	numFuncs, _ := pcTableHeader()
+	return (*pcTableLine)(unsafe.Pointer(pcTableBase() + pcTableHeaderSize + uintptr(numFuncs)*pcTableFuncSize + uintptr(index)*pcTableLineSize))
+}
+
+// Return the NUL-terminated string at the given offset in the PC table.
+func pcTableString(offset uint32) string {
+	ptr := (*byte)(unsafe.Pointer(pcTableBase() + uintptr(offset)))
+	length := uintptr(0)
+	for *(*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(ptr)) + length)) != 0 {
+		length++
+	}
+	s := _string{ptr: ptr, length: length}
+	return *(*string)(unsafe.Pointer(&s))
+}
+
+// findFunc returns the function that contains the given program counter, or
+// nil if it isn't a known (Go) function.
+func findFunc(pc uintptr) *Func {
+	numFuncs, _ := pcTableHeader()
+	// Binary search for the last function that starts at or before pc.
+	low, high := uint32(0), numFuncs
+	for low < high {
+		mid := low + (high-low)/2
+		if pcTableFunc(mid).Entry() <= pc {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	if low == 0 {
+		return nil
+	}
+	f := pcTableFunc(low - 1)
+	if pc >= f.Entry()+uintptr(f.size) {
+		return nil
+	}
+	return f
+}
+
+// Frames may be used to get function/file/line information for a slice of PC
+// values returned by Callers.
 type Frames struct {
-	//
+	callers []uintptr
 }
 
+// Frame is the information returned by Frames for each call frame.
 type Frame struct {
+	// PC is the program counter for the location in this frame. For a frame
+	// that calls another frame, this will be the program counter of a call
+	// instruction.
+	PC uintptr
+
+	// Func is the Func value of this call frame. This may be nil for non-Go
+	// code.
+	Func *Func
+
+	// Function is the package path-qualified function name of this call frame.
 	Function string
 
+	// File and Line are the file name and line number of the location in this
+	// frame. These may not be available when debug information was stripped.
 	File string
 	Line int
-	PC   uintptr
+
+	// Entry point program counter for the function.
+	Entry uintptr
 }
 
+// CallersFrames takes a slice of PCs returned by Callers and prepares to return
+// function/file/line information. Do not change the slice until you are done
+// with the Frames.
 func CallersFrames(callers []uintptr) *Frames {
-	return nil
+	return &Frames{callers: callers}
 }
 
+// Next returns a Frame representing the next call frame in the slice of PC
+// values, and reports whether there are more frames after it.
 func (ci *Frames) Next() (frame Frame, more bool) {
+	for len(ci.callers) != 0 {
+		// Look up the call instruction, not the instruction after it.
+		pc := ci.callers[0] - 1
+		ci.callers = ci.callers[1:]
+		f := findFunc(pc)
+		if f == nil {
+			continue
+		}
+		frame.PC = pc
+		frame.Func = f
+		frame.Function = f.Name()
+		frame.File, frame.Line = f.FileLine(pc)
+		frame.Entry = f.Entry()
+		return frame, len(ci.callers) != 0
+	}
 	return Frame{}, false
 }
@@ -0,0 +1,115 @@
+package runtime
+
+// This file implements the runtime side of time.Timer, time.Ticker,
+// time.After and time.AfterFunc. Active timers are kept in a queue sorted by
+// the time at which they should fire. The scheduler pops expired timers from
+// this queue and runs their callback (implemented in the time package), which
+// either sends the current time on a channel or starts a new goroutine.
+
+// timer is the runtime representation of a timer. It must have the same layout
+// as the runtimeTimer struct in the time package.
+type timer struct {
+	pp       uintptr
+	when     int64
+	period   int64
+	f        func(interface{}, uintptr)
+	arg      interface{}
+	seq      uintptr
+	nextwhen int64
+	status   uint32
+}
+
+// timerNode is a single entry in the timer queue. It is allocated separately
+// from the timer itself so that the timer remains reachable (by the garbage
+// collector) for as long as it is active, even if the program no longer
+// references it, as is common with time.After.
+type timerNode struct {
+	next  *timerNode
+	timer *timer
+}
+
+// timerQueue is a linked list of active timers, sorted by the time at which
+// they fire (earliest first).
+var timerQueue *timerNode
+
+// whenTicks returns the time at which this timer should fire, in ticks.
+func (tn *timerNode) whenTicks() timeUnit {
+	return nanosecondsToTicks(tn.timer.when)
+}
+
+// Add a timer node to the timer queue, keeping the queue sorted.
+func addTimer(tn *timerNode) {
+	q := &timerQueue
+	for ; *q != nil; q = &(*q).next {
+		if tn.timer.when < (*q).timer.when {
+			// this will fire earlier than the next - insert here
+			break
+		}
+	}
+	tn.next = *q
+	*q = tn
+}
+
+// Remove the given timer from the timer queue. It returns whether the timer was
+// found in the queue (meaning it was still active).
+func removeTimer(tim *timer) bool {
+	for q := &timerQueue; *q != nil; q = &(*q).next {
+		if (*q).timer == tim {
+			tn := *q
+			*q = tn.next
+			tn.next = nil
+			return true
+		}
+	}
+	return false
+}
+
+// Pop the first timer from the timer queue and run its callback. Periodic
+// timers (tickers) are added back to the queue.
+func fireTimer() {
+	tn := timerQueue
+	timerQueue = tn.next
+	tn.next = nil
+	tim := tn.timer
+	if tim.period != 0 {
+		// Re-add the timer before running the callback, so that the callback
+		// can stop or reset it. Ticks that were missed (for example because
+		// the scheduler was busy) are dropped, like in the standard Go runtime.
+		delta := nanotime() - tim.when
+		tim.when += tim.period * (1 + delta/tim.period)
+		addTimer(tn)
+	}
+	// Run the timer function (implemented in the time package).
+	tim.f(tim.arg, tim.seq)
+}
+
+//go:linkname startTimer time.startTimer
+func startTimer(tim *timer) {
+	scheduleLog("  start timer")
+	addTimer(&timerNode{timer: tim})
+}
+
+//go:linkname stopTimer time.stopTimer
+func stopTimer(tim *timer) bool {
+	scheduleLog("  stop timer")
+	return removeTimer(tim)
+}
+
+//go:linkname resetTimer time.resetTimer
+func resetTimer(tim *timer, when int64) bool {
+	removed := removeTimer(tim)
+	tim.when = when
+	startTimer(tim)
+	return removed
+}
+
+//go:linkname modTimer time.modTimer
+func modTimer(tim *timer, when, period int64, f func(interface{}, uintptr), arg interface{}, seq uintptr) {
+	removeTimer(tim)
+	tim.when = when
+	tim.period = period
+	tim.f = f
+	tim.arg = arg
+	tim.seq = seq
+	startTimer(tim)
+}
@@ -0,0 +1,135 @@
+package runtime
+
+// This file implements walking the stack, for runtime.Callers and for stack
+// traces printed on panic.
+//
+// Walking the stack relies on two things: the PC table (see symtab.go) and
+// frame pointers, which are maintained by all Go functions when stack traces
+// are enabled. The stack is walked from frame to frame for as long as the
+// return address points into a Go function. This way, the walk stops at the
+// first non-Go frame (such as the C function that called main or the assembly
+// that started a goroutine) without ever following a frame pointer that might
+// not be valid.
+
+// Fill pc with the return addresses of the goroutine stack, skipping the given
+// number of frames. The first frame (for skip=0) is the caller of callers.
+// It returns the number of entries written to pc.
+//go:noinline
+func callers(skip int, pc []uintptr) int {
+	if numFuncs, _ := pcTableHeader(); numFuncs == 0 {
+		// Stack traces are disabled.
+		return 0
+	}
+	n := 0
+	fp := frameAddress(0)
+	for fp != nil && n < len(pc) {
+		parent, returnAddress := nextFrame(fp)
+		if findFunc(returnAddress-1) == nil {
+			// Not returning to a Go function, so the parent frame pointer
+			// cannot be trusted.
+			break
+		}
+		if skip > 0 {
+			skip--
+		} else {
+			pc[n] = returnAddress
+			n++
+		}
+		if uintptr(parent) <= uintptr(fp) {
+			// The stack grows down, so this can't be the parent frame.
+			break
+		}
+		fp = parent
+	}
+	return n
+}
+
+// tracebackWriter writes a stack trace either to a buffer (for runtime.Stack)
+// or directly to the output (for panics), in which case it doesn't allocate.
+type tracebackWriter struct {
+	buf   []byte
+	n     int
+	print bool
+}
+
+func (w *tracebackWriter) writeString(s string) {
+	if w.print {
+		printstring(s)
+		return
+	}
+	w.n += copy(w.buf[w.n:], s)
+}
+
+func (w *tracebackWriter) writeUint(n uintptr, base uintptr) {
+	var digits [20]byte
+	i := len(digits)
+	for {
+		i--
+		digits[i] = "0123456789abcdef"[n%base]
+		n /= base
+		if n == 0 {
+			break
+		}
+	}
+	for _, c := range digits[i:] {
+		if w.print {
+			putchar(c)
+		} else if w.n < len(w.buf) {
+			w.buf[w.n] = c
+			w.n++
+		}
+	}
+}
+
+// Write a stack trace of the current goroutine in a format similar to the one
+// used by the standard Go runtime, skipping the given number of frames (with 0
+// being the caller of traceback). Function arguments are not known, so they
+// are always printed as "(...)".
+func traceback(w *tracebackWriter, skip int) {
+	var pcs [32]uintptr
+	n := callers(skip+1, pcs[:])
+	if n == 0 {
+		return
+	}
+	w.writeString("goroutine [running]:\n")
+	frames := Frames{callers: pcs[:n]}
+	for {
+		frame, more := frames.Next()
+		if frame.Func == nil {
+			break
+		}
+		w.writeString(frame.Function)
+		w.writeString("(...)\n\t")
+		if frame.File != "" {
+			w.writeString(frame.File)
+			w.writeString(":")
+			w.writeUint(uintptr(frame.Line), 10)
+		} else {
+			w.writeString("?")
+		}
+		w.writeString(" +0x")
+		w.writeUint(frame.PC+1-frame.Entry, 16)
+		w.writeString("\n")
+		if !more {
+			break
+		}
+	}
+}
+
+// Print a stack trace of the current goroutine when a program panics. Frames in
+// the runtime at the top of the stack (the panic implementation itself) are not
+// printed.
+func printPanicTraceback() {
+	var pcs [1]uintptr
+	skip := 0
+	for callers(skip+1, pcs[:]) != 0 {
+		name := findFunc(pcs[0] - 1).Name()
+		if len(name) < len("runtime.") || name[:len("runtime.")] != "runtime." {
+			break
+		}
+		skip++
+	}
+	w := tracebackWriter{print: true}
+	printnl()
+	traceback(&w, skip+1)
+}
@@ -0,0 +1,24 @@
+//go:build !avr && !tinygo.wasm && !xtensa && !tinygo.riscv
+// +build !avr,!tinygo.wasm,!xtensa,!tinygo.riscv
+
+package runtime
+
+import "unsafe"
+
+// Return the frame pointer of the calling function. Frame pointers are only
+// maintained by all functions when stack traces are enabled.
+//export llvm.frameaddress.p0i8
+func frameAddress(level int32) unsafe.Pointer
+
+// Return the frame pointer and return address stored in the frame pointed to
+// by fp. On these architectures, the frame pointer points to the saved frame
+// pointer of the parent, which is directly followed by the return address.
+func nextFrame(fp unsafe.Pointer) (parent unsafe.Pointer, returnAddress uintptr) {
+	parent = *(*unsafe.Pointer)(fp)
+	returnAddress = *(*uintptr)(unsafe.Pointer(uintptr(fp) + unsafe.Sizeof(uintptr(0))))
+	if GOARCH == "arm" {
+		// Clear the Thumb bit, if set.
+		returnAddress &^= 1
+	}
+	return
+}
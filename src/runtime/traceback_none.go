@@ -0,0 +1,17 @@
+//go:build avr || tinygo.wasm || xtensa
+// +build avr tinygo.wasm xtensa
+
+package runtime
+
+import "unsafe"
+
+// Stack traces are not supported on these architectures, so the PC table is
+// always empty and these functions are never called.
+
+func frameAddress(level int32) unsafe.Pointer {
+	return nil
+}
+
+func nextFrame(fp unsafe.Pointer) (parent unsafe.Pointer, returnAddress uintptr) {
+	return nil, 0
+}
@@ -0,0 +1,20 @@
+//go:build tinygo.riscv
+// +build tinygo.riscv
+
+package runtime
+
+import "unsafe"
+
+// Return the frame pointer of the calling function. Frame pointers are only
+// maintained by all functions when stack traces are enabled.
+//export llvm.frameaddress.p0i8
+func frameAddress(level int32) unsafe.Pointer
+
+// Return the frame pointer and return address stored in the frame pointed to
+// by fp. On RISC-V, the frame pointer points just past the return address,
+// which is preceded by the saved frame pointer of the parent.
+func nextFrame(fp unsafe.Pointer) (parent unsafe.Pointer, returnAddress uintptr) {
+	parent = *(*unsafe.Pointer)(unsafe.Pointer(uintptr(fp) - unsafe.Sizeof(uintptr(0))*2))
+	returnAddress = *(*uintptr)(unsafe.Pointer(uintptr(fp) - unsafe.Sizeof(uintptr(0))))
+	return
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -18,11 +19,13 @@ import (
 
 func initBenchmarkFlags() {
 	matchBenchmarks = flag.String("test.bench", "", "run only benchmarks matching `regexp`")
+	benchmarkMemory = flag.Bool("test.benchmem", false, "print memory allocations for benchmarks")
 	flag.Var(&benchTime, "test.benchtime", "run each benchmark for duration `d`")
 }
 
 var (
 	matchBenchmarks *string
+	benchmarkMemory *bool
 	benchTime       = benchTimeFlag{d: 1 * time.Second} // changed during test of testing package
 )
 
@@ -77,14 +80,21 @@ type InternalBenchmark struct {
 // affecting benchmark results.
 type B struct {
 	common
-	context      *benchContext
-	N            int
-	benchFunc    func(b *B)
-	bytes        int64
-	missingBytes bool // one of the subbenchmarks does not have bytes set.
-	benchTime    benchTimeFlag
-	timerOn      bool
-	result       BenchmarkResult
+	context         *benchContext
+	N               int
+	benchFunc       func(b *B)
+	bytes           int64
+	missingBytes    bool // one of the subbenchmarks does not have bytes set.
+	benchTime       benchTimeFlag
+	timerOn         bool
+	showAllocResult bool
+	result          BenchmarkResult
+	// The initial states of memStats.Mallocs and memStats.TotalAlloc.
+	startAllocs uint64
+	startBytes  uint64
+	// The net total of this test after being run.
+	netAllocs uint64
+	netBytes  uint64
 }
 
 // StartTimer starts timing a test. This function is called automatically
@@ -92,6 +102,9 @@ type B struct {
 // a call to StopTimer.
 func (b *B) StartTimer() {
 	if !b.timerOn {
+		runtime.ReadMemStats(&memStats)
+		b.startAllocs = memStats.Mallocs
+		b.startBytes = memStats.TotalAlloc
 		b.start = time.Now()
 		b.timerOn = true
 	}
@@ -103,6 +116,9 @@ func (b *B) StartTimer() {
 func (b *B) StopTimer() {
 	if b.timerOn {
 		b.duration += time.Since(b.start)
+		runtime.ReadMemStats(&memStats)
+		b.netAllocs += memStats.Mallocs - b.startAllocs
+		b.netBytes += memStats.TotalAlloc - b.startBytes
 		b.timerOn = false
 	}
 }
@@ -111,9 +127,14 @@ func (b *B) StopTimer() {
 // and deletes user-reported metrics.
 func (b *B) ResetTimer() {
 	if b.timerOn {
+		runtime.ReadMemStats(&memStats)
+		b.startAllocs = memStats.Mallocs
+		b.startBytes = memStats.TotalAlloc
 		b.start = time.Now()
 	}
 	b.duration = 0
+	b.netAllocs = 0
+	b.netBytes = 0
 }
 
 // SetBytes records the number of bytes processed in a single operation.
@@ -124,9 +145,14 @@ func (b *B) SetBytes(n int64) { b.bytes = n }
 // It is equivalent to setting -test.benchmem, but it only affects the
 // benchmark function that calls ReportAllocs.
 func (b *B) ReportAllocs() {
-	return // TODO: implement
+	b.showAllocResult = true
 }
 
+// memStats is used to read the allocation counters when starting and stopping
+// the benchmark timer. It is a global so that reading the counters doesn't
+// allocate memory itself.
+var memStats runtime.MemStats
+
 // runN runs a single benchmark for the specified number of iterations.
 func (b *B) runN(n int) {
 	b.N = n
@@ -216,14 +242,16 @@ func (b *B) launch() {
 			b.runN(int(n))
 		}
 	}
-	b.result = BenchmarkResult{b.N, b.duration, b.bytes}
+	b.result = BenchmarkResult{b.N, b.duration, b.bytes, b.netAllocs, b.netBytes}
 }
 
 // BenchmarkResult contains the results of a benchmark run.
 type BenchmarkResult struct {
-	N     int           // The number of iterations.
-	T     time.Duration // The total time taken.
-	Bytes int64         // Bytes processed in one iteration.
+	N         int           // The number of iterations.
+	T         time.Duration // The total time taken.
+	Bytes     int64         // Bytes processed in one iteration.
+	MemAllocs uint64        // The total number of memory allocations.
+	MemBytes  uint64        // The total number of bytes allocated.
 }
 
 // NsPerOp returns the "ns/op" metric.
@@ -245,13 +273,19 @@ func (r BenchmarkResult) mbPerSec() float64 {
 // AllocsPerOp returns the "allocs/op" metric,
 // which is calculated as r.MemAllocs / r.N.
 func (r BenchmarkResult) AllocsPerOp() int64 {
-	return 0 // Dummy version to allow running e.g. golang.org/test/fibo.go
+	if r.N <= 0 {
+		return 0
+	}
+	return int64(r.MemAllocs) / int64(r.N)
 }
 
 // AllocedBytesPerOp returns the "B/op" metric,
 // which is calculated as r.MemBytes / r.N.
 func (r BenchmarkResult) AllocedBytesPerOp() int64 {
-	return 0 // Dummy version to allow running e.g. golang.org/test/fibo.go
+	if r.N <= 0 {
+		return 0
+	}
+	return int64(r.MemBytes) / int64(r.N)
 }
 
 // String returns a summary of the benchmark results.
@@ -278,6 +312,12 @@ func (r BenchmarkResult) String() string {
 	return buf.String()
 }
 
+// MemString returns r.AllocedBytesPerOp and r.AllocsPerOp in the same format as 'go test'.
+func (r BenchmarkResult) MemString() string {
+	return fmt.Sprintf("%8d B/op\t%8d allocs/op",
+		r.AllocedBytesPerOp(), r.AllocsPerOp())
+}
+
 func prettyPrint(w io.Writer, x float64, unit string) {
 	// Print all numbers with 10 places before the decimal point
 	// and small numbers with four sig figs. Field widths are
@@ -336,7 +376,9 @@ func runBenchmarks(matchString func(pat, str string) (bool, error), benchmarks [
 		benchTime: benchTime,
 		benchFunc: func(b *B) {
 			for _, Benchmark := range bs {
-				b.Run(Benchmark.Name, Benchmark.F)
+				for i := uint(0); i < flagCount; i++ {
+					b.Run(Benchmark.Name, Benchmark.F)
+				}
 			}
 		},
 		context: ctx,
@@ -363,6 +405,9 @@ func (b *B) processBench(ctx *benchContext) {
 	}
 	if ctx != nil {
 		results := r.String()
+		if *benchmarkMemory || b.showAllocResult {
+			results += "\t" + r.MemString()
+		}
 		fmt.Println(results)
 	}
 }
@@ -386,18 +431,21 @@ func (b *B) Run(name string, f func(b *B)) bool {
 			name:  benchName,
 			level: b.level + 1,
 		},
-		benchFunc: f,
-		benchTime: b.benchTime,
-		context:   b.context,
+		benchFunc:       f,
+		benchTime:       b.benchTime,
+		context:         b.context,
+		showAllocResult: b.showAllocResult,
 	}
 	if partial {
 		// Partial name match, like -bench=X/Y matching BenchmarkX.
 		// Only process sub-benchmarks, if any.
 		sub.hasSub = true
 	}
+	startRunning(sub.name)
 	if sub.run1() {
 		sub.run()
 	}
+	stopRunning(sub.name)
 	b.add(sub.result)
 	return !sub.failed
 }
@@ -411,6 +459,8 @@ func (b *B) add(other BenchmarkResult) {
 	// in sequence in a single benchmark.
 	r.N = 1
 	r.T += time.Duration(other.NsPerOp())
+	r.MemAllocs += uint64(other.AllocsPerOp())
+	r.MemBytes += uint64(other.AllocedBytesPerOp())
 	if other.Bytes == 0 {
 		// Summing Bytes is meaningless in aggregate if not all subbenchmarks
 		// set it.
@@ -0,0 +1,135 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+// This file has been modified for use by the TinyGo compiler.
+
+package testing
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	_ "unsafe" // for go:linkname
+)
+
+type InternalExample struct {
+	Name      string
+	F         func()
+	Output    string
+	Unordered bool
+}
+
+// RunExamples is an internal function but exported because it is cross-package;
+// it is part of the implementation of the "go test" command.
+func RunExamples(matchString func(pat, str string) (bool, error), examples []InternalExample) (ok bool) {
+	_, ok = runExamples(matchString, examples)
+	return ok
+}
+
+func runExamples(matchString func(pat, str string) (bool, error), examples []InternalExample) (ran, ok bool) {
+	ok = true
+
+	for _, eg := range examples {
+		matched, err := matchString(flagRunRegexp, eg.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "testing: invalid regexp for -test.run: %s\n", err)
+			os.Exit(1)
+		}
+		if !matched || shouldFailFast() {
+			continue
+		}
+		ran = true
+		if !runExample(eg) {
+			ok = false
+			numFailed++
+		}
+	}
+
+	return ran, ok
+}
+
+// newBufferFile returns an os.File that keeps all data written to it in memory.
+// Upstream Go uses a pipe (or a temporary file on js/wasm) instead, but those
+// are not available on all targets supported by TinyGo.
+//go:linkname newBufferFile os.newBufferFile
+func newBufferFile(name string) *os.File
+
+func runExample(eg InternalExample) (ok bool) {
+	if flagVerbose {
+		fmt.Printf("=== RUN   %s\n", eg.Name)
+	}
+	startRunning(eg.Name)
+
+	// Capture stdout.
+	stdout := os.Stdout
+	f := newBufferFile("example-stdout-" + eg.Name)
+	os.Stdout = f
+	finished := false
+	start := time.Now()
+
+	// Clean up in a deferred call so we can recover if the example panics.
+	defer func() {
+		timeSpent := time.Since(start)
+
+		// Restore stdout and get the output.
+		os.Stdout = stdout
+		var buf strings.Builder
+		io.Copy(&buf, f)
+		f.Close()
+		stopRunning(eg.Name)
+
+		err := recover()
+		ok = eg.processRunResult(buf.String(), timeSpent, finished, err)
+	}()
+
+	// Run example.
+	eg.F()
+	finished = true
+	return
+}
+
+func sortLines(output string) string {
+	lines := strings.Split(output, "\n")
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// processRunResult computes a summary and status of the result of running an example test.
+// stdout is the captured output from stdout of the test.
+// recovered is the result of invoking recover after running the test, in case it panicked.
+//
+// If stdout doesn't match the expected output or if recovered is non-nil, it'll print the cause of failure to stdout.
+// If the test is chatty/verbose, it'll print a success message to stdout.
+// If recovered is non-nil, it'll panic with that value.
+func (eg *InternalExample) processRunResult(stdout string, timeSpent time.Duration, finished bool, recovered interface{}) (passed bool) {
+	passed = true
+	dstr := fmtDuration(timeSpent)
+	var fail string
+	got := strings.TrimSpace(stdout)
+	want := strings.TrimSpace(eg.Output)
+	if eg.Unordered {
+		if sortLines(got) != sortLines(want) && recovered == nil {
+			fail = fmt.Sprintf("got:\n%s\nwant (unordered):\n%s\n", stdout, eg.Output)
+		}
+	} else {
+		if got != want && recovered == nil {
+			fail = fmt.Sprintf("got:\n%s\nwant:\n%s\n", got, want)
+		}
+	}
+	if fail != "" || !finished || recovered != nil {
+		fmt.Printf("--- FAIL: %s (%s)\n%s", eg.Name, dstr, fail)
+		passed = false
+	} else if flagVerbose {
+		fmt.Printf("--- PASS: %s (%s)\n", eg.Name, dstr)
+	}
+	if recovered != nil {
+		// Propagate the previously recovered result, by panicking.
+		panic(recovered)
+	}
+
+	return
+}
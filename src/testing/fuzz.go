@@ -0,0 +1,487 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+// This file has been modified for use by the TinyGo compiler.
+
+package testing
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+func initFuzzFlags() {
+	matchFuzz = flag.String("test.fuzz", "", "run the fuzz test matching `regexp`")
+	flag.Var(&fuzzDuration, "test.fuzztime", "time to spend fuzzing; default is to run indefinitely")
+}
+
+var (
+	matchFuzz    *string
+	fuzzDuration benchTimeFlag
+
+	// fuzzCorpusFiles contains the seed corpus files found in testdata/fuzz,
+	// indexed by fuzz test name. They are embedded in the test binary by the
+	// compiler, so that they are also available on systems without a
+	// filesystem.
+	fuzzCorpusFiles map[string][]corpusFile
+)
+
+// corpusDir is the directory (relative to the package directory) with the seed
+// corpus.
+const corpusDir = "testdata/fuzz"
+
+// InternalFuzzTarget is an internal type but exported because it is
+// cross-package; it is part of the implementation of the "go test" command.
+type InternalFuzzTarget struct {
+	Name string
+	Fn   func(f *F)
+}
+
+// F is a type passed to fuzz tests.
+//
+// Fuzz tests run generated inputs against a provided fuzz target, which can
+// find and report potential bugs in the code being tested.
+//
+// A fuzz test runs the seed corpus by default, which includes entries provided
+// by (*F).Add and entries in the testdata/fuzz/<FuzzTestName> directory. After
+// any necessary setup and calls to (*F).Add, the fuzz test must then call
+// (*F).Fuzz to provide the fuzz target.
+//
+// When fuzzing is enabled with -fuzz, random mutations of the seed corpus are
+// passed to the fuzz target until it fails or until -fuzztime has elapsed.
+// Unlike upstream Go, mutations are not guided by coverage information.
+type F struct {
+	common
+	context    *testContext
+	fuzzing    bool // run the mutation engine instead of only the seed corpus
+	fuzzCalled bool
+	corpus     []corpusEntry
+}
+
+var _ TB = (*F)(nil)
+
+// corpusFile is a seed corpus file as stored in testdata/fuzz.
+type corpusFile struct {
+	name string
+	data string
+}
+
+// corpusEntry is a single input to a fuzz target.
+type corpusEntry struct {
+	name   string
+	values []interface{}
+}
+
+// registerFuzzCorpus is called from the init function the compiler adds to the
+// test binary, once for every file in testdata/fuzz/<target>.
+func registerFuzzCorpus(target, name, data string) {
+	if fuzzCorpusFiles == nil {
+		fuzzCorpusFiles = make(map[string][]corpusFile)
+	}
+	fuzzCorpusFiles[target] = append(fuzzCorpusFiles[target], corpusFile{name, data})
+}
+
+// Add will add the arguments to the seed corpus for the fuzz test. This will be
+// a no-op if called after or within the fuzz target, and args must match the
+// arguments for the fuzz target.
+func (f *F) Add(args ...interface{}) {
+	var values []interface{}
+	for i := range args {
+		if t := reflect.TypeOf(args[i]); t == nil || !supportedFuzzType(t) {
+			panic(fmt.Sprintf("testing: unsupported type to Add %v", t))
+		}
+		values = append(values, args[i])
+	}
+	f.corpus = append(f.corpus, corpusEntry{name: fmt.Sprintf("seed#%d", len(f.corpus)), values: values})
+}
+
+// supportedFuzzType returns whether values of this type can be used as fuzzing
+// arguments.
+func supportedFuzzType(t reflect.Type) bool {
+	if t.PkgPath() != "" {
+		// Named types are not supported.
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8 && t.Elem().PkgPath() == ""
+	default:
+		return false
+	}
+}
+
+// Fuzz runs the fuzz function, ff, for fuzz testing. If ff fails for a set of
+// arguments, those arguments will be added to the seed corpus.
+//
+// ff must be a function with no return value whose first argument is *T and
+// whose remaining arguments are the types to be fuzzed.
+// For example:
+//
+//     f.Fuzz(func(t *testing.T, b []byte, i int) { ... })
+//
+// The following types are allowed: []byte, string, bool, byte, rune, float32,
+// float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64.
+// More types may be supported in the future.
+func (f *F) Fuzz(ff interface{}) {
+	if f.fuzzCalled {
+		panic("testing: F.Fuzz called more than once")
+	}
+	f.fuzzCalled = true
+
+	// Check the signature of the fuzz function.
+	fn := reflect.ValueOf(ff)
+	fnType := fn.Type()
+	if fnType.Kind() != reflect.Func {
+		panic("testing: F.Fuzz must receive a function")
+	}
+	if fnType.NumIn() < 2 || fnType.In(0) != reflect.TypeOf((*T)(nil)) {
+		panic("testing: fuzz target must receive at least two arguments, where the first argument is a *T")
+	}
+	if fnType.NumOut() != 0 {
+		panic("testing: fuzz target must not return a value")
+	}
+	var types []reflect.Type
+	for i := 1; i < fnType.NumIn(); i++ {
+		t := fnType.In(i)
+		if !supportedFuzzType(t) {
+			panic(fmt.Sprintf("testing: unsupported type for fuzzing %v", t))
+		}
+		types = append(types, t)
+	}
+
+	// Add the seed corpus from testdata/fuzz.
+	for _, file := range fuzzCorpusFiles[f.name] {
+		values, err := unmarshalCorpusFile([]byte(file.data))
+		if err == nil {
+			err = checkCorpusTypes(values, types)
+		}
+		if err != nil {
+			f.Errorf("failed to load %s: %v", filepath.Join(corpusDir, f.name, file.name), err)
+			return
+		}
+		f.corpus = append(f.corpus, corpusEntry{name: file.name, values: values})
+	}
+	for _, e := range f.corpus {
+		if err := checkCorpusTypes(e.values, types); err != nil {
+			f.Errorf("%s: %v", e.name, err)
+			return
+		}
+	}
+
+	if f.fuzzing {
+		f.fuzz(fn, types)
+		return
+	}
+
+	// Not fuzzing, so only run the seed corpus as subtests.
+	for _, e := range f.corpus {
+		f.runEntry(fn, e)
+	}
+}
+
+// checkCorpusTypes checks whether the values in a corpus entry match the
+// arguments of the fuzz target.
+func checkCorpusTypes(values []interface{}, types []reflect.Type) error {
+	if len(values) != len(types) {
+		return fmt.Errorf("wrong number of values in corpus entry: %d, want %d", len(values), len(types))
+	}
+	for i := range values {
+		if reflect.TypeOf(values[i]) != types[i] {
+			return fmt.Errorf("mismatched types in corpus entry: %v, want %v", reflect.TypeOf(values[i]), types[i])
+		}
+	}
+	return nil
+}
+
+// runEntry runs a single seed corpus entry as a subtest of the fuzz test.
+func (f *F) runEntry(fn reflect.Value, e corpusEntry) bool {
+	f.hasSub = true
+	testName, ok, _ := f.context.match.fullName(&f.common, e.name)
+	if !ok {
+		return true
+	}
+
+	t := &T{
+		common: common{
+			name:   testName,
+			parent: &f.common,
+			level:  f.level + 1,
+		},
+		context: f.context,
+	}
+	if f.level > 0 {
+		t.indent = t.indent + "    "
+	}
+	if flagVerbose {
+		fmt.Fprintf(&f.output, "=== RUN   %s\n", t.name)
+	}
+
+	tRunner(t, func(t *T) {
+		callFuzzFn(t, fn, e.values)
+	})
+	return !t.failed
+}
+
+// callFuzzFn calls the fuzz target with the given values and returns whether
+// it succeeded. Panics are reported as test failures on systems that support
+// recover().
+func callFuzzFn(t *T, fn reflect.Value, values []interface{}) (ok bool) {
+	args := make([]reflect.Value, len(values)+1)
+	args[0] = reflect.ValueOf(t)
+	for i, v := range values {
+		args[i+1] = reflect.ValueOf(v)
+	}
+	if canRecover {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("panic: %v", r)
+				ok = false
+			}
+		}()
+	}
+	fn.Call(args)
+	return !t.Failed()
+}
+
+// fuzz runs the mutation engine: it passes random mutations of the corpus to
+// the fuzz target until it fails or until -test.fuzztime has passed.
+func (f *F) fuzz(fn reflect.Value, types []reflect.Type) {
+	// Start with the seed corpus, or with zero values if there is none.
+	var corpus [][]interface{}
+	for _, e := range f.corpus {
+		corpus = append(corpus, e.values)
+	}
+	if len(corpus) == 0 {
+		values := make([]interface{}, len(types))
+		for i, t := range types {
+			values[i] = reflect.Zero(t).Interface()
+		}
+		corpus = append(corpus, values)
+	}
+
+	dir := filepath.Join(corpusDir, f.name)
+	crasherPath := filepath.Join(dir, "crasher")
+	if !canRecover {
+		// A panic will terminate the program, so store each input before
+		// running it. If the fuzz target panics, the input remains in the
+		// seed corpus.
+		fmt.Printf("fuzz: this system cannot recover from panics, inputs are written to %s before running them\n", crasherPath)
+		mkdirCorpus(dir)
+	}
+
+	m := newMutator()
+	start := time.Now()
+	lastReport := start
+	execs := 0
+	for {
+		if fuzzDuration.n > 0 && execs >= fuzzDuration.n {
+			break
+		}
+		if fuzzDuration.d > 0 && time.Since(start) >= fuzzDuration.d {
+			break
+		}
+		if now := time.Now(); now.Sub(lastReport) >= 3*time.Second {
+			lastReport = now
+			elapsed := now.Sub(start)
+			fmt.Printf("fuzz: elapsed: %ds, execs: %d (%.0f/sec)\n", int(elapsed.Seconds()), execs, float64(execs)/elapsed.Seconds())
+		}
+
+		values := m.mutate(corpus[m.intn(len(corpus))])
+		if !canRecover {
+			ioutil.WriteFile(crasherPath, marshalCorpusFile(values...), 0666)
+		}
+		t := &T{
+			common: common{
+				name:   f.name,
+				parent: &f.common,
+				level:  f.level + 1,
+				indent: "    ",
+			},
+			context: f.context,
+		}
+		t.start = time.Now()
+		ok := callFuzzFn(t, fn, values)
+		t.duration = time.Since(t.start)
+		execs++
+		if !ok {
+			t.report()
+			data := marshalCorpusFile(values...)
+			name := fmt.Sprintf("%016x", fnv64(data))
+			path := filepath.Join(dir, name)
+			err := mkdirCorpus(dir)
+			if err == nil {
+				err = ioutil.WriteFile(path, data, 0666)
+			}
+			if err != nil {
+				f.Logf("Failed to write failing input to %s: %v\nFailing input:\n%s", path, err, data)
+			} else {
+				f.Logf("Failing input written to %s\nTo re-run:\ntinygo test -run=%s/%s", path, f.name, name)
+			}
+			break
+		}
+
+		// Occasionally keep a mutated input, so that mutations can build upon
+		// each other.
+		if m.intn(16) == 0 {
+			if len(corpus) < 256 {
+				corpus = append(corpus, values)
+			} else {
+				corpus[m.intn(len(corpus))] = values
+			}
+		}
+	}
+	if !canRecover {
+		// The fuzz target didn't panic, so the input doesn't need to be kept.
+		os.Remove(crasherPath)
+	}
+	elapsed := time.Since(start)
+	fmt.Printf("fuzz: elapsed: %ds, execs: %d (%.0f/sec)\n", int(elapsed.Seconds()), execs, float64(execs)/elapsed.Seconds())
+}
+
+// mkdirCorpus creates the corpus directory for a fuzz test. It is a limited
+// version of os.MkdirAll, which is not available on all systems.
+func mkdirCorpus(dir string) error {
+	for _, d := range []string{"testdata", corpusDir, dir} {
+		err := os.Mkdir(d, 0777)
+		if err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// fnv64 returns the 64-bit FNV-1a hash of data. It is used to name files in
+// the corpus.
+func fnv64(data []byte) uint64 {
+	hash := uint64(14695981039346656037)
+	for _, c := range data {
+		hash ^= uint64(c)
+		hash *= 1099511628211
+	}
+	return hash
+}
+
+// fRunner runs a fuzz test, like tRunner does for regular tests.
+func fRunner(f *F, fn func(f *F)) {
+	startRunning(f.name)
+	defer stopRunning(f.name)
+	defer func() {
+		f.runCleanup()
+	}()
+
+	f.start = time.Now()
+	fn(f)
+	f.duration += time.Since(f.start)
+
+	f.report()
+	if f.parent != nil && !f.hasSub {
+		f.setRan()
+	}
+	if f.failed {
+		numFailed++
+	}
+}
+
+// runFuzzTests runs all fuzz tests matching -test.run with their seed corpus,
+// just like regular tests.
+func runFuzzTests(matchString func(pat, str string) (bool, error), fuzzTargets []InternalFuzzTarget) (ran, ok bool) {
+	ok = true
+	if len(fuzzTargets) == 0 {
+		return false, ok
+	}
+
+	ctx := newTestContext(newMatcher(matchString, flagRunRegexp, "-test.run"))
+	t := &T{
+		context: ctx,
+	}
+	tRunner(t, func(t *T) {
+		for i := uint(0); i < flagCount; i++ {
+			for _, target := range fuzzTargets {
+				if !t.runFuzzTest(target, false) {
+					ok = false
+				}
+			}
+		}
+	})
+	return t.ran, ok
+}
+
+// runFuzzing runs the mutation engine for the fuzz test matching -test.fuzz.
+// It returns whether no failure was found.
+func runFuzzing(matchString func(pat, str string) (bool, error), fuzzTargets []InternalFuzzTarget) (ok bool) {
+	if *matchFuzz == "" {
+		return true
+	}
+
+	var matched []InternalFuzzTarget
+	for _, target := range fuzzTargets {
+		match, err := matchString(*matchFuzz, target.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "testing: invalid regexp for -test.fuzz: %s\n", err)
+			os.Exit(1)
+		}
+		if match {
+			matched = append(matched, target)
+		}
+	}
+	if len(matched) == 0 {
+		fmt.Fprintln(os.Stderr, "testing: warning: no fuzz tests to fuzz")
+		return true
+	}
+	if len(matched) > 1 {
+		var names []string
+		for _, target := range matched {
+			names = append(names, target.Name)
+		}
+		fmt.Fprintf(os.Stderr, "testing: will not fuzz, -fuzz matches more than one fuzz test: %v\n", names)
+		return false
+	}
+
+	ctx := newTestContext(newMatcher(matchString, "", "-test.fuzz"))
+	t := &T{
+		context: ctx,
+	}
+	tRunner(t, func(t *T) {
+		ok = t.runFuzzTest(matched[0], true)
+	})
+	return ok
+}
+
+// runFuzzTest runs a single fuzz test as a subtest of t. If fuzzing is set, the
+// mutation engine is run instead of the seed corpus (which has already been run
+// by runFuzzTests).
+func (t *T) runFuzzTest(target InternalFuzzTarget, fuzzing bool) bool {
+	t.hasSub = true
+	testName, ok, _ := t.context.match.fullName(&t.common, target.Name)
+	if !ok || shouldFailFast() {
+		return true
+	}
+
+	f := &F{
+		common: common{
+			name:   testName,
+			parent: &t.common,
+			level:  t.level + 1,
+		},
+		context: t.context,
+		fuzzing: fuzzing,
+	}
+	if flagVerbose {
+		fmt.Fprintf(&t.output, "=== RUN   %s\n", f.name)
+	}
+	t.flushRoot()
+
+	fRunner(f, target.Fn)
+	t.flushRoot()
+	return !f.failed
+}
@@ -0,0 +1,200 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+// This file has been modified for use by the TinyGo compiler.
+
+package testing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// encVersion1 will be the first line of a file with version 1 encoding.
+var encVersion1 = "go test fuzz v1"
+
+// marshalCorpusFile encodes an arbitrary number of arguments into the file
+// format for the corpus. This is the same format as used by upstream Go.
+func marshalCorpusFile(vals ...interface{}) []byte {
+	if len(vals) == 0 {
+		panic("must have at least one value to marshal")
+	}
+	b := bytes.NewBuffer([]byte(encVersion1 + "\n"))
+	for _, val := range vals {
+		switch t := val.(type) {
+		case int:
+			fmt.Fprintf(b, "int(%d)\n", t)
+		case int8:
+			fmt.Fprintf(b, "int8(%d)\n", t)
+		case int16:
+			fmt.Fprintf(b, "int16(%d)\n", t)
+		case int64:
+			fmt.Fprintf(b, "int64(%d)\n", t)
+		case uint:
+			fmt.Fprintf(b, "uint(%d)\n", t)
+		case uint16:
+			fmt.Fprintf(b, "uint16(%d)\n", t)
+		case uint32:
+			fmt.Fprintf(b, "uint32(%d)\n", t)
+		case uint64:
+			fmt.Fprintf(b, "uint64(%d)\n", t)
+		case bool:
+			fmt.Fprintf(b, "bool(%v)\n", t)
+		case float32:
+			if math.IsNaN(float64(t)) || math.IsInf(float64(t), 0) || (t == 0 && math.Signbit(float64(t))) {
+				// Use the exact bit pattern for values that can't be written
+				// as a literal.
+				fmt.Fprintf(b, "math.Float32frombits(0x%x)\n", math.Float32bits(t))
+			} else {
+				fmt.Fprintf(b, "float32(%s)\n", strconv.FormatFloat(float64(t), 'g', -1, 32))
+			}
+		case float64:
+			if math.IsNaN(t) || math.IsInf(t, 0) || (t == 0 && math.Signbit(t)) {
+				fmt.Fprintf(b, "math.Float64frombits(0x%x)\n", math.Float64bits(t))
+			} else {
+				fmt.Fprintf(b, "float64(%s)\n", strconv.FormatFloat(t, 'g', -1, 64))
+			}
+		case string:
+			fmt.Fprintf(b, "string(%s)\n", strconv.Quote(t))
+		case rune: // int32
+			if utf8.ValidRune(t) {
+				fmt.Fprintf(b, "rune(%s)\n", strconv.QuoteRune(t))
+			} else {
+				fmt.Fprintf(b, "int32(%d)\n", t)
+			}
+		case byte: // uint8
+			fmt.Fprintf(b, "byte(%s)\n", strconv.QuoteRune(rune(t)))
+		case []byte:
+			fmt.Fprintf(b, "[]byte(%s)\n", strconv.Quote(string(t)))
+		default:
+			panic(fmt.Sprintf("unsupported type: %T", t))
+		}
+	}
+	return b.Bytes()
+}
+
+// unmarshalCorpusFile decodes corpus bytes into their respective values. It
+// accepts the format written by marshalCorpusFile, which is the format written
+// by upstream Go.
+func unmarshalCorpusFile(b []byte) ([]interface{}, error) {
+	if len(b) == 0 {
+		return nil, errors.New("cannot unmarshal empty string")
+	}
+	lines := strings.Split(string(b), "\n")
+	if len(lines) < 2 {
+		return nil, errors.New("must include version and at least one value")
+	}
+	if strings.TrimSpace(lines[0]) != encVersion1 {
+		return nil, fmt.Errorf("unknown encoding version: %s", lines[0])
+	}
+	var vals []interface{}
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		v, err := parseCorpusValue(line)
+		if err != nil {
+			return nil, fmt.Errorf("malformed line %q: %v", line, err)
+		}
+		vals = append(vals, v)
+	}
+	if len(vals) == 0 {
+		return nil, errors.New("must include version and at least one value")
+	}
+	return vals, nil
+}
+
+// parseCorpusValue parses a single line of a corpus file, which is in the form
+// of a conversion like int(5) or []byte("abc").
+func parseCorpusValue(line string) (interface{}, error) {
+	open := strings.IndexByte(line, '(')
+	if open < 0 || line[len(line)-1] != ')' {
+		return nil, errors.New("expected call expression")
+	}
+	typ := line[:open]
+	lit := strings.TrimSpace(line[open+1 : len(line)-1])
+
+	switch typ {
+	case "math.Float32frombits":
+		bits, err := strconv.ParseUint(lit, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(uint32(bits)), nil
+	case "math.Float64frombits":
+		bits, err := strconv.ParseUint(lit, 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(bits), nil
+	case "string", "[]byte":
+		s, err := strconv.Unquote(lit)
+		if err != nil {
+			return nil, err
+		}
+		if typ == "[]byte" {
+			return []byte(s), nil
+		}
+		return s, nil
+	case "bool":
+		return strconv.ParseBool(lit)
+	case "float32":
+		f, err := strconv.ParseFloat(lit, 32)
+		return float32(f), err
+	case "float64":
+		return strconv.ParseFloat(lit, 64)
+	}
+
+	// The remaining types are integers, which may also be written as a
+	// character literal.
+	if len(lit) != 0 && lit[0] == '\'' {
+		s, err := strconv.Unquote(lit)
+		if err != nil {
+			return nil, err
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		if size != len(s) {
+			return nil, errors.New("invalid character literal")
+		}
+		lit = strconv.Itoa(int(r))
+	}
+	switch typ {
+	case "int":
+		n, err := strconv.ParseInt(lit, 0, 64)
+		return int(n), err
+	case "int8":
+		n, err := strconv.ParseInt(lit, 0, 8)
+		return int8(n), err
+	case "int16":
+		n, err := strconv.ParseInt(lit, 0, 16)
+		return int16(n), err
+	case "int32", "rune":
+		n, err := strconv.ParseInt(lit, 0, 32)
+		return int32(n), err
+	case "int64":
+		return strconv.ParseInt(lit, 0, 64)
+	case "uint":
+		n, err := strconv.ParseUint(lit, 0, 64)
+		return uint(n), err
+	case "uint8", "byte":
+		n, err := strconv.ParseUint(lit, 0, 8)
+		return uint8(n), err
+	case "uint16":
+		n, err := strconv.ParseUint(lit, 0, 16)
+		return uint16(n), err
+	case "uint32":
+		n, err := strconv.ParseUint(lit, 0, 32)
+		return uint32(n), err
+	case "uint64":
+		return strconv.ParseUint(lit, 0, 64)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+}
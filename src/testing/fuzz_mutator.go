@@ -0,0 +1,214 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+// This file has been modified for use by the TinyGo compiler.
+
+package testing
+
+import (
+	"math"
+	"time"
+)
+
+// mutator makes random changes to fuzzing inputs. It is a much simplified
+// version of the mutator in internal/fuzz.
+type mutator struct {
+	state uint64 // xorshift64 state
+}
+
+// maxFuzzSize is the maximum size of a []byte or string input. Inputs on
+// microcontrollers and in WebAssembly need to stay small.
+const maxFuzzSize = 1024
+
+func newMutator() *mutator {
+	seed := uint64(time.Now().UnixNano())
+	if seed == 0 {
+		seed = 1
+	}
+	return &mutator{state: seed}
+}
+
+func (m *mutator) rand() uint64 {
+	m.state ^= m.state << 13
+	m.state ^= m.state >> 7
+	m.state ^= m.state << 17
+	return m.state
+}
+
+// intn returns a random number in the range [0, n).
+func (m *mutator) intn(n int) int {
+	return int(m.rand() % uint64(n))
+}
+
+// mutate returns a copy of values where one of the values has been changed.
+func (m *mutator) mutate(values []interface{}) []interface{} {
+	newValues := make([]interface{}, len(values))
+	copy(newValues, values)
+	i := m.intn(len(values))
+	switch v := values[i].(type) {
+	case bool:
+		newValues[i] = !v
+	case int:
+		newValues[i] = int(m.mutateInt(int64(v), math.MinInt64, math.MaxInt64))
+	case int8:
+		newValues[i] = int8(m.mutateInt(int64(v), math.MinInt8, math.MaxInt8))
+	case int16:
+		newValues[i] = int16(m.mutateInt(int64(v), math.MinInt16, math.MaxInt16))
+	case int32:
+		newValues[i] = int32(m.mutateInt(int64(v), math.MinInt32, math.MaxInt32))
+	case int64:
+		newValues[i] = m.mutateInt(v, math.MinInt64, math.MaxInt64)
+	case uint:
+		newValues[i] = uint(m.mutateUint(uint64(v), math.MaxUint64))
+	case uint8:
+		newValues[i] = uint8(m.mutateUint(uint64(v), math.MaxUint8))
+	case uint16:
+		newValues[i] = uint16(m.mutateUint(uint64(v), math.MaxUint16))
+	case uint32:
+		newValues[i] = uint32(m.mutateUint(uint64(v), math.MaxUint32))
+	case uint64:
+		newValues[i] = m.mutateUint(v, math.MaxUint64)
+	case float32:
+		newValues[i] = float32(m.mutateFloat(float64(v)))
+	case float64:
+		newValues[i] = m.mutateFloat(v)
+	case string:
+		newValues[i] = string(m.mutateBytes([]byte(v)))
+	case []byte:
+		newValues[i] = m.mutateBytes(append([]byte(nil), v...))
+	default:
+		panic("testing: unsupported fuzzing type")
+	}
+	return newValues
+}
+
+// mutateInt changes v, keeping it in the range [min, max]. Note that int and
+// uint are treated as 64-bit values, which is harmless as the conversion back
+// truncates them.
+func (m *mutator) mutateInt(v, min, max int64) int64 {
+	switch m.intn(4) {
+	case 0:
+		// Add or subtract a small number.
+		delta := int64(m.intn(16) + 1)
+		if m.intn(2) == 0 {
+			delta = -delta
+		}
+		v += delta
+	case 1:
+		// Flip a random bit.
+		v ^= 1 << uint(m.intn(64))
+	case 2:
+		// Use an interesting value.
+		v = [...]int64{0, 1, -1, min, max}[m.intn(5)]
+	default:
+		v = int64(m.rand())
+	}
+	if v < min || v > max {
+		// Wrap around, like an overflow in the smaller type would.
+		v = min + int64(uint64(v-min)%(uint64(max-min)+1))
+	}
+	return v
+}
+
+// mutateUint changes v, keeping it in the range [0, max].
+func (m *mutator) mutateUint(v, max uint64) uint64 {
+	switch m.intn(4) {
+	case 0:
+		// Add or subtract a small number.
+		delta := uint64(m.intn(16) + 1)
+		if m.intn(2) == 0 {
+			v -= delta
+		} else {
+			v += delta
+		}
+	case 1:
+		// Flip a random bit.
+		v ^= 1 << uint(m.intn(64))
+	case 2:
+		// Use an interesting value.
+		v = [...]uint64{0, 1, max}[m.intn(3)]
+	default:
+		v = m.rand()
+	}
+	return v & max
+}
+
+// mutateFloat changes v.
+func (m *mutator) mutateFloat(v float64) float64 {
+	switch m.intn(4) {
+	case 0:
+		// Add or subtract a small number.
+		return v + float64(m.intn(32)-16)
+	case 1:
+		// Multiply or divide by a small number.
+		factor := float64(m.intn(8) + 2)
+		if m.intn(2) == 0 {
+			return v * factor
+		}
+		return v / factor
+	case 2:
+		// Use an interesting value.
+		return [...]float64{0, math.Copysign(0, -1), 1, -1, math.Inf(1), math.Inf(-1), math.NaN(), math.MaxFloat64, math.SmallestNonzeroFloat64}[m.intn(9)]
+	default:
+		return math.Float64frombits(math.Float64bits(v) ^ 1<<uint(m.intn(64)))
+	}
+}
+
+// mutateBytes changes b, possibly in place, and returns the result.
+func (m *mutator) mutateBytes(b []byte) []byte {
+	op := m.intn(7)
+	if len(b) == 0 {
+		// Only inserting makes sense for empty inputs.
+		op = 0
+	}
+	switch op {
+	case 0:
+		// Insert random bytes.
+		if len(b) >= maxFuzzSize {
+			return b
+		}
+		n := m.intn(8) + 1
+		if len(b)+n > maxFuzzSize {
+			n = maxFuzzSize - len(b)
+		}
+		pos := m.intn(len(b) + 1)
+		insert := make([]byte, n)
+		for i := range insert {
+			insert[i] = byte(m.rand())
+		}
+		b = append(b[:pos], append(insert, b[pos:]...)...)
+	case 1:
+		// Remove a range of bytes.
+		pos := m.intn(len(b))
+		n := m.intn(len(b)-pos) + 1
+		b = append(b[:pos], b[pos+n:]...)
+	case 2:
+		// Flip a random bit.
+		b[m.intn(len(b))] ^= 1 << uint(m.intn(8))
+	case 3:
+		// Replace a byte with a random byte.
+		b[m.intn(len(b))] = byte(m.rand())
+	case 4:
+		// Replace a byte with an interesting value.
+		b[m.intn(len(b))] = [...]byte{0, 1, '0', 'a', 0x7f, 0x80, 0xff}[m.intn(7)]
+	case 5:
+		// Duplicate a range of bytes.
+		if len(b) >= maxFuzzSize {
+			return b
+		}
+		pos := m.intn(len(b))
+		n := m.intn(len(b)-pos) + 1
+		if len(b)+n > maxFuzzSize {
+			n = maxFuzzSize - len(b)
+		}
+		chunk := append([]byte(nil), b[pos:pos+n]...)
+		dst := m.intn(len(b) + 1)
+		b = append(b[:dst], append(chunk, b[dst:]...)...)
+	default:
+		// Swap two bytes.
+		i, j := m.intn(len(b)), m.intn(len(b))
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
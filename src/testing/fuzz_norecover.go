@@ -0,0 +1,8 @@
+//go:build avr || tinygo.wasm || xtensa
+// +build avr tinygo.wasm xtensa
+
+package testing
+
+// canRecover indicates whether panics in a fuzz target can be caught with
+// recover(). This must be kept in sync with supportsRecover in the runtime.
+const canRecover = false
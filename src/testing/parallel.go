@@ -0,0 +1,52 @@
+//go:build !scheduler.none
+// +build !scheduler.none
+
+package testing
+
+import (
+	"fmt"
+	"time"
+)
+
+// runTest runs the subtest t in a new goroutine. It returns when the subtest
+// has finished or when it has called t.Parallel.
+func runTest(t *T, fn func(t *T)) {
+	t.signal = make(chan bool)
+	go tRunner(t, fn)
+	<-t.signal
+}
+
+// Parallel signals that this test is to be run in parallel with (and only with)
+// other parallel tests. Parallel tests are paused until the test function of
+// their parent has returned, after which they all continue at the same time.
+// As TinyGo runs all goroutines on a single core, parallel tests only actually
+// run at the same time when they block, for example on a channel or in
+// time.Sleep.
+func (t *T) Parallel() {
+	if t.isParallel {
+		panic("testing: t.Parallel called multiple times")
+	}
+	t.isParallel = true
+	if t.parent == nil || t.parent.barrier == nil || t.signal == nil {
+		// This test wasn't started with t.Run, for example because it is an
+		// input of a fuzz test. Keep running it sequentially.
+		return
+	}
+
+	// Don't include the time spent waiting for the parent test in the test
+	// duration.
+	t.duration += time.Since(t.start)
+
+	// Add to the list of tests to be released by the parent.
+	t.parent.sub = append(t.parent.sub, t)
+
+	if flagVerbose {
+		fmt.Fprintf(&t.parent.output, "=== PAUSE %s\n", t.name)
+	}
+	t.signal <- true   // Release calling test.
+	<-t.parent.barrier // Wait for the parent test to complete.
+	if flagVerbose {
+		fmt.Fprintf(&t.parent.output, "=== CONT  %s\n", t.name)
+	}
+	t.start = time.Now()
+}
@@ -0,0 +1,20 @@
+//go:build scheduler.none
+// +build scheduler.none
+
+package testing
+
+// runTest runs the subtest t. Goroutines are not available without a
+// scheduler, so it runs on the goroutine of the parent test.
+func runTest(t *T, fn func(t *T)) {
+	tRunner(t, fn)
+}
+
+// Parallel signals that this test is to be run in parallel with (and only with)
+// other parallel tests. Without a scheduler, all tests run sequentially so
+// this has no effect.
+func (t *T) Parallel() {
+	if t.isParallel {
+		panic("testing: t.Parallel called multiple times")
+	}
+	t.isParallel = true
+}
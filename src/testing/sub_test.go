@@ -78,3 +78,38 @@ func TestNestedCleanup(t *T) {
 		t.Errorf("unexpected cleanup count: got %d want 3", ranCleanup)
 	}
 }
+
+func TestParallelSubtests(t *T) {
+	var order []string
+	t.Run("group", func(t *T) {
+		t.Cleanup(func() { order = append(order, "cleanup") })
+		for _, name := range []string{"a", "b"} {
+			name := name
+			t.Run(name, func(t *T) {
+				t.Parallel()
+				order = append(order, name)
+			})
+		}
+		order = append(order, "group")
+	})
+	if len(order) != 4 || order[0] != "group" || order[3] != "cleanup" {
+		t.Errorf("unexpected order of parallel subtests: %v", order)
+	}
+}
+
+func TestParallelCommunication(t *T) {
+	// These subtests can only pass when they run at the same time.
+	ch := make(chan int)
+	t.Run("group", func(t *T) {
+		t.Run("send", func(t *T) {
+			t.Parallel()
+			ch <- 5
+		})
+		t.Run("receive", func(t *T) {
+			t.Parallel()
+			if n := <-ch; n != 5 {
+				t.Errorf("unexpected value received: got %d want 5", n)
+			}
+		})
+	})
+}
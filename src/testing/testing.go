@@ -13,6 +13,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
 	"time"
 )
@@ -22,8 +23,20 @@ var (
 	flagVerbose   bool
 	flagShort     bool
 	flagRunRegexp string
+	flagCount     uint
+	flagFailFast  bool
+	flagList      string
 )
 
+// reportRunning is set by tinygo test (using -ldflags=-X) when a test timeout
+// is in use. The start and end of every test is then printed to stdout, so that
+// tinygo test knows which tests were running when the timeout expired. These
+// lines are removed from the output again by tinygo test.
+var reportRunning string
+
+// numFailed is the number of tests that failed, used for -test.failfast.
+var numFailed uint32
+
 var initRan bool
 
 // Init registers testing flags. It has no effect if it has already run.
@@ -36,8 +49,12 @@ func Init() {
 	flag.BoolVar(&flagVerbose, "test.v", false, "verbose: print additional output")
 	flag.BoolVar(&flagShort, "test.short", false, "short: run smaller test suite to save time")
 	flag.StringVar(&flagRunRegexp, "test.run", "", "run: regexp of tests to run")
+	flag.UintVar(&flagCount, "test.count", 1, "run tests and benchmarks `n` times")
+	flag.BoolVar(&flagFailFast, "test.failfast", false, "do not start new tests after the first test failure")
+	flag.StringVar(&flagList, "test.list", "", "list tests, examples, and benchmarks matching `regexp` then exit")
 
 	initBenchmarkFlags()
+	initFuzzFlags()
 }
 
 // common holds the elements common between T and B and
@@ -51,7 +68,12 @@ type common struct {
 	cleanups []func() // optional functions to be called at the end of the test
 	finished bool     // Test function has completed.
 
-	hasSub bool // TODO: should be atomic
+	hasSub     bool // TODO: should be atomic
+	isParallel bool // Test is running in parallel.
+
+	signal  chan bool // To signal a test is done or has become parallel.
+	barrier chan bool // To signal parallel subtests they may start. Nil for tests that can't have parallel subtests.
+	sub     []*T      // Queue of subtests to be run in parallel.
 
 	parent   *common
 	level    int       // Nesting depth of test or benchmark.
@@ -65,11 +87,11 @@ func Short() bool {
 	return flagShort
 }
 
-// CoverMode reports what the test coverage mode is set to.
-//
-// Test coverage is not supported; this returns the empty string.
+// CoverMode reports what the test coverage mode is set to. The
+// values are "set", "count", or "atomic". The return value will be
+// empty if test coverage is not enabled.
 func CoverMode() string {
-	return ""
+	return cover.Mode
 }
 
 // Verbose reports whether the -test.v flag is set.
@@ -274,7 +296,8 @@ func (c *common) runCleanup() {
 	}
 }
 
-// Parallel is not implemented, it is only provided for compatibility.
+// Parallel is not implemented for benchmarks, it is only provided for
+// compatibility. See (*T).Parallel for tests.
 func (c *common) Parallel() {
 	// Unimplemented.
 }
@@ -286,18 +309,73 @@ type InternalTest struct {
 }
 
 func tRunner(t *T, fn func(t *T)) {
+	if t.parent != nil {
+		startRunning(t.name)
+	}
 	defer func() {
+		t.duration += time.Since(t.start)
+
+		if len(t.sub) > 0 {
+			// Run parallel subtests: they may continue now that the test
+			// function has returned. Wait for all of them to finish before
+			// running cleanup functions.
+			close(t.barrier)
+			for _, sub := range t.sub {
+				<-sub.signal
+			}
+		}
+
+		cleanupStart := time.Now()
 		t.runCleanup()
+		t.duration += time.Since(cleanupStart)
+
+		t.report() // Report after all subtests have finished.
+		if t.parent != nil && !t.hasSub {
+			t.setRan()
+		}
+		if t.failed && t.parent != nil {
+			numFailed++
+		}
+		if t.parent != nil {
+			stopRunning(t.name)
+		}
+		if t.signal != nil {
+			// Tell the parent test that this test has finished.
+			t.signal <- true
+		}
 	}()
 
 	// Run the test.
 	t.start = time.Now()
 	fn(t)
-	t.duration += time.Since(t.start) // TODO: capture cleanup time, too.
+}
+
+// startRunning and stopRunning tell tinygo test that a test has started or
+// finished. See reportRunning.
+func startRunning(name string) {
+	if reportRunning != "" {
+		fmt.Printf("--- tinygo run %s\n", name)
+	}
+}
+
+func stopRunning(name string) {
+	if reportRunning != "" {
+		fmt.Printf("--- tinygo done %s\n", name)
+	}
+}
+
+// shouldFailFast returns whether no new tests should be started, because
+// -test.failfast is set and a test has already failed.
+func shouldFailFast() bool {
+	return flagFailFast && numFailed > 0
+}
 
-	t.report() // Report after all subtests have finished.
-	if t.parent != nil && !t.hasSub {
-		t.setRan()
+// flushRoot writes the output of a top-level test to stdout. Without this, the
+// output of all tests would only be printed when all tests have finished,
+// which is too late when the test binary is killed due to a timeout.
+func (t *T) flushRoot() {
+	if t.parent == nil {
+		t.output.WriteTo(os.Stdout)
 	}
 }
 
@@ -306,16 +384,17 @@ func tRunner(t *T, fn func(t *T)) {
 func (t *T) Run(name string, f func(t *T)) bool {
 	t.hasSub = true
 	testName, ok, _ := t.context.match.fullName(&t.common, name)
-	if !ok {
+	if !ok || shouldFailFast() {
 		return true
 	}
 
 	// Create a subtest.
-	sub := T{
+	sub := &T{
 		common: common{
-			name:   testName,
-			parent: &t.common,
-			level:  t.level + 1,
+			name:    testName,
+			parent:  &t.common,
+			level:   t.level + 1,
+			barrier: make(chan bool),
 		},
 		context: t.context,
 	}
@@ -325,8 +404,11 @@ func (t *T) Run(name string, f func(t *T)) bool {
 	if flagVerbose {
 		fmt.Fprintf(&t.output, "=== RUN   %s\n", sub.name)
 	}
+	t.flushRoot()
 
-	tRunner(&sub, f)
+	// Run the subtest until it finishes or calls t.Parallel.
+	runTest(sub, f)
+	t.flushRoot()
 	return !sub.failed
 }
 
@@ -345,8 +427,10 @@ func newTestContext(m *matcher) *testContext {
 // M is a test suite.
 type M struct {
 	// tests is a list of the test names to execute
-	Tests      []InternalTest
-	Benchmarks []InternalBenchmark
+	Tests       []InternalTest
+	Benchmarks  []InternalBenchmark
+	FuzzTargets []InternalFuzzTarget
+	Examples    []InternalExample
 
 	deps testDeps
 
@@ -359,15 +443,6 @@ type testDeps interface {
 	MatchString(pat, str string) (bool, error)
 }
 
-func MainStart(deps interface{}, tests []InternalTest, benchmarks []InternalBenchmark, examples []InternalExample) *M {
-	Init()
-	return &M{
-		Tests:      tests,
-		Benchmarks: benchmarks,
-		deps:       deps.(testDeps),
-	}
-}
-
 // Run runs the tests. It returns an exit code to pass to os.Exit.
 func (m *M) Run() (code int) {
 	defer func() {
@@ -378,11 +453,19 @@ func (m *M) Run() (code int) {
 		flag.Parse()
 	}
 
+	if flagList != "" {
+		listTests(m.deps.MatchString, m.Tests, m.Benchmarks, m.FuzzTargets, m.Examples)
+		m.exitCode = 0
+		return
+	}
+
 	testRan, testOk := runTests(m.deps.MatchString, m.Tests)
-	if !testRan && *matchBenchmarks == "" {
+	fuzzTargetsRan, fuzzTargetsOk := runFuzzTests(m.deps.MatchString, m.FuzzTargets)
+	exampleRan, exampleOk := runExamples(m.deps.MatchString, m.Examples)
+	if !testRan && !fuzzTargetsRan && !exampleRan && *matchBenchmarks == "" && *matchFuzz == "" {
 		fmt.Fprintln(os.Stderr, "testing: warning: no tests to run")
 	}
-	if !testOk || !runBenchmarks(m.deps.MatchString, m.Benchmarks) {
+	if !testOk || !fuzzTargetsOk || !exampleOk || !runFuzzing(m.deps.MatchString, m.FuzzTargets) || !runBenchmarks(m.deps.MatchString, m.Benchmarks) {
 		fmt.Println("FAIL")
 		m.exitCode = 1
 	} else {
@@ -391,28 +474,63 @@ func (m *M) Run() (code int) {
 		}
 		m.exitCode = 0
 	}
+	coverReport()
 	return
 }
 
 func runTests(matchString func(pat, str string) (bool, error), tests []InternalTest) (ran, ok bool) {
-	ok = true
-
 	ctx := newTestContext(newMatcher(matchString, flagRunRegexp, "-test.run"))
 	t := &T{
+		common: common{
+			barrier: make(chan bool),
+		},
 		context: ctx,
 	}
 
 	tRunner(t, func(t *T) {
-		for _, test := range tests {
-			t.Run(test.Name, test.F)
-			ok = ok && !t.Failed()
+		for i := uint(0); i < flagCount; i++ {
+			for _, test := range tests {
+				t.Run(test.Name, test.F)
+			}
 		}
 	})
 
-	return t.ran, ok
+	// Parallel tests may fail after the loop above, so check for failures
+	// only after all tests have finished.
+	return t.ran, !t.Failed()
 }
 
-func (t *T) report() {
+// listTests prints the names of all tests, benchmarks, fuzz tests and examples
+// that match -test.list, without running them.
+func listTests(matchString func(pat, str string) (bool, error), tests []InternalTest, benchmarks []InternalBenchmark, fuzzTargets []InternalFuzzTarget, examples []InternalExample) {
+	if _, err := matchString(flagList, "non-empty"); err != nil {
+		fmt.Fprintf(os.Stderr, "testing: invalid regexp in -test.list (%q): %s\n", flagList, err)
+		os.Exit(1)
+	}
+
+	for _, test := range tests {
+		if ok, _ := matchString(flagList, test.Name); ok {
+			fmt.Println(test.Name)
+		}
+	}
+	for _, bench := range benchmarks {
+		if ok, _ := matchString(flagList, bench.Name); ok {
+			fmt.Println(bench.Name)
+		}
+	}
+	for _, fuzzTarget := range fuzzTargets {
+		if ok, _ := matchString(flagList, fuzzTarget.Name); ok {
+			fmt.Println(fuzzTarget.Name)
+		}
+	}
+	for _, example := range examples {
+		if ok, _ := matchString(flagList, example.Name); ok {
+			fmt.Println(example.Name)
+		}
+	}
+}
+
+func (t *common) report() {
 	dstr := fmtDuration(t.duration)
 	format := t.indent + "--- %s: %s (%s)\n"
 	if t.Failed() {
@@ -433,18 +551,35 @@ func (t *T) report() {
 // Although the return value has type float64, it will always be an integral
 // value.
 //
-// Not implemented.
+// To compute the number of allocations, the function will first be run once as
+// a warm-up. The average number of allocations over the specified number of
+// runs will then be measured and returned.
+//
+// AllocsPerRun sets GOMAXPROCS to 1 during its measurement and will restore
+// it before returning.
 func AllocsPerRun(runs int, f func()) (avg float64) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	// Warm up the function
 	f()
+
+	// Measure the starting statistics
+	var memstats runtime.MemStats
+	runtime.ReadMemStats(&memstats)
+	mallocs := 0 - memstats.Mallocs
+
+	// Run the function the specified number of times
 	for i := 0; i < runs; i++ {
 		f()
 	}
-	return 0
-}
 
-type InternalExample struct {
-	Name      string
-	F         func()
-	Output    string
-	Unordered bool
+	// Read the final statistics
+	runtime.ReadMemStats(&memstats)
+	mallocs += memstats.Mallocs
+
+	// Average the mallocs over the runs (not counting the warm-up).
+	// We are forced to return a float64 because the API is silly, but do
+	// the division as integers so we can ask if AllocsPerRun()==1
+	// instead of AllocsPerRun()<2.
+	return float64(mallocs / uint64(runs))
 }
@@ -0,0 +1,18 @@
+//go:build go1.18
+// +build go1.18
+
+package testing
+
+// MainStart is meant for use by tests generated by 'go test'.
+// It is not meant to be called directly and is not subject to the Go 1
+// compatibility document. It may change signature from release to release.
+func MainStart(deps interface{}, tests []InternalTest, benchmarks []InternalBenchmark, fuzzTargets []InternalFuzzTarget, examples []InternalExample) *M {
+	Init()
+	return &M{
+		Tests:       tests,
+		Benchmarks:  benchmarks,
+		FuzzTargets: fuzzTargets,
+		Examples:    examples,
+		deps:        deps.(testDeps),
+	}
+}
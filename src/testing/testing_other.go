@@ -0,0 +1,19 @@
+//go:build !go1.18
+// +build !go1.18
+
+package testing
+
+// MainStart is meant for use by tests generated by 'go test'.
+// It is not meant to be called directly and is not subject to the Go 1
+// compatibility document. It may change signature from release to release.
+//
+// Before Go 1.18, 'go test' does not pass any fuzz targets.
+func MainStart(deps interface{}, tests []InternalTest, benchmarks []InternalBenchmark, examples []InternalExample) *M {
+	Init()
+	return &M{
+		Tests:      tests,
+		Benchmarks: benchmarks,
+		Examples:   examples,
+		deps:       deps.(testDeps),
+	}
+}
@@ -0,0 +1,271 @@
+package stacksize
+
+// This file exports the call graph, so that it's possible to see why a stack
+// size is as large as it is or why it couldn't be determined at all.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Entry is a function that starts on a new stack, such as a goroutine or the
+// reset handler. It is the starting point when exporting the call graph.
+type Entry struct {
+	Name string // human readable name, such as the goroutine function
+	Node *CallNode
+}
+
+// MarshalText implements encoding.TextMarshaler, for the JSON output.
+func (s SizeType) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// WorstCasePath returns the path through the call graph that determines the
+// stack size of this function: the chain of calls with the largest stack
+// usage, or the chain of calls that leads to the function that is the reason
+// the stack size is unknown. For recursive functions, the last node in the path
+// is the function that is called recursively (which is also earlier in the
+// path).
+func (node *CallNode) WorstCasePath() []*CallNode {
+	node.StackSize()
+	path := []*CallNode{node}
+	seen := map[*CallNode]struct{}{node: {}}
+	for n := node.worstChild; n != nil; n = n.worstChild {
+		path = append(path, n)
+		if _, ok := seen[n]; ok {
+			break
+		}
+		seen[n] = struct{}{}
+	}
+	return path
+}
+
+// reason returns a short description why the stack size of this function is
+// not bounded, or the empty string if it is.
+func (node *CallNode) reason() string {
+	switch node.stackSizeType {
+	case Unknown:
+		return "no stack frame information"
+	case Recursive:
+		return "may call itself"
+	case IndirectCall:
+		return "calls a function pointer"
+	default:
+		return ""
+	}
+}
+
+// callGraph is the part of the call graph that can be reached from a list of
+// entry points, in a stable order.
+type callGraph struct {
+	nodes []*CallNode
+	ids   map[*CallNode]int
+	paths [][]*CallNode // worst case path for each entry
+
+	// Edges and nodes that are on the worst case path of an entry, or that are
+	// the reason a stack size is unknown.
+	worstEdges map[[2]*CallNode]struct{}
+	causes     map[*CallNode]struct{}
+}
+
+// newCallGraph collects all functions that can be reached from the given entry
+// points and calculates their stack sizes.
+func newCallGraph(entries []Entry) *callGraph {
+	g := &callGraph{
+		ids:        make(map[*CallNode]int),
+		worstEdges: make(map[[2]*CallNode]struct{}),
+		causes:     make(map[*CallNode]struct{}),
+	}
+	var visit func(node *CallNode)
+	visit = func(node *CallNode) {
+		if _, ok := g.ids[node]; ok {
+			return
+		}
+		g.ids[node] = len(g.nodes)
+		g.nodes = append(g.nodes, node)
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	for _, entry := range entries {
+		visit(entry.Node)
+	}
+
+	// Sort the functions by address, so that the output doesn't depend on the
+	// order in which the entries are passed.
+	sort.SliceStable(g.nodes, func(i, j int) bool {
+		return g.nodes[i].Address < g.nodes[j].Address
+	})
+	for i, node := range g.nodes {
+		g.ids[node] = i
+	}
+
+	for _, entry := range entries {
+		path := entry.Node.WorstCasePath()
+		g.paths = append(g.paths, path)
+		for i := 1; i < len(path); i++ {
+			g.worstEdges[[2]*CallNode{path[i-1], path[i]}] = struct{}{}
+		}
+		if _, sizeType, cause := entry.Node.StackSize(); sizeType != Bounded && cause != nil {
+			g.causes[cause] = struct{}{}
+		}
+	}
+
+	// Also calculate the stack size of functions that weren't needed to
+	// determine the stack size of the entry points, so that all functions in
+	// the output have a stack size (if it can be determined).
+	for _, node := range g.nodes {
+		node.StackSize()
+	}
+	return g
+}
+
+// WriteDOT writes the call graph that can be reached from the given entry
+// points in the Graphviz DOT format. The worst case path of each entry is drawn
+// in red, and functions that are the reason a stack size couldn't be
+// determined (because they are recursive, call a function pointer or have no
+// frame information) are filled in red.
+func WriteDOT(w io.Writer, entries []Entry) error {
+	g := newCallGraph(entries)
+	b := &strings.Builder{}
+	b.WriteString("digraph callgraph {\n")
+	b.WriteString("\tnode [shape=box, fontname=monospace];\n")
+	for i, entry := range entries {
+		stackSize, sizeType, cause := entry.Node.StackSize()
+		label := entry.Name + "\n"
+		if sizeType == Bounded {
+			label += fmt.Sprintf("stack: %d", stackSize)
+		} else if cause != nil {
+			label += fmt.Sprintf("stack: %s, %s %s", sizeType, cause, cause.reason())
+		} else {
+			label += "stack: " + sizeType.String()
+		}
+		fmt.Fprintf(b, "\tentry%d [shape=ellipse, label=%s];\n", i, dotQuote(label))
+		fmt.Fprintf(b, "\tentry%d -> f%d [color=red, penwidth=2];\n", i, g.ids[entry.Node])
+	}
+	for i, node := range g.nodes {
+		label := strings.Join(node.Names, "\n") + "\n"
+		if node.FrameSizeType == Bounded {
+			label += fmt.Sprintf("frame: %d", node.FrameSize)
+		} else {
+			label += "frame: unknown"
+		}
+		if stackSize, sizeType, _ := node.StackSize(); sizeType == Bounded {
+			label += fmt.Sprintf(", stack: %d", stackSize)
+		} else {
+			label += ", stack: " + sizeType.String()
+		}
+		attrs := "label=" + dotQuote(label)
+		if _, ok := g.causes[node]; ok {
+			attrs += ", style=filled, fillcolor=\"#ff8080\", tooltip=" + dotQuote(node.reason())
+		}
+		fmt.Fprintf(b, "\tf%d [%s];\n", i, attrs)
+	}
+	for i, node := range g.nodes {
+		seen := make(map[*CallNode]struct{})
+		for _, child := range node.Children {
+			if _, ok := seen[child]; ok {
+				// A function can be called multiple times from the same
+				// function. Only draw the edge once.
+				continue
+			}
+			seen[child] = struct{}{}
+			attrs := ""
+			if _, ok := g.worstEdges[[2]*CallNode{node, child}]; ok {
+				attrs = " [color=red, penwidth=2]"
+			}
+			fmt.Fprintf(b, "\tf%d -> f%d%s;\n", i, g.ids[child], attrs)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote returns the string as a quoted DOT string, with newlines converted
+// to line breaks.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// WriteJSON writes the call graph that can be reached from the given entry
+// points as JSON. Functions are identified by their index in the list of
+// functions. For every entry point, it includes the worst case path and (if
+// the stack size couldn't be determined) the function that caused it.
+func WriteJSON(w io.Writer, entries []Entry) error {
+	g := newCallGraph(entries)
+
+	type jsonFunction struct {
+		Names         []string `json:"names"`
+		Address       uint64   `json:"address"`
+		Size          uint64   `json:"size"`
+		FrameSize     uint64   `json:"frameSize"`
+		FrameSizeType SizeType `json:"frameSizeType"`
+		StackSize     uint64   `json:"stackSize"`
+		StackSizeType SizeType `json:"stackSizeType"`
+		Calls         []int    `json:"calls"`
+	}
+	type jsonEntry struct {
+		Name          string   `json:"name"`
+		Function      int      `json:"function"`
+		StackSize     uint64   `json:"stackSize"`
+		StackSizeType SizeType `json:"stackSizeType"`
+		Cause         *int     `json:"cause,omitempty"`  // the function responsible for an unknown stack size
+		Reason        string   `json:"reason,omitempty"` // why the stack size is unknown
+		WorstCasePath []int    `json:"worstCasePath"`
+	}
+	functions := make([]jsonFunction, len(g.nodes))
+	for i, node := range g.nodes {
+		stackSize, sizeType, _ := node.StackSize()
+		calls := make([]int, len(node.Children))
+		for j, child := range node.Children {
+			calls[j] = g.ids[child]
+		}
+		functions[i] = jsonFunction{
+			Names:         node.Names,
+			Address:       node.Address,
+			Size:          node.Size,
+			FrameSize:     node.FrameSize,
+			FrameSizeType: node.FrameSizeType,
+			StackSize:     stackSize,
+			StackSizeType: sizeType,
+			Calls:         calls,
+		}
+	}
+	jsonEntries := make([]jsonEntry, len(entries))
+	for i, entry := range entries {
+		stackSize, sizeType, cause := entry.Node.StackSize()
+		path := make([]int, len(g.paths[i]))
+		for j, node := range g.paths[i] {
+			path[j] = g.ids[node]
+		}
+		jsonEntries[i] = jsonEntry{
+			Name:          entry.Name,
+			Function:      g.ids[entry.Node],
+			StackSize:     stackSize,
+			StackSizeType: sizeType,
+			WorstCasePath: path,
+		}
+		if sizeType != Bounded && cause != nil {
+			id := g.ids[cause]
+			jsonEntries[i].Cause = &id
+			jsonEntries[i].Reason = cause.reason()
+		}
+	}
+
+	data, err := json.MarshalIndent(struct {
+		Entries   []jsonEntry    `json:"entries"`
+		Functions []jsonFunction `json:"functions"`
+	}{jsonEntries, functions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
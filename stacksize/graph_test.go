@@ -0,0 +1,127 @@
+package stacksize
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// makeTestGraph creates a small call graph by hand, with one goroutine with a
+// bounded stack size, one recursive goroutine and one goroutine that calls a
+// function pointer.
+func makeTestGraph() []Entry {
+	node := func(name string, address, frameSize uint64, children ...*CallNode) *CallNode {
+		return &CallNode{
+			Names:         []string{name},
+			Address:       address,
+			FrameSize:     frameSize,
+			FrameSizeType: Bounded,
+			Children:      children,
+		}
+	}
+	leaf := node("leaf", 0x10, 4)
+	small := node("small", 0x20, 8, leaf)
+	large := node("large", 0x30, 32, leaf)
+	bounded := node("bounded", 0x40, 16, small, large, small)
+
+	recursive := node("recursive", 0x50, 8, leaf)
+	recursive.Children = append(recursive.Children, recursive)
+	callsRecursive := node("callsRecursive", 0x60, 8, recursive)
+
+	indirect := node("indirect", 0x70, 8)
+	indirect.stackSizeType = IndirectCall
+	indirect.missingFrameInfo = indirect
+	callsIndirect := node("callsIndirect", 0x80, 8, leaf, indirect)
+
+	return []Entry{
+		{"goroutine1", bounded},
+		{"goroutine2", callsRecursive},
+		{"goroutine3", callsIndirect},
+	}
+}
+
+func TestWorstCasePath(t *testing.T) {
+	entries := makeTestGraph()
+	for i, expected := range [][]string{
+		{"bounded", "large", "leaf"},
+		{"callsRecursive", "recursive", "recursive"},
+		{"callsIndirect", "indirect"},
+	} {
+		var path []string
+		for _, node := range entries[i].Node.WorstCasePath() {
+			path = append(path, node.String())
+		}
+		if !reflect.DeepEqual(path, expected) {
+			t.Errorf("unexpected worst case path for %s: %v (expected %v)", entries[i].Name, path, expected)
+		}
+	}
+	if size, sizeType, _ := entries[0].Node.StackSize(); size != 52 || sizeType != Bounded {
+		t.Errorf("unexpected stack size: %d (%s)", size, sizeType)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := WriteDOT(buf, makeTestGraph())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{
+		`entry0 [shape=ellipse, label="goroutine1\nstack: 52"];`,
+		`entry1 [shape=ellipse, label="goroutine2\nstack: recursive, recursive may call itself"];`,
+		`f3 [label="bounded\nframe: 16, stack: 52"];`,
+		`f3 -> f2 [color=red, penwidth=2];`, // bounded -> large
+		`f2 -> f0 [color=red, penwidth=2];`, // large -> leaf
+		`f3 -> f1;`,                         // bounded -> small
+		`f4 -> f4 [color=red, penwidth=2];`, // recursive -> recursive
+		`f6 [label="indirect\nframe: 8, stack: indirect call", style=filled, fillcolor="#ff8080", tooltip="calls a function pointer"];`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("DOT output does not contain %q:\n%s", s, out)
+		}
+	}
+	if strings.Count(out, "f3 -> f1") != 1 {
+		t.Errorf("expected exactly one edge from bounded to small:\n%s", out)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := WriteJSON(buf, makeTestGraph())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Entries []struct {
+			Name          string
+			Function      int
+			StackSize     uint64
+			StackSizeType string
+			Cause         *int
+			Reason        string
+			WorstCasePath []int
+		}
+		Functions []struct {
+			Names         []string
+			StackSizeType string
+		}
+	}
+	err = json.Unmarshal(buf.Bytes(), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Functions) != 8 || len(result.Entries) != 3 {
+		t.Fatalf("unexpected number of functions (%d) or entries (%d)", len(result.Functions), len(result.Entries))
+	}
+	entry := result.Entries[0]
+	if entry.StackSize != 52 || entry.StackSizeType != "bounded" || entry.Cause != nil || !reflect.DeepEqual(entry.WorstCasePath, []int{3, 2, 0}) {
+		t.Errorf("unexpected first entry: %+v", entry)
+	}
+	entry = result.Entries[2]
+	if entry.StackSizeType != "indirect call" || entry.Cause == nil || result.Functions[*entry.Cause].Names[0] != "indirect" || entry.Reason != "calls a function pointer" {
+		t.Errorf("unexpected last entry: %+v", entry)
+	}
+}
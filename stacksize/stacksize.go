@@ -60,6 +60,7 @@ type CallNode struct {
 	stackSize        uint64
 	stackSizeType    SizeType
 	missingFrameInfo *CallNode // the child function that is the cause for not being able to determine the stack size
+	worstChild       *CallNode // the child with the largest stack size, or the child with an unknown stack size
 }
 
 func (n *CallNode) String() string {
@@ -303,12 +304,14 @@ func (node *CallNode) determineStackSize(parents map[*CallNode]struct{}) {
 			}
 			switch child.stackSizeType {
 			case Bounded:
-				if child.stackSize > childMaxStackSize {
+				if child.stackSize > childMaxStackSize || node.worstChild == nil {
 					childMaxStackSize = child.stackSize
+					node.worstChild = child
 				}
 			case Unknown, Recursive, IndirectCall:
 				node.stackSizeType = child.stackSizeType
 				node.missingFrameInfo = child.missingFrameInfo
+				node.worstChild = child
 				return
 			default:
 				panic("unknown child stack size type")
@@ -0,0 +1,135 @@
+package benchmarks
+
+// Benchmarks for the runtime hashmap implementation. Run them with:
+//
+//     tinygo test -bench . ./testdata/benchmarks
+//
+// They can also be run with the standard Go toolchain for comparison.
+
+import (
+	"strconv"
+	"testing"
+)
+
+var mapSizes = []int{10, 100, 1000, 10000}
+
+// Insert keys into a map that starts out empty, so that it has to grow.
+func BenchmarkMapInsertInt(b *testing.B) {
+	for _, n := range mapSizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m := make(map[int]int)
+				for j := 0; j < n; j++ {
+					m[j] = j
+				}
+			}
+		})
+	}
+}
+
+// Insert keys into a map that was created with the right size hint.
+func BenchmarkMapInsertIntPrealloc(b *testing.B) {
+	for _, n := range mapSizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m := make(map[int]int, n)
+				for j := 0; j < n; j++ {
+					m[j] = j
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMapInsertString(b *testing.B) {
+	for _, n := range mapSizes {
+		keys := makeStringKeys(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m := make(map[string]int)
+				for j, key := range keys {
+					m[key] = j
+				}
+			}
+		})
+	}
+}
+
+// Look up keys in a map that was filled without a size hint. Without growing
+// the map, lookup time increases linearly with the number of entries.
+func BenchmarkMapLookupInt(b *testing.B) {
+	for _, n := range mapSizes {
+		m := make(map[int]int)
+		for j := 0; j < n; j++ {
+			m[j] = j
+		}
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if m[i%n] != i%n {
+					b.Fatal("unexpected value")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMapLookupString(b *testing.B) {
+	for _, n := range mapSizes {
+		keys := makeStringKeys(n)
+		m := make(map[string]int)
+		for j, key := range keys {
+			m[key] = j
+		}
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if m[keys[i%n]] != i%n {
+					b.Fatal("unexpected value")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMapLookupInterface(b *testing.B) {
+	for _, n := range mapSizes {
+		m := make(map[interface{}]int)
+		for j := 0; j < n; j++ {
+			m[j] = j
+		}
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if m[i%n] != i%n {
+					b.Fatal("unexpected value")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMapIterate(b *testing.B) {
+	for _, n := range mapSizes {
+		m := make(map[int]int)
+		for j := 0; j < n; j++ {
+			m[j] = j
+		}
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sum := 0
+				for _, v := range m {
+					sum += v
+				}
+				if sum != n*(n-1)/2 {
+					b.Fatal("unexpected sum")
+				}
+			}
+		})
+	}
+}
+
+func makeStringKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = "key-" + strconv.Itoa(i)
+	}
+	return keys
+}
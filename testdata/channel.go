@@ -229,6 +229,84 @@ func main() {
 	}
 	wg.Wait()
 	println("blocking select sum:", sum)
+
+	testSelectFairness()
+}
+
+// testSelectFairness checks that select picks one of the cases that can
+// proceed uniformly at random, as required by the Go spec.
+func testSelectFairness() {
+	const n = 1000
+
+	// All cases can proceed.
+	closed1 := make(chan int)
+	closed2 := make(chan int)
+	closed3 := make(chan int)
+	close(closed1)
+	close(closed2)
+	close(closed3)
+	counts := make([]int, 3)
+	for i := 0; i < n; i++ {
+		select {
+		case <-closed1:
+			counts[0]++
+		case <-closed2:
+			counts[1]++
+		case <-closed3:
+			counts[2]++
+		}
+	}
+	println("select fairness, all ready:", isFair(counts, n))
+
+	// Only some cases can proceed, in a non-blocking select.
+	never := make(chan int)
+	buffered := make(chan int, 1)
+	counts = make([]int, 4)
+	for i := 0; i < n; i++ {
+		select {
+		case <-closed1:
+			counts[0]++
+		case <-never:
+			panic("received from channel without sender")
+		case buffered <- i:
+			<-buffered
+			counts[2]++
+		case <-closed2:
+			counts[3]++
+		default:
+			panic("no case could proceed")
+		}
+	}
+	counts = []int{counts[0], counts[2], counts[3]}
+	println("select fairness, some ready:", isFair(counts, n))
+
+	// Cases on the same channel, which may be blocked.
+	ch := make(chan int)
+	go func() {
+		for i := 0; i < n; i++ {
+			ch <- i
+		}
+	}()
+	counts = make([]int, 2)
+	for i := 0; i < n; i++ {
+		select {
+		case <-ch:
+			counts[0]++
+		case <-ch:
+			counts[1]++
+		}
+	}
+	println("select fairness, same channel:", isFair(counts, n))
+}
+
+// isFair returns whether all counts are close to an equal share of n.
+func isFair(counts []int, n int) bool {
+	for _, count := range counts {
+		if count < n/len(counts)*3/4 {
+			return false
+		}
+	}
+	return true
 }
 
 func send(ch chan<- int) {
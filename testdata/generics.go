@@ -0,0 +1,107 @@
+package main
+
+// Test type parameters (generics), introduced in Go 1.18:
+// https://go.dev/doc/go1.18#generics
+// Once this becomes the minimum Go version of TinyGo, these tests should be
+// merged with the regular tests.
+
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+func Sum[T Number](values ...T) T {
+	var sum T
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+func Map[T, U any](s []T, f func(T) U) []U {
+	result := make([]U, len(s))
+	for i, v := range s {
+		result[i] = f(v)
+	}
+	return result
+}
+
+// Stack is a simple generic container.
+type Stack[T any] struct {
+	items []T
+}
+
+func (s *Stack[T]) Push(v T) {
+	s.items = append(s.items, v)
+}
+
+func (s *Stack[T]) Pop() (T, bool) {
+	var zero T
+	if len(s.items) == 0 {
+		return zero, false
+	}
+	v := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return v, true
+}
+
+func (s *Stack[T]) Len() int {
+	return len(s.items)
+}
+
+type Lener interface {
+	Len() int
+}
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+func Keys[K comparable, V any](m map[K]V) int {
+	return len(m)
+}
+
+type myInt int
+
+func main() {
+	println("sum int:", Sum(1, 2, 3))
+	println("sum float64:", Sum(1.5, 2.25))
+	println("sum myInt:", Sum[myInt](4, 5))
+
+	lengths := Map([]string{"a", "bb", "ccc"}, func(s string) int { return len(s) })
+	println("map:", len(lengths), lengths[0], lengths[1], lengths[2])
+
+	var si Stack[int]
+	si.Push(3)
+	si.Push(5)
+	var ss Stack[string]
+	ss.Push("foo")
+	v, ok := si.Pop()
+	println("stack int:", v, ok, si.Len())
+	s, ok := ss.Pop()
+	println("stack string:", s, ok, ss.Len())
+	_, ok = ss.Pop()
+	println("stack empty:", ok)
+
+	// Instantiated types must have distinct type codes.
+	var values []interface{}
+	values = append(values, &si, &ss, Pair[string, int]{"a", 1}, Pair[int, string]{1, "a"})
+	for _, value := range values {
+		switch value := value.(type) {
+		case *Stack[int]:
+			println("type switch: *Stack[int]", value.Len())
+		case *Stack[string]:
+			println("type switch: *Stack[string]", value.Len())
+		case Pair[string, int]:
+			println("type switch: Pair[string, int]", value.Key, value.Value)
+		case Pair[int, string]:
+			println("type switch: Pair[int, string]", value.Key, value.Value)
+		}
+	}
+
+	// Methods of instantiated types can be called through an interface.
+	var l Lener = &si
+	println("interface:", l.Len())
+
+	println("keys:", Keys(map[string]int{"a": 1, "b": 2}))
+}
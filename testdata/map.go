@@ -125,6 +125,16 @@ func main() {
 	testBigMap(squares, 40)
 	println("tested growing of a map")
 
+	// test maps that grow well beyond their initial size
+	squares = make(map[int]int)
+	testBigMap(squares, 300)
+	testBigStringMap(make(map[string]int), 300)
+	testBigInterfaceMap(make(map[interface{}]int), 300)
+	println("tested growing of a big map")
+
+	testGrowDuringIteration()
+	testGrowIncrementally()
+
 	floatcmplx()
 }
 
@@ -205,3 +215,141 @@ func testBigMap(squares map[int]int, n int) {
 		}
 	}
 }
+
+func testBigStringMap(m map[string]int, n int) {
+	for i := 0; i < n; i++ {
+		m[itoa(i)] = i
+	}
+	if len(m) != n {
+		println("unexpected length of string map:", len(m))
+	}
+	for i := 0; i < n; i++ {
+		if v, ok := m[itoa(i)]; !ok || v != i {
+			println("unexpected value read back from string map:", i, v, ok)
+			return
+		}
+	}
+	for i := 0; i < n; i += 2 {
+		delete(m, itoa(i))
+	}
+	if len(m) != n/2 {
+		println("unexpected length of string map after delete:", len(m))
+	}
+	sum := 0
+	for _, v := range m {
+		sum += v
+	}
+	if sum != (n/2)*(n/2) {
+		println("unexpected sum of string map values:", sum)
+	}
+}
+
+func testBigInterfaceMap(m map[interface{}]int, n int) {
+	for i := 0; i < n; i++ {
+		m[[2]int{i, -i}] = i
+	}
+	for i := 0; i < n; i++ {
+		if v := m[[2]int{i, -i}]; v != i {
+			println("unexpected value read back from interface map:", i, v)
+			return
+		}
+	}
+	if len(m) != n {
+		println("unexpected length of interface map:", len(m))
+	}
+}
+
+// testGrowDuringIteration checks that iterating over a map still follows the
+// Go spec when the map is grown during the iteration: every entry is returned
+// at most once, deleted entries are not returned and the values are current.
+func testGrowDuringIteration() {
+	m := make(map[int]int)
+	for i := 0; i < 20; i++ {
+		m[i] = i
+	}
+	seen := make(map[int]bool)
+	added := 0
+	for k, v := range m {
+		if seen[k] {
+			println("key returned twice:", k)
+		}
+		seen[k] = true
+		if k < 20 && v != k && v != k*100 {
+			println("unexpected value while iterating:", k, v)
+		}
+		if added != 0 && k < 20 && k%2 == 0 && v != k*100 {
+			println("stale value while iterating:", k, v)
+		}
+		if k >= 20 && k < 300 && (k-20)%4 == 0 {
+			println("deleted key returned:", k)
+		}
+		if added == 0 {
+			// Add enough keys to grow the map a few times, delete some of
+			// them, and update some of the existing keys.
+			for i := 20; i < 300; i++ {
+				m[i] = i
+			}
+			for i := 20; i < 300; i += 4 {
+				delete(m, i)
+			}
+			for i := 0; i < 20; i += 2 {
+				if i != k {
+					m[i] = i * 100
+				}
+			}
+			added++
+		}
+	}
+	for i := 0; i < 20; i++ {
+		if !seen[i] {
+			println("key not returned:", i)
+		}
+	}
+	println("tested growing a map during iteration")
+}
+
+// testGrowIncrementally checks a map while it is being grown, as the entries
+// are moved to the new buckets a few at a time. The map is checked after every
+// change, so that it is also looked up and iterated over while still growing.
+func testGrowIncrementally() {
+	m := make(map[int]int)
+	var present [300]bool
+	for i := 0; i < len(present); i++ {
+		m[i] = i
+		present[i] = true
+		if i%3 == 0 {
+			delete(m, i/2)
+			present[i/2] = false
+		}
+		for k := 0; k <= i; k++ {
+			if v, ok := m[k]; ok != present[k] || ok && v != k {
+				println("unexpected lookup while growing:", k, v, ok)
+			}
+		}
+		n := 0
+		for k, v := range m {
+			if k > i || !present[k] || v != k {
+				println("unexpected entry while growing:", k, v)
+			}
+			n++
+		}
+		if n != len(m) {
+			println("unexpected number of entries while growing:", n, len(m))
+		}
+	}
+	println("tested growing a map incrementally")
+}
+
+func itoa(i int) string {
+	if i == 0 {
+		return "0"
+	}
+	var buf [20]byte
+	n := len(buf)
+	for i > 0 {
+		n--
+		buf[n] = byte('0' + i%10)
+		i /= 10
+	}
+	return string(buf[n:])
+}
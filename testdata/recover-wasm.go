@@ -0,0 +1,115 @@
+package main
+
+// Like recover.go, but all deferred calls can recover. Other deferred calls are
+// not run on panic on WebAssembly.
+
+func main() {
+	println("# simple recover")
+	recoverSimple()
+
+	println("\n# recover with result")
+	println("result:", recoverWithResult())
+
+	println("\n# nested panic: recover in both functions")
+	nestedPanic()
+
+	println("\n# panic inside defer")
+	panicInsideDefer()
+
+	println("\n# runtime error")
+	runtimeError()
+
+	println("\n# recover in goroutine")
+	done := make(chan struct{})
+	go func() {
+		recoverDeep(3)
+		close(done)
+	}()
+	<-done
+
+	println("\n# recover is nil when not panicking")
+	defer func() {
+		println("recovered:", recover() == nil)
+	}()
+}
+
+func recoverSimple() {
+	defer func() {
+		println("recovering...")
+		printitf("recovered:", recover())
+	}()
+	println("running panic...")
+	panic("panic")
+}
+
+func recoverWithResult() (result int) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = r.(int) * 2
+		}
+	}()
+	doPanic(21)
+	return 1
+}
+
+func doPanic(value int) {
+	panic(value)
+}
+
+func nestedPanic() {
+	defer func() {
+		printitf("recovered in outer function:", recover())
+	}()
+	inner := func() {
+		defer func() {
+			r := recover()
+			printitf("recovered in inner function:", r)
+			panic(r.(string) + " again")
+		}()
+		panic("inner")
+	}
+	inner()
+	println("unreachable")
+}
+
+func panicInsideDefer() {
+	defer func() {
+		printitf("recovered:", recover())
+	}()
+	defer func() {
+		panic("panic inside defer")
+	}()
+	panic("original panic")
+}
+
+func runtimeError() {
+	defer func() {
+		err := recover().(error)
+		println("recovered:", err.Error())
+	}()
+	var a []int
+	index := 5
+	println(a[index])
+}
+
+func recoverDeep(depth int) {
+	defer func() {
+		if r := recover(); r != nil {
+			println("recovered at depth", depth, "from", r.(int))
+		}
+	}()
+	if depth == 0 {
+		panic(depth)
+	}
+	recoverDeep(depth - 1)
+	println("returned to depth", depth)
+}
+
+func printitf(msg string, itf interface{}) {
+	switch itf := itf.(type) {
+	case string:
+		println(msg, itf)
+	default:
+		println(msg, itf)
+	}
+}
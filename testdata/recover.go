@@ -0,0 +1,88 @@
+package main
+
+func main() {
+	println("# simple recover")
+	recoverSimple()
+
+	println("\n# recover with result")
+	println("result:", recoverWithResult())
+
+	println("\n# nested panic: recover only in outer function")
+	nestedPanic()
+
+	println("\n# panic inside defer")
+	panicInsideDefer()
+
+	println("\n# runtime error")
+	runtimeError()
+
+	println("\n# recover is nil when not panicking")
+	defer func() {
+		println("recovered:", recover() == nil)
+	}()
+}
+
+func recoverSimple() {
+	defer func() {
+		println("recovering...")
+		printitf("recovered:", recover())
+	}()
+	println("running panic...")
+	panic("panic")
+}
+
+func recoverWithResult() (result int) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = r.(int) * 2
+		}
+	}()
+	doPanic(21)
+	return 1
+}
+
+func doPanic(value int) {
+	defer println("deferred call in doPanic")
+	panic(value)
+}
+
+func nestedPanic() {
+	defer func() {
+		printitf("recovered in outer function:", recover())
+	}()
+	inner := func() {
+		defer println("deferred call in inner function")
+		panic("inner")
+	}
+	inner()
+	println("unreachable")
+}
+
+func panicInsideDefer() {
+	defer func() {
+		printitf("recovered:", recover())
+	}()
+	defer func() {
+		panic("panic inside defer")
+	}()
+	panic("original panic")
+}
+
+func runtimeError() {
+	defer func() {
+		err := recover().(error)
+		println("recovered:", err.Error())
+	}()
+	var a []int
+	index := 5
+	println(a[index])
+}
+
+func printitf(msg string, itf interface{}) {
+	switch itf := itf.(type) {
+	case string:
+		println(msg, itf)
+	default:
+		println(msg, itf)
+	}
+}
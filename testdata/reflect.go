@@ -11,6 +11,7 @@ type (
 	myslice  []byte
 	myslice2 []myint
 	mychan   chan int
+	mymap    map[string]myint
 	myptr    *int
 	point    struct {
 		X int16
@@ -111,6 +112,8 @@ func main() {
 		// maps
 		zeroMap,
 		map[string]int{},
+		map[string]int{"one": 1},
+		mymap{"two": 2},
 		// structs
 		struct{}{},
 		struct{ error }{},
@@ -287,6 +290,9 @@ func main() {
 
 	testAppendSlice()
 
+	println("\nmaps")
+	testMaps()
+
 	// Test types that are created in reflect and never created elsewhere in a
 	// value-to-interface conversion.
 	v := reflect.ValueOf(new(unreferencedType))
@@ -330,6 +336,15 @@ func main() {
 	println("\nv.Interface() method")
 	testInterfaceMethod()
 
+	println("\nfunction calls")
+	testCall()
+
+	println("\nmethods")
+	testMethods()
+
+	println("\ntype names")
+	testTypeNames()
+
 	// Test reflect.DeepEqual.
 	var selfref1, selfref2 selfref
 	selfref1.x = &selfref1
@@ -423,8 +438,17 @@ func showValue(rv reflect.Value, indent string) {
 			showValue(rv.Elem(), indent+"  ")
 		}
 	case reflect.Map:
-		println(indent + "  map")
+		println(indent+"  map:", rt.Key().Kind().String(), rt.Elem().Kind().String(), rv.Len())
 		println(indent+"  nil:", rv.IsNil())
+		// Only print the contents of small maps, as map iteration order is
+		// not defined.
+		if rv.Len() == 1 {
+			iter := rv.MapRange()
+			for iter.Next() {
+				showValue(iter.Key(), indent+"  ")
+				showValue(iter.Value(), indent+"  ")
+			}
+		}
 	case reflect.Ptr:
 		println(indent+"  pointer:", rv.Pointer() != 0, rt.Elem().Kind().String())
 		println(indent+"  nil:", rv.IsNil())
@@ -474,6 +498,71 @@ func testAppendSlice() {
 	}
 }
 
+// Test map operations through reflection.
+func testMaps() {
+	// Creating and modifying a map.
+	m := reflect.MakeMap(reflect.TypeOf(map[string]int{}))
+	m.SetMapIndex(reflect.ValueOf("one"), reflect.ValueOf(1))
+	m.SetMapIndex(reflect.ValueOf("two"), reflect.ValueOf(2))
+	m.SetMapIndex(reflect.ValueOf("three"), reflect.ValueOf(3))
+	m.SetMapIndex(reflect.ValueOf("two"), reflect.Value{})
+	native := m.Interface().(map[string]int)
+	println("len:", m.Len(), len(native))
+	println("native lookup:", native["one"], native["three"])
+	println("lookup one:", m.MapIndex(reflect.ValueOf("one")).Int())
+	println("lookup two:", m.MapIndex(reflect.ValueOf("two")).IsValid())
+	sum := 0
+	for _, key := range m.MapKeys() {
+		sum += int(m.MapIndex(key).Int())
+	}
+	println("sum:", sum)
+
+	// Changes through the native map are visible in reflect.
+	native["four"] = 4
+	iter := m.MapRange()
+	sum = 0
+	for iter.Next() {
+		sum += len(iter.Key().String()) * int(iter.Value().Int())
+	}
+	println("weighted sum:", sum)
+
+	// Binary keys (that can be compared using memequal).
+	type key struct {
+		a int32
+		b int32
+	}
+	pm := map[key]string{{1, 2}: "foo"}
+	pv := reflect.ValueOf(pm)
+	println("struct key:", pv.MapIndex(reflect.ValueOf(key{1, 2})).String())
+	pv.SetMapIndex(reflect.ValueOf(key{3, 4}), reflect.ValueOf("bar"))
+	println("struct key set:", pm[key{3, 4}], len(pm))
+
+	// Keys that are stored as an interface.
+	fm := map[float64]bool{}
+	fv := reflect.ValueOf(fm)
+	fv.SetMapIndex(reflect.ValueOf(2.5), reflect.ValueOf(true))
+	println("float key:", fm[2.5], fv.MapIndex(reflect.ValueOf(2.5)).Bool())
+	for _, k := range fv.MapKeys() {
+		println("float key kind:", k.Kind().String(), k.Float())
+	}
+	im := map[interface{}]interface{}{}
+	iv := reflect.ValueOf(im)
+	iv.SetMapIndex(reflect.ValueOf(5), reflect.ValueOf("five"))
+	println("interface key:", im[5].(string), iv.MapIndex(reflect.ValueOf(5)).Elem().String())
+
+	// Named map types.
+	nt := reflect.TypeOf(mymap{})
+	println("named map:", nt.Kind().String(), nt.Key().Kind().String(), nt.Elem().Kind().String(), nt.Elem() == reflect.TypeOf(myint(0)))
+	nm := reflect.MakeMapWithSize(nt, 4).Interface().(mymap)
+	nm["x"] = 5
+	println("named map value:", reflect.ValueOf(nm).MapIndex(reflect.ValueOf("x")).Int())
+
+	// Nil maps.
+	var zero map[string]int
+	zv := reflect.ValueOf(zero)
+	println("nil map:", zv.Len(), len(zv.MapKeys()), zv.MapIndex(reflect.ValueOf("x")).IsValid())
+}
+
 func makeRandomSlice(max int) []uint32 {
 	cap := randuint32() % uint32(max+1)
 	len := randuint32() % (cap + 1)
@@ -531,6 +620,120 @@ func testInterfaceMethod() {
 	}
 }
 
+func add(a, b int) int {
+	return a + b
+}
+
+func concat(prefix string, parts ...string) string {
+	for _, part := range parts {
+		prefix += part
+	}
+	return prefix
+}
+
+func testCall() {
+	f := reflect.ValueOf(add)
+	println("add type:", f.Type().NumIn(), f.Type().NumOut(), f.Type().In(0).Kind().String(), f.Type().IsVariadic())
+	result := f.Call([]reflect.Value{reflect.ValueOf(3), reflect.ValueOf(4)})
+	println("add:", result[0].Int())
+
+	c := reflect.ValueOf(concat)
+	println("concat variadic:", c.Type().IsVariadic())
+	println("concat:", c.Call([]reflect.Value{reflect.ValueOf("a"), reflect.ValueOf("b"), reflect.ValueOf("c")})[0].String())
+	println("concat slice:", c.CallSlice([]reflect.Value{reflect.ValueOf("x"), reflect.ValueOf([]string{"y", "z"})})[0].String())
+
+	var swap func(int, string) (string, int)
+	fn := reflect.MakeFunc(reflect.TypeOf(swap), func(args []reflect.Value) []reflect.Value {
+		return []reflect.Value{args[1], args[0]}
+	})
+	swap = fn.Interface().(func(int, string) (string, int))
+	s, n := swap(5, "five")
+	println("swap:", s, n)
+	println("swap via Call:", fn.Call([]reflect.Value{reflect.ValueOf(1), reflect.ValueOf("one")})[0].String())
+}
+
+type methodStruct struct {
+	a, b int
+}
+
+func (m methodStruct) Sum(x int) int {
+	return m.a + m.b + x
+}
+
+func (m *methodStruct) Set(a, b int) {
+	m.a, m.b = a, b
+}
+
+func (m methodStruct) Pair() (int, string) {
+	return m.a, "pair"
+}
+
+func (m methodStruct) unexported() {
+}
+
+type summer interface {
+	Sum(int) int
+}
+
+func testMethods() {
+	m := methodStruct{1, 2}
+	v := reflect.ValueOf(m)
+	println("num methods:", v.NumMethod(), reflect.ValueOf(&m).NumMethod())
+	for i := 0; i < v.NumMethod(); i++ {
+		method := v.Type().Method(i)
+		println("method:", method.Name, method.Index, method.Type.NumIn())
+	}
+	println("Sum:", v.MethodByName("Sum").Call([]reflect.Value{reflect.ValueOf(10)})[0].Int())
+	method, _ := v.Type().MethodByName("Sum")
+	println("Sum func:", method.Func.Call([]reflect.Value{v, reflect.ValueOf(20)})[0].Int())
+	reflect.ValueOf(&m).MethodByName("Set").Call([]reflect.Value{reflect.ValueOf(5), reflect.ValueOf(6)})
+	println("after Set:", m.a, m.b)
+	pair := reflect.ValueOf(&m).MethodByName("Pair").Call(nil)
+	println("Pair:", pair[0].Int(), pair[1].String())
+	sum := v.MethodByName("Sum").Interface().(func(int) int)
+	println("method value:", sum(100))
+	println("missing method:", v.MethodByName("unexported").IsValid())
+
+	var s summer = m
+	sv := reflect.ValueOf(&s).Elem()
+	println("interface methods:", sv.NumMethod(), sv.Type().Method(0).Name)
+	println("interface call:", sv.Method(0).Call([]reflect.Value{reflect.ValueOf(1)})[0].Int())
+}
+
+type namedPair[T any] struct {
+	a, b T
+}
+
+func testTypeNames() {
+	for _, v := range []interface{}{
+		0,
+		myint(3),
+		methodStruct{},
+		&methodStruct{},
+		[]byte(nil),
+		map[string]interface{}{},
+		[3]*methodStruct{},
+		make(chan int),
+		concat,
+		func(int) {},
+		namedPair[int]{},
+		struct {
+			A int
+			b string `tag:"x"`
+			methodStruct
+		}{},
+	} {
+		t := reflect.TypeOf(v)
+		println(t.String(), "-", t.Name(), "-", t.PkgPath())
+	}
+	var s summer
+	st := reflect.TypeOf(&s).Elem()
+	println(st.String(), "-", st.Name(), "-", st.PkgPath())
+	field := reflect.TypeOf(methodStruct{}).Field(0)
+	println("unexported field:", field.Name, field.PkgPath, field.IsExported())
+	println("value:", reflect.ValueOf(3).String())
+}
+
 var xorshift32State uint32 = 1
 
 func xorshift32(x uint32) uint32 {
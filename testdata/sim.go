@@ -0,0 +1,107 @@
+package main
+
+// Test the simulated board (machine/sim), using the sim-arduino target.
+
+import (
+	"machine"
+	"machine/sim"
+	"strings"
+	"time"
+)
+
+// spiEcho is an SPI device that sends back the previous byte plus one.
+type spiEcho struct {
+	last byte
+}
+
+func (dev *spiEcho) Transfer(w byte) byte {
+	r := dev.last
+	dev.last = w + 1
+	return r
+}
+
+func main() {
+	// GPIO
+	led := machine.LED
+	led.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	changes := 0
+	sim.Pin(led).OnChange(func(high bool) {
+		changes++
+	})
+	led.High()
+	println("led high:", sim.Pin(led).Level(), sim.Pin(led).Mode() == sim.PinOutput)
+	led.Low()
+	println("led low:", sim.Pin(led).Level(), "changes:", changes)
+
+	button := machine.D2
+	button.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	println("button released:", button.Get())
+	sim.Pin(button).Drive(false)
+	println("button pressed:", button.Get())
+	sim.Pin(button).Release()
+	println("button released:", button.Get())
+
+	// ADC
+	sim.Pin(machine.ADC0).SetAnalog(1234)
+	machine.InitADC()
+	adc := machine.ADC{Pin: machine.ADC0}
+	adc.Configure(machine.ADCConfig{})
+	println("adc:", adc.Get())
+
+	// SPI
+	sim.AttachSPI(0, &spiEcho{})
+	machine.SPI0.Configure(machine.SPIConfig{})
+	r1, _ := machine.SPI0.Transfer(5)
+	r2, _ := machine.SPI0.Transfer(10)
+	println("spi:", r1, r2)
+
+	// I2C
+	sensor := &sim.Registers{}
+	sensor.Data[0x10] = 0xab
+	sensor.Data[0x11] = 0xcd
+	sim.AttachI2C(0, 0x42, sensor)
+	machine.I2C0.Configure(machine.I2CConfig{})
+	buf := make([]byte, 2)
+	err := machine.I2C0.Tx(0x42, []byte{0x10}, buf)
+	println("i2c read:", err == nil, buf[0], buf[1])
+	err = machine.I2C0.Tx(0x42, []byte{0x20, 7, 8}, nil)
+	println("i2c write:", err == nil, sensor.Data[0x20], sensor.Data[0x21])
+	err = machine.I2C0.Tx(0x43, []byte{0}, buf)
+	println("i2c missing device:", err == sim.ErrNoDevice)
+
+	// UART
+	uart := machine.UART0
+	sim.UART(0).SetWriter(nil)
+	uart.Write([]byte("hello"))
+	println("uart output:", string(sim.UART(0).Output()))
+	sim.UART(0).Send([]byte("abc"))
+	println("uart buffered:", uart.Buffered())
+	b, _ := uart.ReadByte()
+	println("uart read:", string(rune(b)), uart.Buffered())
+
+	// Script, running while the program echoes the UART input and mirrors
+	// the button on the LED.
+	go func() {
+		for {
+			for uart.Buffered() > 0 {
+				b, _ := uart.ReadByte()
+				uart.WriteByte(b)
+			}
+			led.Set(!button.Get())
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	err = sim.RunScript(strings.NewReader(`
+# Press and release the button.
+drive 18 low
+expect 5 high
+release 18
+expect 5 low
+wait 1ms
+send 0 "ping\n"
+receive 0 "ping\n"
+`))
+	println("script:", err == nil)
+	err = sim.RunScript(strings.NewReader(`expect 5 high 10ms`))
+	println("script error:", err.Error())
+}
@@ -0,0 +1,54 @@
+package main
+
+// This test is run with stack traces enabled (-pctable-size).
+
+import "runtime"
+
+func main() {
+	a()
+	c()
+}
+
+//go:noinline
+func a() {
+	b()
+}
+
+//go:noinline
+func b() {
+	_, file, line, ok := runtime.Caller(0)
+	println("Caller:", basename(file), line, ok)
+	_, file, line, ok = runtime.Caller(1)
+	println("Caller(1):", basename(file), line, ok)
+
+	// Print all Go functions in package main on the stack. Note that main.main
+	// itself may be inlined.
+	var pcs [16]uintptr
+	n := runtime.Callers(1, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "main.a" || frame.Function == "main.b" {
+			println("frame:", frame.Function, basename(frame.File), frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+}
+
+//go:noinline
+func c() {
+	// Look up a function by its address.
+	pc, _, _, _ := runtime.Caller(0)
+	println("FuncForPC:", runtime.FuncForPC(pc).Name())
+}
+
+func basename(path string) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == '/' {
+			return path[i+1:]
+		}
+	}
+	return path
+}
@@ -5,6 +5,7 @@ package main
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"io"
 	"testing"
 )
@@ -54,7 +55,34 @@ var tests = []testing.InternalTest{
 
 var benchmarks = []testing.InternalBenchmark{}
 
-var examples = []testing.InternalExample{}
+func ExampleHello() {
+	fmt.Println("hello")
+	fmt.Println("world")
+	// Output:
+	// hello
+	// world
+}
+
+func ExampleUnordered() {
+	for _, s := range []string{"b", "c", "a"} {
+		fmt.Println(s)
+	}
+	// Unordered output:
+	// a
+	// b
+	// c
+}
+
+func ExampleWrongOutput() {
+	fmt.Println("hello")
+	// Output: goodbye
+}
+
+var examples = []testing.InternalExample{
+	{"ExampleHello", ExampleHello, "hello\nworld\n", false},
+	{"ExampleUnordered", ExampleUnordered, "a\nb\nc\n", true},
+	{"ExampleWrongOutput", ExampleWrongOutput, "goodbye\n", false},
+}
 
 // A fake regexp matcher that can only handle two patterns.
 // Inflexible, but saves 50KB of flash and 50KB of RAM per -size full,
@@ -73,12 +101,22 @@ func fakeMatchString(pat, str string) (bool, error) {
 func main() {
 	testing.Init()
 	flag.Set("test.run", ".*/[BD]")
-	m := testing.MainStart(matchStringOnly(fakeMatchString /*regexp.MatchString*/), tests, benchmarks, examples)
+	m := mainStart(tests, benchmarks, nil)
 
 	exitcode := m.Run()
 	if exitcode != 0 {
 		println("exitcode:", exitcode)
 	}
+
+	// Run the examples separately, as the -test.run pattern above would not
+	// match any of them.
+	flag.Set("test.run", ".*")
+	m = mainStart(nil, benchmarks, examples)
+
+	exitcode = m.Run()
+	if exitcode != 0 {
+		println("exitcode:", exitcode)
+	}
 }
 
 var errMain = errors.New("testing: unexpected use of func Main")
@@ -0,0 +1,10 @@
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import "testing"
+
+func mainStart(tests []testing.InternalTest, benchmarks []testing.InternalBenchmark, examples []testing.InternalExample) *testing.M {
+	return testing.MainStart(matchStringOnly(fakeMatchString /*regexp.MatchString*/), tests, benchmarks, nil, examples)
+}
@@ -0,0 +1,77 @@
+package main
+
+import "time"
+
+func main() {
+	// Timer that fires.
+	timer := time.NewTimer(time.Millisecond)
+	<-timer.C
+	println("timer fired")
+	println("stop after firing:", timer.Stop())
+
+	// Timer that is stopped before it fires.
+	timer = time.NewTimer(time.Hour)
+	println("stop before firing:", timer.Stop())
+	println("stop twice:", timer.Stop())
+
+	// Timer that is reset before it fires.
+	timer = time.NewTimer(time.Hour)
+	println("reset active timer:", timer.Reset(time.Millisecond))
+	<-timer.C
+	println("reset timer fired")
+	println("reset expired timer:", timer.Reset(time.Millisecond))
+	<-timer.C
+	println("reset timer fired again")
+
+	// Timers fire in order, independent of the order they were started.
+	slow := time.After(20 * time.Millisecond)
+	fast := time.After(5 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-slow:
+			println("slow timer")
+		case <-fast:
+			println("fast timer")
+		}
+	}
+
+	// Timers and sleeping goroutines.
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		println("goroutine woke up")
+	}()
+	<-time.After(10 * time.Millisecond)
+	println("after sleeping goroutine")
+
+	// Callbacks.
+	done := make(chan struct{})
+	time.AfterFunc(time.Millisecond, func() {
+		println("AfterFunc called")
+		close(done)
+	})
+	<-done
+	stopped := time.AfterFunc(time.Millisecond, func() {
+		println("FAIL: stopped AfterFunc called")
+	})
+	println("stop AfterFunc:", stopped.Stop())
+
+	// Tickers.
+	ticker := time.NewTicker(2 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		<-ticker.C
+		println("tick", i)
+	}
+	ticker.Stop()
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-ticker.C:
+		// One tick may still be buffered in the channel.
+	default:
+	}
+	select {
+	case <-ticker.C:
+		println("FAIL: tick after Stop")
+	case <-time.After(10 * time.Millisecond):
+		println("no ticks after Stop")
+	}
+}
@@ -0,0 +1,13 @@
+package cover
+
+import "testing"
+
+func TestAbs(t *testing.T) {
+	// Only test one branch, so that coverage is incomplete.
+	if Abs(3) != 3 {
+		t.Error("expected Abs(3) to be 3")
+	}
+	if testing.CoverMode() != "set" {
+		t.Errorf("unexpected cover mode: %q", testing.CoverMode())
+	}
+}
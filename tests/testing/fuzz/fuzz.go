@@ -0,0 +1,10 @@
+package fuzz
+
+// Reverse returns a reversed copy of b.
+func Reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i, c := range b {
+		r[len(b)-1-i] = c
+	}
+	return r
+}
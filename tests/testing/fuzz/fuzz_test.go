@@ -0,0 +1,21 @@
+//go:build go1.18
+// +build go1.18
+
+package fuzz
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzReverse(f *testing.F) {
+	f.Add([]byte("hello"))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		if len(Reverse(b)) != len(b) {
+			t.Errorf("length changed for %q", b)
+		}
+		if !bytes.Equal(Reverse(Reverse(b)), b) {
+			t.Errorf("reversing twice changed %q", b)
+		}
+	})
+}
@@ -5,3 +5,14 @@ import "testing"
 func TestPass(t *testing.T) {
 	// This test passes.
 }
+
+var sink []byte
+
+func TestAllocsPerRun(t *testing.T) {
+	if n := testing.AllocsPerRun(10, func() {}); n != 0 {
+		t.Errorf("expected no allocations, got %v", n)
+	}
+	if n := testing.AllocsPerRun(10, func() { sink = make([]byte, 10) }); n != 1 {
+		t.Errorf("expected 1 allocation per run, got %v", n)
+	}
+}
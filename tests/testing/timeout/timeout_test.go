@@ -0,0 +1,14 @@
+package timeout
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFast(t *testing.T) {
+}
+
+func TestHang(t *testing.T) {
+	// This test should be killed by the -timeout flag of tinygo test.
+	time.Sleep(time.Hour)
+}
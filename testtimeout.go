@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// These lines are printed by the testing package when a test starts or
+// finishes, if a timeout is in use. They must be kept in sync with
+// src/testing/testing.go.
+const (
+	testRunningPrefix  = "--- tinygo run "
+	testFinishedPrefix = "--- tinygo done "
+)
+
+// defaultTestTimeout is the default of the -timeout flag of tinygo test, which
+// is the same as the default of go test.
+const defaultTestTimeout = 10 * time.Minute
+
+// runningTests keeps track of the tests that are currently running in a test
+// binary, so that they can be printed when the test binary is killed after a
+// timeout. It removes the lines printed for this purpose from the output and
+// passes on all other output.
+type runningTests struct {
+	w     io.Writer
+	lock  sync.Mutex
+	line  []byte // incomplete line
+	names []string
+	start map[string]time.Time
+	err   error
+}
+
+func newRunningTests(w io.Writer) *runningTests {
+	return &runningTests{
+		w:     w,
+		start: make(map[string]time.Time),
+	}
+}
+
+func (r *runningTests) Write(data []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.line = append(r.line, data...)
+	for {
+		i := bytes.IndexByte(r.line, '\n')
+		if i < 0 {
+			break
+		}
+		r.writeLine(r.line[:i+1])
+		r.line = r.line[i+1:]
+	}
+	return len(data), r.err
+}
+
+// writeLine processes a single line of output, including the newline.
+func (r *runningTests) writeLine(line []byte) {
+	// Output from emulators may use \r\n line endings.
+	text := string(bytes.TrimRight(line, "\r\n"))
+	switch {
+	case strings.HasPrefix(text, testRunningPrefix):
+		name := text[len(testRunningPrefix):]
+		r.names = append(r.names, name)
+		r.start[name] = time.Now()
+	case strings.HasPrefix(text, testFinishedPrefix):
+		name := text[len(testFinishedPrefix):]
+		for i := len(r.names) - 1; i >= 0; i-- {
+			if r.names[i] == name {
+				r.names = append(r.names[:i], r.names[i+1:]...)
+				break
+			}
+		}
+		delete(r.start, name)
+	default:
+		if r.err == nil {
+			_, r.err = r.w.Write(line)
+		}
+	}
+}
+
+// Flush writes any remaining output that didn't end in a newline.
+func (r *runningTests) Flush() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.line) != 0 {
+		r.writeLine(r.line)
+		r.line = nil
+	}
+	return r.err
+}
+
+// timedOut prints the message that the test binary was killed at the given time
+// after the timeout expired, including the tests that were running at the time.
+// The format is the same as the message printed by go test.
+func (r *runningTests) timedOut(timeout time.Duration, killed time.Time) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.line) != 0 {
+		// Terminate the last line of output, so that the message starts on
+		// a new line.
+		r.writeLine(append(r.line, '\n'))
+		r.line = nil
+	}
+	if r.err != nil {
+		return r.err
+	}
+	msg := fmt.Sprintf("panic: test timed out after %v\n", timeout)
+	if len(r.names) != 0 {
+		msg += "running tests:\n"
+		for _, name := range r.names {
+			msg += fmt.Sprintf("\t%s (%v)\n", name, killed.Sub(r.start[name]).Round(time.Second))
+		}
+	}
+	_, err := io.WriteString(r.w, msg)
+	return err
+}
@@ -281,11 +281,16 @@ func (p *lowerInterfacesPass) run() error {
 	// Remove all method sets, which are now unnecessary and inhibit later
 	// optimizations if they are left in place. Also remove references to the
 	// interface type assert functions just to be sure.
+	// The method sets are left in place when the reflect package needs them
+	// to look up methods at runtime. They are removed in LowerReflect instead.
+	keepMethodSets := p.isReachableWithoutMethodSets(p.mod.NamedGlobal("reflect.methodsSidetable"))
 	zeroUintptr := llvm.ConstNull(p.uintptrType)
 	for _, t := range p.types {
 		initializer := t.typecode.Initializer()
-		methodSet := llvm.ConstExtractValue(initializer, []uint32{2})
-		initializer = llvm.ConstInsertValue(initializer, llvm.ConstNull(methodSet.Type()), []uint32{2})
+		if !keepMethodSets {
+			methodSet := llvm.ConstExtractValue(initializer, []uint32{2})
+			initializer = llvm.ConstInsertValue(initializer, llvm.ConstNull(methodSet.Type()), []uint32{2})
+		}
 		initializer = llvm.ConstInsertValue(initializer, zeroUintptr, []uint32{4})
 		t.typecode.SetInitializer(initializer)
 	}
@@ -293,6 +298,88 @@ func (p *lowerInterfacesPass) run() error {
 	return nil
 }
 
+// isReachableWithoutMethodSets returns whether the given global is reachable
+// from any externally visible function or global, without following method
+// sets of type codes. Method sets reference every method of a type, including
+// the methods of reflect.rawType that themselves need all method sets. Ignoring
+// them avoids keeping all methods alive only because the reflect.Type
+// interface has a Method method.
+func (p *lowerInterfacesPass) isReachableWithoutMethodSets(target llvm.Value) bool {
+	if target.IsNil() {
+		return false
+	}
+	visited := make(map[llvm.Value]struct{})
+	var worklist []llvm.Value
+	push := func(value llvm.Value) {
+		if _, ok := visited[value]; !ok {
+			visited[value] = struct{}{}
+			worklist = append(worklist, value)
+		}
+	}
+	for fn := p.mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		if !fn.IsDeclaration() && isExternallyVisible(fn) {
+			push(fn)
+		}
+	}
+	for global := p.mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+		if !global.IsDeclaration() && isExternallyVisible(global) {
+			push(global)
+		}
+	}
+	for len(worklist) != 0 {
+		value := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		if value == target {
+			return true
+		}
+		var operands []llvm.Value
+		switch {
+		case !value.IsAFunction().IsNil():
+			for bb := value.FirstBasicBlock(); !bb.IsNil(); bb = llvm.NextBasicBlock(bb) {
+				for inst := bb.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+					for i := 0; i < inst.OperandsCount(); i++ {
+						operands = append(operands, inst.Operand(i))
+					}
+				}
+			}
+		case !value.IsAGlobalVariable().IsNil():
+			initializer := value.Initializer()
+			if initializer.IsNil() {
+				continue
+			}
+			if strings.HasPrefix(value.Name(), "reflect/types.type:") {
+				// Skip the method set.
+				methodSet := llvm.ConstExtractValue(initializer, []uint32{2})
+				initializer = llvm.ConstInsertValue(initializer, llvm.ConstNull(methodSet.Type()), []uint32{2})
+			}
+			operands = append(operands, initializer)
+		default:
+			// Constant expression or aggregate.
+			for i := 0; i < value.OperandsCount(); i++ {
+				operands = append(operands, value.Operand(i))
+			}
+		}
+		for _, operand := range operands {
+			if !operand.IsAConstant().IsNil() {
+				push(operand)
+			}
+		}
+	}
+	return false
+}
+
+// isExternallyVisible returns whether the given function or global may be
+// referenced from outside the module, and therefore is a root for
+// isReachableWithoutMethodSets.
+func isExternallyVisible(value llvm.Value) bool {
+	switch value.Linkage() {
+	case llvm.InternalLinkage, llvm.PrivateLinkage, llvm.LinkOnceAnyLinkage, llvm.LinkOnceODRLinkage, llvm.AvailableExternallyLinkage:
+		return false
+	default:
+		return true
+	}
+}
+
 // addTypeMethods reads the method set of the given type info struct. It
 // retrieves the signatures and the references to the method functions
 // themselves for later type<->interface matching.
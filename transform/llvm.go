@@ -30,8 +30,8 @@ func hasUses(value llvm.Value) bool {
 	return !value.FirstUse().IsNil()
 }
 
-// makeGlobalArray creates a new LLVM global with the given name and integers as
-// contents, and returns the global.
+// makeGlobalArray creates a new LLVM global with the given name and integers (or
+// constant values of the element type) as contents, and returns the global.
 // Note that it is left with the default linkage etc., you should set
 // linkage/constant/etc properties yourself.
 func makeGlobalArray(mod llvm.Module, bufItf interface{}, name string, elementType llvm.Type) llvm.Value {
@@ -40,8 +40,11 @@ func makeGlobalArray(mod llvm.Module, bufItf interface{}, name string, elementTy
 	global := llvm.AddGlobal(mod, globalType, name)
 	value := llvm.Undef(globalType)
 	for i := 0; i < buf.Len(); i++ {
-		ch := buf.Index(i).Uint()
-		value = llvm.ConstInsertValue(value, llvm.ConstInt(elementType, ch, false), []uint32{uint32(i)})
+		elem, ok := buf.Index(i).Interface().(llvm.Value)
+		if !ok {
+			elem = llvm.ConstInt(elementType, buf.Index(i).Uint(), false)
+		}
+		value = llvm.ConstInsertValue(value, elem, []uint32{uint32(i)})
 	}
 	global.SetInitializer(value)
 	return global
@@ -0,0 +1,62 @@
+package transform
+
+import (
+	"tinygo.org/x/go-llvm"
+)
+
+// PCTableHeaderSize is the size of the header of the runtime.pcTable global.
+// The table will always be at least this big.
+const PCTableHeaderSize = 8
+
+// CreatePCTable defines the runtime.pcTable global, which maps program counters
+// to function names and source locations. It is used by the runtime to produce
+// stack traces.
+//
+// The table is reserved here with the given size, but can only be filled in
+// after linking when the addresses of all functions are known. When size is
+// zero, stack traces are disabled and the table is created as an empty table
+// that will never be filled in. This should be done before optimizing, so that
+// all code that walks the stack is optimized away.
+//
+// When stack traces are enabled, this must be done after all optimizations so
+// that the optimizer does not see the (still empty) contents of the table. In
+// that case frame pointers are also enabled for all functions, as they are
+// needed to walk the stack.
+//
+// The return value indicates whether the runtime uses the table at all.
+func CreatePCTable(mod llvm.Module, size int) bool {
+	oldTable := mod.NamedGlobal("runtime.pcTable")
+	if oldTable.IsNil() {
+		// Stack traces are never used in this program.
+		return false
+	}
+
+	ctx := mod.Context()
+	if size < PCTableHeaderSize {
+		size = PCTableHeaderSize
+	}
+	tableType := llvm.ArrayType(ctx.Int8Type(), size)
+	table := llvm.AddGlobal(mod, tableType, "runtime.pcTable.tmp")
+	table.SetInitializer(llvm.ConstNull(tableType))
+	table.SetGlobalConstant(true)
+	table.SetLinkage(llvm.InternalLinkage)
+	table.SetAlignment(4)
+	oldTable.ReplaceAllUsesWith(llvm.ConstBitCast(table, oldTable.Type()))
+	oldTable.EraseFromParentAsGlobal()
+	table.SetName("runtime.pcTable")
+
+	if size > PCTableHeaderSize {
+		// Stack traces are walked using frame pointers, so make sure every
+		// function maintains one. Tail calls are disabled as well, as they
+		// remove the calling function from the stack.
+		for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+			if fn.IsDeclaration() {
+				continue
+			}
+			fn.AddFunctionAttr(ctx.CreateStringAttribute("frame-pointer", "all"))
+			fn.AddFunctionAttr(ctx.CreateStringAttribute("disable-tail-calls", "true"))
+		}
+	}
+
+	return true
+}
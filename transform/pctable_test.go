@@ -0,0 +1,15 @@
+package transform_test
+
+import (
+	"testing"
+
+	"github.com/tinygo-org/tinygo/transform"
+	"tinygo.org/x/go-llvm"
+)
+
+func TestCreatePCTable(t *testing.T) {
+	t.Parallel()
+	testTransform(t, "testdata/pctable", func(mod llvm.Module) {
+		transform.CreatePCTable(mod, 64)
+	})
+}
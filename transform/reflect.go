@@ -26,6 +26,12 @@ package transform
 // This distinction is also important for how named types are encoded. At the
 // moment, named basic type just get a unique number assigned while named
 // non-basic types have their underlying type stored in a sidetable.
+//
+// The names and package paths of named types are stored in a separate
+// sidetable, which is only created when the program needs it (for example to
+// implement reflect.Type.String). It can be left empty to save the space taken
+// by the names, see compileopts.Options.NoReflectNames. This doesn't remove
+// the code of reflect.Type.String or any of the other sidetables.
 
 import (
 	"encoding/binary"
@@ -34,6 +40,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/tinygo-org/tinygo/compileopts"
 	"tinygo.org/x/go-llvm"
 )
 
@@ -80,11 +87,15 @@ type typeCodeAssignmentState struct {
 	// type codes that are not yet fully supported otherwise by the reflect
 	// package (or are simply unused in the compiled program).
 	fallbackIndex int
+	fallbackTypes map[string]int
 
 	// This is the length of an uintptr. Only used occasionally to know whether
 	// a given number can be encoded as a varint.
 	uintptrLen int
 
+	// The uintptr type, used to store function pointers in a sidetable.
+	uintptrType llvm.Type
+
 	// Map of named types to their type code. It is important that named types
 	// get unique IDs for each type.
 	namedBasicTypes    map[string]int
@@ -95,6 +106,33 @@ type typeCodeAssignmentState struct {
 	arrayTypesSidetable      []byte
 	needsArrayTypesSidetable bool
 
+	// Map of map types to their type code.
+	mapTypes               map[string]int
+	mapTypesSidetable      []byte
+	needsMapTypesSidetable bool
+
+	// Map of func types to their type code.
+	funcTypes               map[string]int
+	funcTypesSidetable      []byte
+	needsFuncTypesSidetable bool
+
+	// Methods of all types that have (exported) methods. See addTypeMethods.
+	methodsSidetable      []byte
+	needsMethodsSidetable bool
+
+	// Function pointers used by the func types and methods sidetables, stored
+	// as uintptr values.
+	funcPointers               []llvm.Value
+	needsFuncPointersSidetable bool
+
+	// Names and package paths of all named types. See addTypeName.
+	typeNamesSidetable      []byte
+	needsTypeNamesSidetable bool
+
+	// Whether to store the names and package paths of named types in the type
+	// names sidetable. If false, this sidetable is left empty.
+	reflectNames bool
+
 	// Map of struct types to their type code.
 	structTypes               map[string]int
 	structTypesSidetable      []byte
@@ -126,7 +164,7 @@ type typeCodeAssignmentState struct {
 // LowerReflect is used to assign a type code to each type in the program
 // that is ever stored in an interface. It tries to use the smallest possible
 // numbers to make the code that works with interfaces as small as possible.
-func LowerReflect(mod llvm.Module) {
+func LowerReflect(mod llvm.Module, config *compileopts.Config) {
 	// if reflect were not used, we could skip generating the sidetable
 	// this does not help in practice, and is difficult to do correctly
 
@@ -164,16 +202,26 @@ func LowerReflect(mod llvm.Module) {
 	uintptrType := mod.Context().IntType(llvm.NewTargetData(mod.DataLayout()).PointerSize() * 8)
 	state := typeCodeAssignmentState{
 		fallbackIndex:                    1,
+		fallbackTypes:                    make(map[string]int),
 		uintptrLen:                       llvm.NewTargetData(mod.DataLayout()).PointerSize() * 8,
+		uintptrType:                      uintptrType,
 		namedBasicTypes:                  make(map[string]int),
 		namedNonBasicTypes:               make(map[string]int),
 		arrayTypes:                       make(map[string]int),
+		mapTypes:                         make(map[string]int),
+		funcTypes:                        make(map[string]int),
 		structTypes:                      make(map[string]int),
 		structNames:                      make(map[string]int),
 		needsNamedNonBasicTypesSidetable: len(getUses(mod.NamedGlobal("reflect.namedNonBasicTypesSidetable"))) != 0,
 		needsStructTypesSidetable:        len(getUses(mod.NamedGlobal("reflect.structTypesSidetable"))) != 0,
 		needsStructNamesSidetable:        len(getUses(mod.NamedGlobal("reflect.structNamesSidetable"))) != 0,
 		needsArrayTypesSidetable:         len(getUses(mod.NamedGlobal("reflect.arrayTypesSidetable"))) != 0,
+		needsMapTypesSidetable:           len(getUses(mod.NamedGlobal("reflect.mapTypesSidetable"))) != 0,
+		needsFuncTypesSidetable:          len(getUses(mod.NamedGlobal("reflect.funcTypesSidetable"))) != 0,
+		needsMethodsSidetable:            len(getUses(mod.NamedGlobal("reflect.methodsSidetable"))) != 0,
+		needsFuncPointersSidetable:       len(getUses(mod.NamedGlobal("reflect.funcPointersSidetable"))) != 0,
+		needsTypeNamesSidetable:          len(getUses(mod.NamedGlobal("reflect.typeNamesSidetable"))) != 0,
+		reflectNames:                     config.ReflectNames(),
 	}
 	for _, t := range types {
 		num := state.getTypeCodeNum(t.typecode)
@@ -205,6 +253,27 @@ func LowerReflect(mod llvm.Module) {
 		}
 	}
 
+	// Collect the methods of all types. This is done after all types have
+	// been assigned a type code, because the method set of a type is not part
+	// of the type itself.
+	if state.needsMethodsSidetable {
+		for _, t := range types {
+			state.addTypeMethods(t.typecode)
+		}
+		// The list is terminated by an invalid (zero) type code.
+		state.methodsSidetable = append(state.methodsSidetable, 0)
+	}
+
+	// Collect the names of all named types, in the same way as methods.
+	if state.needsTypeNamesSidetable {
+		if state.reflectNames {
+			for _, t := range types {
+				state.addTypeName(t.typecode)
+			}
+		}
+		state.typeNamesSidetable = append(state.typeNamesSidetable, 0)
+	}
+
 	// Only create this sidetable when it is necessary.
 	if state.needsNamedNonBasicTypesSidetable {
 		global := replaceGlobalIntWithArray(mod, "reflect.namedNonBasicTypesSidetable", state.namedNonBasicTypesSidetable)
@@ -218,6 +287,36 @@ func LowerReflect(mod llvm.Module) {
 		global.SetUnnamedAddr(true)
 		global.SetGlobalConstant(true)
 	}
+	if state.needsMapTypesSidetable {
+		global := replaceGlobalIntWithArray(mod, "reflect.mapTypesSidetable", state.mapTypesSidetable)
+		global.SetLinkage(llvm.InternalLinkage)
+		global.SetUnnamedAddr(true)
+		global.SetGlobalConstant(true)
+	}
+	if state.needsFuncTypesSidetable {
+		global := replaceGlobalIntWithArray(mod, "reflect.funcTypesSidetable", state.funcTypesSidetable)
+		global.SetLinkage(llvm.InternalLinkage)
+		global.SetUnnamedAddr(true)
+		global.SetGlobalConstant(true)
+	}
+	if state.needsMethodsSidetable {
+		global := replaceGlobalIntWithArray(mod, "reflect.methodsSidetable", state.methodsSidetable)
+		global.SetLinkage(llvm.InternalLinkage)
+		global.SetUnnamedAddr(true)
+		global.SetGlobalConstant(true)
+	}
+	if state.needsFuncPointersSidetable {
+		global := replaceGlobalIntWithArray(mod, "reflect.funcPointersSidetable", state.funcPointers)
+		global.SetLinkage(llvm.InternalLinkage)
+		global.SetUnnamedAddr(true)
+		global.SetGlobalConstant(true)
+	}
+	if state.needsTypeNamesSidetable {
+		global := replaceGlobalIntWithArray(mod, "reflect.typeNamesSidetable", state.typeNamesSidetable)
+		global.SetLinkage(llvm.InternalLinkage)
+		global.SetUnnamedAddr(true)
+		global.SetGlobalConstant(true)
+	}
 	if state.needsStructTypesSidetable {
 		global := replaceGlobalIntWithArray(mod, "reflect.structTypesSidetable", state.structTypesSidetable)
 		global.SetLinkage(llvm.InternalLinkage)
@@ -238,13 +337,15 @@ func LowerReflect(mod llvm.Module) {
 		initializer := typ.typecode.Initializer()
 		references := llvm.ConstExtractValue(initializer, []uint32{0})
 		typ.typecode.SetInitializer(llvm.ConstNull(initializer.Type()))
-		if strings.HasPrefix(typ.name, "reflect/types.type:struct:") {
-			// Structs have a 'references' field that is not a typecode but
-			// a pointer to a runtime.structField array and therefore a
-			// bitcast. This global should be erased separately, otherwise
-			// typecode objects cannot be erased.
-			structFields := references.Operand(0)
-			structFields.EraseFromParentAsGlobal()
+		if strings.HasPrefix(typ.name, "reflect/types.type:struct:") || strings.HasPrefix(typ.name, "reflect/types.type:map:") || strings.HasPrefix(typ.name, "reflect/types.type:func:") {
+			// Structs, maps and funcs have a 'references' field that is not a
+			// typecode but a pointer to a runtime.structField array (for
+			// structs), an array with the key and element type (for maps) or
+			// a struct with parameter and result types (for funcs) and
+			// therefore a bitcast. This global should be erased separately,
+			// otherwise typecode objects cannot be erased.
+			elems := references.Operand(0)
+			elems.EraseFromParentAsGlobal()
 		}
 	}
 }
@@ -348,14 +449,26 @@ func (state *typeCodeAssignmentState) getNonBasicTypeCode(class string, typecode
 		// An array is basically a pair of (typecode, length) stored in a
 		// sidetable.
 		return big.NewInt(int64(state.getArrayTypeNum(typecode)))
+	case "map":
+		// A map is a pair of (key typecode, element typecode) stored in a
+		// sidetable.
+		return big.NewInt(int64(state.getMapTypeNum(typecode)))
+	case "func":
+		// A func is a list of parameter and result types stored in a
+		// sidetable.
+		return big.NewInt(int64(state.getFuncTypeNum(typecode)))
 	case "struct":
 		// More complicated type kind. The upper bits contain the index to the
 		// struct type in the struct types sidetable.
 		return big.NewInt(int64(state.getStructTypeNum(typecode)))
 	default:
 		// Type has not yet been implemented, so fall back by using a unique
-		// number.
+		// number. Make sure the same type gets the same number each time.
+		if num, ok := state.fallbackTypes[typecode.Name()]; ok {
+			return big.NewInt(int64(num))
+		}
 		num := big.NewInt(int64(state.fallbackIndex))
+		state.fallbackTypes[typecode.Name()] = state.fallbackIndex
 		state.fallbackIndex++
 		return num
 	}
@@ -429,6 +542,266 @@ func (state *typeCodeAssignmentState) getArrayTypeNum(typecode llvm.Value) int {
 	return index
 }
 
+// getMapTypeNum returns the map type number, which is an index into the
+// reflect.mapTypesSidetable or a unique number for this type if this table is
+// not used.
+func (state *typeCodeAssignmentState) getMapTypeNum(typecode llvm.Value) int {
+	name := typecode.Name()
+	if num, ok := state.mapTypes[name]; ok {
+		// This map type already has an entry in the sidetable. Don't store it
+		// twice.
+		return num
+	}
+
+	if !state.needsMapTypesSidetable {
+		// We don't need map sidetables, so we can just assign monotonically
+		// increasing numbers to each map type.
+		num := len(state.mapTypes)
+		state.mapTypes[name] = num
+		return num
+	}
+
+	// The map side table is a sequence of {key type, element type}.
+	mapElems := llvm.ConstExtractValue(typecode.Initializer(), []uint32{0}).Operand(0).Initializer()
+	var buf []byte
+	for i := 0; i < 2; i++ {
+		typeNum := state.getTypeCodeNum(llvm.ConstExtractValue(mapElems, []uint32{uint32(i)}))
+		if typeNum.BitLen() > state.uintptrLen || !typeNum.IsUint64() {
+			// TODO: make this a regular error
+			panic("map key or element type has a type code that is too big")
+		}
+		buf = append(buf, makeVarint(typeNum.Uint64())...)
+	}
+
+	// The key or element type may have been added to the sidetable in the
+	// meantime (for recursive types), so check again.
+	if num, ok := state.mapTypes[name]; ok {
+		return num
+	}
+	index := len(state.mapTypesSidetable)
+	state.mapTypes[name] = index
+	state.mapTypesSidetable = append(state.mapTypesSidetable, buf...)
+	return index
+}
+
+// getFuncTypeNum returns the func type number, which is an index into the
+// reflect.funcTypesSidetable or a unique number for this type if this table is
+// not used.
+func (state *typeCodeAssignmentState) getFuncTypeNum(typecode llvm.Value) int {
+	name := typecode.Name()
+	if num, ok := state.funcTypes[name]; ok {
+		// This func type already has an entry in the sidetable. Don't store it
+		// twice.
+		return num
+	}
+
+	if !state.needsFuncTypesSidetable {
+		// We don't need func sidetables, so we can just assign monotonically
+		// increasing numbers to each func type.
+		num := len(state.funcTypes)
+		state.funcTypes[name] = num
+		return num
+	}
+
+	// The func side table is a sequence of {flags, number of parameters,
+	// parameter types..., number of results, result types..., index of the
+	// wrappers in the func pointers sidetable}. The only flag at the moment
+	// indicates whether the function is variadic.
+	funcElems := llvm.ConstExtractValue(typecode.Initializer(), []uint32{0}).Operand(0).Initializer()
+	flags := uint64(0)
+	if llvm.ConstExtractValue(funcElems, []uint32{2}).ZExtValue() != 0 {
+		flags |= 1
+	}
+	buf := makeVarint(flags)
+	for i := uint32(0); i < 2; i++ {
+		// Add the parameter types (i == 0) or result types (i == 1).
+		tuple := llvm.ConstExtractValue(funcElems, []uint32{i})
+		numElems := tuple.Type().ArrayLength()
+		buf = append(buf, makeVarint(uint64(numElems))...)
+		for j := 0; j < numElems; j++ {
+			typeNum := state.getTypeCodeNum(llvm.ConstExtractValue(tuple, []uint32{uint32(j)}))
+			if typeNum.BitLen() > state.uintptrLen || !typeNum.IsUint64() {
+				// TODO: make this a regular error
+				panic("func parameter or result type has a type code that is too big")
+			}
+			buf = append(buf, makeVarint(typeNum.Uint64())...)
+		}
+	}
+	callWrapper := llvm.ConstExtractValue(funcElems, []uint32{3})
+	makeFuncWrapper := llvm.ConstExtractValue(funcElems, []uint32{4})
+	buf = append(buf, makeVarint(uint64(state.getFuncPointerIndex(callWrapper, makeFuncWrapper)))...)
+
+	// The parameter or result types may have been added to the sidetable in
+	// the meantime (for recursive types), so check again.
+	if num, ok := state.funcTypes[name]; ok {
+		return num
+	}
+	index := len(state.funcTypesSidetable)
+	state.funcTypes[name] = index
+	state.funcTypesSidetable = append(state.funcTypesSidetable, buf...)
+	return index
+}
+
+// getFuncPointerIndex adds the given functions to the func pointers sidetable
+// and returns the index of the first one. It returns 0 if this sidetable is not
+// used.
+func (state *typeCodeAssignmentState) getFuncPointerIndex(fns ...llvm.Value) int {
+	if !state.needsFuncPointersSidetable {
+		return 0
+	}
+	index := len(state.funcPointers)
+	for _, fn := range fns {
+		if fn.Type().TypeKind() == llvm.PointerTypeKind {
+			fn = llvm.ConstPtrToInt(fn, state.uintptrType)
+		}
+		state.funcPointers = append(state.funcPointers, fn)
+	}
+	return index
+}
+
+// addTypeMethods adds the methods of the given type to the methods sidetable,
+// if it has any. For non-interface types only exported methods are added,
+// together with the method as a function so that it can be called. For
+// interface types, all methods are added.
+//
+// Each type in the sidetable is stored as {type code, number of methods,
+// methods...} and each method as {flags, name, package path, method type,
+// method type with receiver, index in the func pointers sidetable}. The last
+// two are zero for interface methods. The only flag indicates whether the
+// method is exported, the package path is only present for unexported
+// methods. Names and package paths are stored in the struct names sidetable.
+func (state *typeCodeAssignmentState) addTypeMethods(typecode llvm.Value) {
+	initializer := typecode.Initializer()
+	var buf []byte
+	numMethods := 0
+	if methodSet := llvm.ConstExtractValue(initializer, []uint32{2}); !methodSet.IsNull() {
+		// Concrete type with methods.
+		methods := methodSet.Operand(0).Initializer()
+		for i := 0; i < methods.Type().ArrayLength(); i++ {
+			method := llvm.ConstExtractValue(methods, []uint32{uint32(i)})
+			funcType := llvm.ConstExtractValue(method, []uint32{2})
+			if funcType.IsNull() {
+				// Unexported method.
+				continue
+			}
+			signature := llvm.ConstExtractValue(method, []uint32{0})
+			function := llvm.ConstExtractValue(method, []uint32{3})
+			buf = append(buf, state.makeMethodEntry(signature, funcType, function)...)
+			numMethods++
+		}
+	} else {
+		underlying := typecode
+		if class, _ := getClassAndValueFromTypeCode(typecode); class == "named" {
+			underlying = llvm.ConstExtractValue(initializer, []uint32{0})
+		}
+		if class, _ := getClassAndValueFromTypeCode(underlying); class != "interface" {
+			// Not an interface, and no methods.
+			return
+		}
+		// The references field is a bitcast of the array of method signatures
+		// (possibly through a GEP).
+		methodsGlobal := llvm.ConstExtractValue(underlying.Initializer(), []uint32{0}).Operand(0)
+		if methodsGlobal.IsAGlobalVariable().IsNil() {
+			methodsGlobal = methodsGlobal.Operand(0)
+		}
+		methods := methodsGlobal.Initializer()
+		for i := 0; i < methods.Type().ArrayLength(); i++ {
+			signature := llvm.ConstExtractValue(methods, []uint32{uint32(i)})
+			buf = append(buf, state.makeMethodEntry(signature, llvm.Value{}, llvm.Value{})...)
+			numMethods++
+		}
+	}
+	if numMethods == 0 {
+		return
+	}
+	state.methodsSidetable = append(state.methodsSidetable, state.makeTypeCodeVarint(typecode)...)
+	state.methodsSidetable = append(state.methodsSidetable, makeVarint(uint64(numMethods))...)
+	state.methodsSidetable = append(state.methodsSidetable, buf...)
+}
+
+// makeMethodEntry returns a single method as stored in the methods sidetable.
+// The funcType and function may be nil for interface methods.
+func (state *typeCodeAssignmentState) makeMethodEntry(signature, funcType, function llvm.Value) []byte {
+	name := getMethodName(signature.Name())
+	flagsByte := byte(0)
+	if ast.IsExported(name) {
+		flagsByte |= 1
+	}
+	buf := []byte{flagsByte}
+	buf = append(buf, makeVarint(uint64(state.getStructNameNumber([]byte(name))))...)
+	if flagsByte&1 == 0 {
+		pkgPath := getMethodPkgPath(signature.Name())
+		buf = append(buf, makeVarint(uint64(state.getStructNameNumber([]byte(pkgPath))))...)
+	}
+	buf = append(buf, state.makeTypeCodeVarint(signature.Initializer())...)
+	if funcType.IsNil() {
+		return append(buf, 0, 0)
+	}
+	buf = append(buf, state.makeTypeCodeVarint(funcType)...)
+	buf = append(buf, makeVarint(uint64(state.getFuncPointerIndex(function)))...)
+	return buf
+}
+
+// makeTypeCodeVarint returns the type code of the given type as a varint.
+func (state *typeCodeAssignmentState) makeTypeCodeVarint(typecode llvm.Value) []byte {
+	typeNum := state.getTypeCodeNum(typecode)
+	if typeNum.BitLen() > state.uintptrLen || !typeNum.IsUint64() {
+		// TODO: make this a regular error
+		panic("method type has a type code that is too big")
+	}
+	return makeVarint(typeNum.Uint64())
+}
+
+// getMethodName extracts the method name from the name of a method signature
+// global, which is either of the form "reflect/methods.Name(...) ..." for
+// exported methods or "pkgpath.$methods.name(...) ..." for unexported methods.
+func getMethodName(signature string) string {
+	if index := strings.Index(signature, ".$methods."); index >= 0 {
+		signature = signature[index+len(".$methods."):]
+	} else {
+		signature = strings.TrimPrefix(signature, "reflect/methods.")
+	}
+	return signature[:strings.IndexByte(signature, '(')]
+}
+
+// getMethodPkgPath returns the package path of an unexported method from the
+// name of its method signature global (see getMethodName).
+func getMethodPkgPath(signature string) string {
+	if index := strings.Index(signature, ".$methods."); index >= 0 {
+		return signature[:index]
+	}
+	return ""
+}
+
+// addTypeName adds the name of the given type to the type names sidetable, if
+// it is a named type. Each entry is stored as {type code, package path, name}
+// where the name is the type name qualified with the package name as used in
+// reflect.Type.String, for example "base64.Encoding". The package path and
+// name are stored in the struct names sidetable. The list is terminated by a
+// zero type code.
+func (state *typeCodeAssignmentState) addTypeName(typecode llvm.Value) {
+	class, value := getClassAndValueFromTypeCode(typecode)
+	if class != "named" {
+		return
+	}
+	// The package path is everything before the last dot in the type name,
+	// excluding type parameters (which may contain dots themselves).
+	pkgPath := value
+	if index := strings.IndexByte(pkgPath, '['); index >= 0 {
+		pkgPath = pkgPath[:index]
+	}
+	if index := strings.LastIndexByte(pkgPath, '.'); index >= 0 {
+		pkgPath = pkgPath[:index]
+	} else {
+		pkgPath = "" // predeclared type, like error
+	}
+	nameGlobal := llvm.ConstExtractValue(typecode.Initializer(), []uint32{1}).Operand(0)
+	name := getGlobalBytes(nameGlobal)
+	state.typeNamesSidetable = append(state.typeNamesSidetable, state.makeTypeCodeVarint(typecode)...)
+	state.typeNamesSidetable = append(state.typeNamesSidetable, makeVarint(uint64(state.getStructNameNumber([]byte(pkgPath))))...)
+	state.typeNamesSidetable = append(state.typeNamesSidetable, makeVarint(uint64(state.getStructNameNumber(name)))...)
+}
+
 // getStructTypeNum returns the struct type number, which is an index into
 // reflect.structTypesSidetable or an unique number for every struct if this
 // sidetable is not needed in the to-be-compiled program.
@@ -519,6 +892,16 @@ func (state *typeCodeAssignmentState) getStructTypeNum(typecode llvm.Value) int
 		if hasTag {
 			buf = append(buf, makeVarint(uint64(tagNumber))...)
 		}
+
+		// Add the package path for unexported fields.
+		if flagsByte&4 == 0 {
+			pkgPathGlobal := llvm.ConstExtractValue(field, []uint32{4})
+			if pkgPathGlobal.IsNull() {
+				panic("compiler: no package path for this unexported struct field")
+			}
+			pkgPathNumber := state.getStructNameNumber(getGlobalBytes(pkgPathGlobal.Operand(0)))
+			buf = append(buf, makeVarint(uint64(pkgPathNumber))...)
+		}
 	}
 
 	num := len(state.structTypesSidetable)
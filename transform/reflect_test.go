@@ -60,7 +60,7 @@ func TestReflect(t *testing.T) {
 	}
 
 	// Now lower the type codes.
-	transform.LowerReflect(mod)
+	transform.LowerReflect(mod, defaultTestConfig)
 
 	// Check whether the values are as expected.
 	for _, assert := range asserts {
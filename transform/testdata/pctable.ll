@@ -0,0 +1,13 @@
+target datalayout = "e-m:e-p:32:32-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7m-none-eabi"
+
+@runtime.pcTable = external global [0 x i32]
+
+declare void @runtime.printuint32(i32)
+
+define void @runtime.printNumFuncs() {
+entry:
+  %numFuncs = load i32, i32* getelementptr inbounds ([0 x i32], [0 x i32]* @runtime.pcTable, i32 0, i32 0), align 4
+  call void @runtime.printuint32(i32 %numFuncs)
+  ret void
+}
@@ -0,0 +1,15 @@
+target datalayout = "e-m:e-p:32:32-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7m-none-eabi"
+
+@runtime.pcTable = internal constant [64 x i8] zeroinitializer, align 4
+
+declare void @runtime.printuint32(i32)
+
+define void @runtime.printNumFuncs() #0 {
+entry:
+  %numFuncs = load i32, i32* getelementptr inbounds ([0 x i32], [0 x i32]* bitcast ([64 x i8]* @runtime.pcTable to [0 x i32]*), i32 0, i32 0), align 4
+  call void @runtime.printuint32(i32 %numFuncs)
+  ret void
+}
+
+attributes #0 = { "disable-tail-calls"="true" "frame-pointer"="all" }